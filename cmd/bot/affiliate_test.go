@@ -5,70 +5,136 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
-	texttemplate "text/template"
 
 	"github.com/bcmk/siren/v4/internal/botconfig"
 	"github.com/bcmk/siren/v4/internal/checkers"
 	"github.com/bcmk/siren/v4/lib/cmdlib"
 )
 
+// siteWorker builds a worker serving cfg's sites, with no checker behind any of them.
+func siteWorker(cfg botconfig.Config) *worker {
+	w := &worker{cfg: &cfg}
+	extra := map[string]checkers.Checker{}
+	for name := range cfg.Sites {
+		extra[name] = nil
+	}
+	w.sites, w.defaultSite = buildSites(w.cfg, nil, extra)
+	return w
+}
+
 func TestStreamerLink(t *testing.T) {
-	legacyTpl := texttemplate.Must(texttemplate.New("affiliate_link").
-		Parse("<a href='https://siren.chat/out/cb/{{ . }}'>{{ . }}</a>"))
-	base := &worker{cfg: &botconfig.Config{AffiliateBase: "https://siren.chat/out/cb"}}
-	slash := &worker{cfg: &botconfig.Config{AffiliateBase: "https://siren.chat/out/cb/"}}
-	legacy := &worker{cfg: &botconfig.Config{}, legacyAffiliateTpl: legacyTpl}
-	plain := &worker{cfg: &botconfig.Config{}}
+	base := siteWorker(botconfig.Config{Website: "cb", AffiliateBase: "https://siren.chat/out/cb"})
+	slash := siteWorker(botconfig.Config{Website: "cb", AffiliateBase: "https://siren.chat/out/cb/"})
+	legacy := siteWorker(botconfig.Config{
+		Website:       "cb",
+		AffiliateLink: "<a href='https://siren.chat/out/cb/{{ . }}'>{{ . }}</a>",
+	})
+	plain := siteWorker(botconfig.Config{Website: "cb"})
+	multi := siteWorker(botconfig.Config{
+		Website:       "cb",
+		AffiliateBase: "https://siren.chat/out/cb",
+		Sites: map[string]botconfig.Site{
+			"twitch":    {AffiliateBase: "https://siren.chat/out/tw"},
+			"stripchat": {},
+		},
+	})
 	tests := []struct {
 		name      string
 		w         *worker
+		site      string
 		affiliate map[string]string
 		want      string
 	}{
-		{"legacy template", legacy, nil, "<a href='https://siren.chat/out/cb/alice'>alice</a>"},
+		{"legacy template", legacy, "cb", nil, "<a href='https://siren.chat/out/cb/alice'>alice</a>"},
 		// affiliate_base with no affiliate must match the legacy output byte for byte.
-		{"affiliate_base, no affiliate", base, nil, "<a href='https://siren.chat/out/cb/alice'>alice</a>"},
+		{"affiliate_base, no affiliate", base, "cb", nil, "<a href='https://siren.chat/out/cb/alice'>alice</a>"},
 		{
 			"a trailing slash on the base does not double up",
 			slash,
+			"cb",
 			nil,
 			"<a href='https://siren.chat/out/cb/alice'>alice</a>",
 		},
 		{
 			"affiliate_base with affiliate",
 			base,
+			"cb",
 			map[string]string{"campaign": "modelX", "tour": "7Bge", "track": "default"},
 			"<a href='https://siren.chat/out/cb/alice?campaign=modelX&amp;tour=7Bge&amp;track=default'>alice</a>",
 		},
 		{
 			"the opt-in flag rides the query",
 			base,
+			"cb",
 			map[string]string{"referrer": "streamer"},
 			"<a href='https://siren.chat/out/cb/alice?referrer=streamer'>alice</a>",
 		},
-		{"no affiliate config falls back to the plain name", plain, nil, "alice"},
+		{"no affiliate config falls back to the plain name", plain, "cb", nil, "alice"},
+		{
+			"another site links under its own base, named with its site, without the chat's params",
+			multi,
+			"twitch",
+			map[string]string{"referrer": "streamer"},
+			"<a href='https://siren.chat/out/tw/alice'>twitch:alice</a>",
+		},
+		{"another site with no base gets its qualified name", multi, "stripchat", nil, "stripchat:alice"},
+		{"a site dropped from the config gets its qualified name", multi, "camsoda", nil, "camsoda:alice"},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			if got := tc.w.streamerLink("alice", tc.affiliate); got != tc.want {
+			if got := tc.w.streamerLink(tc.site, "alice", tc.affiliate); got != tc.want {
 				t.Errorf("streamerLink() = %q, want %q", got, tc.want)
 			}
 		})
 	}
 }
 
+// TestParseStreamer pins which prefixes pick a site:
+// only a configured site name, so a pasted link still reaches the default site whole.
+func TestParseStreamer(t *testing.T) {
+	w := &worker{cfg: &botconfig.Config{Website: "chaturbate", Sites: map[string]botconfig.Site{"twitch": {}}}}
+	w.sites, w.defaultSite = buildSites(w.cfg, &checkers.ChaturbateChecker{},
+		map[string]checkers.Checker{"twitch": &checkers.TwitchChecker{}})
+	tests := []struct {
+		input     string
+		wantSite  string
+		wantNick  string
+		qualified string
+	}{
+		{"alice", "chaturbate", "alice", "alice"},
+		{"twitch:bob", "twitch", "bob", "twitch:bob"},
+		{"Twitch:Bob", "twitch", "bob", "twitch:bob"},
+		{"chaturbate:alice", "chaturbate", "alice", "alice"},
+		{"https://chaturbate.com/alice/", "chaturbate", "alice", "alice"},
+		// Not a site: the default site's checker sees it whole and rejects the separator.
+		{"nosuch:carol", "chaturbate", "nosuch:carol", "nosuch:carol"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			s, nickname := w.parseStreamer(tc.input)
+			if s.name != tc.wantSite || nickname != tc.wantNick {
+				t.Fatalf("parseStreamer(%q) = %s, %q, want %s, %q", tc.input, s.name, nickname, tc.wantSite, tc.wantNick)
+			}
+			if got := w.qualifiedName(s.name, nickname); got != tc.qualified {
+				t.Errorf("qualifiedName() = %q, want %q", got, tc.qualified)
+			}
+		})
+	}
+}
+
 // affiliateWorker builds a worker. Chaturbate supports affiliate, Twitch not.
 // A non-empty affiliate_base is required for custom affiliate.
-func affiliateWorker(enabled, supported bool) *worker {
-	w := &worker{cfg: &botconfig.Config{
-		EnableCustomAffiliateLink: enabled,
-		AffiliateBase:             "https://siren.chat/out/cb",
-	}}
+func affiliateWorker(enabled, supported, base bool) *worker {
+	cfg := &botconfig.Config{EnableCustomAffiliateLink: enabled}
+	if base {
+		cfg.AffiliateBase = "https://siren.chat/out/cb"
+	}
+	var checker checkers.Checker = &checkers.TwitchChecker{}
 	if supported {
-		w.checker = &checkers.ChaturbateChecker{}
-	} else {
-		w.checker = &checkers.TwitchChecker{}
+		checker = &checkers.ChaturbateChecker{}
 	}
+	w := &worker{cfg: cfg}
+	w.sites, w.defaultSite = buildSites(cfg, checker, nil)
 	return w
 }
 
@@ -86,15 +152,14 @@ func TestAffiliateLinkEnabled(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			w := affiliateWorker(tc.enabled, tc.supported)
+			w := affiliateWorker(tc.enabled, tc.supported, true)
 			if got := w.customAffiliateLinkEnabled(); got != tc.want {
 				t.Errorf("customAffiliateLinkEnabled() = %v, want %v", got, tc.want)
 			}
 		})
 	}
 	t.Run("no affiliate_base", func(t *testing.T) {
-		w := affiliateWorker(true, true)
-		w.cfg.AffiliateBase = ""
+		w := affiliateWorker(true, true, false)
 		if w.customAffiliateLinkEnabled() {
 			t.Error("customAffiliateLinkEnabled() = true without affiliate_base, want false")
 		}
@@ -117,7 +182,7 @@ func TestGatedAffiliate(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			got := affiliateWorker(tc.enabled, tc.supported).gatedAffiliate(tc.custom)
+			got := affiliateWorker(tc.enabled, tc.supported, true).gatedAffiliate(tc.custom)
 			if !maps.Equal(got, tc.want) {
 				t.Errorf("gatedAffiliate(%v) = %v, want %v", tc.custom, got, tc.want)
 			}
@@ -173,7 +238,7 @@ func TestStripchatAffiliate(t *testing.T) {
 			cfg.AffiliateBase = "https://siren.chat/out/sc"
 			cfg.EnableCustomAffiliateLink = true
 			w.cfg = &cfg
-			w.checker = &checkers.StripchatChecker{}
+			w.sites, w.defaultSite = buildSites(w.cfg, &checkers.StripchatChecker{}, nil)
 			m := testMessage(w, -10, "affiliate", 0)
 			w.setAffiliate(m, tc.arg)
 
@@ -0,0 +1,130 @@
+// The online list guard: a site's online list far shorter than it has lately been
+// is taken for a truncated page rather than a mass logout.
+// A streamer missing from such a list is unknown to the poll, not offline,
+// so it keeps the status it had and thousands of chats are spared an offline alert and the online one after it.
+// It is not stored as StatusUnknown: that status is confirmed at once,
+// and the streamer's return would then be confirmed as a new online status, alerting every chat all the same.
+// A held list is logged, kept out of the median it is judged against, marked held in performance_log,
+// and reported to the owner. A drop outlasting maxHeldOnlineLists polls is believed.
+// The recent counts are kept in memory, read from performance_log only on a site's first poll,
+// so a restart keeps guarding.
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+const (
+	// onlineHistorySeconds is how far back the recent online counts of a site are read.
+	onlineHistorySeconds = 3600
+	// onlineHistoryPolls caps the recent counts the median is taken over.
+	onlineHistoryPolls = 10
+	// maxHeldOnlineLists is how many short lists in a row are held before the drop is believed.
+	maxHeldOnlineLists = 5
+)
+
+// median is the middle of counts, the lower one of the middle two for an even number, zero for none.
+func median(counts []int) int {
+	if len(counts) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), counts...)
+	sort.Ints(sorted)
+	return sorted[(len(sorted)-1)/2]
+}
+
+// onlineListDropped reports whether count falls at least dropPercent below baseline,
+// a baseline under minCount, or none at all, being too small to judge.
+// A zero dropPercent, which checkConfig never leaves, guards nothing.
+func onlineListDropped(count, baseline, dropPercent, minCount int) bool {
+	if dropPercent == 0 || baseline == 0 || baseline < minCount {
+		return false
+	}
+	return count*100 <= baseline*(100-dropPercent)
+}
+
+// recentOnlineCounts returns the counts of the site's believed online lists of the last onlineHistorySeconds.
+// Main goroutine only, as guardOnlineList is.
+func (w *worker) recentOnlineCounts(s *site, now int) []int {
+	if !s.onlineCountsLoaded {
+		s.onlineCounts = w.db.RecentOnlineCounts(s.name, now-onlineHistorySeconds, onlineHistoryPolls)
+		s.onlineCountsLoaded = true
+	}
+	s.onlineCounts = slices.DeleteFunc(s.onlineCounts, func(c db.OnlineCount) bool {
+		return c.Timestamp < now-onlineHistorySeconds
+	})
+	counts := make([]int, len(s.onlineCounts))
+	for i, c := range s.onlineCounts {
+		counts[i] = c.Count
+	}
+	return counts
+}
+
+// recordOnlineCount adds a believed list's count to the site's recent counts,
+// dropping the oldest past onlineHistoryPolls.
+func recordOnlineCount(s *site, count int, now int) {
+	s.onlineCounts = append(s.onlineCounts, db.OnlineCount{Timestamp: now, Count: count})
+	if len(s.onlineCounts) > onlineHistoryPolls {
+		s.onlineCounts = slices.Delete(s.onlineCounts, 0, len(s.onlineCounts)-onlineHistoryPolls)
+	}
+}
+
+// guardOnlineList decides whether a site's online list of count streamers is held as truncated.
+// A list taken as it is joins the counts later lists are judged against.
+// Main goroutine only, as it keeps the site's run of held lists and its recent counts.
+func (w *worker) guardOnlineList(s *site, count int, now int) bool {
+	held := w.judgeOnlineList(s, count, now)
+	if !held {
+		recordOnlineCount(s, count, now)
+	}
+	return held
+}
+
+// judgeOnlineList is guardOnlineList's verdict on a list, keeping the site's run of held lists.
+func (w *worker) judgeOnlineList(s *site, count int, now int) bool {
+	baseline := median(w.recentOnlineCounts(s, now))
+	if !onlineListDropped(count, baseline, w.cfg.OnlineDropPercent, w.cfg.OnlineDropMinCount) {
+		if s.heldOnlineLists > 0 {
+			linf("%s: the online list is back to %d against a recent median of %d", s.name, count, baseline)
+		}
+		s.heldOnlineLists = 0
+		return false
+	}
+	s.heldOnlineLists++
+	if s.heldOnlineLists > maxHeldOnlineLists {
+		if s.heldOnlineLists == maxHeldOnlineLists+1 {
+			w.alertOwner(fmt.Sprintf(
+				"%s: the online list has stayed at %d against a recent median of %d for %d polls, taking it as it is",
+				s.name, count, baseline, maxHeldOnlineLists))
+		}
+		return false
+	}
+	lerr("%s: the online list of %d against a recent median of %d looks truncated, holding it", s.name, count, baseline)
+	if s.heldOnlineLists == 1 {
+		w.alertOwner(fmt.Sprintf(
+			"%s: the online list dropped to %d from a recent median of %d, missing streamers are held as they were",
+			s.name, count, baseline))
+	}
+	return true
+}
+
+// heldOnlineStreamers is what a held list leaves online: the list over the streamers online before it.
+func heldOnlineStreamers(before, list map[string]cmdlib.StreamerInfo) map[string]cmdlib.StreamerInfo {
+	after := maps.Clone(before)
+	maps.Copy(after, list)
+	return after
+}
+
+// alertOwner tells the owner of something the bot noticed on its own.
+func (w *worker) alertOwner(text string) {
+	w.sendText(
+		db.PriorityHigh, w.cfg.OwnerEndpoint, w.ownerUserID, true, true, cmdlib.ParseRaw, text,
+		unprompted(db.MessagePacket))
+}
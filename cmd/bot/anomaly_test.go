@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestMedian(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		counts []int
+		want   int
+	}{
+		{nil, 0},
+		{[]int{5}, 5},
+		{[]int{9, 1, 5}, 5},
+		{[]int{4, 1, 3, 2}, 2},
+	}
+	for _, tc := range tests {
+		if got := median(tc.counts); got != tc.want {
+			t.Errorf("median(%v) = %d, want %d", tc.counts, got, tc.want)
+		}
+	}
+}
+
+func TestOnlineListDropped(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		count    int
+		baseline int
+		want     bool
+	}{
+		{"steady", 5000, 5000, false},
+		{"a small dip", 4000, 5000, false},
+		{"half gone", 2500, 5000, true},
+		{"all gone", 0, 5000, true},
+		{"a small site", 10, 50, false},
+		{"no history", 0, 0, false},
+	}
+	for _, tc := range tests {
+		if got := onlineListDropped(tc.count, tc.baseline, 50, 100); got != tc.want {
+			t.Errorf("%s: dropped = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if onlineListDropped(0, 5000, 0, 100) {
+		t.Error("a zero percent guarded a list")
+	}
+}
+
+// The recent counts keep the latest onlineHistoryPolls lists of the last onlineHistorySeconds.
+func TestRecentOnlineCounts(t *testing.T) {
+	t.Parallel()
+	w := &worker{}
+	s := &site{onlineCountsLoaded: true}
+	for now := 1; now <= onlineHistoryPolls+2; now++ {
+		recordOnlineCount(s, now*100, now)
+	}
+	if got := w.recentOnlineCounts(s, onlineHistoryPolls+2); len(got) != onlineHistoryPolls || got[0] != 300 {
+		t.Errorf("counts = %v, want the latest %d from 300 on", got, onlineHistoryPolls)
+	}
+	if got := w.recentOnlineCounts(s, onlineHistoryPolls+onlineHistorySeconds); len(got) != 3 {
+		t.Errorf("counts = %v, want the three of the last hour", got)
+	}
+}
+
+// A truncated list keeps the streamers missing from it online, so no one hears they went offline,
+// not even once it is whole again, and a drop lasting past maxHeldOnlineLists polls is believed.
+// The counts it is judged against start from performance_log, skipping the lists held there.
+func TestTruncatedOnlineListIsHeld(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+	w.cfg.OnlineDropPercent = 50
+	w.cfg.OnlineDropMinCount = 2
+
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 1, "b")
+	for now, count := range []int{4, 4, 1} {
+		w.db.LogPerformance(now+1, db.PerformanceLogUpdateQuery, 0, map[string]any{
+			"site": testSite, "failed": false, "count": count, "held": count == 1,
+		})
+	}
+	full := map[string]cmdlib.StreamerInfo{"a": {}, "b": {}, "c": {}, "d": {}}
+	truncated := map[string]cmdlib.StreamerInfo{"c": {}}
+	poll := func(now int, streamers map[string]cmdlib.StreamerInfo) processingResult {
+		return w.handleCheckerResults(w.defaultSite, &cmdlib.OnlineListResults{Streamers: streamers}, now)
+	}
+	poll(4, full)
+	if r := poll(5, truncated); !r.heldOnlineList || len(r.notifications) != 0 {
+		t.Fatalf("held = %v with %d notifications, want held and silent", r.heldOnlineList, len(r.notifications))
+	}
+	if r := poll(6, full); r.heldOnlineList || len(r.notifications) != 0 || r.unconfirmedChangesCount != 0 {
+		t.Errorf("the whole list back made %d changes and %d notifications, want none",
+			r.unconfirmedChangesCount, len(r.notifications))
+	}
+	for now := 7; now < 7+maxHeldOnlineLists; now++ {
+		r := poll(now, truncated)
+		if !r.heldOnlineList || len(r.notifications) != 0 {
+			t.Fatalf("poll %d: held = %v with %d notifications, want held and silent", now, r.heldOnlineList, len(r.notifications))
+		}
+	}
+	if _, online := w.defaultSite.unconfirmedOnlineStreamers["a"]; !online {
+		t.Error("a held list dropped a streamer from the online cache")
+	}
+	r := poll(7+maxHeldOnlineLists, truncated)
+	if r.heldOnlineList {
+		t.Error("a drop outlasting the held lists was still held")
+	}
+	if _, online := w.defaultSite.unconfirmedOnlineStreamers["a"]; online {
+		t.Error("a believed drop kept a missing streamer online")
+	}
+}
@@ -0,0 +1,290 @@
+// The read-only REST API: a streamer's confirmed status, its status and subject changes over a window,
+// and the week grid /week prints, as JSON for dashboards and partner sites.
+// It is served only when api_keys is configured, and every request carries one as a bearer token.
+// apiDaemon owns apiDB, as fuzzySearchDaemon owns fuzzySearchDB,
+// so the handlers hand it their queries rather than touch a connection themselves.
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/db"
+)
+
+const (
+	// apiDefaultWindow is the changes window when the request names no from.
+	apiDefaultWindow = 7 * 24 * time.Hour
+	// apiMaxWindow caps the changes window, so one request cannot read a streamer's whole history.
+	apiMaxWindow = 31 * 24 * time.Hour
+)
+
+// apiRequest is a query an API handler needs run on apiDB.
+// done is closed once query has returned.
+type apiRequest struct {
+	query func(d *db.Database)
+	done  chan struct{}
+}
+
+type apiStatus struct {
+	Site     string `json:"site"`
+	Nickname string `json:"nickname"`
+	Status   string `json:"status"`
+}
+
+type apiChange struct {
+	Status    string `json:"status"`
+	Timestamp int    `json:"timestamp"`
+}
+
+type apiChanges struct {
+	Site     string `json:"site"`
+	Nickname string `json:"nickname"`
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+	// Changes opens with the last change before from, when there is one,
+	// so the status at from is known.
+	Changes []apiChange `json:"changes"`
+}
+
+type apiSubject struct {
+	Subject   string `json:"subject"`
+	Timestamp int    `json:"timestamp"`
+}
+
+type apiSubjects struct {
+	Site     string `json:"site"`
+	Nickname string `json:"nickname"`
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+	// Subjects opens with the last subject before from, when there is one,
+	// so the subject at from is known.
+	Subjects []apiSubject `json:"subjects"`
+}
+
+type apiWeek struct {
+	Site     string `json:"site"`
+	Nickname string `json:"nickname"`
+	Timezone string `json:"timezone"`
+	// FirstWeekday names the first row; the last row is today.
+	FirstWeekday string `json:"first_weekday"`
+	// Hours holds the rows of the grid, 24 cells each, true for an hour the streamer was online in.
+	Hours [][]bool `json:"hours"`
+}
+
+func (w *worker) apiEnabled() bool {
+	return len(w.cfg.APIKeys) > 0
+}
+
+func (w *worker) registerAPI() {
+	if !w.apiEnabled() {
+		return
+	}
+	http.HandleFunc("GET /api/v1/streamers/{site}/{nickname}", w.apiAuthorized(w.handleAPIStatus))
+	http.HandleFunc("GET /api/v1/streamers/{site}/{nickname}/changes", w.apiAuthorized(w.handleAPIChanges))
+	http.HandleFunc("GET /api/v1/streamers/{site}/{nickname}/subjects", w.apiAuthorized(w.handleAPISubjects))
+	http.HandleFunc("GET /api/v1/streamers/{site}/{nickname}/week", w.apiAuthorized(w.handleAPIWeek))
+}
+
+func (w *worker) apiDaemon() {
+	for req := range w.apiRequests {
+		req.query(&w.apiDB)
+		close(req.done)
+	}
+}
+
+// apiQuery runs query on the API daemon and waits for it.
+// ok is false when shutdown began before the daemon took it.
+func (w *worker) apiQuery(query func(d *db.Database)) (ok bool) {
+	req := apiRequest{query: query, done: make(chan struct{})}
+	select {
+	case w.apiRequests <- req:
+	case <-w.shutdownCh:
+		return false
+	}
+	<-req.done
+	return true
+}
+
+// apiAuthorized admits a request bearing one of the configured keys.
+func (w *worker) apiAuthorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || !w.validAPIKey(token) {
+			rw.Header().Set("WWW-Authenticate", `Bearer realm="siren"`)
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		rw.Header().Set("Cache-Control", "no-store")
+		handler(rw, r)
+	}
+}
+
+// validAPIKey compares against every key in constant time,
+// so neither a key's content nor its position leaks through timing.
+func (w *worker) validAPIKey(token string) bool {
+	valid := 0
+	for _, key := range w.cfg.APIKeys {
+		valid |= subtle.ConstantTimeCompare([]byte(token), []byte(key))
+	}
+	return valid == 1
+}
+
+// apiStreamer resolves the site and nickname a request names,
+// answering it when they name no streamer the bot knows.
+func (w *worker) apiStreamer(rw http.ResponseWriter, r *http.Request) (streamer db.Streamer, ok bool) {
+	s := w.sites[strings.ToLower(r.PathValue("site"))]
+	if s == nil {
+		http.Error(rw, "unknown site", http.StatusNotFound)
+		return db.Streamer{}, false
+	}
+	nickname := s.checker.NicknamePreprocessing(r.PathValue("nickname"))
+	var found *db.Streamer
+	if !w.apiQuery(func(d *db.Database) { found = d.MaybeStreamer(s.name, nickname) }) {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return db.Streamer{}, false
+	}
+	if found == nil {
+		http.Error(rw, "unknown streamer", http.StatusNotFound)
+		return db.Streamer{}, false
+	}
+	return *found, true
+}
+
+func (w *worker) handleAPIStatus(rw http.ResponseWriter, r *http.Request) {
+	streamer, ok := w.apiStreamer(rw, r)
+	if !ok {
+		return
+	}
+	writeAPIResponse(rw, apiStatus{
+		Site:     streamer.Site,
+		Nickname: streamer.Nickname,
+		Status:   streamer.ConfirmedStatus.String(),
+	})
+}
+
+func (w *worker) handleAPIChanges(rw http.ResponseWriter, r *http.Request) {
+	from, to, err := apiWindow(r, time.Now())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	streamer, ok := w.apiStreamer(rw, r)
+	if !ok {
+		return
+	}
+	var changes []db.StatusChange
+	if !w.apiQuery(func(d *db.Database) {
+		changes = d.ChangesFromToForStreamers([]int{streamer.ID}, from, to)[streamer.ID]
+	}) {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	result := apiChanges{Site: streamer.Site, Nickname: streamer.Nickname, From: from, To: to, Changes: []apiChange{}}
+	// The last entry is the sentinel closing the window, not a change.
+	for _, c := range changes[:len(changes)-1] {
+		result.Changes = append(result.Changes, apiChange{Status: c.Status.String(), Timestamp: c.Timestamp})
+	}
+	writeAPIResponse(rw, result)
+}
+
+func (w *worker) handleAPISubjects(rw http.ResponseWriter, r *http.Request) {
+	from, to, err := apiWindow(r, time.Now())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	streamer, ok := w.apiStreamer(rw, r)
+	if !ok {
+		return
+	}
+	var subjects []db.HistoricSubject
+	if !w.apiQuery(func(d *db.Database) { subjects = d.SubjectsFromTo(streamer.ID, from, to) }) {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	result := apiSubjects{Site: streamer.Site, Nickname: streamer.Nickname, From: from, To: to, Subjects: []apiSubject{}}
+	for _, s := range subjects {
+		result.Subjects = append(result.Subjects, apiSubject{Subject: s.Subject, Timestamp: s.Timestamp})
+	}
+	writeAPIResponse(rw, result)
+}
+
+func (w *worker) handleAPIWeek(rw http.ResponseWriter, r *http.Request) {
+	loc, zone := time.UTC, utcZone
+	if name := r.URL.Query().Get("timezone"); name != "" {
+		var known bool
+		if loc, zone, known = w.parseTimezone(name); !known {
+			http.Error(rw, "unknown timezone", http.StatusBadRequest)
+			return
+		}
+	}
+	streamer, ok := w.apiStreamer(rw, r)
+	if !ok {
+		return
+	}
+	var cells []bool
+	var weekday time.Weekday
+	if !w.apiQuery(func(d *db.Database) {
+		var grid map[int][]bool
+		grid, weekday = weekGrid(d, []int{streamer.ID}, time.Now(), loc)
+		cells = grid[streamer.ID]
+	}) {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	result := apiWeek{
+		Site:         streamer.Site,
+		Nickname:     streamer.Nickname,
+		Timezone:     zone,
+		FirstWeekday: weekday.String(),
+	}
+	for len(cells) > 0 {
+		row := cells[:min(24, len(cells))]
+		result.Hours = append(result.Hours, row)
+		cells = cells[len(row):]
+	}
+	writeAPIResponse(rw, result)
+}
+
+// apiWindow reads the from and to Unix times of a changes request,
+// to defaulting to now and from to apiDefaultWindow before to.
+// The error is what to answer a malformed window with.
+func apiWindow(r *http.Request, now time.Time) (from, to int, err error) {
+	to = int(now.Unix())
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, parseErr := strconv.Atoi(value)
+		if parseErr != nil {
+			return 0, 0, errors.New("to is not a Unix time")
+		}
+		to = parsed
+	}
+	from = to - int(apiDefaultWindow/time.Second)
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, parseErr := strconv.Atoi(value)
+		if parseErr != nil {
+			return 0, 0, errors.New("from is not a Unix time")
+		}
+		from = parsed
+	}
+	if from > to {
+		return 0, 0, errors.New("from is after to")
+	}
+	if to-from > int(apiMaxWindow/time.Second) {
+		return 0, 0, errors.New("the window is longer than 31 days")
+	}
+	return from, to, nil
+}
+
+func writeAPIResponse(rw http.ResponseWriter, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		lerr("cannot write an API response, %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/botconfig"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestAPIWindow(t *testing.T) {
+	now := time.Unix(100_000_000, 0)
+	day := int(24 * time.Hour / time.Second)
+	tests := []struct {
+		query    string
+		wantFrom int
+		wantTo   int
+		wantErr  bool
+	}{
+		{query: "", wantFrom: 100_000_000 - 7*day, wantTo: 100_000_000},
+		{query: "to=90000000", wantFrom: 90_000_000 - 7*day, wantTo: 90_000_000},
+		{query: "from=99990000&to=99999000", wantFrom: 99_990_000, wantTo: 99_999_000},
+		{query: "from=99999000&to=99990000", wantErr: true},
+		{query: "from=yesterday", wantErr: true},
+		{query: "to=now", wantErr: true},
+		{query: "from=0", wantErr: true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/streamers/cb/a/changes?"+tt.query, nil)
+		from, to, err := apiWindow(r, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr = %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (from != tt.wantFrom || to != tt.wantTo) {
+			t.Errorf("%q: got (%d, %d), want (%d, %d)", tt.query, from, to, tt.wantFrom, tt.wantTo)
+		}
+	}
+}
+
+func TestAPIAuthorized(t *testing.T) {
+	w := siteWorker(botconfig.Config{Website: testSite, APIKeys: []cmdlib.Secret{"first", "second"}})
+	handler := w.apiAuthorized(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	for header, want := range map[string]int{
+		"Bearer first":  http.StatusOK,
+		"Bearer second": http.StatusOK,
+		"Bearer third":  http.StatusUnauthorized,
+		"Bearer ":       http.StatusUnauthorized,
+		"first":         http.StatusUnauthorized,
+		"":              http.StatusUnauthorized,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/streamers/cb/a", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		if rec.Code != want {
+			t.Errorf("%q: status %d, want %d", header, rec.Code, want)
+		}
+		if want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%q: no WWW-Authenticate challenge", header)
+		}
+	}
+}
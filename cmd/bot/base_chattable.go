@@ -38,6 +38,25 @@ func (d *renderParams) asDeferredSendable(tr *cmdlib.Translation, notify bool, i
 	return d.asDeferredPhoto(notify, tr.DisablePreview, tr.Parse, img)
 }
 
+// asDeferredEdit packages the render as an edit of a sent message:
+// of its text, or of a picture's caption, and its picture too where img holds a fresh one.
+func (d *renderParams) asDeferredEdit(
+	messageID int,
+	photo bool,
+	disablePreview bool,
+	parse cmdlib.ParseKind,
+	img []byte,
+) *editParams {
+	e := &editParams{messageID: messageID, photo: photo, parseMode: parseMode(parse), renderParams: d}
+	if photo {
+		e.imageData = img
+	}
+	if disablePreview {
+		e.linkPreview = &models.LinkPreviewOptions{IsDisabled: bot.True()}
+	}
+	return e
+}
+
 // asDeferredText packages the render as a text message;
 // dispatch fills the text in once the chat and its mention are known.
 func (d *renderParams) asDeferredText(notify, disablePreview bool, parse cmdlib.ParseKind) *messageParams {
@@ -75,13 +94,14 @@ type sendable interface {
 	// It renders once: a retry resends that text, and the queue stops holding the data.
 	// A retry needs no new mention: a group becoming a supergroup keeps the chat id negative.
 	render(mention string)
-	send(ctx context.Context, b *bot.Bot) (*models.Message, error)
 }
 
 type messageParams struct {
 	*bot.SendMessageParams
 	// renderParams is nil when the text is already final.
 	renderParams *renderParams
+	// sentID is the id Telegram gave the message, zero until it is sent.
+	sentID int
 }
 
 func (m *messageParams) chatID() int64 {
@@ -107,12 +127,16 @@ func (m *messageParams) render(mention string) {
 	m.renderParams = nil
 }
 
-func (m *messageParams) send(ctx context.Context, b *bot.Bot) (*models.Message, error) {
+func (m *messageParams) sendTelegram(ctx context.Context, b *bot.Bot) (*models.Message, error) {
 	// See messageParams.chatID: a send before render is a bug.
 	if m.renderParams != nil {
 		panic("send before render")
 	}
-	return b.SendMessage(ctx, m.SendMessageParams)
+	sent, err := b.SendMessage(ctx, m.SendMessageParams)
+	if err == nil && sent != nil {
+		m.sentID = sent.ID
+	}
+	return sent, err
 }
 
 type photoParams struct {
@@ -122,6 +146,8 @@ type photoParams struct {
 	disablePreview bool
 	// renderParams is nil when the caption is already final.
 	renderParams *renderParams
+	// sentID is the id Telegram gave the message, zero until it is sent.
+	sentID int
 }
 
 func (p *photoParams) chatID() int64 {
@@ -145,7 +171,7 @@ func (p *photoParams) render(mention string) {
 	p.renderParams = nil
 }
 
-func (p *photoParams) send(ctx context.Context, b *bot.Bot) (*models.Message, error) {
+func (p *photoParams) sendTelegram(ctx context.Context, b *bot.Bot) (*models.Message, error) {
 	// See messageParams.chatID: a send before render is a bug.
 	if p.renderParams != nil {
 		panic("send before render")
@@ -153,7 +179,150 @@ func (p *photoParams) send(ctx context.Context, b *bot.Bot) (*models.Message, er
 	// Create reader here rather than pass it in.
 	// Otherwise retries consume it and we must rewind it.
 	p.Photo = &models.InputFileUpload{Filename: "preview", Data: bytes.NewReader(p.imageData)}
-	return b.SendPhoto(ctx, p.SendPhotoParams)
+	sent, err := b.SendPhoto(ctx, p.SendPhotoParams)
+	if err == nil && sent != nil {
+		p.sentID = sent.ID
+	}
+	return sent, err
+}
+
+// editParams edits a sent message in place: a text message by its text,
+// a picture by its caption, or by its media where a fresh picture came with the edit.
+// An edit never notifies, so it carries no sound to set.
+type editParams struct {
+	// chat is any, as ChatID is in the library's params, so an unset one reads as such.
+	chat        any
+	messageID   int
+	photo       bool
+	imageData   []byte
+	text        string
+	parseMode   models.ParseMode
+	linkPreview *models.LinkPreviewOptions
+	// replyMarkup is sent with every edit, as one without it takes the buttons away.
+	replyMarkup models.ReplyMarkup
+	// renderParams is nil when the text is already final.
+	renderParams *renderParams
+}
+
+func (e *editParams) chatID() int64 {
+	// See messageParams.chatID: a read before setChatID is a bug.
+	id, ok := e.chat.(int64)
+	if !ok {
+		panic("chatID read before setChatID")
+	}
+	return id
+}
+
+func (e *editParams) setChatID(id int64) {
+	e.chat = id
+}
+
+func (e *editParams) render(mention string) {
+	if e.renderParams == nil {
+		return
+	}
+	e.text = e.renderParams.render(mention)
+	e.renderParams = nil
+}
+
+func (e *editParams) sendTelegram(ctx context.Context, b *bot.Bot) (*models.Message, error) {
+	// See messageParams.chatID: a send before render is a bug.
+	if e.renderParams != nil {
+		panic("send before render")
+	}
+	switch {
+	case !e.photo:
+		return b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:             e.chat,
+			MessageID:          e.messageID,
+			Text:               e.text,
+			ParseMode:          e.parseMode,
+			LinkPreviewOptions: e.linkPreview,
+			ReplyMarkup:        e.replyMarkup,
+		})
+	case len(e.imageData) == 0:
+		return b.EditMessageCaption(ctx, &bot.EditMessageCaptionParams{
+			ChatID:      e.chat,
+			MessageID:   e.messageID,
+			Caption:     e.text,
+			ParseMode:   e.parseMode,
+			ReplyMarkup: e.replyMarkup,
+		})
+	}
+	// Created here, as in photoParams.sendTelegram, so a retry reads the picture whole.
+	return b.EditMessageMedia(ctx, &bot.EditMessageMediaParams{
+		ChatID:    e.chat,
+		MessageID: e.messageID,
+		Media: &models.InputMediaPhoto{
+			Media:           "attach://preview",
+			Caption:         e.text,
+			ParseMode:       e.parseMode,
+			MediaAttachment: bytes.NewReader(e.imageData),
+		},
+		ReplyMarkup: e.replyMarkup,
+	})
+}
+
+// pinParams pins a sent message without a sound, or deletes it with remove set.
+type pinParams struct {
+	// chat is any, as in editParams.
+	chat      any
+	messageID int
+	remove    bool
+}
+
+func (p *pinParams) chatID() int64 {
+	// See messageParams.chatID: a read before setChatID is a bug.
+	id, ok := p.chat.(int64)
+	if !ok {
+		panic("chatID read before setChatID")
+	}
+	return id
+}
+
+func (p *pinParams) setChatID(id int64) {
+	p.chat = id
+}
+
+// render has nothing to do: a pin carries no text.
+func (p *pinParams) render(string) {}
+
+func (p *pinParams) sendTelegram(ctx context.Context, b *bot.Bot) (*models.Message, error) {
+	var err error
+	if p.remove {
+		_, err = b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: p.chat, MessageID: p.messageID})
+	} else {
+		_, err = b.PinChatMessage(ctx, &bot.PinChatMessageParams{
+			ChatID:              p.chat,
+			MessageID:           p.messageID,
+			DisableNotification: true,
+		})
+	}
+	return nil, err
+}
+
+// sentAs reads the id Telegram gave a sent message and whether it went as a picture,
+// a zero id for a message not sent as a new one.
+func sentAs(msg sendable) (messageID int, photo bool) {
+	switch m := msg.(type) {
+	case *messageParams:
+		return m.sentID, false
+	case *photoParams:
+		return m.sentID, true
+	}
+	return 0, false
+}
+
+// withKeyboard puts buttons under a message, whichever way it goes out.
+func withKeyboard(msg sendable, keyboard models.ReplyMarkup) {
+	switch m := msg.(type) {
+	case *messageParams:
+		m.ReplyMarkup = keyboard
+	case *photoParams:
+		m.ReplyMarkup = keyboard
+	case *editParams:
+		m.replyMarkup = keyboard
+	}
 }
 
 // toText swaps a photo for text where a chat takes no photo.
@@ -164,6 +333,7 @@ func (p *photoParams) toText() *messageParams {
 		Text:                p.Caption,
 		ParseMode:           p.ParseMode,
 		DisableNotification: p.DisableNotification,
+		ReplyMarkup:         p.ReplyMarkup,
 	}
 	if p.disablePreview {
 		params.LinkPreviewOptions = &models.LinkPreviewOptions{IsDisabled: bot.True()}
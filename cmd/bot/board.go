@@ -0,0 +1,179 @@
+// Boards: /board in a group or channel posts and pins one message listing its subscriptions online,
+// with their viewers and links, for a chat that wants a current list rather than a stream of alerts.
+// A confirmed change of a subscribed streamer marks the board dirty,
+// and every boardEditPeriod the dirty boards are edited, which keeps the edits within Telegram's limits.
+// /board again posts a fresh board in place of the old one, and /board off deletes it.
+// A board deleted by hand is posted anew on the next change.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/db"
+)
+
+const (
+	// boardEditPeriod is how often the dirty boards are edited.
+	// A group takes 20 messages a minute, edits included, and alerts share them.
+	boardEditPeriod = 30 * time.Second
+	// boardMaxEntries caps the streamers a board lists, keeping it within a message.
+	boardMaxEntries = 50
+	// boardOffArgument takes a chat's board away.
+	boardOffArgument = "off"
+)
+
+// boardSend ties a send to a chat's board: its first post, an edit, or a pin.
+type boardSend struct {
+	// edit marks an edit of the board, and pin a pin of it or the deletion of an old one,
+	// where neither is set for the post that starts it.
+	edit bool
+	pin  bool
+	// messageID is the board message an edit is of.
+	messageID int
+}
+
+// boardEntry is one streamer of a board.
+type boardEntry struct {
+	Link    string
+	Viewers *int
+	name    string
+}
+
+// boardEntries lists a chat's subscriptions online, the most watched first,
+// capped at boardMaxEntries, with the count of those left out.
+func (w *worker) boardEntries(endpoint string, user db.User) (entries []boardEntry, more int) {
+	link := w.streamerLinker(w.gatedAffiliate(user.AffiliateParams))
+	for _, s := range w.onlineSubscriptions(endpoint, user.UserID) {
+		info := w.onlineInfo(s.Site, s.Nickname)
+		entries = append(entries, boardEntry{
+			Link:    link(s.Site, s.Nickname),
+			Viewers: info.Viewers,
+			name:    w.qualifiedName(s.Site, s.Nickname),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].Viewers, entries[j].Viewers
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a != nil && *a != *b {
+			return *a > *b
+		}
+		return entries[i].name < entries[j].name
+	})
+	if len(entries) > boardMaxEntries {
+		return entries[:boardMaxEntries], len(entries) - boardMaxEntries
+	}
+	return entries, 0
+}
+
+// sendBoard posts a chat's board, or edits it in where it is posted.
+func (w *worker) sendBoard(b db.Board, priority db.Priority) {
+	tr := w.tr[b.Endpoint]
+	if tr == nil {
+		// An endpoint dropped from the config; there is no one to show.
+		lerr("dropping board for unknown endpoint %s", b.Endpoint)
+		return
+	}
+	user, found := w.db.UserByID(b.UserID)
+	if !found {
+		return
+	}
+	entries, more := w.boardEntries(b.Endpoint, user)
+	params := &renderParams{templates: w.tpl[b.Endpoint], key: tr.Board.Key, data: tplData{
+		"entries": entries,
+		"more":    more,
+	}}
+	var msg sendable
+	if b.MessageID == 0 {
+		msg = params.asDeferredText(false, true, tr.Board.Parse)
+	} else {
+		msg = params.asDeferredEdit(b.MessageID, false, true, tr.Board.Parse, nil)
+	}
+	w.enqueueNew(&queuedMessage{
+		userID:   b.UserID,
+		endpoint: b.Endpoint,
+		message:  msg,
+		priority: priority,
+		tag:      unprompted(db.BoardPacket),
+		board:    &boardSend{edit: b.MessageID != 0, messageID: b.MessageID},
+	})
+}
+
+// enqueueBoardPin pins a board's message, or with remove deletes it.
+func (w *worker) enqueueBoardPin(userID db.UserID, endpoint string, messageID int, remove bool) {
+	w.enqueueNew(&queuedMessage{
+		userID:   userID,
+		endpoint: endpoint,
+		message:  &pinParams{messageID: messageID, remove: remove},
+		priority: db.PriorityLow,
+		tag:      unprompted(db.BoardPacket),
+		board:    &boardSend{pin: true},
+	})
+}
+
+// editBoards edits in the changes of the dirty boards.
+func (w *worker) editBoards() {
+	for _, b := range w.db.TakeDirtyBoards() {
+		w.sendBoard(b, db.PriorityLow)
+	}
+}
+
+// markBoards marks dirty the boards a batch of confirmed changes shows on.
+func (w *worker) markBoards(changes []db.ConfirmedStatusChange) {
+	if len(changes) == 0 {
+		return
+	}
+	streamerIDs := make([]int, len(changes))
+	for i, c := range changes {
+		streamerIDs[i] = c.StreamerID
+	}
+	w.db.MarkBoardsDirty(streamerIDs)
+}
+
+// completeBoardSend keeps the message a board was posted as and pins it,
+// and lets go of a board message that can no longer be edited. Main goroutine only.
+func (w *worker) completeBoardSend(r msgSendResult) {
+	switch {
+	case r.board.pin:
+		if r.result != messageSent {
+			// The bot may post but not pin; the board is kept current unpinned.
+			ldbg("cannot pin or delete a board: chat = %d, result = %d", r.chatID, r.result)
+		}
+	case !r.board.edit:
+		if r.result != messageSent || r.messageID == 0 {
+			return
+		}
+		// A board replaced or stopped while this post was on its way is deleted at once.
+		pinned := w.db.SetBoardMessage(r.userID, r.endpoint, r.messageID)
+		w.enqueueBoardPin(r.userID, r.endpoint, r.messageID, !pinned)
+	case r.result == messageBadRequest:
+		w.db.LoseBoardMessage(r.userID, r.endpoint, r.board.messageID)
+	}
+}
+
+// setBoard posts a fresh board to a group or channel, or with off takes it away.
+func (w *worker) setBoard(m receivedMessage, arguments string) {
+	arguments = strings.TrimSpace(arguments)
+	off := strings.EqualFold(arguments, boardOffArgument)
+	if !isGroupOrChannel(m.chatID) || (arguments != "" && !off) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].SyntaxBoard, nil)
+		return
+	}
+	var old int
+	if off {
+		old = w.db.StopBoard(m.userID, m.endpoint)
+	} else {
+		old = w.db.StartBoard(m.userID, m.endpoint)
+	}
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].BoardSet, tplData{"on": !off})
+	if !off {
+		w.sendBoard(db.Board{UserID: m.userID, Endpoint: m.endpoint}, db.PriorityHigh)
+	}
+	if old != 0 {
+		w.enqueueBoardPin(m.userID, m.endpoint, old, true)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestBoardTemplatesRender(t *testing.T) {
+	t.Parallel()
+	viewers := 12
+	entries := []boardEntry{{Link: "alice", Viewers: &viewers}, {Link: "bob"}}
+	for _, lang := range realLangs {
+		templates := realTemplates(t, lang)
+		for _, c := range []struct {
+			key  string
+			data tplData
+		}{
+			{"board", tplData{"entries": entries, "more": 3}},
+			{"board", tplData{"entries": entries, "more": 0}},
+			{"board", tplData{"entries": []boardEntry(nil), "more": 0}},
+			{"board_set", tplData{"on": true}},
+			{"board_set", tplData{"on": false}},
+			{"syntax_board", nil},
+		} {
+			out := (&renderParams{templates: templates, key: c.key, data: c.data}).render("")
+			if out == "" || strings.Contains(out, "<no value>") {
+				t.Errorf("%s %s with %v rendered %q", lang, c.key, c.data, out)
+			}
+		}
+	}
+}
+
+func TestBoards(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	aID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a", UnconfirmedStatus: cmdlib.StatusOnline})
+	insertTestStreamer(&w.db, db.Streamer{Nickname: "b", UnconfirmedStatus: cmdlib.StatusOnline})
+	insertTestStreamer(&w.db, db.Streamer{Nickname: "c", UnconfirmedStatus: cmdlib.StatusOffline})
+	for _, nickname := range []string{"a", "b", "c"} {
+		insertSubscription(&w.db, "test", -100, nickname)
+	}
+	viewers := 5
+	w.defaultSite.unconfirmedOnlineStreamers["b"] = cmdlib.StreamerInfo{Viewers: &viewers}
+	user, _ := w.db.User(-100)
+
+	entries, more := w.boardEntries("test", user)
+	if len(entries) != 2 || more != 0 || entries[0].Viewers == nil || entries[1].Viewers != nil {
+		t.Errorf("entries = %+v, more = %d, want b with its viewers before a", entries, more)
+	}
+
+	if old := w.db.StartBoard(user.UserID, "test"); old != 0 {
+		t.Errorf("a first board replaced message %d", old)
+	}
+	if !w.db.SetBoardMessage(user.UserID, "test", 10) {
+		t.Fatal("the board's message was not kept")
+	}
+	w.db.MarkBoardsDirty([]int{aID})
+	if boards := w.db.TakeDirtyBoards(); len(boards) != 1 || boards[0].MessageID != 10 {
+		t.Errorf("dirty boards = %+v, want the one of message 10", boards)
+	}
+	if boards := w.db.TakeDirtyBoards(); len(boards) != 0 {
+		t.Errorf("boards stayed dirty: %+v", boards)
+	}
+
+	w.db.LoseBoardMessage(user.UserID, "test", 10)
+	if boards := w.db.TakeDirtyBoards(); len(boards) != 1 || boards[0].MessageID != 0 {
+		t.Errorf("a lost board comes back as %+v, want one to send anew", boards)
+	}
+	w.db.SetBoardMessage(user.UserID, "test", 11)
+	if old := w.db.StartBoard(user.UserID, "test"); old != 11 {
+		t.Errorf("a fresh board replaced message %d, want 11", old)
+	}
+	if w.db.SetBoardMessage(user.UserID, "test", 12) && w.db.SetBoardMessage(user.UserID, "test", 13) {
+		t.Error("a board took a second message")
+	}
+	if old := w.db.StopBoard(user.UserID, "test"); old != 12 {
+		t.Errorf("stopping deleted message %d, want 12", old)
+	}
+	if w.db.SetBoardMessage(user.UserID, "test", 14) {
+		t.Error("a stopped board took a message")
+	}
+}
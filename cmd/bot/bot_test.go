@@ -15,19 +15,19 @@ func checkUnconfirmedOnlineStreamers(w *testWorker, t *testing.T) {
 	dbOnline := map[string]bool{}
 	var nickname string
 	w.db.MustQuery(
-		"select nickname from streamers where unconfirmed_status = $1",
-		[]interface{}{cmdlib.StatusOnline},
+		"select nickname from streamers where site = $1 and unconfirmed_status = $2",
+		[]interface{}{testSite, cmdlib.StatusOnline},
 		db.ScanTo{&nickname},
 		func() { dbOnline[nickname] = true })
-	if len(w.unconfirmedOnlineStreamers) != len(dbOnline) {
-		t.Errorf("unconfirmedOnlineStreamers size %d != DB size %d", len(w.unconfirmedOnlineStreamers), len(dbOnline))
+	if len(w.defaultSite.unconfirmedOnlineStreamers) != len(dbOnline) {
+		t.Errorf("unconfirmedOnlineStreamers size %d != DB size %d", len(w.defaultSite.unconfirmedOnlineStreamers), len(dbOnline))
 	}
 	for ch := range dbOnline {
-		if _, ok := w.unconfirmedOnlineStreamers[ch]; !ok {
+		if _, ok := w.defaultSite.unconfirmedOnlineStreamers[ch]; !ok {
 			t.Errorf("streamer %s in DB but not in unconfirmedOnlineStreamers", ch)
 		}
 	}
-	for ch := range w.unconfirmedOnlineStreamers {
+	for ch := range w.defaultSite.unconfirmedOnlineStreamers {
 		if !dbOnline[ch] {
 			t.Errorf("streamer %s in unconfirmedOnlineStreamers but not in DB", ch)
 		}
@@ -157,21 +157,21 @@ func TestUpdateNotifications(t *testing.T) {
 		RequestedStreamers: allStreamers,
 		Streamers:          map[string]cmdlib.StreamerInfo{"a": {}},
 	}
-	if r := w.handleCheckerResults(result, 2); len(r.notifications) != 2 {
+	if r := w.handleCheckerResults(w.defaultSite, result, 2); len(r.notifications) != 2 {
 		t.Errorf("expected 2 notifications for streamer 'a' online, got %d", len(r.notifications))
 	}
 	checkInv(&w.worker, t)
 
 	// Streamer "a" goes offline — no notifications yet (needs 5s confirmation)
 	result.Streamers = map[string]cmdlib.StreamerInfo{}
-	if r := w.handleCheckerResults(result, 3); len(r.notifications) != 0 {
+	if r := w.handleCheckerResults(w.defaultSite, result, 3); len(r.notifications) != 0 {
 		t.Errorf("expected 0 notifications before offline confirmation, got %d", len(r.notifications))
 	}
 	checkInv(&w.worker, t)
 
 	// Trigger confirmation check at t=8 — offline confirmed, 2 notifications
 	result.Streamers = map[string]cmdlib.StreamerInfo{}
-	if r := w.handleCheckerResults(result, 8); len(r.notifications) != 2 {
+	if r := w.handleCheckerResults(w.defaultSite, result, 8); len(r.notifications) != 2 {
 		t.Errorf("expected 2 notifications after offline confirmation, got %d", len(r.notifications))
 	}
 	checkInv(&w.worker, t)
@@ -180,7 +180,7 @@ func TestUpdateNotifications(t *testing.T) {
 	result.Streamers = map[string]cmdlib.StreamerInfo{
 		"d": {},
 	}
-	if r := w.handleCheckerResults(result, 9); len(r.notifications) != 2 {
+	if r := w.handleCheckerResults(w.defaultSite, result, 9); len(r.notifications) != 2 {
 		t.Errorf("expected 2 notifications for streamer 'd' online, got %d", len(r.notifications))
 	}
 	checkInv(&w.worker, t)
@@ -310,10 +310,10 @@ func TestStreamers(t *testing.T) {
 	defer w.terminate()
 	w.createDatabase()
 	insertTestStreamer(&w.db, db.Streamer{Nickname: "a", ConfirmedStatus: cmdlib.StatusOffline})
-	if w.db.MaybeStreamer("a") == nil {
+	if w.db.MaybeStreamer(testSite, "a") == nil {
 		t.Error("unexpected result")
 	}
-	if w.db.MaybeStreamer("b") != nil {
+	if w.db.MaybeStreamer(testSite, "b") != nil {
 		t.Error("unexpected result")
 	}
 }
@@ -353,9 +353,9 @@ func TestCopyFromAndBatchInTransaction(t *testing.T) {
 	// SendBatch with invalid status (violates check constraint) should fail
 	batch := &pgx.Batch{}
 	batch.Queue(`
-		insert into streamers (nickname, unconfirmed_status)
-		values ($1, $2)`,
-		"test_streamer", 999) // 999 violates check constraint
+		insert into streamers (site, nickname, unconfirmed_status)
+		values ($1, $2, $3)`,
+		testSite, "test_streamer", 999) // 999 violates check constraint
 	br := tx.SendBatch(context.Background(), batch)
 	err = br.Close()
 
@@ -498,11 +498,11 @@ func TestUnconfirmedStatusConsistency(t *testing.T) {
 	w.initCache()
 
 	// Insert first status change for streamer "a"
-	w.db.UpsertUnconfirmedStatusChanges([]db.StatusChange{
+	w.db.UpsertUnconfirmedStatusChanges(testSite, []db.StatusChange{
 		{Nickname: "a", Status: cmdlib.StatusOnline},
-	}, 100)
+	}, nil, 100)
 
-	streamer := w.db.MaybeStreamer("a")
+	streamer := w.db.MaybeStreamer(testSite, "a")
 	if streamer == nil {
 		t.Fatal("streamer not found")
 	}
@@ -514,11 +514,11 @@ func TestUnconfirmedStatusConsistency(t *testing.T) {
 	}
 
 	// Insert second status change — prev should be updated
-	w.db.UpsertUnconfirmedStatusChanges([]db.StatusChange{
+	w.db.UpsertUnconfirmedStatusChanges(testSite, []db.StatusChange{
 		{Nickname: "a", Status: cmdlib.StatusOffline},
-	}, 200)
+	}, nil, 200)
 
-	streamer = w.db.MaybeStreamer("a")
+	streamer = w.db.MaybeStreamer(testSite, "a")
 	if streamer.UnconfirmedStatus != cmdlib.StatusOffline || streamer.UnconfirmedTimestamp != 200 {
 		t.Errorf("unexpected unconfirmed status: %+v", streamer)
 	}
@@ -527,11 +527,11 @@ func TestUnconfirmedStatusConsistency(t *testing.T) {
 	}
 
 	// Insert third status change — prev should shift
-	w.db.UpsertUnconfirmedStatusChanges([]db.StatusChange{
+	w.db.UpsertUnconfirmedStatusChanges(testSite, []db.StatusChange{
 		{Nickname: "a", Status: cmdlib.StatusOnline},
-	}, 300)
+	}, nil, 300)
 
-	streamer = w.db.MaybeStreamer("a")
+	streamer = w.db.MaybeStreamer(testSite, "a")
 	if streamer.UnconfirmedStatus != cmdlib.StatusOnline || streamer.UnconfirmedTimestamp != 300 {
 		t.Errorf("unexpected unconfirmed status: %+v", streamer)
 	}
@@ -763,7 +763,7 @@ func TestConfirmSub(t *testing.T) {
 	}
 
 	// Check streamer was created
-	if w.db.MaybeStreamer("a") == nil {
+	if w.db.MaybeStreamer(testSite, "a") == nil {
 		t.Error("expected streamer to exist after ConfirmSub")
 	}
 
@@ -808,14 +808,14 @@ func TestProcessSubsConfirmations(t *testing.T) {
 	insertPendingSubscription(&w.db, "test", 7, "unknown_model", true)
 	// Referral pending subscription — should create referral event on confirmation
 	w.db.MustExec(
-		"insert into pending_subscriptions (endpoint, user_id, nickname, checking, referral) select $1, u.id, $3, $4, $5 from users u where u.chat_id = $2",
-		"test", 8, "referral_model", true, true,
+		"insert into pending_subscriptions (endpoint, user_id, site, nickname, checking, referral) select $1, u.id, $6, $3, $4, $5 from users u where u.chat_id = $2",
+		"test", 8, "referral_model", true, true, testSite,
 	)
 	// Non-referral pending subscription — should NOT create referral event
 	insertPendingSubscription(&w.db, "test", 9, "nonreferral_model", true)
 
 	// Process confirmations with checker results
-	w.processSubsConfirmations(&cmdlib.ExistenceListResults{
+	w.processSubsConfirmations(w.defaultSite, &cmdlib.ExistenceListResults{
 		Streamers: map[string]cmdlib.StreamerInfoWithStatus{
 			"online_model":          {Status: cmdlib.StatusOnline},
 			"offline_model":         {Status: cmdlib.StatusOffline},
@@ -930,7 +930,7 @@ func TestProcessSubsConfirmationsPartialList(t *testing.T) {
 	insertPendingSubscription(&w.db, "test", 2, "still_pending_model", true)
 	insertPendingSubscription(&w.db, "test", 3, "also_still_pending_model", true)
 
-	w.processSubsConfirmations(&cmdlib.ExistenceListResults{
+	w.processSubsConfirmations(w.defaultSite, &cmdlib.ExistenceListResults{
 		Streamers: map[string]cmdlib.StreamerInfoWithStatus{
 			"answered_model": {Status: cmdlib.StatusOnline},
 		},
@@ -1297,7 +1297,7 @@ func TestStatusConfirmations(t *testing.T) {
 				UnconfirmedTimestamp: tt.timestamp,
 			})
 
-			changes := w.db.ConfirmStatusChanges(
+			changes, _ := w.db.ConfirmStatusChanges(
 				tt.now,
 				w.cfg.StatusConfirmationSeconds.Online,
 				w.cfg.StatusConfirmationSeconds.Offline,
@@ -1357,7 +1357,7 @@ func TestQueryLastSubscriptionStatuses(t *testing.T) {
 		"test", 2, "model_without_status",
 	)
 
-	statuses := w.db.QueryLastSubscriptionStatuses()
+	statuses := w.db.QueryLastSubscriptionStatuses(testSite)
 
 	// Model with unconfirmed_status should return correct status
 	if statuses["model_with_status"] != cmdlib.StatusOnline {
@@ -1385,21 +1385,21 @@ func TestHandleStatusUpdates(t *testing.T) {
 	result := &cmdlib.OnlineListResults{
 		Streamers: map[string]cmdlib.StreamerInfo{"a": {ImageURL: "http://a.jpg"}},
 	}
-	r := w.handleCheckerResults(result, 100)
+	r := w.handleCheckerResults(w.defaultSite, result, 100)
 	if r.unconfirmedChangesCount != 1 {
 		t.Errorf("expected 1 change with OnlineListResults, got %d", r.unconfirmedChangesCount)
 	}
-	if w.unconfirmedOnlineStreamers["a"].ImageURL != "http://a.jpg" {
-		t.Errorf("expected ImageURL to be set, got %s", w.unconfirmedOnlineStreamers["a"].ImageURL)
+	if w.defaultSite.unconfirmedOnlineStreamers["a"].ImageURL != "http://a.jpg" {
+		t.Errorf("expected ImageURL to be set, got %s", w.defaultSite.unconfirmedOnlineStreamers["a"].ImageURL)
 	}
 	checkUnconfirmedOnlineStreamers(w, t)
 	checkInv(&w.worker, t)
 
 	// Test ImageURL update for streamer that remains online
 	result.Streamers["a"] = cmdlib.StreamerInfo{ImageURL: "http://a2.jpg"}
-	w.handleCheckerResults(result, 101)
-	if w.unconfirmedOnlineStreamers["a"].ImageURL != "http://a2.jpg" {
-		t.Errorf("expected ImageURL to be updated, got %s", w.unconfirmedOnlineStreamers["a"].ImageURL)
+	w.handleCheckerResults(w.defaultSite, result, 101)
+	if w.defaultSite.unconfirmedOnlineStreamers["a"].ImageURL != "http://a2.jpg" {
+		t.Errorf("expected ImageURL to be updated, got %s", w.defaultSite.unconfirmedOnlineStreamers["a"].ImageURL)
 	}
 	checkUnconfirmedOnlineStreamers(w, t)
 	checkInv(&w.worker, t)
@@ -1409,11 +1409,11 @@ func TestHandleStatusUpdates(t *testing.T) {
 		RequestedStreamers: map[string]bool{"a": true},
 		Streamers:          map[string]cmdlib.StreamerInfo{}, // empty = "a" is offline
 	}
-	r = w.handleCheckerResults(result2, 102)
+	r = w.handleCheckerResults(w.defaultSite, result2, 102)
 	if r.unconfirmedChangesCount != 1 {
 		t.Errorf("expected 1 change with FixedListOnlineResults, got %d", r.unconfirmedChangesCount)
 	}
-	if _, ok := w.unconfirmedOnlineStreamers["a"]; ok {
+	if _, ok := w.defaultSite.unconfirmedOnlineStreamers["a"]; ok {
 		t.Error("expected offline streamer to be removed from unconfirmedOnlineStreamers")
 	}
 	checkUnconfirmedOnlineStreamers(w, t)
@@ -1421,14 +1421,14 @@ func TestHandleStatusUpdates(t *testing.T) {
 
 	// Streamer comes back online (use new map to avoid aliasing with unconfirmedOnlineStreamers)
 	result2.Streamers = map[string]cmdlib.StreamerInfo{"a": {ImageURL: "http://a3.jpg"}}
-	w.handleCheckerResults(result2, 103)
+	w.handleCheckerResults(w.defaultSite, result2, 103)
 	checkUnconfirmedOnlineStreamers(w, t)
 	checkInv(&w.worker, t)
 
 	// Streamer goes offline again (use new empty map)
 	result2.Streamers = map[string]cmdlib.StreamerInfo{}
-	w.handleCheckerResults(result2, 104)
-	if _, ok := w.unconfirmedOnlineStreamers["a"]; ok {
+	w.handleCheckerResults(w.defaultSite, result2, 104)
+	if _, ok := w.defaultSite.unconfirmedOnlineStreamers["a"]; ok {
 		t.Error("expected offline streamer to be removed from unconfirmedOnlineStreamers")
 	}
 	checkUnconfirmedOnlineStreamers(w, t)
@@ -1436,7 +1436,7 @@ func TestHandleStatusUpdates(t *testing.T) {
 
 	// Test error case (should return early with zero values)
 	result3 := cmdlib.NewOnlineListResultsFailed()
-	r = w.handleCheckerResults(result3, 105)
+	r = w.handleCheckerResults(w.defaultSite, result3, 105)
 	if r.unconfirmedChangesCount != 0 || r.confirmedChangesCount != 0 || len(r.notifications) != 0 || r.elapsed != 0 {
 		t.Errorf(
 			"expected zero values on error, got changes=%d, confirmedChanges=%d, nots=%d, elapsed=%d",
@@ -1445,7 +1445,7 @@ func TestHandleStatusUpdates(t *testing.T) {
 
 	// Test error case with FixedListResults
 	result4 := cmdlib.NewFixedListOnlineResultsFailed()
-	r = w.handleCheckerResults(result4, 106)
+	r = w.handleCheckerResults(w.defaultSite, result4, 106)
 	if r.unconfirmedChangesCount != 0 || r.confirmedChangesCount != 0 || len(r.notifications) != 0 || r.elapsed != 0 {
 		t.Errorf(
 			"expected zero values on error with FixedListResults, got changes=%d, confirmedChanges=%d, nots=%d, elapsed=%d",
@@ -1457,7 +1457,7 @@ func TestHandleStatusUpdates(t *testing.T) {
 	result5 := &cmdlib.OnlineListResults{
 		Streamers: map[string]cmdlib.StreamerInfo{"newmodel": {}},
 	}
-	w.handleCheckerResults(result5, 107)
+	w.handleCheckerResults(w.defaultSite, result5, 107)
 	checkInv(&w.worker, t)
 }
 
@@ -1482,14 +1482,14 @@ func TestUnsubscribeBeforeRestart(t *testing.T) {
 			"b": {},
 		},
 	}
-	w.handleCheckerResults(result, 100)
+	w.handleCheckerResults(w.defaultSite, result, 100)
 	checkInv(&w.worker, t)
 
 	// Verify both are online in DB
-	if w.db.MaybeStreamer("a").UnconfirmedStatus != cmdlib.StatusOnline {
+	if w.db.MaybeStreamer(testSite, "a").UnconfirmedStatus != cmdlib.StatusOnline {
 		t.Error("expected 'a' to be online")
 	}
-	if w.db.MaybeStreamer("b").UnconfirmedStatus != cmdlib.StatusOnline {
+	if w.db.MaybeStreamer(testSite, "b").UnconfirmedStatus != cmdlib.StatusOnline {
 		t.Error("expected 'b' to be online")
 	}
 
@@ -1509,12 +1509,12 @@ func TestUnsubscribeBeforeRestart(t *testing.T) {
 			"b": {},
 		},
 	}
-	w.handleCheckerResults(result2, 101)
+	w.handleCheckerResults(w.defaultSite, result2, 101)
 	checkInv(&w.worker, t)
 
 	// "a" should now have StatusUnknown in DB because it's a known streamer
 	// but not in RequestedStreamers (not subscribed anymore).
-	streamerA := w.db.MaybeStreamer("a")
+	streamerA := w.db.MaybeStreamer(testSite, "a")
 	if streamerA.UnconfirmedStatus != cmdlib.StatusUnknown {
 		t.Errorf("expected 'a' to have StatusUnknown, got %v", streamerA.UnconfirmedStatus)
 	}
@@ -1546,7 +1546,7 @@ func TestUnknownStreamerFirstOfflineSaved(t *testing.T) {
 	// 2. Subscription is confirmed — checker returns offline status (Twitch returns Online|Offline)
 	// Set subscription to "checking" state as queryUnconfirmedSubs would do
 	w.db.MustExec("update pending_subscriptions set checking = true where nickname = $1", "unknown_model")
-	w.processSubsConfirmations(&cmdlib.ExistenceListResults{
+	w.processSubsConfirmations(w.defaultSite, &cmdlib.ExistenceListResults{
 		Streamers: map[string]cmdlib.StreamerInfoWithStatus{
 			// Twitch returns Online|Offline when streamer exists but is offline
 			"unknown_model": {Status: cmdlib.StatusOnline | cmdlib.StatusOffline},
@@ -1567,7 +1567,7 @@ func TestUnknownStreamerFirstOfflineSaved(t *testing.T) {
 		RequestedStreamers: map[string]bool{"unknown_model": true},
 		Streamers:          map[string]cmdlib.StreamerInfo{}, // empty = offline
 	}
-	r := w.handleCheckerResults(result, 101)
+	r := w.handleCheckerResults(w.defaultSite, result, 101)
 	checkInv(&w.worker, t)
 
 	// 4. Offline status should be recorded for proper online time calculation
@@ -1585,7 +1585,7 @@ func TestUnknownStreamerFirstOfflineSaved(t *testing.T) {
 	}
 
 	// Verify streamer has offline status
-	streamer := w.db.MaybeStreamer("unknown_model")
+	streamer := w.db.MaybeStreamer(testSite, "unknown_model")
 	if streamer == nil {
 		t.Fatal("expected streamer to exist")
 	}
@@ -1595,7 +1595,7 @@ func TestUnknownStreamerFirstOfflineSaved(t *testing.T) {
 
 	// 5. Subsequent status update with same offline status should NOT record a new change
 	result.Streamers = map[string]cmdlib.StreamerInfo{} // use new map to avoid aliasing
-	r = w.handleCheckerResults(result, 102)
+	r = w.handleCheckerResults(w.defaultSite, result, 102)
 	checkInv(&w.worker, t)
 	if r.unconfirmedChangesCount != 0 {
 		t.Errorf("expected 0 changes for same offline status, got %d", r.unconfirmedChangesCount)
@@ -1777,7 +1777,7 @@ func TestStatusTransitions(t *testing.T) {
 					if *tt.dbBefore == cmdlib.StatusOnline {
 						setupResult.Streamers["ch"] = cmdlib.StreamerInfo{}
 					}
-					w.handleCheckerResults(setupResult, 100)
+					w.handleCheckerResults(w.defaultSite, setupResult, 100)
 				} else {
 					setupResult := &cmdlib.OnlineListResults{
 						Streamers: map[string]cmdlib.StreamerInfo{"always_online": {}},
@@ -1785,7 +1785,7 @@ func TestStatusTransitions(t *testing.T) {
 					if *tt.dbBefore == cmdlib.StatusOnline {
 						setupResult.Streamers["ch"] = cmdlib.StreamerInfo{}
 					}
-					w.handleCheckerResults(setupResult, 100)
+					w.handleCheckerResults(w.defaultSite, setupResult, 100)
 				}
 				checkInv(&w.worker, t)
 			}
@@ -1801,7 +1801,7 @@ func TestStatusTransitions(t *testing.T) {
 				if tt.checkerStatus != nil && *tt.checkerStatus == cmdlib.StatusOnline {
 					result.Streamers["ch"] = cmdlib.StreamerInfo{}
 				}
-				w.handleCheckerResults(result, 101)
+				w.handleCheckerResults(w.defaultSite, result, 101)
 			} else {
 				result := &cmdlib.OnlineListResults{
 					Streamers: map[string]cmdlib.StreamerInfo{"always_online": {}},
@@ -1809,11 +1809,11 @@ func TestStatusTransitions(t *testing.T) {
 				if tt.checkerStatus != nil && *tt.checkerStatus == cmdlib.StatusOnline {
 					result.Streamers["ch"] = cmdlib.StreamerInfo{}
 				}
-				w.handleCheckerResults(result, 101)
+				w.handleCheckerResults(w.defaultSite, result, 101)
 			}
 			checkInv(&w.worker, t)
 
-			streamer := w.db.MaybeStreamer("ch")
+			streamer := w.db.MaybeStreamer(testSite, "ch")
 			if tt.dbAfter == nil {
 				if streamer != nil {
 					t.Errorf("expected no streamer in DB, got %v", streamer)
@@ -1827,13 +1827,13 @@ func TestStatusTransitions(t *testing.T) {
 			}
 
 			// Verify background streamers were not affected
-			if ch := w.db.MaybeStreamer("always_online"); ch == nil || ch.UnconfirmedStatus != cmdlib.StatusOnline {
+			if ch := w.db.MaybeStreamer(testSite, "always_online"); ch == nil || ch.UnconfirmedStatus != cmdlib.StatusOnline {
 				t.Errorf("always_online was affected, got %v", ch)
 			}
-			if ch := w.db.MaybeStreamer("always_offline"); ch == nil || ch.UnconfirmedStatus != cmdlib.StatusOffline {
+			if ch := w.db.MaybeStreamer(testSite, "always_offline"); ch == nil || ch.UnconfirmedStatus != cmdlib.StatusOffline {
 				t.Errorf("always_offline was affected, got %v", ch)
 			}
-			if ch := w.db.MaybeStreamer("always_unknown"); ch == nil || ch.UnconfirmedStatus != cmdlib.StatusUnknown {
+			if ch := w.db.MaybeStreamer(testSite, "always_unknown"); ch == nil || ch.UnconfirmedStatus != cmdlib.StatusUnknown {
 				t.Errorf("always_unknown was affected, got %v", ch)
 			}
 		})
@@ -0,0 +1,171 @@
+// Burst collapsing: a checker cycle can queue a dozen status alerts to one chat,
+// each paced by the chat's cooldown, three seconds for a group.
+// When a chat has more than notification_burst of them queued at dispatch,
+// they go out as one message, so the last comes without the wait
+// and a group stays under Telegram's 20 messages a minute.
+// A collapsed message is text alone: pictures and buttons belong to one streamer each.
+
+package main
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/bcmk/siren/v4/internal/db"
+)
+
+// burstTextLimit is Telegram's cap on a message's text in UTF-16 units.
+// The alerts that do not fit wait for the next send.
+const burstTextLimit = 4096
+
+// burstSeparator goes between the alerts of a collapsed message.
+const burstSeparator = "\n\n"
+
+// collapsible reports whether a queued message is a status alert a burst may take in,
+// and its parse mode, which every alert of one burst shares.
+// A live alert is left out: it is edited later by the id of its own message.
+func collapsible(q *queuedMessage) (models.ParseMode, bool) {
+	if q.tag.kind != db.NotificationPacket || q.notificationID == 0 || q.live != nil || len(q.burst) != 0 {
+		return "", false
+	}
+	switch m := q.message.(type) {
+	case *messageParams:
+		return m.ParseMode, true
+	case *photoParams:
+		return m.ParseMode, true
+	}
+	return "", false
+}
+
+// renderedText renders a message and reads its text, or a picture's caption.
+func renderedText(msg sendable, mention string) (text string, notify bool) {
+	msg.render(mention)
+	switch m := msg.(type) {
+	case *messageParams:
+		return m.Text, !m.DisableNotification
+	case *photoParams:
+		return m.Caption, !m.DisableNotification
+	}
+	return "", false
+}
+
+// takeFrom removes and returns a user's queued messages that take admits, in queue order.
+// The user must be cooling, out of the ready heap, as it is once its send is dispatched.
+func (s *sendQueue) takeFrom(userID db.UserID, take func(*queuedMessage) bool) []*queuedMessage {
+	u := s.byUser[userID]
+	if u == nil {
+		return nil
+	}
+	var taken []*queuedMessage
+	var kept msgHeap
+	for _, q := range u.items {
+		if take(q) {
+			taken = append(taken, q)
+		} else {
+			kept = append(kept, q)
+		}
+	}
+	if len(taken) == 0 {
+		return nil
+	}
+	s.size -= len(taken)
+	if len(kept) == 0 {
+		delete(s.byUser, userID)
+	} else {
+		u.items = kept
+		heap.Init(&u.items)
+	}
+	sort.Slice(taken, func(i, j int) bool { return messageLess(taken[i], taken[j]) })
+	return taken
+}
+
+// collapseBurst folds the status alerts queued to q's user into q
+// when q is one of them and there are more than threshold in all, bound for q's topic.
+// q keeps its place and envelope, its message replaced by the combined text
+// and burst holding the notification rows of the alerts it took in.
+// Once one does not fit in burstTextLimit, it and the rest go back to the queue with their places,
+// so no alert overtakes an earlier one of the same streamer.
+// A combined message notifies if any of its alerts would.
+func (s *sendQueue) collapseBurst(q *queuedMessage, threshold int, mention string) *queuedMessage {
+	parse, ok := collapsible(q)
+	if !ok {
+		return q
+	}
+	take := func(other *queuedMessage) bool {
+		otherParse, ok := collapsible(other)
+		return ok && otherParse == parse && other.topic == q.topic
+	}
+	count := 1
+	if u := s.byUser[q.userID]; u != nil {
+		for _, other := range u.items {
+			if take(other) {
+				count++
+			}
+		}
+	}
+	if count <= threshold {
+		return q
+	}
+	text, notify := renderedText(q.message, mention)
+	parts := []string{text}
+	units := utf16Len(text)
+	var burst []int
+	full := false
+	for _, other := range s.takeFrom(q.userID, take) {
+		if full {
+			s.push(other)
+			continue
+		}
+		otherText, otherNotify := renderedText(other.message, mention)
+		size := utf16Len(burstSeparator) + utf16Len(otherText)
+		if units+size > burstTextLimit {
+			full = true
+			s.push(other)
+			continue
+		}
+		units += size
+		parts = append(parts, otherText)
+		burst = append(burst, other.notificationID)
+		notify = notify || otherNotify
+	}
+	if len(burst) == 0 {
+		return q
+	}
+	q.message = &messageParams{SendMessageParams: &bot.SendMessageParams{
+		ChatID:              q.message.chatID(),
+		Text:                strings.Join(parts, burstSeparator),
+		ParseMode:           parse,
+		DisableNotification: !notify,
+		LinkPreviewOptions:  &models.LinkPreviewOptions{IsDisabled: bot.True()},
+	}}
+	q.burst = burst
+	return q
+}
+
+// utf16Len counts a text in UTF-16 units, as Telegram counts its cap.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// finalizeBurst clears the rows of the alerts a sent message took in.
+func (w *worker) finalizeBurst(burst []int) {
+	for _, id := range burst {
+		w.finalizeNotification(id)
+	}
+}
+
+// requeueBurst puts the alerts a message took in back for a later fetch.
+func (w *worker) requeueBurst(burst []int) {
+	for _, id := range burst {
+		w.db.RequeueNotification(id)
+	}
+}
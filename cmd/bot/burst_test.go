@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// burstAlert is a queued status alert of the streamer named, as notifyOfStatus enqueues one.
+func burstAlert(tpl *texttemplate.Template, id int, name string, notify bool) *queuedMessage {
+	params := &renderParams{templates: tpl, key: "online", data: tplData{"name": name}}
+	return &queuedMessage{
+		userID:         1,
+		endpoint:       "test",
+		message:        params.asDeferredText(notify, true, cmdlib.ParseHTML),
+		priority:       db.PriorityLow,
+		tag:            unprompted(db.NotificationPacket),
+		notificationID: id,
+		seq:            uint64(id),
+	}
+}
+
+func TestCollapseBurst(t *testing.T) {
+	t.Parallel()
+	tpl := texttemplate.New("")
+	texttemplate.Must(tpl.New("online").Parse("{{ .name }} online"))
+
+	// dispatch pops the head as trySend does, its user cooling, and collapses the rest into it.
+	dispatch := func(s *sendQueue, threshold int) *queuedMessage {
+		q := s.pop()
+		s.startCooling(q.userID)
+		q.message.setChatID(-100)
+		return s.collapseBurst(q, threshold, "")
+	}
+
+	s := newSendQueue()
+	for i, name := range []string{"a", "b", "c"} {
+		s.push(burstAlert(tpl, i+1, name, false))
+	}
+	if q := dispatch(&s, 3); len(q.burst) != 0 || s.Len() != 2 {
+		t.Errorf("three alerts collapsed at a threshold of 3: burst %v, %d left", q.burst, s.Len())
+	}
+
+	s = newSendQueue()
+	for i, name := range []string{"a", "b", "c", "d"} {
+		s.push(burstAlert(tpl, i+1, name, i == 2))
+	}
+	live := burstAlert(tpl, 5, "e", false)
+	live.live = &liveSend{}
+	s.push(live)
+	s.push(&queuedMessage{userID: 1, endpoint: "test", message: textMessage("reply", false, false, cmdlib.ParseRaw),
+		priority: db.PriorityLow, tag: reply("list"), seq: 6})
+	q := dispatch(&s, 3)
+	text := q.message.(*messageParams).Text
+	if text != "a online\n\nb online\n\nc online\n\nd online" {
+		t.Errorf("collapsed text = %q", text)
+	}
+	if q.message.(*messageParams).DisableNotification {
+		t.Error("a burst with an alert that notifies went out silent")
+	}
+	if len(q.burst) != 3 || q.burst[0] != 2 || q.burst[2] != 4 || q.notificationID != 1 {
+		t.Errorf("burst = %v on notification %d, want 2 to 4 on 1", q.burst, q.notificationID)
+	}
+	if s.Len() != 2 {
+		t.Errorf("%d messages left, want the live alert and the reply", s.Len())
+	}
+
+	s = newSendQueue()
+	long := strings.Repeat("x", burstTextLimit/2)
+	for i, name := range []string{"a", long, long, "d", "e"} {
+		s.push(burstAlert(tpl, i+1, name, false))
+	}
+	q = dispatch(&s, 3)
+	if len(q.burst) != 1 || s.Len() != 3 {
+		t.Errorf("burst = %v with %d left, want the first long alert taken and the rest kept", q.burst, s.Len())
+	}
+	s.stopCooling(1)
+	if next := s.pop(); next.notificationID != 3 {
+		t.Errorf("the alert after the burst is %d, want 3 in its place", next.notificationID)
+	}
+}
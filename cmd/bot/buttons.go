@@ -0,0 +1,127 @@
+// Notification buttons: a Telegram alert of a streamer carries a row of inline buttons
+// to mute the streamer for a while, remove it, or see its week, and a link to watch it.
+// A channel alert carries the link alone: every subscriber of the channel could tap the rest,
+// and the answer, a week or a refusal, would be posted into the channel for all of them.
+// A tap comes back as a callback query on the path the /buy_subs menu takes,
+// names the streamer by its id, and is gated as the command it stands for.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/bcmk/siren/v4/internal/db"
+)
+
+const (
+	// notificationCallbackPrefix marks the callback data of a notification button,
+	// as "buy:" marks a buy menu tap.
+	notificationCallbackPrefix = "n:"
+	// buttonMuteDuration is how long the mute button silences a streamer.
+	buttonMuteDuration = 8 * time.Hour
+)
+
+// The actions a notification button asks for, named as the commands they stand for.
+const (
+	muteAction   = "mute"
+	removeAction = "remove"
+	weekAction   = "week"
+)
+
+// notificationCallbackData packs an action on a streamer into a button's callback data,
+// well under the 64 bytes Telegram allows.
+func notificationCallbackData(action string, streamerID int) string {
+	return fmt.Sprintf("%s%s:%d", notificationCallbackPrefix, action, streamerID)
+}
+
+// parseNotificationCallback reads the callback data of a notification button.
+func parseNotificationCallback(data string) (action string, streamerID int, ok bool) {
+	rest, ok := strings.CutPrefix(data, notificationCallbackPrefix)
+	if !ok {
+		return "", 0, false
+	}
+	action, id, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", 0, false
+	}
+	streamerID, err := strconv.Atoi(id)
+	if err != nil {
+		return "", 0, false
+	}
+	switch action {
+	case muteAction, removeAction, weekAction:
+		return action, streamerID, true
+	}
+	return "", 0, false
+}
+
+// notificationKeyboard is the buttons under a notification of a streamer,
+// nil for one going anywhere but Telegram or naming no streamer.
+// Week is offered where /week is, and Watch where the streamer's page has a plain address.
+// A channel alert gets Watch alone, nil where there is no address.
+func (w *worker) notificationKeyboard(p plannedNotification) *models.InlineKeyboardMarkup {
+	if p.StreamerID == nil || !isTelegram(p.Endpoint) {
+		return nil
+	}
+	tr := w.tr[p.Endpoint]
+	var keyboard [][]models.InlineKeyboardButton
+	if !isChannel(p.ChatType) {
+		actions := []models.InlineKeyboardButton{
+			{Text: tr.NotificationMuteButton.Str, CallbackData: notificationCallbackData(muteAction, *p.StreamerID)},
+			{Text: tr.NotificationRemoveButton.Str, CallbackData: notificationCallbackData(removeAction, *p.StreamerID)},
+		}
+		if w.cfg.EnableWeek {
+			actions = append(actions, models.InlineKeyboardButton{
+				Text: tr.NotificationWeekButton.Str, CallbackData: notificationCallbackData(weekAction, *p.StreamerID),
+			})
+		}
+		keyboard = append(keyboard, actions)
+	}
+	if link := w.streamerURL(p.Site, p.Nickname, w.gatedAffiliate(p.AffiliateParams)); link != "" {
+		keyboard = append(keyboard, []models.InlineKeyboardButton{{Text: tr.NotificationWatchButton.Str, URL: link}})
+	}
+	if len(keyboard) == 0 {
+		return nil
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+// handleNotificationCallback acts on a tap on a notification button.
+// The tap is logged and answered as the command it stands for, and gated as it:
+// in a group, removing or muting a streamer takes an admin unless the chat opened subscriptions to members.
+// Data it cannot read, from a keyboard of an older build, is dropped, the query already answered.
+func (w *worker) handleNotificationCallback(endpoint string, chatID int64, snd sender, data string) {
+	action, streamerID, ok := parseNotificationCallback(data)
+	if !ok {
+		return
+	}
+	if action == weekAction && !w.cfg.EnableWeek {
+		return
+	}
+	siteName, nickname, found := w.db.StreamerName(streamerID)
+	if !found {
+		return
+	}
+	m, _ := w.newReceivedMessage(int(time.Now().Unix()), endpoint, chatID, "", action)
+	w.logReceived(m)
+	name := w.qualifiedName(siteName, nickname)
+	if isGroupOrChannel(chatID) && w.groupAdminOnly(m, action, name) && !w.senderIsGroupAdmin(endpoint, chatID, snd) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[endpoint].AdminsOnly, tplData{
+			"member_subscriptions": w.commandGate(action, name).memberSubscriptions,
+		})
+		return
+	}
+	switch action {
+	case muteAction:
+		w.muteStreamer(m, siteName, nickname, int(buttonMuteDuration.Seconds()))
+	case removeAction:
+		w.removeStreamer(m, name)
+	case weekAction:
+		w.showWeek(m, name)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestParseNotificationCallback(t *testing.T) {
+	t.Parallel()
+	for _, action := range []string{muteAction, removeAction, weekAction} {
+		data := notificationCallbackData(action, 42)
+		if len(data) > 64 {
+			t.Errorf("callback data %q is over 64 bytes", data)
+		}
+		got, streamerID, ok := parseNotificationCallback(data)
+		if !ok || got != action || streamerID != 42 {
+			t.Errorf("parseNotificationCallback(%q) = %q, %d, %v", data, got, streamerID, ok)
+		}
+	}
+	for _, data := range []string{"", "buy:stars:10", "n:mute", "n:mute:x", "n:ban:42"} {
+		if _, _, ok := parseNotificationCallback(data); ok {
+			t.Errorf("parseNotificationCallback(%q) read it", data)
+		}
+	}
+}
+
+func TestWithKeyboard(t *testing.T) {
+	t.Parallel()
+	keyboard := &models.InlineKeyboardMarkup{}
+	params := &renderParams{}
+	text := params.asDeferredSendable(&cmdlib.Translation{}, true, nil)
+	withKeyboard(text, keyboard)
+	if text.(*messageParams).ReplyMarkup != keyboard {
+		t.Error("a text message lost its buttons")
+	}
+	photo := params.asDeferredPhoto(true, false, cmdlib.ParseRaw, []byte{1})
+	withKeyboard(photo, keyboard)
+	if photo.ReplyMarkup != keyboard || photo.toText().ReplyMarkup != keyboard {
+		t.Error("a picture lost its buttons, or the text it fell back to did")
+	}
+	edit := params.asDeferredEdit(1, false, false, cmdlib.ParseRaw, nil)
+	withKeyboard(edit, keyboard)
+	if edit.replyMarkup != keyboard {
+		t.Error("an edit would take the buttons away")
+	}
+}
+
+// A notification carries the buttons, and a tap on Mute silences the streamer for the chat that tapped it alone.
+func TestNotificationButtons(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 2, "a")
+
+	keyboard := w.notificationKeyboard(plannedNotification{Notification: db.Notification{
+		Endpoint: "test", StreamerID: &streamerID, Site: testSite, Nickname: "a",
+	}})
+	if keyboard == nil || len(keyboard.InlineKeyboard) == 0 || len(keyboard.InlineKeyboard[0]) < 2 {
+		t.Fatalf("keyboard = %+v, want mute and remove at least", keyboard)
+	}
+	if got := keyboard.InlineKeyboard[0][0].CallbackData; got != notificationCallbackData(muteAction, streamerID) {
+		t.Errorf("the first button calls back with %q", got)
+	}
+	if w.notificationKeyboard(plannedNotification{Notification: db.Notification{Endpoint: discordEndpoint, StreamerID: &streamerID}}) != nil {
+		t.Error("a Discord post got buttons")
+	}
+	channel := "channel"
+	keyboard = w.notificationKeyboard(plannedNotification{Notification: db.Notification{
+		Endpoint: "test", StreamerID: &streamerID, Site: testSite, Nickname: "a", ChatType: &channel,
+	}})
+	var rows [][]models.InlineKeyboardButton
+	if keyboard != nil {
+		rows = keyboard.InlineKeyboard
+	}
+	for _, row := range rows {
+		for _, button := range row {
+			if button.CallbackData != "" {
+				t.Errorf("a channel alert got the %q button its subscribers could tap", button.Text)
+			}
+		}
+	}
+
+	w.handleNotificationCallback("test", 1, sender{}, notificationCallbackData(muteAction, streamerID))
+	queued := w.sendQueue.pop()
+	queued.message.render("")
+	if got := queued.message.(*messageParams).Text; got != "Muted" {
+		t.Errorf("replied %q, want the mute confirmed", got)
+	}
+	alerted := w.buildNotifications([]db.ConfirmedStatusChange{
+		{StreamerID: streamerID, Site: testSite, Nickname: "a", Status: cmdlib.StatusOnline, PrevStatus: cmdlib.StatusOffline},
+	})
+	other, _ := w.db.User(2)
+	if len(alerted) != 1 || alerted[0].UserID != other.UserID {
+		t.Errorf("alerted %+v, want the chat that did not mute alone", alerted)
+	}
+
+	w.handleNotificationCallback("test", 2, sender{}, notificationCallbackData(removeAction, streamerID))
+	if w.db.SubscribedOrPending("test", other.UserID, testSite, "a") {
+		t.Error("the remove button left the subscription")
+	}
+}
@@ -150,8 +150,8 @@ func TestDeferredAddResultLogsItsCommand(t *testing.T) {
 	// Drop the immediate reply, so the next drain sees the deferred one alone.
 	drainSendQueueToLog(t, w)
 
-	w.db.MarkUnconfirmedAsChecking()
-	w.processSubsConfirmations(&cmdlib.ExistenceListResults{
+	w.db.MarkUnconfirmedAsChecking(testSite)
+	w.processSubsConfirmations(w.defaultSite, &cmdlib.ExistenceListResults{
 		Streamers: map[string]cmdlib.StreamerInfoWithStatus{
 			"pending_model": {Status: cmdlib.StatusOnline},
 		},
@@ -1073,7 +1073,7 @@ func TestOnlinePicsCarryTheHintInTheLastMessage(t *testing.T) {
 				insertSubscription(&w.db, "test", tc.chatID, nickname)
 				if tc.withPic {
 					url := "http://" + nickname + ".jpg"
-					w.unconfirmedOnlineStreamers[nickname] = cmdlib.StreamerInfo{ImageURL: url}
+					w.defaultSite.unconfirmedOnlineStreamers[nickname] = cmdlib.StreamerInfo{ImageURL: url}
 					images[url] = []byte("image")
 				}
 			}
@@ -1132,8 +1132,8 @@ func TestPendingAddNumbersItsWholeAnswer(t *testing.T) {
 		t.Errorf("the checking notice was not the zero, got %v", got)
 	}
 
-	w.db.MarkUnconfirmedAsChecking()
-	w.processSubsConfirmations(&cmdlib.ExistenceListResults{
+	w.db.MarkUnconfirmedAsChecking(testSite)
+	w.processSubsConfirmations(w.defaultSite, &cmdlib.ExistenceListResults{
 		Streamers: map[string]cmdlib.StreamerInfoWithStatus{
 			"pending_model": {Status: cmdlib.StatusOnline},
 		},
@@ -1166,7 +1166,7 @@ func TestPolledListNumbersItsChunks(t *testing.T) {
 	for i := range 60 {
 		nickname := fmt.Sprintf("polled_%02d", i)
 		insertTestStreamer(&w.db, db.Streamer{Nickname: nickname})
-		if !w.db.SetPoll(nickname, true) {
+		if !w.db.SetPoll(testSite, nickname, true) {
 			t.Fatalf("cannot poll %s", nickname)
 		}
 	}
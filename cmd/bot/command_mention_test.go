@@ -71,6 +71,14 @@ var unaddressedKeys = map[string]bool{
 	"timezone_app_placeholder":     true,
 	"timezone_app_no_results":      true,
 	"timezone_app_failed":          true,
+	"quiet_button":                 true,
+	"quiet_app_header":             true,
+	"quiet_app_from":               true,
+	"quiet_app_to":                 true,
+	"quiet_app_summary":            true,
+	"quiet_app_save":               true,
+	"quiet_app_off":                true,
+	"quiet_app_failed":             true,
 	"remove_button":                true,
 	"removal_app_header":           true,
 	"removal_app_placeholder":      true,
@@ -78,6 +86,13 @@ var unaddressedKeys = map[string]bool{
 	"removal_app_no_results":       true,
 	"removal_app_failed":           true,
 	"removal_app_failed_to_remove": true,
+
+	// The removal app's filter editor, and the button opening it from /filter.
+	"removal_app_filter":             true,
+	"removal_app_filter_placeholder": true,
+	"removal_app_filter_save":        true,
+	"removal_app_failed_to_filter":   true,
+	"filter_button":                  true,
 }
 
 // TestTranslationsMarkEveryCommand guards the one gap of the explicit form:
@@ -25,8 +25,12 @@ func (w *testWorker) nextOutgoing() *queuedMessage {
 	return w.sendQueue.pop()
 }
 
+// testSite is the test worker's default site, the one streamers are stored under.
+const testSite = "test"
+
 var testConfig = botconfig.Config{
 	CheckGID: true,
+	Website:  testSite,
 	MaxSubs:  3,
 	// Off the small ids ordinary-user fixtures use, so no test passes the owner gate by accident.
 	OwnerID:              424242,
@@ -79,6 +83,10 @@ var testTranslations = cmdlib.Translations{
 		Key: "timezone_app_no_results", Str: "TimezoneAppNoResults", Parse: cmdlib.ParseRaw},
 	TimezoneAppFailed: &cmdlib.Translation{
 		Key: "timezone_app_failed", Str: "TimezoneAppFailed", Parse: cmdlib.ParseRaw},
+	Quiet:        &cmdlib.Translation{Key: "quiet", Str: "Quiet", Parse: cmdlib.ParseRaw},
+	QuietInvalid: &cmdlib.Translation{Key: "quiet_invalid", Str: "QuietInvalid", Parse: cmdlib.ParseRaw},
+	QuietButton:  &cmdlib.Translation{Key: "quiet_button", Str: "QuietButton", Parse: cmdlib.ParseRaw},
+	QuietSummary: &cmdlib.Translation{Key: "quiet_summary", Str: "QuietSummary", Parse: cmdlib.ParseRaw},
 	RemoveButton: &cmdlib.Translation{
 		Key: "remove_button", Str: "RemoveButton", Parse: cmdlib.ParseRaw},
 	RemovalAppHeader: &cmdlib.Translation{
@@ -93,6 +101,45 @@ var testTranslations = cmdlib.Translations{
 		Key: "removal_app_failed", Str: "RemovalAppFailed", Parse: cmdlib.ParseRaw},
 	RemovalAppFailedToRemove: &cmdlib.Translation{
 		Key: "removal_app_failed_to_remove", Str: "RemovalAppFailedToRemove", Parse: cmdlib.ParseRaw},
+	RemovalAppFilter: &cmdlib.Translation{
+		Key: "removal_app_filter", Str: "RemovalAppFilter", Parse: cmdlib.ParseRaw},
+	RemovalAppFilterPlaceholder: &cmdlib.Translation{
+		Key: "removal_app_filter_placeholder", Str: "RemovalAppFilterPlaceholder", Parse: cmdlib.ParseRaw},
+	RemovalAppFilterSave: &cmdlib.Translation{
+		Key: "removal_app_filter_save", Str: "RemovalAppFilterSave", Parse: cmdlib.ParseRaw},
+	RemovalAppFailedToFilter: &cmdlib.Translation{
+		Key: "removal_app_failed_to_filter", Str: "RemovalAppFailedToFilter", Parse: cmdlib.ParseRaw},
+	SyntaxFilter:           &cmdlib.Translation{Key: "syntax_filter", Str: "SyntaxFilter", Parse: cmdlib.ParseRaw},
+	Filter:                 &cmdlib.Translation{Key: "filter", Str: "Filter", Parse: cmdlib.ParseRaw},
+	FilterInvalid:          &cmdlib.Translation{Key: "filter_invalid", Str: "FilterInvalid", Parse: cmdlib.ParseRaw},
+	FilterPending:          &cmdlib.Translation{Key: "filter_pending", Str: "FilterPending", Parse: cmdlib.ParseRaw},
+	FilterButton:           &cmdlib.Translation{Key: "filter_button", Str: "FilterButton", Parse: cmdlib.ParseRaw},
+	ShowKindChange:         &cmdlib.Translation{Key: "show_kind_change", Str: "ShowKindChange", Parse: cmdlib.ParseRaw},
+	ShowChanges:            &cmdlib.Translation{Key: "show_changes", Str: "ShowChanges", Parse: cmdlib.ParseRaw},
+	ShowChangesInvalid:     &cmdlib.Translation{Key: "show_changes_invalid", Str: "ShowChangesInvalid", Parse: cmdlib.ParseRaw},
+	SubjectChange:          &cmdlib.Translation{Key: "subject_change", Str: "SubjectChange", Parse: cmdlib.ParseRaw},
+	Digest:                 &cmdlib.Translation{Key: "digest", Str: "Digest", Parse: cmdlib.ParseRaw},
+	DigestInvalid:          &cmdlib.Translation{Key: "digest_invalid", Str: "DigestInvalid", Parse: cmdlib.ParseRaw},
+	DigestSummary:          &cmdlib.Translation{Key: "digest_summary", Str: "DigestSummary", Parse: cmdlib.ParseRaw},
+	NoDigest:               &cmdlib.Translation{Key: "nodigest", Str: "NoDigest", Parse: cmdlib.ParseRaw},
+	SyntaxNoDigest:         &cmdlib.Translation{Key: "syntax_nodigest", Str: "SyntaxNoDigest", Parse: cmdlib.ParseRaw},
+	NoDigestPending:        &cmdlib.Translation{Key: "nodigest_pending", Str: "NoDigestPending", Parse: cmdlib.ParseRaw},
+	LiveEnded:              &cmdlib.Translation{Key: "live_ended", Str: "LiveEnded", Parse: cmdlib.ParseRaw},
+	Muted:                  &cmdlib.Translation{Key: "muted", Str: "Muted", Parse: cmdlib.ParseRaw},
+	Unmuted:                &cmdlib.Translation{Key: "unmuted", Str: "Unmuted", Parse: cmdlib.ParseRaw},
+	SyntaxMute:             &cmdlib.Translation{Key: "syntax_mute", Str: "SyntaxMute", Parse: cmdlib.ParseRaw},
+	Favourite:              &cmdlib.Translation{Key: "favourite", Str: "Favourite", Parse: cmdlib.ParseRaw},
+	SyntaxFavourite:        &cmdlib.Translation{Key: "syntax_favourite", Str: "SyntaxFavourite", Parse: cmdlib.ParseRaw},
+	FavouritePending:       &cmdlib.Translation{Key: "favourite_pending", Str: "FavouritePending", Parse: cmdlib.ParseRaw},
+	InlineStatus:           &cmdlib.Translation{Key: "inline_status", Str: "InlineStatus", Parse: cmdlib.ParseHTML},
+	InlineDescription:      &cmdlib.Translation{Key: "inline_description", Str: "InlineDescription", Parse: cmdlib.ParseRaw},
+	InlineSubscribeButton:  &cmdlib.Translation{Key: "inline_subscribe_button", Str: "Subscribe", Parse: cmdlib.ParseRaw},
+	Topic:                  &cmdlib.Translation{Key: "topic", Str: "Topic", Parse: cmdlib.ParseRaw},
+	SyntaxTopic:            &cmdlib.Translation{Key: "syntax_topic", Str: "SyntaxTopic", Parse: cmdlib.ParseRaw},
+	TopicForumsOnly:        &cmdlib.Translation{Key: "topic_forums_only", Str: "TopicForumsOnly", Parse: cmdlib.ParseRaw},
+	Board:                  &cmdlib.Translation{Key: "board", Str: "Board", Parse: cmdlib.ParseRaw},
+	BoardSet:               &cmdlib.Translation{Key: "board_set", Str: "BoardSet", Parse: cmdlib.ParseRaw},
+	SyntaxBoard:            &cmdlib.Translation{Key: "syntax_board", Str: "SyntaxBoard", Parse: cmdlib.ParseRaw},
 	Week:                   &cmdlib.Translation{Key: "week", Str: "Week", Parse: cmdlib.ParseRaw},
 	WeekChunk:              &cmdlib.Translation{Key: "week_chunk", Str: "WeekChunk", Parse: cmdlib.ParseRaw},
 	WeekNeverOnline:        &cmdlib.Translation{Key: "week_never_online", Str: "WeekNeverOnline", Parse: cmdlib.ParseRaw},
@@ -119,6 +166,14 @@ var testTranslations = cmdlib.Translations{
 		Str:   "BuySubsPackageButton",
 		Parse: cmdlib.ParseRaw,
 	},
+	NotificationMuteButton: &cmdlib.Translation{
+		Key: "notification_mute_button", Str: "NotificationMuteButton", Parse: cmdlib.ParseRaw},
+	NotificationRemoveButton: &cmdlib.Translation{
+		Key: "notification_remove_button", Str: "NotificationRemoveButton", Parse: cmdlib.ParseRaw},
+	NotificationWeekButton: &cmdlib.Translation{
+		Key: "notification_week_button", Str: "NotificationWeekButton", Parse: cmdlib.ParseRaw},
+	NotificationWatchButton: &cmdlib.Translation{
+		Key: "notification_watch_button", Str: "NotificationWatchButton", Parse: cmdlib.ParseRaw},
 	SubsPurchased: &cmdlib.Translation{
 		Key:   "subs_purchased",
 		Str:   "SubsPurchased",
@@ -263,6 +318,20 @@ func newTestWorker() *testWorker {
 	template.Must(tpl.New("list").Parse("List"))
 	template.Must(tpl.New("week").Parse("Week"))
 	template.Must(tpl.New("ok").Parse("OK"))
+	template.Must(tpl.New("muted").Parse("Muted"))
+	template.Must(tpl.New("unmuted").Parse("Unmuted"))
+	template.Must(tpl.New("syntax_mute").Parse("SyntaxMute"))
+	template.Must(tpl.New("favourite").Parse("Favourite"))
+	template.Must(tpl.New("syntax_favourite").Parse("SyntaxFavourite"))
+	template.Must(tpl.New("favourite_pending").Parse("FavouritePending"))
+	template.Must(tpl.New("topic").Parse("Topic"))
+	template.Must(tpl.New("syntax_topic").Parse("SyntaxTopic"))
+	template.Must(tpl.New("topic_forums_only").Parse("TopicForumsOnly"))
+	template.Must(tpl.New("board").Parse("Board{{ range .entries }} {{ .Link }}{{ end }}"))
+	template.Must(tpl.New("board_set").Parse("BoardSet"))
+	template.Must(tpl.New("syntax_board").Parse("SyntaxBoard"))
+	template.Must(tpl.New("inline_status").Parse("{{ .streamer }} {{ .status }}"))
+	template.Must(tpl.New("inline_description").Parse("{{ .status }}"))
 	template.Must(tpl.New("admins_only").Parse("AdminsOnly"))
 	template.Must(tpl.New("groups_only").Parse("GroupsOnly"))
 	template.Must(tpl.New("timezone").Parse("Timezone {{ .timezone }}"))
@@ -286,9 +355,13 @@ func newTestWorker() *testWorker {
 			sendResults: make(chan msgSendResult, sendChanCap),
 			cooledUsers: make(chan cooledUser, sendChanCap),
 			shutdownCh:  make(chan struct{}),
-			checker:     &checkers.RandomChecker{BaseChecker: checkers.NewBaseChecker(&checkers.TestCheckerConfig{})},
+			hookPosts:   make(chan *hookPost, hookQueueLen),
 		},
 	}
+	w.sites, w.defaultSite = buildSites(
+		&testConfig,
+		&checkers.RandomChecker{BaseChecker: checkers.NewBaseChecker(&checkers.TestCheckerConfig{})},
+		nil)
 	// Hold the test endpoint's slot so enqueues park for inspection.
 	s := w.sender("test")
 	s.cooling = true
@@ -347,18 +420,24 @@ func (w *testWorker) chatsForStreamer(nickname string) (chats []int64, endpoints
 	return
 }
 
+// insertTestStreamer stores s under its Site, testSite where it names none.
 func insertTestStreamer(d *db.Database, s db.Streamer) int {
-	d.MustExec("insert into nicknames (nickname) values ($1)", s.Nickname)
+	if s.Site == "" {
+		s.Site = testSite
+	}
+	d.MustExec("insert into nicknames (site, nickname) values ($1, $2)", s.Site, s.Nickname)
 	return d.MustInt(`
 		insert into streamers (
+			site,
 			nickname,
 			confirmed_status,
 			unconfirmed_status,
 			unconfirmed_timestamp,
 			prev_unconfirmed_status,
 			prev_unconfirmed_timestamp)
-		values ($1, $2, $3, $4, $5, $6)
+		values ($1, $2, $3, $4, $5, $6, $7)
 		returning id`,
+		s.Site,
 		s.Nickname,
 		s.ConfirmedStatus,
 		s.UnconfirmedStatus,
@@ -373,16 +452,16 @@ func insertSubscription(d *db.Database, endpoint string, chatID int64, nickname
 		insert into subscriptions (endpoint, user_id, streamer_id)
 		select $1, u.id, s.id
 		from users u, streamers s
-		where u.chat_id = $2 and s.nickname = $3`,
-		endpoint, chatID, nickname)
+		where u.chat_id = $2 and s.site = $4 and s.nickname = $3`,
+		endpoint, chatID, nickname, testSite)
 }
 
 func insertPendingSubscription(d *db.Database, endpoint string, chatID int64, nickname string, checking bool) {
 	d.AddUser(chatID, 0, 0, "")
 	d.MustExec(`
-		insert into pending_subscriptions (endpoint, user_id, nickname, checking)
-		select $1, u.id, $3, $4
+		insert into pending_subscriptions (endpoint, user_id, site, nickname, checking)
+		select $1, u.id, $5, $3, $4
 		from users u
 		where u.chat_id = $2`,
-		endpoint, chatID, nickname, checking)
+		endpoint, chatID, nickname, checking, testSite)
 }
@@ -0,0 +1,258 @@
+// Digests: a chat may have its streamer alerts replaced by one scheduled message,
+// sent daily or on Mondays at a time of its local day,
+// listing who was online since the last one, for how long and with how many viewers at the peak.
+// The time online is read off status_changes; the peaks are kept hourly in viewer_peaks,
+// only for the streamers some digest reads of.
+// A subscription marked with /nodigest is alerted as usual and left out of the digest.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+const (
+	// digestCheckPeriod is how often the chats are checked for a digest that has come due.
+	digestCheckPeriod = time.Minute
+	// digestChunk caps the lines of one digest message, as quietSummaryChunk does.
+	digestChunk = 50
+	// digestOffArgument takes a chat off its digest.
+	digestOffArgument = "off"
+	// noDigestOffArgument returns a subscription marked with /nodigest to the digest.
+	noDigestOffArgument = "off"
+	// viewerPeaksRetention keeps the hourly peaks a weekly digest reads, with a day to spare.
+	viewerPeaksRetention = 8 * 24 * time.Hour
+)
+
+// digestPeriodArguments names the digest periods as /digest takes them.
+var digestPeriodArguments = []struct {
+	name   string
+	period db.DigestPeriod
+}{
+	{"daily", db.DigestDaily},
+	{"weekly", db.DigestWeekly},
+}
+
+// parseDigest reads a digest as daily 09:00 or weekly 09:00; off reads as db.DigestOff.
+func parseDigest(args []string) (period db.DigestPeriod, minute int, ok bool) {
+	if len(args) == 1 && strings.EqualFold(args[0], digestOffArgument) {
+		return db.DigestOff, 0, true
+	}
+	if len(args) != 2 {
+		return db.DigestOff, 0, false
+	}
+	for _, a := range digestPeriodArguments {
+		if strings.EqualFold(args[0], a.name) {
+			period = a.period
+		}
+	}
+	if period == db.DigestOff {
+		return db.DigestOff, 0, false
+	}
+	minute, ok = parseClock(args[1])
+	return period, minute, ok
+}
+
+// formatDigest prints a digest the way /digest takes it, empty for none.
+func formatDigest(period db.DigestPeriod, minute int) string {
+	for _, a := range digestPeriodArguments {
+		if a.period == period {
+			return a.name + " " + formatClock(minute)
+		}
+	}
+	return ""
+}
+
+// digestDue is the last time at or before now a digest was due on the chat's clock:
+// minute past midnight of every day, or of every Monday for a weekly one.
+// The date is walked in UTC, as in weekWindow, so no shift of loc can move it.
+func digestDue(now time.Time, loc *time.Location, period db.DigestPeriod, minute int) time.Time {
+	year, month, day := now.In(loc).Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	for {
+		if period != db.DigestWeekly || date.Weekday() == time.Monday {
+			year, month, day = date.Date()
+			due := time.Date(year, month, day, minute/60, minute%60, 0, 0, loc)
+			if !due.After(now) {
+				return due
+			}
+		}
+		date = date.AddDate(0, 0, -1)
+	}
+}
+
+// onlineSeconds sums the time a streamer was online from from on,
+// its changes ending with the sentinel ChangesFromToForStreamers closes them with.
+func onlineSeconds(changes []db.StatusChange, from int) int {
+	total := 0
+	for i, c := range changes[:len(changes)-1] {
+		if c.Status == cmdlib.StatusOnline {
+			total += changes[i+1].Timestamp - max(c.Timestamp, from)
+		}
+	}
+	return total
+}
+
+// digestEntry is a line of a digest: a streamer that was online in its window.
+// Viewers is nil where no peak was recorded.
+type digestEntry struct {
+	Link    string
+	Online  timeDiff
+	Viewers *int
+	seconds int
+}
+
+// digestEntries lists the streamers of subs online between from and to, the longest online first.
+func digestEntries(
+	subs []db.DigestSubscription,
+	changes map[int][]db.StatusChange,
+	peaks map[int]int,
+	from int,
+	link func(siteName, nickname string) string,
+) []digestEntry {
+	var entries []digestEntry
+	for _, sub := range subs {
+		seconds := onlineSeconds(changes[sub.StreamerID], from)
+		if seconds <= 0 {
+			continue
+		}
+		entry := digestEntry{Link: link(sub.Site, sub.Nickname), Online: calcTimeDiff(seconds), seconds: seconds}
+		if viewers, ok := peaks[sub.StreamerID]; ok {
+			entry.Viewers = &viewers
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].seconds > entries[j].seconds })
+	return entries
+}
+
+// sendDigests sends every chat whose digest has come due the one it is owed.
+// A digest missed while the bot was down is sent once, for the last window.
+// Main goroutine only.
+func (w *worker) sendDigests(now time.Time) {
+	for _, user := range w.db.UsersWithDigests() {
+		loc, _ := w.chatLocation(user)
+		due := digestDue(now, loc, user.DigestPeriod, user.DigestTime)
+		if int(due.Unix()) <= user.DigestSentAt {
+			continue
+		}
+		from := digestDue(due.Add(-time.Second), loc, user.DigestPeriod, user.DigestTime)
+		w.sendDigest(user, int(from.Unix()), int(due.Unix()))
+		w.db.MarkDigestSent(user.UserID, int(due.Unix()))
+	}
+}
+
+// sendDigest sends a chat its digest of the window from from to to, one per endpoint it subscribed on.
+func (w *worker) sendDigest(user db.User, from, to int) {
+	subs := w.db.DigestSubscriptions(user.UserID)
+	if len(subs) == 0 {
+		return
+	}
+	streamerIDs := make([]int, len(subs))
+	byEndpoint := map[string][]db.DigestSubscription{}
+	var endpoints []string
+	for i, sub := range subs {
+		streamerIDs[i] = sub.StreamerID
+		if _, seen := byEndpoint[sub.Endpoint]; !seen {
+			endpoints = append(endpoints, sub.Endpoint)
+		}
+		byEndpoint[sub.Endpoint] = append(byEndpoint[sub.Endpoint], sub)
+	}
+	changes := w.db.ChangesFromToForStreamers(streamerIDs, from, to)
+	peaks := w.db.PeakViewers(streamerIDs, from, to)
+	link := w.streamerLinker(w.gatedAffiliate(user.AffiliateParams))
+	weekly := user.DigestPeriod == db.DigestWeekly
+	for _, endpoint := range endpoints {
+		if w.tr[endpoint] == nil {
+			// An endpoint dropped from the config; there is no one to tell.
+			lerr("dropping digest for unknown endpoint %s", endpoint)
+			continue
+		}
+		entries := digestEntries(byEndpoint[endpoint], changes, peaks, from, link)
+		// An empty digest still goes out, so the chat knows it is on one.
+		for i := 0; i == 0 || i < len(entries); i += digestChunk {
+			w.sendTr(db.PriorityLow, endpoint, user.UserID, !user.SilentMessages, w.tr[endpoint].DigestSummary,
+				tplData{"entries": entries[i:min(i+digestChunk, len(entries))], "first": i == 0, "weekly": weekly},
+				unprompted(db.NotificationPacket))
+		}
+	}
+}
+
+// replyDigest answers with the chat's digest.
+// help carries the ways to change it and belongs to the bare command, as for replyQuiet.
+func (w *worker) replyDigest(m receivedMessage, help bool) {
+	user := w.mustUserByID(m.userID)
+	_, zone := w.chatLocation(user)
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].Digest, tplData{
+		"digest":   formatDigest(user.DigestPeriod, user.DigestTime),
+		"timezone": zone,
+		"help":     help,
+	})
+}
+
+// setDigest shows the chat's digest or sets it from daily 09:00, weekly 09:00 or off.
+// The first digest of a new schedule is the next one due, never one already past.
+func (w *worker) setDigest(m receivedMessage, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) == 0 {
+		w.replyDigest(m, true)
+		return
+	}
+	period, minute, ok := parseDigest(parts)
+	if !ok {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].DigestInvalid, nil)
+		return
+	}
+	if period == db.DigestOff {
+		w.db.ResetDigest(m.userID)
+	} else {
+		w.db.SetDigest(m.userID, period, minute, m.timestamp)
+	}
+	w.replyDigest(m, false)
+}
+
+// setNoDigest keeps a subscription out of the chat's digest, alerted as usual, or with off returns it to the digest.
+func (w *worker) setNoDigest(m receivedMessage, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) == 0 || len(parts) > 2 || (len(parts) == 2 && !strings.EqualFold(parts[1], noDigestOffArgument)) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].SyntaxNoDigest, nil)
+		return
+	}
+	s, nickname := w.parseStreamer(parts[0])
+	name := w.qualifiedName(s.name, nickname)
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": name})
+		return
+	}
+	exempt := len(parts) == 1
+	if !w.db.SetSubscriptionNoDigest(m.userID, s.name, nickname, m.endpoint, exempt) {
+		tr := w.tr[m.endpoint].StreamerNotInList
+		if w.db.SubscribedOrPending(m.endpoint, m.userID, s.name, nickname) {
+			tr = w.tr[m.endpoint].NoDigestPending
+		}
+		w.replyTr(m, db.PriorityHigh, false, tr, tplData{"streamer": name})
+		return
+	}
+	user := w.mustUserByID(m.userID)
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].NoDigest, tplData{
+		"streamer": name,
+		"exempt":   exempt,
+		"digest":   user.DigestPeriod != db.DigestOff,
+	})
+}
+
+// viewerCounts lists the viewers of the streamers online that report them.
+func viewerCounts(streamers map[string]cmdlib.StreamerInfo) []db.ViewerCount {
+	var counts []db.ViewerCount
+	for nickname, info := range streamers {
+		if info.Viewers != nil {
+			counts = append(counts, db.ViewerCount{Nickname: nickname, Viewers: *info.Viewers})
+		}
+	}
+	return counts
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestParseDigest(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		ok   bool
+		want string
+	}{
+		{"daily 09:00", true, "daily 09:00"},
+		{"WEEKLY 9:30", true, "weekly 09:30"},
+		{"off", true, ""},
+		{"OFF", true, ""},
+		{"", false, ""},
+		{"daily", false, ""},
+		{"daily 24:00", false, ""},
+		{"monthly 09:00", false, ""},
+		{"daily 09:00 extra", false, ""},
+		{"off 09:00", false, ""},
+	}
+	for _, tc := range tests {
+		period, minute, ok := parseDigest(strings.Fields(tc.in))
+		if ok != tc.ok {
+			t.Errorf("parseDigest(%q) ok = %v, want %v", tc.in, ok, tc.ok)
+			continue
+		}
+		if got := formatDigest(period, minute); ok && got != tc.want {
+			t.Errorf("parseDigest(%q) reads back as %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestDigestDue(t *testing.T) {
+	t.Parallel()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	// 2026-03-11 is a Wednesday.
+	at := func(loc *time.Location, day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, loc)
+	}
+	tests := []struct {
+		name   string
+		now    time.Time
+		loc    *time.Location
+		period db.DigestPeriod
+		want   time.Time
+	}{
+		{"daily after the time", at(time.UTC, 11, 10, 0), time.UTC, db.DigestDaily, at(time.UTC, 11, 9, 0)},
+		{"daily on the time", at(time.UTC, 11, 9, 0), time.UTC, db.DigestDaily, at(time.UTC, 11, 9, 0)},
+		{"daily before the time", at(time.UTC, 11, 8, 59), time.UTC, db.DigestDaily, at(time.UTC, 10, 9, 0)},
+		{"weekly", at(time.UTC, 11, 10, 0), time.UTC, db.DigestWeekly, at(time.UTC, 9, 9, 0)},
+		{"weekly before Monday's time", at(time.UTC, 9, 8, 0), time.UTC, db.DigestWeekly, at(time.UTC, 2, 9, 0)},
+		{"on the chat's clock", at(time.UTC, 11, 8, 30), berlin, db.DigestDaily, at(berlin, 11, 9, 0)},
+	}
+	for _, tc := range tests {
+		if got := digestDue(tc.now, tc.loc, tc.period, 9*60); !got.Equal(tc.want) {
+			t.Errorf("%s: due %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDigestEntries(t *testing.T) {
+	t.Parallel()
+	const from, to = 10000, 20000
+	changes := map[int][]db.StatusChange{
+		// Online since before the window, offline inside it.
+		1: {{Status: cmdlib.StatusOnline, Timestamp: 9000}, {Status: cmdlib.StatusOffline, Timestamp: 10600}, {Timestamp: to}},
+		// Two sessions, the last still going.
+		2: {
+			{Status: cmdlib.StatusOnline, Timestamp: 11000}, {Status: cmdlib.StatusOffline, Timestamp: 14600},
+			{Status: cmdlib.StatusOnline, Timestamp: 18200}, {Timestamp: to},
+		},
+		// Offline throughout.
+		3: {{Status: cmdlib.StatusOffline, Timestamp: 5000}, {Timestamp: to}},
+	}
+	subs := []db.DigestSubscription{
+		{StreamerID: 1, Nickname: "a"},
+		{StreamerID: 2, Nickname: "b"},
+		{StreamerID: 3, Nickname: "c"},
+	}
+	link := func(_, nickname string) string { return nickname }
+	got := digestEntries(subs, changes, map[int]int{2: 150}, from, link)
+	if len(got) != 2 || got[0].Link != "b" || got[1].Link != "a" {
+		t.Fatalf("entries = %+v, want b then a", got)
+	}
+	if got[0].Online != calcTimeDiff(5400) || got[0].Viewers == nil || *got[0].Viewers != 150 {
+		t.Errorf("b = %+v, want 1h 30m with 150 viewers", got[0])
+	}
+	if got[1].Online != calcTimeDiff(600) || got[1].Viewers != nil {
+		t.Errorf("a = %+v, want 10m with no viewers", got[1])
+	}
+}
+
+func TestDigestTemplatesRender(t *testing.T) {
+	t.Parallel()
+	viewers := 150
+	entries := []digestEntry{
+		{Link: "alice", Online: calcTimeDiff(5400), Viewers: &viewers},
+		{Link: "bob", Online: calcTimeDiff(600)},
+	}
+	settings := settingsData(false)
+	settings["digest"] = "daily 09:00"
+	assertTemplatesRender(t, []templateCase{
+		{"off", "digest", tplData{"digest": "", "timezone": "UTC"}, "09:00", false},
+		{"set", "digest", tplData{"digest": "weekly 09:00", "timezone": "Europe/Berlin"}, "Europe/Berlin", true},
+		{"help", "digest", tplData{"digest": "", "timezone": "UTC", "help": true}, "weekly", true},
+		{"invalid", "digest_invalid", nil, "daily 09:00", true},
+		{"summary", "digest_summary", tplData{"entries": entries, "first": true}, "bob", true},
+		{"summary viewers", "digest_summary", tplData{"entries": entries, "first": true, "weekly": true}, "150", true},
+		{"summary tail", "digest_summary", tplData{"entries": entries, "first": false}, "📋", false},
+		{"summary empty", "digest_summary", tplData{"entries": []digestEntry{}, "first": true}, "📋", true},
+		{"nodigest", "nodigest", tplData{"streamer": "alice", "exempt": true, "digest": true}, "alice", true},
+		{"nodigest off", "nodigest", tplData{"streamer": "alice", "exempt": false, "digest": true}, "alice", true},
+		{"nodigest syntax", "syntax_nodigest", nil, "off", true},
+		{"nodigest pending", "nodigest_pending", tplData{"streamer": "alice"}, "alice", true},
+		{"commands", "commands", nil, "nodigest", true},
+		{"settings", "settings", settings, "daily 09:00", true},
+	})
+}
+
+// A chat on a digest is alerted at once only of the subscriptions marked with /nodigest,
+// and the digest lists the rest once it comes due, and only once.
+func TestDigestReplacesLiveAlerts(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	aID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	bID := insertTestStreamer(&w.db, db.Streamer{Nickname: "b"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 1, "b")
+	user, _ := w.db.User(1)
+	now := time.Now()
+	due := digestDue(now, time.UTC, db.DigestDaily, 0)
+	w.db.SetDigest(user.UserID, db.DigestDaily, 0, int(due.Unix())-1)
+	if !w.db.SetSubscriptionNoDigest(user.UserID, testSite, "b", "test", true) {
+		t.Fatal("the subscription to keep out of the digest was not found")
+	}
+
+	alerted := func() (nicknames []string) {
+		for _, n := range w.buildNotifications([]db.ConfirmedStatusChange{
+			{StreamerID: aID, Site: testSite, Nickname: "a", Status: cmdlib.StatusOnline, PrevStatus: cmdlib.StatusOffline},
+			{StreamerID: bID, Site: testSite, Nickname: "b", Status: cmdlib.StatusOnline, PrevStatus: cmdlib.StatusOffline},
+		}) {
+			nicknames = append(nicknames, n.Nickname)
+		}
+		return
+	}
+	if got := alerted(); len(got) != 1 || got[0] != "b" {
+		t.Errorf("alerted %v, want the subscription kept out of the digest alone", got)
+	}
+
+	w.db.UpsertUnconfirmedStatusChanges(testSite, []db.StatusChange{
+		{Nickname: "a", Status: cmdlib.StatusOnline, Timestamp: int(due.Unix()) - 3600},
+	}, nil, int(due.Unix())-3600)
+	w.db.ConfirmStatusChanges(int(due.Unix())-3600, 0, 0)
+	w.db.RecordViewerPeaks(testSite, []db.ViewerCount{{Nickname: "a", Viewers: 42}}, int(due.Unix())-1800)
+
+	w.sendDigests(now)
+	if got := w.sendQueue.Len(); got != 1 {
+		t.Fatalf("queued %d digests, want 1", got)
+	}
+	queued := w.sendQueue.pop()
+	queued.message.render("")
+	if got := queued.message.(*messageParams).Text; got != "DigestSummary" {
+		t.Errorf("sent %q, want the digest", got)
+	}
+	if peaks := w.db.PeakViewers([]int{aID}, int(due.Unix())-7200, int(due.Unix())); peaks[aID] != 42 {
+		t.Errorf("peak viewers %v, want 42", peaks)
+	}
+	w.sendDigests(now)
+	if got := w.sendQueue.Len(); got != 0 {
+		t.Errorf("sent the same digest again, %d queued", got)
+	}
+
+	w.db.ResetDigest(user.UserID)
+	if got := alerted(); len(got) != 2 {
+		t.Errorf("off the digest alerted %v, want both", got)
+	}
+}
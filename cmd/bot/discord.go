@@ -0,0 +1,314 @@
+// Discord delivery: a chat's online and offline notifications are mirrored
+// to the Discord channels the owner linked to it, each posted through the channel's webhook.
+// The posts go through the same scheduler as Telegram messages,
+// queued on discordEndpoint under the chat's user.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/botconfig"
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+const (
+	// discordEndpoint names the sender the Discord posts queue on.
+	discordEndpoint = botconfig.DiscordEndpoint
+	// discordChannelCooldown is the gap between two posts to one chat's channels,
+	// inside Discord's 30 messages a minute for a channel.
+	discordChannelCooldown = 2 * time.Second
+	// Embed colors
+	discordOnlineColor  = 0x2ecc71
+	discordOfflineColor = 0x95a5a6
+)
+
+// discordEmbed is the part of a Discord embed a notification fills.
+type discordEmbed struct {
+	Title       string                `json:"title"`
+	URL         string                `json:"url,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Color       int                   `json:"color"`
+	Fields      []discordEmbedField   `json:"fields,omitempty"`
+	Image       *discordEmbedImageRef `json:"image,omitempty"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbedImageRef struct {
+	URL string `json:"url"`
+}
+
+// discordMessage is one post to one Discord channel.
+type discordMessage struct {
+	webhookURL string
+	// chat is the Telegram chat the post mirrors,
+	// so the whitelist and the send log treat it as that chat's.
+	chat  int64
+	embed discordEmbed
+}
+
+func (m *discordMessage) chatID() int64 { return m.chat }
+
+func (m *discordMessage) setChatID(id int64) { m.chat = id }
+
+// render has nothing to do: a Discord post names no command.
+func (m *discordMessage) render(string) {}
+
+// discordTransport posts to Discord webhooks.
+type discordTransport struct {
+	client *http.Client
+}
+
+func (t *discordTransport) userCooldown(int64) time.Duration {
+	return discordChannelCooldown
+}
+
+// send posts the embed and maps Discord's answer onto the Telegram results,
+// so the scheduler retries and logs it alike:
+// a deleted webhook reads as a chat not found, a revoked one as a block,
+// and a Discord outage as a network error.
+func (t *discordTransport) send(ctx context.Context, msg sendable) (result int, migrateTo int64, retryAfter int) {
+	m, ok := msg.(*discordMessage)
+	if !ok {
+		panic("a message Discord cannot send")
+	}
+	body, err := json.Marshal(map[string]any{"embeds": []discordEmbed{m.embed}})
+	checkErr(err)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		lerr("cannot build a Discord request, %v", err)
+		return messageBadRequest, 0, 0
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			ldbg("cannot post to Discord, timeout")
+			return messageTimeout, 0, 0
+		}
+		lerr("cannot post to Discord, %v", err)
+		return messageUnknownNetworkError, 0, 0
+	}
+	defer cmdlib.CloseBody(resp.Body)
+	switch status := resp.StatusCode; {
+	case status >= 200 && status < 300:
+		return messageSent, 0, 0
+	case status == http.StatusTooManyRequests:
+		retryAfter := discordRetryAfter(resp)
+		ldbg("cannot post to Discord, too many requests: retry_after = %d", retryAfter)
+		return messageTooManyRequests, 0, retryAfter
+	case status == http.StatusNotFound:
+		ldbg("cannot post to Discord, webhook not found")
+		return messageChatNotFound, 0, 0
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		ldbg("cannot post to Discord, webhook forbidden")
+		return messageBlocked, 0, 0
+	case status >= 500:
+		lerr("cannot post to Discord, status %d", status)
+		return messageUnknownNetworkError, 0, 0
+	default:
+		lerr("cannot post to Discord, status %d", status)
+		return messageBadRequest, 0, 0
+	}
+}
+
+// discordRetryAfter reads a 429's pause in whole seconds, rounded up:
+// the body's retry_after in fractional seconds, the Retry-After header when the body has none.
+func discordRetryAfter(resp *http.Response) int {
+	var limited struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err == nil && json.Unmarshal(data, &limited) == nil && limited.RetryAfter > 0 {
+		return int(math.Ceil(limited.RetryAfter))
+	}
+	return parseRetryAfter(resp.Header.Get("Retry-After"))
+}
+
+// validDiscordWebhookURL reports whether rawURL is a Discord webhook,
+// so the owner cannot aim the sender at any other address by a typo.
+func validDiscordWebhookURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+	switch parsed.Host {
+	case "discord.com", "discordapp.com", "ptb.discord.com", "canary.discord.com":
+	default:
+		return false
+	}
+	return strings.HasPrefix(parsed.Path, "/api/webhooks/")
+}
+
+// discordEmbedFor renders a planned status notification as an embed.
+// It carries what the Telegram message would: the picture, viewers, show kind and subject,
+// or the summary of the session an offline alert closes, each only where the notification has it.
+func (w *worker) discordEmbedFor(p plannedNotification, link string) discordEmbed {
+	e := discordEmbed{
+		Title: w.qualifiedName(p.Site, p.Nickname) + " is " + p.Status.String(),
+		URL:   link,
+		Color: discordOfflineColor,
+	}
+	if p.Status != cmdlib.StatusOnline {
+		if p.Session != nil {
+			e.Fields = discordSessionFields(p.Session)
+		}
+		return e
+	}
+	e.Color = discordOnlineColor
+	e.Description = p.Subject
+	if p.ImageURL != "" {
+		e.Image = &discordEmbedImageRef{URL: p.ImageURL}
+	}
+	if p.Viewers != nil {
+		e.Fields = append(e.Fields, discordEmbedField{Name: "Viewers", Value: strconv.Itoa(*p.Viewers), Inline: true})
+	}
+	if p.ShowKind != cmdlib.ShowUnknown {
+		e.Fields = append(e.Fields, discordEmbedField{Name: "Show", Value: p.ShowKind.String(), Inline: true})
+	}
+	return e
+}
+
+// discordSessionFields lays a session summary out as embed fields.
+func discordSessionFields(s *db.Session) []discordEmbedField {
+	fields := []discordEmbedField{{Name: "Session", Value: discordDuration(s.End - s.Start), Inline: true}}
+	if s.PeakViewers != nil && s.AverageViewers != nil {
+		fields = append(fields,
+			discordEmbedField{Name: "Peak viewers", Value: strconv.Itoa(*s.PeakViewers), Inline: true},
+			discordEmbedField{Name: "Average viewers", Value: strconv.Itoa(*s.AverageViewers), Inline: true})
+	}
+	if len(s.ShowKinds) > 0 {
+		kinds := make([]string, len(s.ShowKinds))
+		for i, k := range s.ShowKinds {
+			kinds[i] = k.String()
+		}
+		fields = append(fields, discordEmbedField{Name: "Shows", Value: strings.Join(kinds, ", "), Inline: true})
+	}
+	return fields
+}
+
+// discordDuration prints seconds as the duration template does in English, e.g. 1h 30m.
+func discordDuration(seconds int) string {
+	d := calcTimeDiff(max(seconds, 0))
+	switch {
+	case d.Days > 0:
+		return fmt.Sprintf("%dd %dh", d.Days, d.Hours)
+	case d.Hours > 0:
+		return fmt.Sprintf("%dh %dm", d.Hours, d.Minutes)
+	}
+	return fmt.Sprintf("%dm", d.Minutes)
+}
+
+// mirrorToDiscord queues the batch's online and offline notifications
+// for the Discord channels of their chats.
+// A chat subscribed through two endpoints gets two notifications of one change;
+// its channels get one post.
+// The posts are best-effort: no queue row tracks them, so one lost at shutdown is gone.
+// Main goroutine only.
+func (w *worker) mirrorToDiscord(plans []plannedNotification) {
+	var userIDs []db.UserID
+	for _, p := range plans {
+		if mirroredToDiscord(p) {
+			userIDs = append(userIDs, p.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	channels := w.db.DiscordChannelsForUsers(userIDs)
+	type change struct {
+		userID     db.UserID
+		streamerID int
+		status     cmdlib.StatusKind
+	}
+	seen := map[change]bool{}
+	for _, p := range plans {
+		if !mirroredToDiscord(p) || len(channels[p.UserID]) == 0 {
+			continue
+		}
+		c := change{userID: p.UserID, status: p.Status}
+		if p.StreamerID != nil {
+			c.streamerID = *p.StreamerID
+		}
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		link := w.streamerURL(p.Site, p.Nickname, w.gatedAffiliate(p.AffiliateParams))
+		embed := w.discordEmbedFor(p, link)
+		for _, webhookURL := range channels[p.UserID] {
+			msg := &discordMessage{webhookURL: webhookURL, embed: embed}
+			w.enqueueMessage(p.Priority, discordEndpoint, msg, unprompted(db.NotificationPacket), p.UserID, 0)
+		}
+	}
+}
+
+// mirroredToDiscord reports whether a notification is a status alert a Discord channel gets.
+// A live message ending stands in for its chat's offline alert, so it is posted as one.
+func mirroredToDiscord(p plannedNotification) bool {
+	if p.Kind == db.LivePacket {
+		return p.translation != nil && p.Status == cmdlib.StatusOffline
+	}
+	return p.Kind == db.NotificationPacket &&
+		p.translation != nil &&
+		(p.Status == cmdlib.StatusOnline || p.Status == cmdlib.StatusOffline)
+}
+
+// addDiscord links a Discord channel, by its webhook URL, to a chat.
+func (w *worker) addDiscord(endpoint string, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) != 2 {
+		w.replyToOwner(endpoint, "expecting two arguments")
+		return
+	}
+	user, ok := w.ownerChatArgument(endpoint, parts[0])
+	if !ok {
+		return
+	}
+	if !validDiscordWebhookURL(parts[1]) {
+		w.replyToOwner(endpoint, "second argument is not a Discord webhook URL")
+		return
+	}
+	if !w.db.AddDiscordChannel(user.UserID, parts[1]) {
+		w.replyToOwner(endpoint, "already linked")
+		return
+	}
+	w.replyToOwner(endpoint, "OK")
+}
+
+// removeDiscord unlinks a Discord channel from a chat.
+func (w *worker) removeDiscord(endpoint string, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) != 2 {
+		w.replyToOwner(endpoint, "expecting two arguments")
+		return
+	}
+	user, ok := w.ownerChatArgument(endpoint, parts[0])
+	if !ok {
+		return
+	}
+	if !w.db.RemoveDiscordChannel(user.UserID, parts[1]) {
+		w.replyToOwner(endpoint, "no such Discord channel")
+		return
+	}
+	w.replyToOwner(endpoint, "OK")
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bcmk/siren/v4/internal/botconfig"
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// Discord's answers map onto the results the scheduler already retries and logs.
+func TestDiscordTransportSend(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		header         string
+		body           string
+		wantResult     int
+		wantRetryAfter int
+	}{
+		{name: "sent", status: http.StatusNoContent, wantResult: messageSent},
+		{name: "deleted webhook", status: http.StatusNotFound, wantResult: messageChatNotFound},
+		{name: "revoked webhook", status: http.StatusUnauthorized, wantResult: messageBlocked},
+		{name: "malformed embed", status: http.StatusBadRequest, wantResult: messageBadRequest},
+		{name: "outage", status: http.StatusBadGateway, wantResult: messageUnknownNetworkError},
+		{
+			name:           "rate limited, fractional body pause rounds up",
+			status:         http.StatusTooManyRequests,
+			body:           `{"retry_after": 1.2}`,
+			wantResult:     messageTooManyRequests,
+			wantRetryAfter: 2,
+		},
+		{
+			name:           "rate limited, header pause",
+			status:         http.StatusTooManyRequests,
+			header:         "3",
+			wantResult:     messageTooManyRequests,
+			wantRetryAfter: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted map[string][]discordEmbed
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(data, &posted)
+				if tt.header != "" {
+					rw.Header().Set("Retry-After", tt.header)
+				}
+				rw.WriteHeader(tt.status)
+				_, _ = rw.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			tr := &discordTransport{client: srv.Client()}
+			msg := &discordMessage{webhookURL: srv.URL, embed: discordEmbed{Title: "a is online"}}
+			result, _, retryAfter := tr.send(context.Background(), msg)
+			if result != tt.wantResult || retryAfter != tt.wantRetryAfter {
+				t.Errorf("got (%d, %d), want (%d, %d)", result, retryAfter, tt.wantResult, tt.wantRetryAfter)
+			}
+			if len(posted["embeds"]) != 1 || posted["embeds"][0].Title != "a is online" {
+				t.Errorf("posted %v", posted)
+			}
+		})
+	}
+}
+
+func TestValidDiscordWebhookURL(t *testing.T) {
+	for rawURL, want := range map[string]bool{
+		"https://discord.com/api/webhooks/1/token":        true,
+		"https://discordapp.com/api/webhooks/1/token":     true,
+		"https://canary.discord.com/api/webhooks/1/token": true,
+		"http://discord.com/api/webhooks/1/token":         false,
+		"https://discord.com/channels/1/2":                false,
+		"https://discord.com.example.com/api/webhooks/1":  false,
+		"https://example.com/api/webhooks/1/token":        false,
+		"discord.com/api/webhooks/1/token":                false,
+	} {
+		if got := validDiscordWebhookURL(rawURL); got != want {
+			t.Errorf("validDiscordWebhookURL(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
+
+func TestDiscordEmbedFor(t *testing.T) {
+	w := siteWorker(botconfig.Config{Website: testSite})
+	viewers := 12
+	online := w.discordEmbedFor(plannedNotification{Notification: db.Notification{
+		Site: testSite, Nickname: "a", Status: cmdlib.StatusOnline,
+		ImageURL: "https://img/a.jpg", Viewers: &viewers, ShowKind: cmdlib.ShowGroup, Subject: "hi",
+	}}, "https://example.com/a")
+	if online.Title != "a is online" || online.URL != "https://example.com/a" || online.Description != "hi" ||
+		online.Image == nil || online.Image.URL != "https://img/a.jpg" || len(online.Fields) != 2 {
+		t.Errorf("online embed = %+v", online)
+	}
+	offline := w.discordEmbedFor(plannedNotification{Notification: db.Notification{
+		Site: testSite, Nickname: "a", Status: cmdlib.StatusOffline, Subject: "hi",
+	}}, "https://example.com/a")
+	if offline.Title != "a is offline" || offline.Description != "" || offline.Color != discordOfflineColor {
+		t.Errorf("offline embed = %+v", offline)
+	}
+	peak, average := 30, 20
+	summarized := w.discordEmbedFor(plannedNotification{Notification: db.Notification{
+		Site: testSite, Nickname: "a", Status: cmdlib.StatusOffline,
+		Session: &db.Session{
+			Start: 100, End: 5500, PeakViewers: &peak, AverageViewers: &average,
+			ShowKinds: []cmdlib.ShowKind{cmdlib.ShowPublic, cmdlib.ShowPrivate},
+		},
+	}}, "https://example.com/a")
+	if len(summarized.Fields) != 4 || summarized.Fields[0].Value != "1h 30m" || summarized.Fields[3].Value != "public, private" {
+		t.Errorf("offline embed with a session = %+v", summarized)
+	}
+}
+
+// A chat's status alerts go to each of its Discord channels once,
+// however many endpoints notified it of the change.
+func TestMirrorToDiscord(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	// Hold the Discord slot too, so the posts park for inspection.
+	discord := w.sender(discordEndpoint)
+	discord.cooling = true
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 2, "a")
+	linked, _ := w.db.User(1)
+	unlinked, _ := w.db.User(2)
+	w.db.AddDiscordChannel(linked.UserID, "https://discord.com/api/webhooks/1/x")
+	w.db.AddDiscordChannel(linked.UserID, "https://discord.com/api/webhooks/2/y")
+
+	notification := func(userID db.UserID, kind db.PacketKind) db.Notification {
+		return db.Notification{
+			Endpoint:   "test",
+			UserID:     userID,
+			StreamerID: &streamerID,
+			Site:       testSite,
+			Nickname:   "a",
+			Status:     cmdlib.StatusOnline,
+			Kind:       kind,
+		}
+	}
+	w.mirrorToDiscord(w.planNotifications([]db.Notification{
+		notification(linked.UserID, db.NotificationPacket),
+		notification(linked.UserID, db.NotificationPacket),
+		notification(unlinked.UserID, db.NotificationPacket),
+		// A command's deferred answer is not an alert.
+		notification(linked.UserID, db.ReplyPacket),
+	}))
+
+	if got := discord.queue.Len(); got != 2 {
+		t.Fatalf("queued %d Discord posts, want 2", got)
+	}
+	urls := map[string]bool{}
+	for q := discord.queue.pop(); q != nil; q = discord.queue.pop() {
+		msg := q.message.(*discordMessage)
+		if q.userID != linked.UserID || q.notificationID != 0 || msg.embed.Title != "a is online" {
+			t.Errorf("queued %+v, %+v", q, msg)
+		}
+		urls[msg.webhookURL] = true
+	}
+	if len(urls) != 2 {
+		t.Errorf("posted to %v, want both channels", urls)
+	}
+}
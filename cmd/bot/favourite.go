@@ -0,0 +1,43 @@
+// Favourites: /favourite alice stars a subscription, and /favourite alice off unstars it.
+// A favourite alerts with sound in a silent chat, always with its picture and subject,
+// ahead of the chat's other alerts, and live while the chat is on a digest.
+// /list shows favourites first, marked.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/bcmk/siren/v4/internal/db"
+)
+
+// favouriteOffArgument unstars a favourite.
+const favouriteOffArgument = "off"
+
+// setFavourite stars a subscription as a favourite, or with off unstars it.
+func (w *worker) setFavourite(m receivedMessage, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) == 0 || len(parts) > 2 || (len(parts) == 2 && !strings.EqualFold(parts[1], favouriteOffArgument)) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].SyntaxFavourite, nil)
+		return
+	}
+	s, nickname := w.parseStreamer(parts[0])
+	name := w.qualifiedName(s.name, nickname)
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": name})
+		return
+	}
+	favourite := len(parts) == 1
+	if !w.db.SetSubscriptionFavourite(m.userID, s.name, nickname, m.endpoint, favourite) {
+		tr := w.tr[m.endpoint].StreamerNotInList
+		if w.db.SubscribedOrPending(m.endpoint, m.userID, s.name, nickname) {
+			tr = w.tr[m.endpoint].FavouritePending
+		}
+		w.replyTr(m, db.PriorityHigh, false, tr, tplData{"streamer": name})
+		return
+	}
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].Favourite, tplData{
+		"streamer":  name,
+		"favourite": favourite,
+	})
+}
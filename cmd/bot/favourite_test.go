@@ -0,0 +1,94 @@
+package main
+
+import (
+	"container/heap"
+	"slices"
+	"testing"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestMessageLessFavourites(t *testing.T) {
+	t.Parallel()
+	h := &msgHeap{}
+	for _, q := range []*queuedMessage{
+		{seq: 1, priority: db.PriorityLow},
+		{seq: 2, priority: db.PriorityLow, favourite: true},
+		{seq: 3, priority: db.PriorityHigh},
+		{seq: 4, priority: db.PriorityLow, favourite: true},
+		{seq: 5, priority: db.PriorityLow},
+	} {
+		heap.Push(h, q)
+	}
+	var got []uint64
+	for h.Len() > 0 {
+		got = append(got, heap.Pop(h).(*queuedMessage).seq)
+	}
+	if want := []uint64{3, 2, 4, 1, 5}; !slices.Equal(got, want) {
+		t.Errorf("pop order = %v, want %v: priority, then favourites, each in order", got, want)
+	}
+}
+
+func TestFavouriteTemplatesRender(t *testing.T) {
+	t.Parallel()
+	list := tplData{
+		"online":  []streamerListEntry{{Link: "alice", Favourite: true, Muted: true}},
+		"offline": []streamerListEntry{{Link: "bob"}},
+	}
+	assertTemplatesRender(t, []templateCase{
+		{"starred", "favourite", tplData{"streamer": "alice", "favourite": true}, "alice off", true},
+		{"unstarred", "favourite", tplData{"streamer": "alice", "favourite": false}, "off", false},
+		{"syntax", "syntax_favourite", nil, "off", true},
+		{"pending", "favourite_pending", tplData{"streamer": "alice"}, "alice", true},
+		{"list marker", "list", list, "alice ⭐ 🔕", true},
+		{"list unmarked", "list", list, "bob ⭐", false},
+		{"commands", "commands", nil, "favourite", true},
+	})
+}
+
+// A favourite is alerted live in a chat on a digest, with its picture in a chat that shows none,
+// and is left out of the digest.
+func TestFavourites(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	aID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	bID := insertTestStreamer(&w.db, db.Streamer{Nickname: "b"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 1, "b")
+	user, _ := w.db.User(1)
+	w.db.SetShowImages(user.UserID, false)
+	w.db.SetDigest(user.UserID, db.DigestDaily, 0, 0)
+
+	if !w.db.SetSubscriptionFavourite(user.UserID, testSite, "b", "test", true) {
+		t.Fatal("the subscription to star was not found")
+	}
+	if w.db.SetSubscriptionFavourite(user.UserID, testSite, "c", "test", true) {
+		t.Error("starred a subscription the chat does not have")
+	}
+	if favourites := w.db.FavouriteStreamers("test", user.UserID); len(favourites) != 1 || !favourites[bID] {
+		t.Errorf("favourites = %v, want b alone", favourites)
+	}
+	if subs := w.db.DigestSubscriptions(user.UserID); len(subs) != 1 || subs[0].StreamerID != aID {
+		t.Errorf("digest subscriptions = %+v, want a alone", subs)
+	}
+
+	w.defaultSite.unconfirmedOnlineStreamers["b"] = cmdlib.StreamerInfo{ImageURL: "https://example.com/b.jpg"}
+	alerted := w.buildNotifications([]db.ConfirmedStatusChange{
+		{StreamerID: aID, Site: testSite, Nickname: "a", Status: cmdlib.StatusOnline, PrevStatus: cmdlib.StatusOffline},
+		{StreamerID: bID, Site: testSite, Nickname: "b", Status: cmdlib.StatusOnline, PrevStatus: cmdlib.StatusOffline},
+	})
+	if len(alerted) != 1 || alerted[0].Nickname != "b" || !alerted[0].Favourite || alerted[0].ImageURL == "" {
+		t.Errorf("alerted %+v, want b alone, a favourite with its picture", alerted)
+	}
+
+	w.db.StoreNotifications(alerted)
+	stored := w.db.NewNotifications()
+	if len(stored) != 1 || !stored[0].Favourite {
+		t.Errorf("stored notifications %+v lost the favourite mark", stored)
+	}
+}
@@ -0,0 +1,276 @@
+// Subscription filters: conditions a start must meet to be alerted to one subscription.
+// A filter reads "public viewers 100 keyword dance" in /filter and in the removal web app alike,
+// and is stored as a db.SubscriptionFilter in the subscription's row.
+// It judges only what the streamer's site reports:
+// an unknown show kind, a missing viewer count or a site without subjects lets a start through.
+
+package main
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+const (
+	// filterOffArgument clears a subscription's filter.
+	filterOffArgument = "off"
+	// filterPublicArgument admits a public show alone.
+	filterPublicArgument = "public"
+	// filterViewersArgument takes the fewest viewers a start may have.
+	filterViewersArgument = "viewers"
+	// filterKeywordArgument takes a word the subject must contain.
+	filterKeywordArgument = "keyword"
+	// maxFilterKeywordLength bounds a keyword, which is matched against every start.
+	maxFilterKeywordLength = 64
+)
+
+// parseFilter reads a filter from the words following the nickname.
+// off reads as nil, a filter cleared; each condition may be given once.
+func parseFilter(args []string) (filter *db.SubscriptionFilter, ok bool) {
+	if len(args) == 1 && strings.EqualFold(args[0], filterOffArgument) {
+		return nil, true
+	}
+	if len(args) == 0 {
+		return nil, false
+	}
+	f := db.SubscriptionFilter{}
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case filterPublicArgument:
+			if f.PublicOnly {
+				return nil, false
+			}
+			f.PublicOnly = true
+		case filterViewersArgument:
+			if f.MinViewers != nil || i+1 == len(args) {
+				return nil, false
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n <= 0 {
+				return nil, false
+			}
+			f.MinViewers = &n
+		case filterKeywordArgument:
+			if f.Keyword != "" || i+1 == len(args) {
+				return nil, false
+			}
+			i++
+			if len(args[i]) > maxFilterKeywordLength {
+				return nil, false
+			}
+			f.Keyword = strings.ToLower(args[i])
+		default:
+			return nil, false
+		}
+	}
+	return &f, true
+}
+
+// formatFilter writes a filter the way parseFilter reads it, empty for none.
+func formatFilter(f *db.SubscriptionFilter) string {
+	if f == nil {
+		return ""
+	}
+	var parts []string
+	if f.PublicOnly {
+		parts = append(parts, filterPublicArgument)
+	}
+	if f.MinViewers != nil {
+		parts = append(parts, filterViewersArgument, strconv.Itoa(*f.MinViewers))
+	}
+	if f.Keyword != "" {
+		parts = append(parts, filterKeywordArgument, f.Keyword)
+	}
+	return strings.Join(parts, " ")
+}
+
+// filterAdmits reports whether a start with this info passes the filter.
+// subjects tells whether the streamer's site reports a subject at all,
+// since a keyword cannot be looked for where there is none to look in.
+func filterAdmits(f *db.SubscriptionFilter, info cmdlib.StreamerInfo, subjects bool) bool {
+	if f == nil {
+		return true
+	}
+	if f.PublicOnly && info.ShowKind != cmdlib.ShowUnknown && info.ShowKind != cmdlib.ShowPublic {
+		return false
+	}
+	if f.MinViewers != nil && info.Viewers != nil && *info.Viewers < *f.MinViewers {
+		return false
+	}
+	if f.Keyword != "" && subjects && !strings.Contains(strings.ToLower(info.Subject), f.Keyword) {
+		return false
+	}
+	return true
+}
+
+// siteReportsSubject reports whether a site surfaces room subjects, false for a site not served.
+func (w *worker) siteReportsSubject(siteName string) bool {
+	s := w.sites[siteName]
+	return s != nil && s.checker.Capabilities().SupportsSubject
+}
+
+// replyFilterSyntax explains /filter, offering the removal app,
+// which lists the subscriptions with their filters, in a private chat.
+func (w *worker) replyFilterSyntax(m receivedMessage) {
+	tr := w.tr[m.endpoint].SyntaxFilter
+	params := &renderParams{templates: w.tpl[m.endpoint], key: tr.Key}
+	msg := params.asDeferredText(false, tr.DisablePreview, tr.Parse)
+	if !isGroupOrChannel(m.chatID) {
+		msg.ReplyMarkup = &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{{
+				{
+					Text:   w.tr[m.endpoint].FilterButton.Str,
+					WebApp: &models.WebAppInfo{URL: w.removalAppURL(m.endpoint)},
+				},
+			}},
+		}
+	}
+	w.replyMessage(m, db.PriorityHigh, msg)
+}
+
+// setFilter shows a subscription's filter, or sets it from the words after the nickname.
+func (w *worker) setFilter(m receivedMessage, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) == 0 {
+		w.replyFilterSyntax(m)
+		return
+	}
+	s, nickname := w.parseStreamer(parts[0])
+	name := w.qualifiedName(s.name, nickname)
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": name})
+		return
+	}
+	var filter *db.SubscriptionFilter
+	var found bool
+	if len(parts) == 1 {
+		filter, found = w.db.SubscriptionFilter(m.userID, s.name, nickname, m.endpoint)
+	} else {
+		var ok bool
+		filter, ok = parseFilter(parts[1:])
+		if !ok {
+			w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].FilterInvalid, nil)
+			return
+		}
+		found = w.db.SetSubscriptionFilter(m.userID, s.name, nickname, m.endpoint, filter)
+	}
+	if !found {
+		tr := w.tr[m.endpoint].StreamerNotInList
+		if w.db.SubscribedOrPending(m.endpoint, m.userID, s.name, nickname) {
+			tr = w.tr[m.endpoint].FilterPending
+		}
+		w.replyTr(m, db.PriorityHigh, false, tr, tplData{"streamer": name})
+		return
+	}
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].Filter, tplData{
+		"streamer":          name,
+		"filter":            html.EscapeString(formatFilter(filter)),
+		"keyword_unchecked": filter != nil && filter.Keyword != "" && !w.siteReportsSubject(s.name),
+	})
+}
+
+// webAppFilterRequest carries a filter saved in the removal web app.
+type webAppFilterRequest struct {
+	endpoint string
+	chatID   int64
+	nickname string
+	// filter is the filter in /filter's words, off to clear it.
+	filter string
+	// admittedCh reports whether the request was admitted, as for webAppRemoveRequest:
+	// an admitted one answers in the chat, having saved the filter or explained a refusal.
+	admittedCh chan bool
+}
+
+// webAppFilterCommand names a filter saved from the removal web app.
+const webAppFilterCommand = "web_app_filter"
+
+// performWebAppFilter saves a filter from the removal web app.
+// Main goroutine only, gated and logged as performWebAppRemove is.
+func (w *worker) performWebAppFilter(req webAppFilterRequest) {
+	if !w.admitChat("web app filter", req.chatID) {
+		req.admittedCh <- false
+		return
+	}
+	m, _ := w.newReceivedMessage(int(time.Now().Unix()), req.endpoint, req.chatID, "", webAppFilterCommand)
+	w.logReceived(m)
+	// Either way setFilter has answered in the chat.
+	w.setFilter(m, req.nickname+" "+req.filter)
+	req.admittedCh <- true
+}
+
+// submitWebAppFilter hands a request to the main loop and waits for its verdict,
+// as submitWebAppRemove does.
+func (w *worker) submitWebAppFilter(req webAppFilterRequest) (admitted, alive bool) {
+	select {
+	case w.webAppFilterRequests <- req:
+	case <-w.shutdownCh:
+		return false, false
+	}
+	return <-req.admittedCh, true
+}
+
+func (w *worker) handleWebAppFilter(rw http.ResponseWriter, r *http.Request) {
+	endpoint := r.URL.Query().Get("endpoint")
+	if _, ok := w.cfg.Endpoints[endpoint]; !ok {
+		lerr("web app filter: bad endpoint %q", endpoint)
+		http.Error(rw, "bad endpoint", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		http.Error(rw, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	rw.Header().Set("Cache-Control", "no-store")
+	values, ok := w.parseInitData(r.Header.Get("X-Init-Data"), string(w.cfg.Endpoints[endpoint].BotToken))
+	if !ok {
+		lerr("web app filter: invalid init data for endpoint %s", endpoint)
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	// One word: the rest would be read by setFilter as the filter.
+	nickname := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("streamer")))
+	if nickname == "" || strings.ContainsAny(nickname, " \t\n") {
+		http.Error(rw, "bad streamer", http.StatusBadRequest)
+		return
+	}
+	// Checked here as well, so a filter the page got wrong reads as a failure on the page
+	// rather than as a complaint in the chat behind it.
+	filter := strings.TrimSpace(r.URL.Query().Get("filter"))
+	if _, ok := parseFilter(strings.Fields(filter)); !ok {
+		http.Error(rw, "bad filter", http.StatusBadRequest)
+		return
+	}
+	userID, ok := webAppUserID(values)
+	if !ok {
+		lerr("web app filter: missing user id")
+		http.Error(rw, "missing user id", http.StatusBadRequest)
+		return
+	}
+	req := webAppFilterRequest{
+		endpoint:   endpoint,
+		chatID:     userID,
+		nickname:   nickname,
+		filter:     filter,
+		admittedCh: make(chan bool, 1),
+	}
+	admitted, alive := w.submitWebAppFilter(req)
+	if !alive {
+		http.Error(rw, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if !admitted {
+		http.Error(rw, "forbidden", http.StatusForbidden)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in    string
+		ok    bool
+		clear bool
+		want  string
+	}{
+		{"public", true, false, "public"},
+		{"viewers 100 public", true, false, "public viewers 100"},
+		{"KEYWORD Dance", true, false, "keyword dance"},
+		{"public viewers 5 keyword dance", true, false, "public viewers 5 keyword dance"},
+		{"off", true, true, ""},
+		{"OFF", true, true, ""},
+		{"", false, false, ""},
+		{"viewers", false, false, ""},
+		{"viewers 0", false, false, ""},
+		{"viewers many", false, false, ""},
+		{"keyword", false, false, ""},
+		{"public public", false, false, ""},
+		{"public off", false, false, ""},
+		{"private", false, false, ""},
+		{"keyword " + strings.Repeat("x", maxFilterKeywordLength+1), false, false, ""},
+	}
+	for _, tc := range tests {
+		f, ok := parseFilter(strings.Fields(tc.in))
+		if ok != tc.ok {
+			t.Errorf("parseFilter(%q) ok = %v, want %v", tc.in, ok, tc.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if (f == nil) != tc.clear {
+			t.Errorf("parseFilter(%q) cleared = %v, want %v", tc.in, f == nil, tc.clear)
+		}
+		if got := formatFilter(f); got != tc.want {
+			t.Errorf("parseFilter(%q) reads back as %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestFilterAdmits(t *testing.T) {
+	t.Parallel()
+	viewers := func(n int) *int { return &n }
+	public := &db.SubscriptionFilter{PublicOnly: true}
+	crowd := &db.SubscriptionFilter{MinViewers: viewers(100)}
+	keyword := &db.SubscriptionFilter{Keyword: "dance"}
+	tests := []struct {
+		name     string
+		filter   *db.SubscriptionFilter
+		info     cmdlib.StreamerInfo
+		subjects bool
+		want     bool
+	}{
+		{"no filter", nil, cmdlib.StreamerInfo{ShowKind: cmdlib.ShowPrivate}, true, true},
+		{"public show", public, cmdlib.StreamerInfo{ShowKind: cmdlib.ShowPublic}, true, true},
+		{"private show", public, cmdlib.StreamerInfo{ShowKind: cmdlib.ShowPrivate}, true, false},
+		{"away", public, cmdlib.StreamerInfo{ShowKind: cmdlib.ShowAway}, true, false},
+		{"unknown show kind", public, cmdlib.StreamerInfo{}, true, true},
+		{"enough viewers", crowd, cmdlib.StreamerInfo{Viewers: viewers(100)}, true, true},
+		{"too few viewers", crowd, cmdlib.StreamerInfo{Viewers: viewers(99)}, true, false},
+		{"unknown viewers", crowd, cmdlib.StreamerInfo{}, true, true},
+		{"keyword in subject", keyword, cmdlib.StreamerInfo{Subject: "Let's DANCE"}, true, true},
+		{"keyword missing", keyword, cmdlib.StreamerInfo{Subject: "chat"}, true, false},
+		{"no subjects on the site", keyword, cmdlib.StreamerInfo{}, false, true},
+		{
+			"every condition must hold",
+			&db.SubscriptionFilter{PublicOnly: true, MinViewers: viewers(10)},
+			cmdlib.StreamerInfo{ShowKind: cmdlib.ShowPublic, Viewers: viewers(5)},
+			true, false,
+		},
+	}
+	for _, tc := range tests {
+		if got := filterAdmits(tc.filter, tc.info, tc.subjects); got != tc.want {
+			t.Errorf("%s: admits = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFilterTemplatesRender(t *testing.T) {
+	t.Parallel()
+	assertTemplatesRender(t, []templateCase{
+		{"set", "filter", tplData{"streamer": "alice", "filter": "public viewers 100"}, "viewers 100", true},
+		{"cleared", "filter", tplData{"streamer": "alice", "filter": ""}, "alice", true},
+		{
+			"keyword unchecked", "filter",
+			tplData{"streamer": "alice", "filter": "keyword dance", "keyword_unchecked": true},
+			"\n", true,
+		},
+		{"syntax", "syntax_filter", nil, "viewers N", true},
+		{"invalid", "filter_invalid", nil, "off", true},
+		{"pending", "filter_pending", tplData{"streamer": "alice"}, "alice", true},
+		{"commands", "commands", nil, "filter", true},
+	})
+}
+
+// TestHandleWebAppFilterValidates holds the filter submit to the removal's shape,
+// refusing a filter /filter would refuse before it reaches the loop.
+func TestHandleWebAppFilterValidates(t *testing.T) {
+	t.Parallel()
+	const botToken = "123:test-token"
+	w := &worker{
+		cfg:                  searchConfig(t, botToken, nil),
+		webAppFilterRequests: make(chan webAppFilterRequest, 1),
+		shutdownCh:           make(chan struct{}),
+	}
+	send := func(method, query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/apps/remove/api/filter?endpoint=test&"+query, nil)
+		r.Header.Set("X-Init-Data", initDataFor(botToken, 1))
+		rw := httptest.NewRecorder()
+		w.handleWebAppFilter(rw, r)
+		return rw
+	}
+	if rw := send(http.MethodGet, "streamer=a&filter=public"); rw.Code != http.StatusMethodNotAllowed {
+		t.Errorf("a GET got %d, want %d", rw.Code, http.StatusMethodNotAllowed)
+	}
+	for _, query := range []string{
+		"streamer=&filter=public",
+		"streamer=a+b&filter=public",
+		"streamer=a&filter=",
+		"streamer=a&filter=viewers+none",
+	} {
+		if rw := send(http.MethodPost, query); rw.Code != http.StatusBadRequest {
+			t.Errorf("%s got %d, want %d", query, rw.Code, http.StatusBadRequest)
+		}
+	}
+	if queued := len(w.webAppFilterRequests); queued != 0 {
+		t.Errorf("a refused filter still queued %d requests", queued)
+	}
+	serve := func(query string, admit bool) (*httptest.ResponseRecorder, webAppFilterRequest) {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() { done <- send(http.MethodPost, query) }()
+		var req webAppFilterRequest
+		select {
+		case req = <-w.webAppFilterRequests:
+			req.admittedCh <- admit
+		case <-time.After(time.Second):
+			t.Fatal("a valid filter never reached the loop")
+		}
+		return <-done, req
+	}
+	rw, req := serve("streamer=Some_Model&filter=public+viewers+10", true)
+	if rw.Code != http.StatusOK {
+		t.Errorf("an admitted filter got %d, want %d", rw.Code, http.StatusOK)
+	}
+	if req.chatID != 1 || req.nickname != "some_model" || req.filter != "public viewers 10" {
+		t.Errorf("queued %+v", req)
+	}
+	if rw, _ := serve("streamer=a&filter=off", false); rw.Code != http.StatusForbidden {
+		t.Errorf("an unvetted chat got %d, want %d", rw.Code, http.StatusForbidden)
+	}
+}
+
+// A filtered subscription hears of the starts it admits alone, and never of going offline,
+// while another subscription to the same streamer hears of everything,
+// offline alerts included, as testConfig has them on.
+func TestBuildNotificationsAppliesFilters(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 2, "a")
+	filtered, _ := w.db.User(1)
+	minViewers := 100
+	if !w.db.SetSubscriptionFilter(filtered.UserID, testSite, "a", "test", &db.SubscriptionFilter{MinViewers: &minViewers}) {
+		t.Fatal("the subscription to filter was not found")
+	}
+
+	alerted := func(status cmdlib.StatusKind, viewers int) (chats []int64) {
+		w.defaultSite.unconfirmedOnlineStreamers["a"] = cmdlib.StreamerInfo{Viewers: &viewers}
+		nots := w.buildNotifications([]db.ConfirmedStatusChange{{
+			StreamerID: streamerID,
+			Site:       testSite,
+			Nickname:   "a",
+			Status:     status,
+			PrevStatus: cmdlib.StatusOffline,
+		}})
+		for _, n := range nots {
+			user := w.mustUserByID(n.UserID)
+			chats = append(chats, user.ChatID)
+		}
+		return
+	}
+	has := func(chats []int64, chatID int64) bool {
+		for _, c := range chats {
+			if c == chatID {
+				return true
+			}
+		}
+		return false
+	}
+	if chats := alerted(cmdlib.StatusOnline, 10); has(chats, 1) || !has(chats, 2) {
+		t.Errorf("a small start alerted %v, want chat 2 alone", chats)
+	}
+	if chats := alerted(cmdlib.StatusOnline, 150); !has(chats, 1) || !has(chats, 2) {
+		t.Errorf("a crowded start alerted %v, want both chats", chats)
+	}
+	if chats := alerted(cmdlib.StatusOffline, 0); has(chats, 1) || !has(chats, 2) {
+		t.Errorf("going offline alerted %v, want chat 2 alone", chats)
+	}
+
+	// Cleared, the subscription hears of every start again.
+	w.db.SetSubscriptionFilter(filtered.UserID, testSite, "a", "test", nil)
+	if chats := alerted(cmdlib.StatusOnline, 10); !has(chats, 1) {
+		t.Errorf("a cleared filter still held back a start, alerted %v", chats)
+	}
+}
+
+// /filter reads back what it stores, and tells a pending subscription from a missing one.
+func TestSetFilterCommand(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertPendingSubscription(&w.db, "test", 1, "b", false)
+
+	reply := func(arguments string) string {
+		w.setFilter(testMessage(w, 1, "filter", 100), arguments)
+		queued := w.sendQueue.pop()
+		queued.message.render("")
+		return queued.message.(*messageParams).Text
+	}
+	if got := reply("a public keyword Dance"); got != "Filter" {
+		t.Errorf("setting a filter answered %q", got)
+	}
+	user, _ := w.db.User(1)
+	f, found := w.db.SubscriptionFilter(user.UserID, testSite, "a", "test")
+	if !found || formatFilter(f) != "public keyword dance" {
+		t.Errorf("stored filter %q, found %v", formatFilter(f), found)
+	}
+	if got := reply("a viewers"); got != "FilterInvalid" {
+		t.Errorf("a bad filter answered %q", got)
+	}
+	if got := reply("b public"); got != "FilterPending" {
+		t.Errorf("a pending subscription answered %q", got)
+	}
+	if got := reply("c public"); !strings.HasPrefix(got, "StreamerNotInList") {
+		t.Errorf("a missing subscription answered %q", got)
+	}
+	reply("a off")
+	if f, _ := w.db.SubscriptionFilter(user.UserID, testSite, "a", "test"); f != nil {
+		t.Errorf("off left the filter %q", formatFilter(f))
+	}
+}
@@ -0,0 +1,370 @@
+// Notification webhooks: every confirmed status change is also posted as signed JSON
+// to the HTTP targets configured globally and those the owner registered for a chat.
+// "Hook" here always means such a target, never the Telegram webhook the bot listens on.
+// The main goroutine builds the posts; poster goroutines do the I/O only.
+// Retries live in memory: a post still retrying at shutdown is lost.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/botconfig"
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/internal/metrics"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+const (
+	// hookQueueLen caps the posts waiting for a poster; a post past it is dropped.
+	hookQueueLen = 10000
+	// hookPosters is the number of posts in flight at once,
+	// so one slow receiver cannot hold up the rest.
+	hookPosters = 4
+	// hookMaxAttempts bounds the tries of one post, the first included.
+	hookMaxAttempts = 5
+	// hookRetryBackoff delays the first redelivery of a failed post,
+	// doubling for every further one up to tooManyRequestsMaxBackoff.
+	// A 429 with Retry-After waits what the receiver asked for instead.
+	hookRetryBackoff = 10 * time.Second
+	// hookSignatureHeader carries sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">.
+	hookSignatureHeader = "X-Siren-Signature"
+	// hookTimestampHeader is the Unix time the post was signed at, for replay checks.
+	hookTimestampHeader = "X-Siren-Timestamp"
+	// hookDeliveryHeader is the same on every attempt of a post, so a receiver can drop a repeat.
+	hookDeliveryHeader = "X-Siren-Delivery"
+)
+
+// Post results
+const (
+	hookDelivered = iota
+	hookRejected
+	hookTooManyRequests
+	hookServerError
+	hookNetworkError
+)
+
+var hookResultNames = map[int]string{
+	hookDelivered:       "delivered",
+	hookRejected:        "rejected",
+	hookTooManyRequests: "too_many_requests",
+	hookServerError:     "server_error",
+	hookNetworkError:    "network_error",
+}
+
+// hookPayload is the JSON body of a post.
+type hookPayload struct {
+	Site           string `json:"site"`
+	Nickname       string `json:"nickname"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status"`
+	// Timestamp is when the change was confirmed, not when it is posted.
+	Timestamp int    `json:"timestamp"`
+	ImageURL  string `json:"image_url,omitempty"`
+	Viewers   *int   `json:"viewers,omitempty"`
+	ShowKind  string `json:"show_kind,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+	// ChatID names the chat whose hook this is, absent for a global one.
+	ChatID *int64 `json:"chat_id,omitempty"`
+}
+
+// hookPost is one post to one target, carried unchanged through its retries.
+type hookPost struct {
+	url      string
+	secret   string
+	delivery string
+	body     []byte
+	attempt  int
+}
+
+func newHookPayload(c db.ConfirmedStatusChange, info cmdlib.StreamerInfo) hookPayload {
+	p := hookPayload{
+		Site:           c.Site,
+		Nickname:       c.Nickname,
+		Status:         c.Status.String(),
+		PreviousStatus: c.PrevStatus.String(),
+		Timestamp:      c.Timestamp,
+	}
+	// What the online list said is stale once the streamer is gone.
+	if c.Status == cmdlib.StatusOnline {
+		p.ImageURL = info.ImageURL
+		p.Viewers = info.Viewers
+		p.Subject = info.Subject
+		if info.ShowKind != cmdlib.ShowUnknown {
+			p.ShowKind = info.ShowKind.String()
+		}
+	}
+	return p
+}
+
+// signHook returns the signature header value for a body posted at timestamp.
+// The timestamp is signed along, so a captured post cannot be replayed later as fresh.
+func signHook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newHookSecret returns a random key for a chat's hook.
+func newHookSecret() string {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	checkErr(err)
+	return hex.EncodeToString(b)
+}
+
+func newHookPost(url, secret string, payload hookPayload) *hookPost {
+	body, err := json.Marshal(payload)
+	checkErr(err)
+	delivery := make([]byte, 16)
+	_, err = rand.Read(delivery)
+	checkErr(err)
+	return &hookPost{url: url, secret: secret, delivery: hex.EncodeToString(delivery), body: body}
+}
+
+// buildHookPosts makes the posts for confirmed status changes:
+// one to every global hook, and one to the hook of every chat subscribed to the streamer
+// whose subscription would alert the chat of it, mutes and filters applied as buildNotifications does.
+// It skips unknown -> offline like buildNotifications does.
+// Main goroutine only.
+func (w *worker) buildHookPosts(confirmedStatusChanges []db.ConfirmedStatusChange) []*hookPost {
+	if len(confirmedStatusChanges) == 0 {
+		return nil
+	}
+	streamerIDs := make([]int, len(confirmedStatusChanges))
+	for i, c := range confirmedStatusChanges {
+		streamerIDs[i] = c.StreamerID
+	}
+	chatHooks := w.db.NotificationWebhooksForStreamers(streamerIDs, int(time.Now().Unix()))
+	var posts []*hookPost
+	for _, c := range confirmedStatusChanges {
+		if c.PrevStatus == cmdlib.StatusUnknown && c.Status == cmdlib.StatusOffline {
+			continue
+		}
+		info := w.onlineInfo(c.Site, c.Nickname)
+		payload := newHookPayload(c, info)
+		for _, h := range w.cfg.NotificationWebhooks {
+			posts = append(posts, newHookPost(h.URL, string(h.Secret), payload))
+		}
+		subjects := w.siteReportsSubject(c.Site)
+		// A chat subscribed through several endpoints posts once per change.
+		posted := map[db.NotificationWebhook]bool{}
+		for _, h := range chatHooks[c.StreamerID] {
+			if posted[h.NotificationWebhook] {
+				continue
+			}
+			if h.Filter != nil && (c.Status != cmdlib.StatusOnline || !filterAdmits(h.Filter, info, subjects)) {
+				continue
+			}
+			posted[h.NotificationWebhook] = true
+			chatPayload := payload
+			chatPayload.ChatID = &h.ChatID
+			posts = append(posts, newHookPost(h.URL, h.Secret, chatPayload))
+		}
+	}
+	return posts
+}
+
+// enqueueHookPost hands a post to the posters without blocking,
+// dropping it when the queue is full: the main loop must never wait on a receiver.
+func (w *worker) enqueueHookPost(p *hookPost) {
+	select {
+	case w.hookPosts <- p:
+	default:
+		lerr("the notification webhook queue is full, dropping a post to %s", p.url)
+	}
+}
+
+// runHookPoster posts queued hooks until ctx is done.
+func (w *worker) runHookPoster(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-w.hookPosts:
+			w.postHookAttempt(p)
+		}
+	}
+}
+
+// postHookAttempt makes one attempt and schedules the next one if the failure is transient.
+// A pending retry parks a timer entry, not a poster.
+func (w *worker) postHookAttempt(p *hookPost) {
+	p.attempt++
+	result, retryAfter := w.postHook(p)
+	metrics.HookResults.WithLabelValues(hookResultNames[result]).Inc()
+	if result == hookDelivered {
+		return
+	}
+	pause, transient := hookRetryDelay(result, p.attempt, retryAfter)
+	if !transient {
+		linf("a notification webhook rejected a post: url = %s", p.url)
+		return
+	}
+	if p.attempt >= hookMaxAttempts {
+		lerr("giving up on a notification webhook post after %d attempts: url = %s", p.attempt, p.url)
+		return
+	}
+	time.AfterFunc(pause, func() {
+		select {
+		case <-w.shutdownCh:
+		default:
+			w.enqueueHookPost(p)
+		}
+	})
+}
+
+// postHook sends the post once and classifies the answer.
+// retryAfter is the receiver's Retry-After in seconds, zero when absent.
+func (w *worker) postHook(p *hookPost) (result int, retryAfter int) {
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(p.body))
+	if err != nil {
+		lerr("cannot build a notification webhook request, %v", err)
+		return hookRejected, 0
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(hookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(hookDeliveryHeader, p.delivery)
+	req.Header.Set(hookSignatureHeader, signHook(p.secret, timestamp, p.body))
+	resp, err := w.hookClient.Do(req)
+	if err != nil {
+		linf("cannot post to a notification webhook, %v", err)
+		return hookNetworkError, 0
+	}
+	defer cmdlib.CloseBody(resp.Body)
+	return hookResult(resp.StatusCode), parseRetryAfter(resp.Header.Get("Retry-After"))
+}
+
+// hookResult classifies an HTTP status a receiver answered with.
+func hookResult(status int) int {
+	switch {
+	case status >= 200 && status < 300:
+		return hookDelivered
+	case status == http.StatusTooManyRequests:
+		return hookTooManyRequests
+	case status >= 500:
+		return hookServerError
+	}
+	return hookRejected
+}
+
+// parseRetryAfter reads a Retry-After given in seconds;
+// the HTTP-date form and garbage read as absent.
+func parseRetryAfter(value string) int {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
+// hookRetryDelay maps a failed attempt to the pause before the next one.
+// A rejection is not transient: the receiver will not change its mind.
+func hookRetryDelay(result int, attempt int, retryAfterSeconds int) (pause time.Duration, transient bool) {
+	switch result {
+	case hookTooManyRequests:
+		if retryAfterSeconds > 0 {
+			return tooManyRequestsDelay(retryAfterSeconds), true
+		}
+	case hookServerError, hookNetworkError:
+	default:
+		return 0, false
+	}
+	pause = hookRetryBackoff
+	for i := 1; i < attempt && pause < tooManyRequestsMaxBackoff; i++ {
+		pause *= 2
+	}
+	return min(pause, tooManyRequestsMaxBackoff), true
+}
+
+// addHook registers a chat's notification webhook and answers with its secret.
+// Only the owner registers hooks: a chat choosing where the bot posts
+// would let anyone aim it at any address.
+func (w *worker) addHook(endpoint string, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) != 2 {
+		w.replyToOwner(endpoint, "expecting two arguments")
+		return
+	}
+	user, ok := w.ownerChatArgument(endpoint, parts[0])
+	if !ok {
+		return
+	}
+	if !botconfig.ValidWebhookURL(parts[1]) {
+		w.replyToOwner(endpoint, "second argument is not an http or https URL")
+		return
+	}
+	secret := newHookSecret()
+	w.db.SetNotificationWebhook(user.UserID, parts[1], secret)
+	w.replyToOwner(endpoint, "OK, secret: "+secret)
+}
+
+// removeHook removes a chat's notification webhook.
+func (w *worker) removeHook(endpoint string, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) != 2 {
+		w.replyToOwner(endpoint, "expecting two arguments")
+		return
+	}
+	user, ok := w.ownerChatArgument(endpoint, parts[0])
+	if !ok {
+		return
+	}
+	if !w.db.RemoveNotificationWebhook(user.UserID, parts[1]) {
+		w.replyToOwner(endpoint, "no such webhook")
+		return
+	}
+	w.replyToOwner(endpoint, "OK")
+}
+
+// listHooks lists a chat's notification webhooks, secrets left out.
+func (w *worker) listHooks(endpoint string, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) != 1 {
+		w.replyToOwner(endpoint, "expecting one argument")
+		return
+	}
+	user, ok := w.ownerChatArgument(endpoint, parts[0])
+	if !ok {
+		return
+	}
+	hooks := w.db.NotificationWebhooks(user.UserID)
+	if len(hooks) == 0 {
+		w.replyToOwner(endpoint, "no webhooks")
+		return
+	}
+	urls := make([]string, len(hooks))
+	for i, h := range hooks {
+		urls[i] = h.URL
+	}
+	w.replyToOwner(endpoint, strings.Join(urls, "\n"))
+}
+
+// ownerChatArgument resolves the chat argument of an owner command, answering the owner when it cannot.
+func (w *worker) ownerChatArgument(endpoint string, chat string) (db.User, bool) {
+	chatID, err := strconv.ParseInt(chat, 10, 64)
+	if err != nil {
+		w.replyToOwner(endpoint, "first argument is invalid")
+		return db.User{}, false
+	}
+	// User, not EnsureUser: a bad owner arg must not materialize a stray row (see direct).
+	user, found := w.db.User(chatID)
+	if !found {
+		w.replyToOwner(endpoint, "no such user")
+		return db.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/botconfig"
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// A receiver verifies a post by recomputing the signature from the headers and the raw body.
+func TestPostHookIsSigned(t *testing.T) {
+	const secret = "s3cret"
+	type received struct {
+		body      []byte
+		signature string
+		timestamp string
+		delivery  string
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{
+			body:      body,
+			signature: r.Header.Get(hookSignatureHeader),
+			timestamp: r.Header.Get(hookTimestampHeader),
+			delivery:  r.Header.Get(hookDeliveryHeader),
+		}
+	}))
+	defer srv.Close()
+
+	w := &worker{hookClient: srv.Client()}
+	viewers := 7
+	p := newHookPost(srv.URL, secret, newHookPayload(
+		db.ConfirmedStatusChange{Site: "test", Nickname: "a", Status: cmdlib.StatusOnline, PrevStatus: cmdlib.StatusOffline, Timestamp: 100},
+		cmdlib.StreamerInfo{ImageURL: "https://img/a.jpg", Viewers: &viewers, ShowKind: cmdlib.ShowPublic, Subject: "hi"}))
+	if result, _ := w.postHook(p); result != hookDelivered {
+		t.Fatalf("result = %s, want delivered", hookResultNames[result])
+	}
+	r := <-got
+	timestamp, err := strconv.ParseInt(r.timestamp, 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header %q: %v", r.timestamp, err)
+	}
+	if want := signHook(secret, timestamp, r.body); !hmac.Equal([]byte(r.signature), []byte(want)) {
+		t.Errorf("signature = %q, want %q", r.signature, want)
+	}
+	if signHook("other", timestamp, r.body) == r.signature {
+		t.Error("another secret produced the same signature")
+	}
+	if r.delivery != p.delivery || r.delivery == "" {
+		t.Errorf("delivery = %q, want %q", r.delivery, p.delivery)
+	}
+	var payload hookPayload
+	if err := json.Unmarshal(r.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Nickname != "a" || payload.Status != "online" || payload.PreviousStatus != "offline" ||
+		payload.ShowKind != "public" || payload.Subject != "hi" || payload.Viewers == nil || *payload.Viewers != 7 ||
+		payload.ChatID != nil {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+// Going offline posts no stale picture of the show that ended.
+func TestHookPayloadOfflineDropsStreamerInfo(t *testing.T) {
+	p := newHookPayload(
+		db.ConfirmedStatusChange{Site: "test", Nickname: "a", Status: cmdlib.StatusOffline, PrevStatus: cmdlib.StatusOnline},
+		cmdlib.StreamerInfo{ImageURL: "https://img/a.jpg", ShowKind: cmdlib.ShowPublic, Subject: "hi"})
+	if p.ImageURL != "" || p.ShowKind != "" || p.Subject != "" || p.Viewers != nil {
+		t.Errorf("payload = %+v, want no streamer info", p)
+	}
+}
+
+func TestHookResult(t *testing.T) {
+	for status, want := range map[int]int{
+		200: hookDelivered,
+		204: hookDelivered,
+		301: hookRejected,
+		400: hookRejected,
+		410: hookRejected,
+		429: hookTooManyRequests,
+		500: hookServerError,
+		503: hookServerError,
+	} {
+		if got := hookResult(status); got != want {
+			t.Errorf("hookResult(%d) = %s, want %s", status, hookResultNames[got], hookResultNames[want])
+		}
+	}
+}
+
+func TestHookRetryDelay(t *testing.T) {
+	tests := []struct {
+		name          string
+		result        int
+		attempt       int
+		retryAfter    int
+		wantPause     time.Duration
+		wantTransient bool
+	}{
+		{name: "rejected", result: hookRejected, attempt: 1},
+		{name: "first server error", result: hookServerError, attempt: 1, wantPause: hookRetryBackoff, wantTransient: true},
+		{name: "third network error doubles twice", result: hookNetworkError, attempt: 3, wantPause: 4 * hookRetryBackoff, wantTransient: true},
+		{name: "backoff is capped", result: hookServerError, attempt: 100, wantPause: tooManyRequestsMaxBackoff, wantTransient: true},
+		{name: "retry-after wins", result: hookTooManyRequests, attempt: 3, retryAfter: 2, wantPause: 2 * time.Second, wantTransient: true},
+		{name: "429 without retry-after backs off", result: hookTooManyRequests, attempt: 2, wantPause: 2 * hookRetryBackoff, wantTransient: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pause, transient := hookRetryDelay(tt.result, tt.attempt, tt.retryAfter)
+			if pause != tt.wantPause || transient != tt.wantTransient {
+				t.Errorf("got (%v, %v), want (%v, %v)", pause, transient, tt.wantPause, tt.wantTransient)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	for value, want := range map[string]int{
+		"":                              0,
+		"5":                             5,
+		" 12 ":                          12,
+		"-1":                            0,
+		"Wed, 21 Oct 2015 07:28:00 GMT": 0,
+	} {
+		if got := parseRetryAfter(value); got != want {
+			t.Errorf("parseRetryAfter(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
+
+// A change posts once to every global hook and once to each subscribed chat's hook,
+// even for a chat subscribed through two endpoints.
+func TestBuildHookPosts(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	cfg := testConfig
+	cfg.NotificationWebhooks = []botconfig.NotificationWebhook{{URL: "https://global.example.com/", Secret: "g"}}
+	w.cfg = &cfg
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertTestStreamer(&w.db, db.Streamer{Nickname: "b"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "other", 1, "a")
+	insertSubscription(&w.db, "test", 2, "a")
+	user, _ := w.db.User(1)
+	w.db.SetNotificationWebhook(user.UserID, "https://chat.example.com/", "c")
+
+	posts := w.buildHookPosts([]db.ConfirmedStatusChange{
+		{StreamerID: streamerID, Site: testSite, Nickname: "a", Status: cmdlib.StatusOnline, PrevStatus: cmdlib.StatusOffline},
+	})
+	var urls []string
+	for _, p := range posts {
+		urls = append(urls, p.url)
+		var payload hookPayload
+		if err := json.Unmarshal(p.body, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if chatHook := p.url == "https://chat.example.com/"; chatHook != (payload.ChatID != nil) {
+			t.Errorf("post to %s has chat_id %v", p.url, payload.ChatID)
+		}
+	}
+	slices.Sort(urls)
+	if want := []string{"https://chat.example.com/", "https://global.example.com/"}; !slices.Equal(urls, want) {
+		t.Errorf("posted to %v, want %v", urls, want)
+	}
+
+	if posts := w.buildHookPosts([]db.ConfirmedStatusChange{
+		{StreamerID: streamerID, Site: testSite, Nickname: "a", Status: cmdlib.StatusOffline, PrevStatus: cmdlib.StatusUnknown},
+	}); len(posts) != 0 {
+		t.Errorf("unknown -> offline posted %d times, want none", len(posts))
+	}
+}
+
+// A chat's hook hears of a change as the chat's alerts would: nothing while muted,
+// and only the starts its filter admits.
+func TestBuildHookPostsAppliesMutesAndFilters(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		insertSubscription(&w.db, "test", chatID, "a")
+		user, _ := w.db.User(chatID)
+		w.db.SetNotificationWebhook(user.UserID, "https://chat"+strconv.FormatInt(chatID, 10)+".example.com/", "c")
+	}
+	muted, _ := w.db.User(2)
+	w.db.MuteSubscription(muted.UserID, testSite, "a", "test", db.MutedForever)
+	filtered, _ := w.db.User(3)
+	minViewers := 100
+	w.db.SetSubscriptionFilter(filtered.UserID, testSite, "a", "test", &db.SubscriptionFilter{MinViewers: &minViewers})
+
+	posted := func(status cmdlib.StatusKind, viewers int) (urls []string) {
+		w.defaultSite.unconfirmedOnlineStreamers["a"] = cmdlib.StreamerInfo{Viewers: &viewers}
+		for _, p := range w.buildHookPosts([]db.ConfirmedStatusChange{
+			{StreamerID: streamerID, Site: testSite, Nickname: "a", Status: status, PrevStatus: cmdlib.StatusOffline},
+		}) {
+			urls = append(urls, p.url)
+		}
+		slices.Sort(urls)
+		return urls
+	}
+	if got, want := posted(cmdlib.StatusOnline, 10), []string{"https://chat1.example.com/"}; !slices.Equal(got, want) {
+		t.Errorf("a start below the filter posted to %v, want %v", got, want)
+	}
+	if got, want := posted(cmdlib.StatusOnline, 200), []string{"https://chat1.example.com/", "https://chat3.example.com/"}; !slices.Equal(got, want) {
+		t.Errorf("a start the filter admits posted to %v, want %v", got, want)
+	}
+	if got, want := posted(cmdlib.StatusOffline, 0), []string{"https://chat1.example.com/"}; !slices.Equal(got, want) {
+		t.Errorf("an offline change posted to %v, want %v", got, want)
+	}
+}
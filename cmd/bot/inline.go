@@ -0,0 +1,223 @@
+// Inline mode: typing @bot alice in any chat shows a card of alice's status,
+// with the picture and viewers while online and a deep link subscribing to alice.
+// A streamer the bot tracks is read from its confirmed status;
+// any other is looked up with the site's QueryStatus, and the answer kept for inlineStatusTTL,
+// so a user typing the name letter by letter costs the site one query per name at most.
+// Inline mode must be turned on for the bot in BotFather.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+const (
+	// inlineQueryLimit is how many inline queries one user gets answered in an inlineQueryWindow;
+	// the rest go unanswered, as Telegram sends one for every letter typed.
+	inlineQueryLimit  = 30
+	inlineQueryWindow = time.Minute
+	// inlineStatusTTL is how long a looked up status of a streamer the bot does not track is reused.
+	inlineStatusTTL = 5 * time.Minute
+	// inlineCacheSeconds is how long Telegram may show an answer again without asking.
+	inlineCacheSeconds = 60
+	// inlineAnswerTimeout caps an answer's request to Telegram.
+	inlineAnswerTimeout = 2 * time.Second
+)
+
+// inlineStatus is a looked up status of a streamer the bot does not track.
+type inlineStatus struct {
+	info cmdlib.StreamerInfoWithStatus
+	at   time.Time
+}
+
+// pendingInlineQuery is an inline query waiting for its streamer's status to be looked up.
+type pendingInlineQuery struct {
+	endpoint string
+	queryID  string
+}
+
+// inlineAllowed counts an inline query against its user's share of the window.
+// The counts start afresh with every window, so a quiet user holds no entry for long.
+func (w *worker) inlineAllowed(userID int64, now time.Time) bool {
+	if now.Sub(w.inlineWindowStart) >= inlineQueryWindow {
+		w.inlineWindowStart = now
+		w.inlineQueries = map[int64]int{}
+	}
+	if w.inlineQueries[userID] >= inlineQueryLimit {
+		return false
+	}
+	w.inlineQueries[userID]++
+	return true
+}
+
+// handleInlineQuery answers an inline query with the status card of the streamer it names.
+// An empty query, one over its user's limit, or a name the site cannot hold goes unanswered.
+func (w *worker) handleInlineQuery(endpoint string, q *models.InlineQuery, now time.Time) {
+	if q.From == nil || !w.admitChat("inline_query", q.From.ID) {
+		return
+	}
+	query := strings.TrimSpace(q.Query)
+	if query == "" || strings.ContainsAny(query, " \n\t") {
+		return
+	}
+	if !w.inlineAllowed(q.From.ID, now) {
+		ldbg("inline query over the limit: user = %d", q.From.ID)
+		return
+	}
+	s, nickname := w.parseStreamer(query)
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
+		return
+	}
+	if streamer := w.db.MaybeStreamer(s.name, nickname); streamer != nil && streamer.ConfirmedStatus != cmdlib.StatusUnknown {
+		info := cmdlib.StreamerInfoWithStatus{Status: streamer.ConfirmedStatus}
+		if streamer.ConfirmedStatus == cmdlib.StatusOnline {
+			info.StreamerInfo = w.onlineInfo(s.name, nickname)
+		}
+		w.answerInline(endpoint, q.ID, s.name, nickname, info)
+		return
+	}
+	if cached, ok := s.inlineStatuses[nickname]; ok && now.Sub(cached.at) < inlineStatusTTL {
+		w.answerInline(endpoint, q.ID, s.name, nickname, cached.info)
+		return
+	}
+	if !s.checker.Capabilities().SupportsQueryStatus {
+		w.answerInline(endpoint, q.ID, s.name, nickname, cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown})
+		return
+	}
+	pending := s.pendingInline[nickname]
+	s.pendingInline[nickname] = append(pending, pendingInlineQuery{endpoint: endpoint, queryID: q.ID})
+	if len(pending) != 0 {
+		// A lookup of this name is already on its way.
+		return
+	}
+	err := s.checker.PushStatusRequest(&cmdlib.SingleStatusRequest{ResultsCh: s.inlineResults, Streamer: nickname})
+	if err != nil {
+		lerr("%s: %v", s.name, err)
+		w.completeInlineLookup(s, nickname, cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}, false, now)
+	}
+}
+
+// processInlineResults answers the inline queries waiting for the statuses a lookup brought,
+// and keeps those statuses for the queries to come. A failed lookup is answered but not kept.
+func (w *worker) processInlineResults(s *site, res *cmdlib.ExistenceListResults, now time.Time) {
+	for nickname, info := range res.Streamers {
+		w.completeInlineLookup(s, nickname, info, !res.Failed(), now)
+	}
+}
+
+// completeInlineLookup answers the inline queries waiting for a streamer's status.
+func (w *worker) completeInlineLookup(s *site, nickname string, info cmdlib.StreamerInfoWithStatus, keep bool, now time.Time) {
+	if keep {
+		s.inlineStatuses[nickname] = inlineStatus{info: info, at: now}
+	}
+	for _, p := range s.pendingInline[nickname] {
+		w.answerInline(p.endpoint, p.queryID, s.name, nickname, info)
+	}
+	delete(s.pendingInline, nickname)
+}
+
+// dropExpiredInlineStatuses forgets the looked up statuses past inlineStatusTTL.
+func (w *worker) dropExpiredInlineStatuses(now time.Time) {
+	for _, s := range w.sites {
+		for nickname, cached := range s.inlineStatuses {
+			if now.Sub(cached.at) >= inlineStatusTTL {
+				delete(s.inlineStatuses, nickname)
+			}
+		}
+	}
+}
+
+// inlineStatusName names a status for the inline templates.
+func inlineStatusName(status cmdlib.StatusKind) string {
+	switch status {
+	case cmdlib.StatusOnline:
+		return "online"
+	case cmdlib.StatusOffline:
+		return "offline"
+	case cmdlib.StatusNotFound:
+		return "not_found"
+	case cmdlib.StatusDenied:
+		return "denied"
+	}
+	return "unknown"
+}
+
+// inlineResult is the card of a streamer's status:
+// its description sums the status up, and picking it posts the status with the link to the streamer.
+func (w *worker) inlineResult(endpoint, siteName, nickname string, info cmdlib.StreamerInfoWithStatus) *models.InlineQueryResultArticle {
+	tr := w.tr[endpoint]
+	data := tplData{
+		"streamer":      w.qualifiedName(siteName, nickname),
+		"streamer_link": w.streamerLinker(nil)(siteName, nickname),
+		"status":        inlineStatusName(info.Status),
+	}
+	if info.Status == cmdlib.StatusOnline && info.Viewers != nil {
+		data["viewers"] = *info.Viewers
+	}
+	description := &renderParams{templates: w.tpl[endpoint], key: tr.InlineDescription.Key, data: data}
+	text := &renderParams{templates: w.tpl[endpoint], key: tr.InlineStatus.Key, data: data}
+	mention := w.botMention(endpoint)
+	result := &models.InlineQueryResultArticle{
+		ID:          siteName + siteSeparator + nickname,
+		Title:       w.qualifiedName(siteName, nickname),
+		Description: description.render(mention),
+		InputMessageContent: &models.InputTextMessageContent{
+			MessageText:        text.render(mention),
+			ParseMode:          parseMode(tr.InlineStatus.Parse),
+			LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: bot.True()},
+		},
+	}
+	if info.Status == cmdlib.StatusOnline {
+		result.ThumbnailURL = info.ImageURL
+	}
+	if keyboard := w.inlineKeyboard(endpoint, siteName, nickname); keyboard != nil {
+		result.ReplyMarkup = keyboard
+	}
+	return result
+}
+
+// inlineKeyboard is the button under a posted status card, subscribing to the streamer,
+// nil for a streamer of a site other than the default, which a deep link cannot name.
+func (w *worker) inlineKeyboard(endpoint, siteName, nickname string) *models.InlineKeyboardMarkup {
+	if siteName != w.defaultSite.name {
+		return nil
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{{
+		Text: w.tr[endpoint].InlineSubscribeButton.Str,
+		URL:  w.botLink(endpoint) + "?start=" + modelPayloadPrefix + nickname,
+	}}}}
+}
+
+// answerInline answers an inline query with a streamer's status card.
+// The card is rendered here, on the main goroutine; the request goes out on its own.
+func (w *worker) answerInline(endpoint, queryID, siteName, nickname string, info cmdlib.StreamerInfoWithStatus) {
+	w.answerInlineQuery(endpoint, &bot.AnswerInlineQueryParams{
+		InlineQueryID: queryID,
+		Results:       []models.InlineQueryResult{w.inlineResult(endpoint, siteName, nickname, info)},
+		CacheTime:     inlineCacheSeconds,
+	})
+}
+
+// postInlineAnswer sends an inline answer to Telegram on a goroutine of its own,
+// so a user typing in inline mode never holds the update loop,
+// and is what worker.answerInlineQuery holds outside tests.
+// The answer touches no shared state, and shutdown does not wait for it:
+// an inline answer is worth nothing once its user has typed on.
+func (w *worker) postInlineAnswer(endpoint string, params *bot.AnswerInlineQueryParams) {
+	b := w.bots[endpoint]
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), inlineAnswerTimeout)
+		defer cancel()
+		if _, err := b.AnswerInlineQuery(ctx, params); err != nil {
+			// Most often a query the user typed past, which Telegram no longer takes answers to.
+			ldbg("cannot answer inline query %s: %v", params.InlineQueryID, err)
+		}
+	}()
+}
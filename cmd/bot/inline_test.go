@@ -0,0 +1,167 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/bcmk/siren/v4/internal/checkers"
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestInlineAllowed(t *testing.T) {
+	t.Parallel()
+	w := &worker{}
+	now := time.Unix(1000, 0)
+	for i := range inlineQueryLimit {
+		if !w.inlineAllowed(1, now) {
+			t.Fatalf("query %d of the window was refused", i+1)
+		}
+	}
+	if w.inlineAllowed(1, now.Add(time.Second)) {
+		t.Error("a query over the limit was allowed")
+	}
+	if !w.inlineAllowed(2, now.Add(time.Second)) {
+		t.Error("another user was refused for the first one's queries")
+	}
+	if !w.inlineAllowed(1, now.Add(inlineQueryWindow)) {
+		t.Error("a query of the next window was refused")
+	}
+}
+
+// An answer goes out without the update loop waiting on Telegram, however long Telegram takes.
+func TestPostInlineAnswerLeavesTheLoop(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		<-release
+		_, _ = rw.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+	stub, err := bot.New("token", bot.WithServerURL(srv.URL), bot.WithSkipGetMe())
+	if err != nil {
+		t.Fatalf("cannot build the stub bot: %v", err)
+	}
+	w := &worker{bots: map[string]*bot.Bot{"test": stub}}
+
+	start := time.Now()
+	w.postInlineAnswer("test", &bot.AnswerInlineQueryParams{InlineQueryID: "1"})
+	if elapsed := time.Since(start); elapsed >= inlineAnswerTimeout/2 {
+		t.Errorf("the answer held its caller for %v", elapsed)
+	}
+}
+
+func TestInlineResult(t *testing.T) {
+	t.Parallel()
+	w := &worker{
+		tr:       map[string]*cmdlib.Translations{"test": &testTranslations},
+		tpl:      map[string]*texttemplate.Template{"test": realTemplates(t, "en")},
+		botNames: map[string]string{"test": "bot"},
+	}
+	w.sites, w.defaultSite = buildSites(
+		&testConfig,
+		&checkers.RandomChecker{BaseChecker: checkers.NewBaseChecker(&checkers.TestCheckerConfig{})},
+		nil)
+	viewers := 120
+	online := cmdlib.StreamerInfoWithStatus{
+		StreamerInfo: cmdlib.StreamerInfo{ImageURL: "https://example.com/a.jpg", Viewers: &viewers},
+		Status:       cmdlib.StatusOnline,
+	}
+	result := w.inlineResult("test", testSite, "alice", online)
+	if result.ThumbnailURL != online.ImageURL || !strings.Contains(result.Description, "120") {
+		t.Errorf("online card = %+v, want the thumbnail and the viewers", result)
+	}
+	text := result.InputMessageContent.(*models.InputTextMessageContent).MessageText
+	if !strings.Contains(text, "alice") || !strings.Contains(text, "120") {
+		t.Errorf("posted %q, want alice and the viewers", text)
+	}
+	if button := w.inlineKeyboard("test", testSite, "alice").InlineKeyboard[0][0]; button.URL != "https://t.me/bot?start=m-alice" {
+		t.Errorf("the subscribe button opens %q", button.URL)
+	}
+	if w.inlineKeyboard("test", "twitch", "alice") != nil {
+		t.Error("a streamer of another site got a deep link, which would subscribe to the default site's")
+	}
+
+	offline := w.inlineResult("test", testSite, "alice", cmdlib.StreamerInfoWithStatus{
+		StreamerInfo: online.StreamerInfo,
+		Status:       cmdlib.StatusOffline,
+	})
+	if offline.ThumbnailURL != "" || strings.Contains(offline.Description, "120") {
+		t.Errorf("offline card = %+v, shows what only an online streamer has", offline)
+	}
+}
+
+func TestInlineTemplatesRender(t *testing.T) {
+	t.Parallel()
+	statuses := []cmdlib.StatusKind{
+		cmdlib.StatusOnline, cmdlib.StatusOffline, cmdlib.StatusNotFound, cmdlib.StatusDenied, cmdlib.StatusUnknown,
+	}
+	for _, lang := range realLangs {
+		templates := realTemplates(t, lang)
+		seen := map[string]bool{}
+		for _, status := range statuses {
+			data := tplData{"streamer": "alice", "streamer_link": "alice", "status": inlineStatusName(status), "viewers": 7}
+			for _, key := range []string{"inline_status", "inline_description"} {
+				out := (&renderParams{templates: templates, key: key, data: data}).render("")
+				if out == "" || strings.Contains(out, "<no value>") {
+					t.Errorf("%s %s for %s rendered %q", lang, key, status, out)
+				}
+				if seen[key+out] {
+					t.Errorf("%s %s reads %q for two statuses", lang, key, out)
+				}
+				seen[key+out] = true
+			}
+		}
+	}
+}
+
+// A tracked streamer is answered at once from its confirmed status,
+// and the queries waiting on a lookup are answered when it comes, the status kept for the next.
+func TestInlineQueries(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	var answered []*bot.AnswerInlineQueryParams
+	w.answerInlineQuery = func(_ string, params *bot.AnswerInlineQueryParams) {
+		answered = append(answered, params)
+	}
+	insertTestStreamer(&w.db, db.Streamer{Nickname: "a", ConfirmedStatus: cmdlib.StatusOffline})
+	now := time.Unix(1000, 0)
+	from := &models.User{ID: 1}
+
+	w.handleInlineQuery("test", &models.InlineQuery{ID: "1", From: from, Query: "a"}, now)
+	if len(answered) != 1 || answered[0].InlineQueryID != "1" {
+		t.Fatalf("answered %+v, want the tracked streamer at once", answered)
+	}
+	w.handleInlineQuery("test", &models.InlineQuery{ID: "2", From: from, Query: ""}, now)
+	if len(answered) != 1 {
+		t.Error("an empty query was answered")
+	}
+
+	s := w.defaultSite
+	s.pendingInline["b"] = []pendingInlineQuery{{endpoint: "test", queryID: "3"}, {endpoint: "test", queryID: "4"}}
+	w.processInlineResults(s, cmdlib.NewExistenceListResults(
+		map[string]cmdlib.StreamerInfoWithStatus{"b": {Status: cmdlib.StatusOnline}}, time.Second), now)
+	if len(answered) != 3 || len(s.pendingInline) != 0 {
+		t.Errorf("answered %d, %d still waiting; want both waiting queries answered", len(answered), len(s.pendingInline))
+	}
+	w.handleInlineQuery("test", &models.InlineQuery{ID: "5", From: from, Query: "b"}, now.Add(time.Minute))
+	if len(answered) != 4 || len(s.pendingInline) != 0 {
+		t.Error("a looked up status was looked up again within its TTL")
+	}
+	w.dropExpiredInlineStatuses(now.Add(inlineStatusTTL))
+	if len(s.inlineStatuses) != 0 {
+		t.Error("a looked up status outlived its TTL")
+	}
+}
@@ -0,0 +1,159 @@
+// Live messages: a chat may have its online alert edited in place while the session lasts,
+// showing the time online, the viewers, the subject and a fresh picture,
+// and edited once more as the session ends instead of getting a new offline alert.
+// The Telegram id of such an alert is kept in live_messages once it is sent.
+// Each poll of a site queues a LivePacket for its live messages not edited for live_message_refresh_seconds,
+// and a confirmed offline status queues one that ends the live message.
+// An edit takes its turn in the send queue and is paced like any other message.
+
+package main
+
+import (
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// liveMessageRetention lets go of a live message not edited for this long,
+// one whose streamer left with no offline status confirmed.
+const liveMessageRetention = 24 * time.Hour
+
+// liveSend ties a send to a live message: an online alert to keep as one, or an edit of one.
+type liveSend struct {
+	key       db.LiveMessageKey
+	startedAt int
+	// edit marks an edit of the live message rather than the alert that starts it.
+	edit bool
+	// ended marks the edit ending it, after which it is let go.
+	ended bool
+}
+
+// liveAlert is the liveSend of an online alert a chat keeps as its live message, nil for any other.
+// Only a Telegram message can be edited.
+func liveAlert(p plannedNotification, now time.Time) *liveSend {
+	if !p.LiveMessages || p.Kind != db.NotificationPacket || p.Status != cmdlib.StatusOnline ||
+		p.StreamerID == nil || !isTelegram(p.Endpoint) {
+		return nil
+	}
+	return &liveSend{
+		key:       db.LiveMessageKey{UserID: p.UserID, Endpoint: p.Endpoint, StreamerID: *p.StreamerID},
+		startedAt: int(now.Unix()),
+	}
+}
+
+// liveTranslation is the message a live edit renders, nil where the endpoint has none.
+// A refresh renders the online alert afresh.
+func (w *worker) liveTranslation(n db.Notification) *cmdlib.Translation {
+	tr := w.tr[n.Endpoint]
+	if tr == nil {
+		return nil
+	}
+	if n.Status == cmdlib.StatusOffline {
+		return tr.LiveEnded
+	}
+	return tr.Online
+}
+
+// buildLiveRefreshes queues an edit of each live message of a site due one,
+// with what the poll just reported of its streamer.
+// A message sent as text stays text, so it is refreshed with no picture.
+func (w *worker) buildLiveRefreshes(s *site, now int) []db.Notification {
+	var notifications []db.Notification
+	for _, r := range w.db.TakeLiveRefreshes(s.name, now-w.cfg.LiveMessageRefreshSeconds, now) {
+		info := s.unconfirmedOnlineStreamers[r.Nickname]
+		online := now - r.StartedAt
+		n := db.Notification{
+			Endpoint:   r.Endpoint,
+			UserID:     r.UserID,
+			StreamerID: &r.StreamerID,
+			Site:       r.Site,
+			Nickname:   r.Nickname,
+			Status:     cmdlib.StatusOnline,
+			TimeDiff:   &online,
+			Viewers:    info.Viewers,
+			ShowKind:   info.ShowKind,
+			Priority:   db.PriorityLow,
+			Kind:       db.LivePacket,
+		}
+		if r.ShowImages && r.Photo {
+			n.ImageURL = info.ImageURL
+		}
+		if r.ShowSubject {
+			n.Subject = info.Subject
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications
+}
+
+// liveEnd is the edit ending a chat's live message as its streamer is confirmed offline.
+func liveEnd(c db.ConfirmedStatusChange, userID db.UserID, endpoint string) db.Notification {
+	return db.Notification{
+		Endpoint:   endpoint,
+		UserID:     userID,
+		StreamerID: &c.StreamerID,
+		Site:       c.Site,
+		Nickname:   c.Nickname,
+		Status:     cmdlib.StatusOffline,
+		Priority:   db.PriorityLow,
+		Kind:       db.LivePacket,
+		Session:    c.Session,
+	}
+}
+
+// editLiveMessage queues a fetched LivePacket as an edit of its live message.
+// One let go meanwhile, by the chat or by its end, is left as it is.
+func (w *worker) editLiveMessage(p plannedNotification, data tplData, image []byte, now time.Time) {
+	if p.translation == nil || p.StreamerID == nil {
+		w.finalizeNotification(p.ID)
+		return
+	}
+	key := db.LiveMessageKey{UserID: p.UserID, Endpoint: p.Endpoint, StreamerID: *p.StreamerID}
+	m, found := w.db.LiveMessage(key)
+	if !found {
+		w.finalizeNotification(p.ID)
+		return
+	}
+	ended := p.Status == cmdlib.StatusOffline
+	if ended {
+		// The session's own length where it was kept, the time since the alert otherwise.
+		seconds := int(now.Unix()) - m.StartedAt
+		if p.Session != nil {
+			seconds = p.Session.End - p.Session.Start
+		}
+		data["duration"] = calcTimeDiff(max(seconds, 0))
+		// The picture stays as last shown.
+		image = nil
+	}
+	params := &renderParams{templates: w.tpl[p.Endpoint], key: p.translation.Key, data: data}
+	msg := params.asDeferredEdit(m.MessageID, m.Photo, p.translation.DisablePreview, p.translation.Parse, image)
+	if keyboard := w.notificationKeyboard(p); keyboard != nil {
+		withKeyboard(msg, keyboard)
+	}
+	w.enqueueNotification(p, msg, &liveSend{key: key, startedAt: m.StartedAt, edit: true, ended: ended})
+}
+
+// completeLiveSend keeps the id of a live online alert once it is sent,
+// and lets go of a live message once its end is edited in or it can no longer be edited.
+// Main goroutine only.
+func (w *worker) completeLiveSend(r msgSendResult) {
+	switch {
+	case !r.live.edit:
+		if r.result == messageSent && r.messageID != 0 {
+			w.db.SetLiveMessage(db.LiveMessage{
+				LiveMessageKey: r.live.key,
+				MessageID:      r.messageID,
+				Photo:          r.photo,
+				StartedAt:      r.live.startedAt,
+			}, r.timestamp)
+		}
+	case r.live.ended || r.result != messageSent:
+		w.db.DeleteLiveMessage(r.live.key)
+	}
+}
+
+func (w *worker) enableLiveMessages(m receivedMessage, liveMessages bool) {
+	w.db.SetLiveMessages(m.userID, liveMessages)
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].OK, nil)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestAsDeferredEdit(t *testing.T) {
+	t.Parallel()
+	params := &renderParams{}
+	text := params.asDeferredEdit(7, false, true, cmdlib.ParseHTML, []byte{1})
+	if text.messageID != 7 || text.photo || text.imageData != nil {
+		t.Errorf("text edit = %+v, want message 7 with no picture", text)
+	}
+	if text.linkPreview == nil {
+		t.Error("text edit keeps the link preview its translation disables")
+	}
+	photo := params.asDeferredEdit(8, true, false, cmdlib.ParseHTML, []byte{1})
+	if !photo.photo || len(photo.imageData) != 1 || photo.linkPreview != nil {
+		t.Errorf("photo edit = %+v, want message 8 with its fresh picture", photo)
+	}
+}
+
+func TestSentAs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		msg   sendable
+		id    int
+		photo bool
+	}{
+		{"text", &messageParams{sentID: 3}, 3, false},
+		{"photo", &photoParams{sentID: 4}, 4, true},
+		{"edit", &editParams{messageID: 5}, 0, false},
+	}
+	for _, tc := range tests {
+		if id, photo := sentAs(tc.msg); id != tc.id || photo != tc.photo {
+			t.Errorf("%s: sent as %d, photo %v, want %d, photo %v", tc.name, id, photo, tc.id, tc.photo)
+		}
+	}
+}
+
+func TestLiveTemplatesRender(t *testing.T) {
+	t.Parallel()
+	settings := settingsData(false)
+	settings["live_messages"] = true
+	assertTemplatesRender(t, []templateCase{
+		{"ended", "live_ended", tplData{"streamer_link": "alice", "duration": calcTimeDiff(8040)}, "2", true},
+		{"settings on", "settings", settings, "disable_live_messages", true},
+		{"settings off", "settings", settingsData(false), "enable_live_messages", true},
+	})
+}
+
+// A chat keeping live messages has its sent online alert refreshed while the streamer stays online,
+// and ended in place rather than alerted anew as it goes offline.
+func TestLiveMessages(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 2, "a")
+	user, _ := w.db.User(1)
+	w.db.SetLiveMessages(user.UserID, true)
+
+	w.db.UpsertUnconfirmedStatusChanges(testSite, []db.StatusChange{
+		{Nickname: "a", Status: cmdlib.StatusOnline, Timestamp: 100},
+	}, nil, 100)
+	w.db.ConfirmStatusChanges(100, 0, 0)
+
+	key := db.LiveMessageKey{UserID: user.UserID, Endpoint: "test", StreamerID: streamerID}
+	w.completeLiveSend(msgSendResult{
+		result: messageSent, timestamp: 100, messageID: 42, photo: true,
+		live: &liveSend{key: key, startedAt: 100},
+	})
+	if m, found := w.db.LiveMessage(key); !found || m.MessageID != 42 || !m.Photo || m.StartedAt != 100 {
+		t.Fatalf("live message = %+v, found %v, want message 42 as a picture", m, found)
+	}
+
+	if refreshes := w.db.TakeLiveRefreshes(testSite, 50, 160); len(refreshes) != 0 {
+		t.Errorf("refreshed %d live messages edited since 50", len(refreshes))
+	}
+	refreshes := w.db.TakeLiveRefreshes(testSite, 100, 160)
+	if len(refreshes) != 1 || refreshes[0].LiveMessageKey != key || refreshes[0].Nickname != "a" {
+		t.Errorf("refreshes = %+v, want the live message of chat 1", refreshes)
+	}
+	if refreshes := w.db.TakeLiveRefreshes(testSite, 100, 170); len(refreshes) != 0 {
+		t.Errorf("refreshed %d live messages just refreshed", len(refreshes))
+	}
+
+	notifications := w.buildNotifications([]db.ConfirmedStatusChange{
+		{StreamerID: streamerID, Site: testSite, Nickname: "a", Status: cmdlib.StatusOffline, PrevStatus: cmdlib.StatusOnline},
+	})
+	kinds := map[db.UserID]db.PacketKind{}
+	for _, n := range notifications {
+		kinds[n.UserID] = n.Kind
+	}
+	other, _ := w.db.User(2)
+	if len(kinds) != 2 || kinds[user.UserID] != db.LivePacket || kinds[other.UserID] != db.NotificationPacket {
+		t.Errorf("offline notifications %+v, want chat 1 ended in place and chat 2 alerted", notifications)
+	}
+
+	w.completeLiveSend(msgSendResult{result: messageSent, timestamp: 200, live: &liveSend{key: key, edit: true, ended: true}})
+	if _, found := w.db.LiveMessage(key); found {
+		t.Error("an ended live message was kept")
+	}
+}
@@ -16,7 +16,6 @@ import (
 	"io"
 	"maps"
 	"math/rand"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -46,6 +45,7 @@ import (
 	"github.com/bcmk/siren/v4/internal/botconfig"
 	"github.com/bcmk/siren/v4/internal/checkers"
 	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/internal/metrics"
 	"github.com/bcmk/siren/v4/lib/cmdlib"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -72,40 +72,46 @@ type timeDiff struct {
 // streamerListEntry is the per-row payload of the list and week_never_online templates,
 // so a field rename must reach both, or the loser fails at render.
 type streamerListEntry struct {
-	Link     string
-	TimeDiff *timeDiff
+	Link      string
+	TimeDiff  *timeDiff
+	Muted     bool
+	Favourite bool
 }
 
 type worker struct {
-	db                         db.Database
-	fuzzySearchDB              db.Database
-	client                     *http.Client
-	bots                       map[string]*bot.Bot
-	cfg                        *botconfig.Config
-	tr                         map[string]*cmdlib.Translations
-	tpl                        map[string]*texttemplate.Template
-	legacyAffiliateTpl         *texttemplate.Template
-	trAds                      map[string]map[string]*cmdlib.Translation
-	tplAds                     map[string]*texttemplate.Template
-	checker                    checkers.Checker
-	imageDownloadLogs          chan imageDownloadLog
-	unconfirmedOnlineStreamers map[string]cmdlib.StreamerInfo
-	botNames                   map[string]string
-	senders                    map[string]*endpointSender
-	sendSeq                    uint64
-	sendResults                chan msgSendResult
-	cooledUsers                chan cooledUser
-	ownerUserID                db.UserID
-	deliverWG                  sync.WaitGroup
-	existenceListResults       chan *cmdlib.ExistenceListResults
-	checkerResults             chan cmdlib.CheckerResults
-	sendingNotifications       chan []db.Notification
-	imagedNotifications        chan notificationBatch
-	ourIDs                     []int64
-	searchHTML                 *htmltemplate.Template
-	timezoneHTML               *htmltemplate.Template
-	removalHTML                *htmltemplate.Template
-	profilePhotos              map[string][]byte
+	db            db.Database
+	fuzzySearchDB db.Database
+	// apiDB is apiDaemon's own connection, opened only when the API is served.
+	apiDB  db.Database
+	client *http.Client
+	bots   map[string]*bot.Bot
+	cfg    *botconfig.Config
+	tr     map[string]*cmdlib.Translations
+	tpl    map[string]*texttemplate.Template
+	trAds  map[string]map[string]*cmdlib.Translation
+	tplAds map[string]*texttemplate.Template
+	// sites holds every site the bot serves, defaultSite among them, keyed by name.
+	sites                map[string]*site
+	defaultSite          *site
+	imageDownloadLogs    chan imageDownloadLog
+	botNames             map[string]string
+	senders              map[string]*endpointSender
+	sendSeq              uint64
+	sendResults          chan msgSendResult
+	cooledUsers          chan cooledUser
+	ownerUserID          db.UserID
+	deliverWG            sync.WaitGroup
+	existenceListResults chan siteExistenceResults
+	checkerResults       chan siteCheckerResults
+	inlineResults        chan siteExistenceResults
+	sendingNotifications chan []db.Notification
+	imagedNotifications  chan notificationBatch
+	ourIDs               []int64
+	searchHTML           *htmltemplate.Template
+	timezoneHTML         *htmltemplate.Template
+	quietHTML            *htmltemplate.Template
+	removalHTML          *htmltemplate.Template
+	profilePhotos        map[string][]byte
 	// zoneNames maps a lowercased IANA name to the zone the binary loaded for it,
 	// whose own name is the spelling to store.
 	// Written once at startup and only read after, so a concurrent reader needs no lock.
@@ -113,14 +119,29 @@ type worker struct {
 	searchRequests            chan searchRequest
 	webAppAddRequests         chan webAppAddRequest
 	webAppTimezoneRequests    chan webAppTimezoneRequest
+	webAppQuietRequests       chan webAppQuietRequest
 	webAppRemovalListRequests chan webAppRemovalListRequest
 	webAppRemoveRequests      chan webAppRemoveRequest
+	webAppFilterRequests      chan webAppFilterRequest
+	apiRequests               chan apiRequest
 	incomingPackets           chan incomingPacket
 	maintenance               atomic.Bool
 	shuttingDown              atomic.Bool
 	// chatMember is the admin gate's lookup, a field so tests can fake the answer.
 	chatMember func(endpoint string, chatID, userID int64) (*models.ChatMember, error)
-	shutdownCh chan struct{}
+	// answerInlineQuery hands an inline answer off the main goroutine, a field so tests can catch it.
+	answerInlineQuery func(endpoint string, params *bot.AnswerInlineQueryParams)
+	// createForumTopic opens a forum topic and returns its thread, a field so tests can fake it.
+	createForumTopic func(endpoint string, chatID int64, name string) (int, error)
+	// inlineQueries counts each user's inline queries since inlineWindowStart.
+	inlineQueries     map[int64]int
+	inlineWindowStart time.Time
+	shutdownCh        chan struct{}
+	// hookPosts queues notification webhook posts for the posters, hookClient sends them.
+	hookPosts  chan *hookPost
+	hookClient *http.Client
+	// discord delivers what is queued on discordEndpoint.
+	discord *discordTransport
 }
 
 type searchRequest struct {
@@ -175,7 +196,15 @@ type webAppRemovalListRequest struct {
 // so a refusal reads apart from an empty list.
 type webAppRemovalListResult struct {
 	nicknames []string
-	allowed   bool
+	// filters holds the filters of the nicknames that have one, in /filter's words.
+	filters map[string]string
+	allowed bool
+}
+
+// removalListItem is one row of the removal web app's list.
+type removalListItem struct {
+	Nickname string `json:"nickname"`
+	Filter   string `json:"filter,omitempty"`
 }
 
 // webAppRemoveRequest carries a removal tapped in the removal web app.
@@ -217,8 +246,35 @@ const (
 	messageNoPhotoRights       = -7
 	messageNoTextRights        = -8
 	messageTopicClosed         = -9
+	messageTopicDeleted        = -10
 )
 
+// sendResultNames labels the send results in the metrics.
+var sendResultNames = map[int]string{
+	messageSent:                "sent",
+	messageBadRequest:          "bad_request",
+	messageBlocked:             "blocked",
+	messageTooManyRequests:     "too_many_requests",
+	messageUnknownError:        "unknown_error",
+	messageUnknownNetworkError: "unknown_network_error",
+	messageTimeout:             "timeout",
+	messageMigrate:             "migrate",
+	messageChatNotFound:        "chat_not_found",
+	messageSkipped:             "skipped",
+	messageNoPhotoRights:       "no_photo_rights",
+	messageNoTextRights:        "no_text_rights",
+	messageTopicClosed:         "topic_closed",
+	messageTopicDeleted:        "topic_deleted",
+}
+
+// sendResultName is a send result's metrics label, its code for one without a name.
+func sendResultName(result int) string {
+	if name, ok := sendResultNames[result]; ok {
+		return name
+	}
+	return strconv.Itoa(result)
+}
+
 type msgSendResult struct {
 	priority  db.Priority
 	timestamp int
@@ -231,10 +287,22 @@ type msgSendResult struct {
 	latency         int
 	tag             sendTag
 	notificationID  int
+	// burst holds the notification rows of the alerts collapsed into the message beside its own.
+	burst []int
+	// topic is what the send learned of the chat's forum topics.
+	topic topicOutcome
 	// resend is the original queued message,
 	// handed back whole for the main loop to re-queue,
 	// so no field is lost in a copy on the way.
 	resend *queuedMessage
+	// live is the live message the send is tied to, nil for none.
+	// messageID is the id Telegram gave a message sent as a new one,
+	// and photo whether it went as a picture, which decides how it is edited.
+	live      *liveSend
+	messageID int
+	photo     bool
+	// board is the board the send is tied to, nil for none.
+	board *boardSend
 }
 
 type sendDisposition int
@@ -270,7 +338,7 @@ type imageDownloadLog struct {
 	durationMs int
 }
 
-func newWorker(cfg *botconfig.Config, checker checkers.Checker) *worker {
+func newWorker(cfg *botconfig.Config, checker checkers.Checker, extraCheckers map[string]checkers.Checker) *worker {
 	client := cmdlib.HTTPClientWithTimeout(cfg.ImageDownloadTimeout())
 
 	incomingPackets := make(chan incomingPacket, incomingBufferSize*len(cfg.Endpoints))
@@ -291,42 +359,47 @@ func newWorker(cfg *botconfig.Config, checker checkers.Checker) *worker {
 	}
 	tr, tpl := cmdlib.LoadAllTranslations(trsByEndpoint(cfg))
 	trAds, tplAds := cmdlib.LoadAllAds(trsAdsByEndpoint(cfg))
-	// A standalone template: affiliate_link gets no translation func or named template.
-	var legacyAffiliateTpl *texttemplate.Template
-	if cfg.AffiliateLink != "" {
-		legacyAffiliateTpl = texttemplate.Must(texttemplate.New("affiliate_link").Parse(cfg.AffiliateLink))
-	}
 	w := &worker{
-		bots:                       bots,
-		db:                         db.NewDatabase(string(cfg.DBConnectionString), cfg.CheckGID, cfg.MaxSubs),
-		fuzzySearchDB:              db.NewDatabase(string(cfg.DBConnectionString), false, cfg.MaxSubs),
-		cfg:                        cfg,
-		client:                     client,
-		tr:                         tr,
-		tpl:                        tpl,
-		legacyAffiliateTpl:         legacyAffiliateTpl,
-		trAds:                      trAds,
-		tplAds:                     tplAds,
-		imageDownloadLogs:          make(chan imageDownloadLog),
-		unconfirmedOnlineStreamers: map[string]cmdlib.StreamerInfo{},
-		botNames:                   map[string]string{},
-		senders:                    map[string]*endpointSender{},
-		sendResults:                make(chan msgSendResult, sendChanCap),
-		cooledUsers:                make(chan cooledUser, sendChanCap),
-		shutdownCh:                 make(chan struct{}),
-		existenceListResults:       make(chan *cmdlib.ExistenceListResults),
-		checkerResults:             make(chan cmdlib.CheckerResults),
-		sendingNotifications:       make(chan []db.Notification, 1000),
-		imagedNotifications:        make(chan notificationBatch),
-		ourIDs:                     getOurIDs(cfg),
-		searchRequests:             make(chan searchRequest),
-		webAppAddRequests:          make(chan webAppAddRequest),
-		webAppTimezoneRequests:     make(chan webAppTimezoneRequest),
-		webAppRemovalListRequests:  make(chan webAppRemovalListRequest),
-		webAppRemoveRequests:       make(chan webAppRemoveRequest),
-		incomingPackets:            incomingPackets,
+		bots:                      bots,
+		db:                        db.NewDatabase(string(cfg.DBConnectionString), cfg.CheckGID, cfg.MaxSubs),
+		fuzzySearchDB:             db.NewDatabase(string(cfg.DBConnectionString), false, cfg.MaxSubs),
+		cfg:                       cfg,
+		client:                    client,
+		tr:                        tr,
+		tpl:                       tpl,
+		trAds:                     trAds,
+		tplAds:                    tplAds,
+		imageDownloadLogs:         make(chan imageDownloadLog),
+		botNames:                  map[string]string{},
+		senders:                   map[string]*endpointSender{},
+		sendResults:               make(chan msgSendResult, sendChanCap),
+		cooledUsers:               make(chan cooledUser, sendChanCap),
+		shutdownCh:                make(chan struct{}),
+		existenceListResults:      make(chan siteExistenceResults),
+		checkerResults:            make(chan siteCheckerResults),
+		inlineResults:             make(chan siteExistenceResults),
+		sendingNotifications:      make(chan []db.Notification, 1000),
+		imagedNotifications:       make(chan notificationBatch),
+		ourIDs:                    getOurIDs(cfg),
+		searchRequests:            make(chan searchRequest),
+		webAppAddRequests:         make(chan webAppAddRequest),
+		webAppTimezoneRequests:    make(chan webAppTimezoneRequest),
+		webAppQuietRequests:       make(chan webAppQuietRequest),
+		webAppRemovalListRequests: make(chan webAppRemovalListRequest),
+		webAppRemoveRequests:      make(chan webAppRemoveRequest),
+		webAppFilterRequests:      make(chan webAppFilterRequest),
+		apiRequests:               make(chan apiRequest),
+		incomingPackets:           incomingPackets,
+		hookPosts:                 make(chan *hookPost, hookQueueLen),
+		hookClient:                cmdlib.HTTPClientWithTimeout(cfg.WebhookTimeout()),
+		discord:                   &discordTransport{client: telegramClient},
 	}
 	w.chatMember = w.getChatMember
+	w.answerInlineQuery = w.postInlineAnswer
+	w.createForumTopic = w.postForumTopic
+	if w.apiEnabled() {
+		w.apiDB = db.NewDatabase(string(cfg.DBConnectionString), false, cfg.MaxSubs)
+	}
 	// The bot starts in maintenance: the database is not created yet.
 	w.maintenance.Store(true)
 	for endpoint, a := range tr {
@@ -340,7 +413,7 @@ func newWorker(cfg *botconfig.Config, checker checkers.Checker) *worker {
 		}
 	}
 
-	w.checker = checker
+	w.sites, w.defaultSite = buildSites(cfg, checker, extraCheckers)
 
 	searchHTMLBytes, err := os.ReadFile("res/webapp/search.html")
 	checkErr(err)
@@ -352,6 +425,11 @@ func newWorker(cfg *botconfig.Config, checker checkers.Checker) *worker {
 	w.timezoneHTML, err = htmltemplate.New("timezone").Parse(string(timezoneHTMLBytes))
 	checkErr(err)
 
+	quietHTMLBytes, err := os.ReadFile("res/webapp/quiet.html")
+	checkErr(err)
+	w.quietHTML, err = htmltemplate.New("quiet").Parse(string(quietHTMLBytes))
+	checkErr(err)
+
 	removalHTMLBytes, err := os.ReadFile("res/webapp/removal.html")
 	checkErr(err)
 	w.removalHTML, err = htmltemplate.New("removal").Parse(string(removalHTMLBytes))
@@ -566,66 +644,16 @@ func (w *worker) sendMaintenance(endpoint string, chatID int64, notify bool, tex
 	w.enqueueMessage(db.PriorityHigh, endpoint, msg, unprompted(db.MaintenancePacket), 0, 0)
 }
 
-// sendMessageInternal sends one message and classifies the outcome.
-// retryAfter is Telegram's requested 429 pause in seconds, 0 otherwise.
+// sendMessageInternal sends one message over its endpoint's transport and classifies the outcome.
+// retryAfter is the requested 429 pause in seconds, 0 otherwise.
 func (w *worker) sendMessageInternal(
 	endpoint string,
 	msg sendable,
 ) (result int, migrateTo int64, retryAfter int) {
-	chatID := msg.chatID()
-	if !w.cfg.ChatWhitelisted(chatID) {
+	if !w.cfg.ChatWhitelisted(msg.chatID()) {
 		return messageSkipped, 0, 0
 	}
-	ctx := context.Background()
-	if _, err := msg.send(ctx, w.bots[endpoint]); err != nil {
-		var migrateErr *bot.MigrateError
-		if errors.As(err, &migrateErr) {
-			ldbg("cannot send a message, group migration")
-			// The library builds a MigrateError only around a set target, never a zero.
-			return messageMigrate, int64(migrateErr.MigrateToChatID), 0
-		}
-		var tooManyErr *bot.TooManyRequestsError
-		if errors.As(err, &tooManyErr) {
-			ldbg("cannot send a message, too many requests: retry_after = %d", tooManyErr.RetryAfter)
-			return messageTooManyRequests, 0, tooManyErr.RetryAfter
-		}
-		if errors.Is(err, bot.ErrorForbidden) {
-			ldbg("cannot send a message, bot blocked")
-			return messageBlocked, 0, 0
-		}
-		if errors.Is(err, bot.ErrorBadRequest) {
-			if strings.Contains(err.Error(), "chat not found") {
-				ldbg("cannot send a message, chat not found")
-				return messageChatNotFound, 0, 0
-			}
-			if strings.Contains(err.Error(), "not enough rights to send photos") {
-				ldbg("cannot send a message, no photo rights")
-				return messageNoPhotoRights, 0, 0
-			}
-			if strings.Contains(err.Error(), "not enough rights to send text messages") {
-				ldbg("cannot send a message, no text rights")
-				return messageNoTextRights, 0, 0
-			}
-			if strings.Contains(err.Error(), "TOPIC_CLOSED") {
-				ldbg("cannot send a message, topic closed")
-				return messageTopicClosed, 0, 0
-			}
-			lerr("cannot send a message, bad request, error: %v", err)
-			return messageBadRequest, 0, 0
-		}
-		var netErr net.Error
-		if errors.As(err, &netErr) {
-			if netErr.Timeout() {
-				ldbg("cannot send a message, timeout")
-				return messageTimeout, 0, 0
-			}
-			lerr("cannot send a message, unknown network error")
-			return messageUnknownNetworkError, 0, 0
-		}
-		lerr("unexpected error type while sending a message to %d, %v", chatID, err)
-		return messageUnknownError, 0, 0
-	}
-	return messageSent, 0, 0
+	return w.transport(endpoint).send(context.Background(), msg)
 }
 
 func templateToString(t *texttemplate.Template, key string, data tplData) string {
@@ -714,8 +742,14 @@ func (w *worker) createDatabase() {
 		// and no request arrives until registerWebApp, which runs after this.
 		// fuzzySearchDB has no GID check to catch a slip in that ordering.
 		w.fuzzySearchDB.MustExec(prelude)
+		// The same holds for apiDaemon and registerAPI.
+		if w.apiEnabled() {
+			w.apiDB.MustExec(prelude)
+		}
 	}
 	w.db.ApplyMigrations()
+	// Rows from before sites were stored belong to the site the bot then served.
+	w.db.ClaimUnsitedRows(w.cfg.Website)
 	w.db.ResetQueryStats()
 }
 
@@ -756,13 +790,16 @@ func (w *worker) setZoneNames(names map[string]string) {
 }
 
 func (w *worker) initCache() {
-	start := time.Now()
-	w.unconfirmedOnlineStreamers = map[string]cmdlib.StreamerInfo{}
-	for nickname := range w.db.QueryLastOnlineStreamers() {
-		w.unconfirmedOnlineStreamers[nickname] = cmdlib.StreamerInfo{}
+	for _, s := range w.sites {
+		start := time.Now()
+		s.unconfirmedOnlineStreamers = map[string]cmdlib.StreamerInfo{}
+		for nickname := range w.db.QueryLastOnlineStreamers(s.name) {
+			s.unconfirmedOnlineStreamers[nickname] = cmdlib.StreamerInfo{}
+		}
+		elapsed := time.Since(start)
+		linf("cache initialized with %d online streamers of %s in %d ms",
+			len(s.unconfirmedOnlineStreamers), s.name, elapsed.Milliseconds())
 	}
-	elapsed := time.Since(start)
-	linf("cache initialized with %d online streamers in %d ms", len(w.unconfirmedOnlineStreamers), elapsed.Milliseconds())
 }
 
 func (w *worker) notifyOfAddResults(priority db.Priority, notifications []db.Notification) {
@@ -776,7 +813,7 @@ func (w *worker) notifyOfAddResults(priority db.Priority, notifications []db.Not
 			lerr("dropping add result for unknown endpoint %s", n.Endpoint)
 			continue
 		}
-		data := tplData{"streamer": n.Nickname}
+		data := tplData{"streamer": w.qualifiedName(n.Site, n.Nickname)}
 		if n.Status&(cmdlib.StatusOnline|cmdlib.StatusOffline|cmdlib.StatusDenied) != 0 {
 			w.sendTr(priority, n.Endpoint, n.UserID, false, w.tr[n.Endpoint].StreamerAdded, data, notificationTag(n))
 		} else {
@@ -819,6 +856,7 @@ func (w *worker) enqueueNotifications(batch notificationBatch) {
 	for _, p := range plans {
 		w.notifyOfStatus(p, batch.images[p.ImageURL])
 	}
+	w.mirrorToDiscord(plans)
 }
 
 // plannedNotification is a fetched notification with the message its status calls for,
@@ -836,7 +874,7 @@ func (w *worker) planNotifications(nots []db.Notification) []plannedNotification
 	for i, n := range nots {
 		plans[i] = plannedNotification{
 			Notification: n,
-			translation:  w.statusTranslation(n.Endpoint, n.Status),
+			translation:  w.notificationTranslation(n),
 		}
 	}
 	return plans
@@ -894,6 +932,20 @@ func (w *worker) numberNotifications(plans []plannedNotification) {
 	}
 }
 
+// notificationTranslation is the message a notification calls for, nil where it or its endpoint has none.
+// A show-kind or subject alert is online by status, yet a message of its own, and so is a live edit.
+func (w *worker) notificationTranslation(n db.Notification) *cmdlib.Translation {
+	switch n.Kind {
+	case db.ShowKindPacket:
+		return w.showKindTranslation(n.Endpoint)
+	case db.SubjectPacket:
+		return w.subjectTranslation(n.Endpoint)
+	case db.LivePacket:
+		return w.liveTranslation(n)
+	}
+	return w.statusTranslation(n.Endpoint, n.Status)
+}
+
 // statusTranslation is the message a status calls for, nil where status or endpoint has none.
 func (w *worker) statusTranslation(endpoint string, status cmdlib.StatusKind) *cmdlib.Translation {
 	tr := w.tr[endpoint]
@@ -992,7 +1044,24 @@ func (w *worker) channelBotLink(n db.Notification, reports int) string {
 }
 
 // notifyOfStatus composes one fetched notification.
+// An alert in the chat's quiet hours goes out silently or is held for its summary.
 func (w *worker) notifyOfStatus(p plannedNotification, image []byte) {
+	now := time.Now()
+	if p.Kind == db.LivePacket {
+		// An edit is silent and adds no message, so quiet hours leave it be.
+		w.editLiveMessage(p, w.statusData(p), image, now)
+		return
+	}
+	quiet := w.quietActionFor(p.Notification, now)
+	if p.translation != nil && quiet == quietHold {
+		if p.Kind != db.NotificationPacket {
+			// A summary tells of time online, which a show-kind or subject change leaves as it was.
+			w.finalizeNotification(p.ID)
+			return
+		}
+		w.holdNotification(p.Notification, now)
+		return
+	}
 	if p.translation == nil {
 		// Nothing to send for this status, or an endpoint dropped from the config;
 		// clear the queue row so it doesn't strand.
@@ -1001,32 +1070,18 @@ func (w *worker) notifyOfStatus(p plannedNotification, image []byte) {
 		ldbg("notifying of status of the streamer %s", p.Nickname)
 		notify := false
 		if p.Status == cmdlib.StatusOnline {
-			notify = !p.SilentMessages
+			// A favourite alerts with sound in a silent chat, though not in its quiet hours.
+			notify = (!p.SilentMessages || p.Favourite) && quiet == quietOff
 		} else {
 			// Only an online notification carries a picture.
 			image = nil
 		}
-		var timeDiff *timeDiff
-		if p.TimeDiff != nil {
-			temp := calcTimeDiff(*p.TimeDiff)
-			timeDiff = &temp
-		}
-		subject, subjectClipped := clipSubject(p.Subject)
-		data := tplData{
-			"streamer":        p.Nickname,
-			"streamer_link":   w.streamerLink(p.Nickname, w.gatedAffiliate(p.AffiliateParams)),
-			"time_diff":       timeDiff,
-			"viewers":         p.Viewers,
-			"show_kind":       p.ShowKind,
-			"subject":         html.EscapeString(subject),
-			"subject_clipped": subjectClipped,
-			// A group or channel gets no hint: settings are admin-only there, so it is noise.
-			// The chat is read here rather than stored, so a queued row holds no chat identity.
-			"fields_hint": p.FieldsHint && !isGroupOrChannel(p.ChatID),
-			"bot_link":    w.channelBotLink(p.Notification, p.reports),
+		params := &renderParams{templates: w.tpl[p.Endpoint], key: p.translation.Key, data: w.statusData(p)}
+		msg := params.asDeferredSendable(p.translation, notify, image)
+		if keyboard := w.notificationKeyboard(p); keyboard != nil {
+			withKeyboard(msg, keyboard)
 		}
-		w.enqueueTr(p.Priority, p.Endpoint, p.UserID, notify, p.translation, data, image,
-			notificationTag(p.Notification), p.ID)
+		w.enqueueNotification(p, msg, liveAlert(p, now))
 	}
 	if p.Social && w.cfg.AdChancePercent > 0 && rand.Intn(100) < w.cfg.AdChancePercent {
 		// Empty today: only a status notification is ever social,
@@ -1037,6 +1092,30 @@ func (w *worker) notifyOfStatus(p plannedNotification, image []byte) {
 	}
 }
 
+// statusData is what a status notification renders with.
+func (w *worker) statusData(p plannedNotification) tplData {
+	var timeDiff *timeDiff
+	if p.TimeDiff != nil {
+		temp := calcTimeDiff(*p.TimeDiff)
+		timeDiff = &temp
+	}
+	subject, subjectClipped := clipSubject(p.Subject)
+	return tplData{
+		"streamer":        w.qualifiedName(p.Site, p.Nickname),
+		"streamer_link":   w.streamerLink(p.Site, p.Nickname, w.gatedAffiliate(p.AffiliateParams)),
+		"time_diff":       timeDiff,
+		"viewers":         p.Viewers,
+		"show_kind":       p.ShowKind,
+		"subject":         html.EscapeString(subject),
+		"subject_clipped": subjectClipped,
+		"session":         summarizeSession(p.Session),
+		// A group or channel gets no hint: settings are admin-only there, so it is noise.
+		// The chat is read here rather than stored, so a queued row holds no chat identity.
+		"fields_hint": p.FieldsHint && !isGroupOrChannel(p.ChatID),
+		"bot_link":    w.channelBotLink(p.Notification, p.reports),
+	}
+}
+
 func (w *worker) mustUserByID(userID db.UserID) (user db.User) {
 	user, found := w.db.UserByID(userID)
 	if !found {
@@ -1047,59 +1126,88 @@ func (w *worker) mustUserByID(userID db.UserID) (user db.User) {
 
 // customAffiliateLinkEnabled reports whether chats may set their own affiliate link.
 // It needs affiliate_base, since only a Go-built link carries the params.
+// The params are the default site's, the only site whose parser reads them.
 func (w *worker) customAffiliateLinkEnabled() bool {
 	return w.cfg.EnableCustomAffiliateLink &&
-		w.cfg.AffiliateBase != "" &&
-		w.checker.Capabilities().SupportsCustomAffiliateLink
+		w.defaultSite.affiliateBase != "" &&
+		w.defaultSite.checker.Capabilities().SupportsCustomAffiliateLink
 }
 
-// affiliateBase is affiliate_base without its trailing slash, so every link spells it one way.
+// affiliateBase is the default site's affiliate_base without its trailing slash,
+// so every link spells it one way.
 func (w *worker) affiliateBase() string {
-	return strings.TrimRight(w.cfg.AffiliateBase, "/")
+	return w.defaultSite.affiliateBase
 }
 
-// siteHomeLink is the site's homepage link, affiliate_base over website_link.
+// siteHomeLink is the default site's homepage link, affiliate_base over website_link.
 func (w *worker) siteHomeLink() string {
-	if w.cfg.AffiliateBase != "" {
+	if w.defaultSite.affiliateBase != "" {
 		return w.affiliateBase()
 	}
 	return w.cfg.WebsiteLink
 }
 
 // streamerLinker returns a link builder that encodes the affiliate query once.
-func (w *worker) streamerLinker(affiliate map[string]string) func(string) string {
-	if w.cfg.AffiliateBase != "" {
-		prefix := w.affiliateBase() + "/"
-		suffix := ""
-		if len(affiliate) > 0 {
-			suffix = "?" + affiliateQuery(affiliate)
-		}
-		return func(streamer string) string {
-			return "<a href='" + prefix + streamer + suffix + "'>" + streamer + "</a>"
+// A chat's affiliate params ride on the default site's links only.
+// A site dropped from the config, whose rows linger, gets its plain name.
+func (w *worker) streamerLinker(affiliate map[string]string) func(siteName, nickname string) string {
+	suffix := ""
+	if len(affiliate) > 0 {
+		suffix = "?" + affiliateQuery(affiliate)
+	}
+	return func(siteName, nickname string) string {
+		name := w.qualifiedName(siteName, nickname)
+		s := w.sites[siteName]
+		switch {
+		case s == nil:
+			return name
+		case s.affiliateBase != "":
+			query := ""
+			if s == w.defaultSite {
+				query = suffix
+			}
+			return "<a href='" + s.affiliateBase + "/" + nickname + query + "'>" + name + "</a>"
+		case s.legacyAffiliateTpl != nil:
+			var b bytes.Buffer
+			checkErr(s.legacyAffiliateTpl.Execute(&b, nickname))
+			return b.String()
 		}
-	}
-	if w.legacyAffiliateTpl == nil {
-		return func(streamer string) string { return streamer }
-	}
-	return func(streamer string) string {
-		var b bytes.Buffer
-		checkErr(w.legacyAffiliateTpl.Execute(&b, streamer))
-		return b.String()
+		return name
 	}
 }
 
 // streamerLink renders one streamer's affiliate link as an HTML anchor.
-func (w *worker) streamerLink(streamer string, affiliate map[string]string) string {
-	return w.streamerLinker(affiliate)(streamer)
+func (w *worker) streamerLink(siteName, nickname string, affiliate map[string]string) string {
+	return w.streamerLinker(affiliate)(siteName, nickname)
+}
+
+// streamerURL is the plain address of a streamer's page, as a button or an embed takes it,
+// carrying the chat's affiliate params as streamerLinker does.
+// It is empty where no address is known:
+// a site linked through the legacy affiliate_link template, which renders HTML, or one dropped from the config.
+func (w *worker) streamerURL(siteName, nickname string, affiliate map[string]string) string {
+	s := w.sites[siteName]
+	if s == nil || s.affiliateBase == "" {
+		return ""
+	}
+	link := s.affiliateBase + "/" + nickname
+	if s == w.defaultSite && len(affiliate) > 0 {
+		link += "?" + affiliateValues(affiliate).Encode()
+	}
+	return link
 }
 
 // affiliateQuery encodes affiliate params as an href-escaped URL query.
 func affiliateQuery(affiliate map[string]string) string {
+	return html.EscapeString(affiliateValues(affiliate).Encode())
+}
+
+func affiliateValues(affiliate map[string]string) url.Values {
 	values := url.Values{}
 	for name, value := range affiliate {
 		values.Set(name, value)
 	}
-	return html.EscapeString(values.Encode())
+	return values
 }
 
 // gatedAffiliate returns a chat's own affiliate params, or nil when off.
@@ -1129,20 +1237,21 @@ func (w *worker) gatedAffiliateForChat(chatID int64, user db.User) map[string]st
 
 func (w *worker) showWeek(m receivedMessage, nickname string) {
 	if nickname != "" {
-		nickname = w.checker.NicknamePreprocessing(nickname)
-		if !w.checker.NicknameRegexp().MatchString(nickname) {
-			w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": nickname})
+		s, nickname := w.parseStreamer(nickname)
+		if !s.checker.NicknameRegexp().MatchString(nickname) {
+			w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols,
+				tplData{"streamer": w.qualifiedName(s.name, nickname)})
 			return
 		}
 		user := w.mustUserByID(m.userID)
 		affiliate := w.gatedAffiliateForChat(m.chatID, user)
 		loc, zone := w.chatLocation(user)
-		hours, weekday := w.week(nickname, loc)
+		hours, weekday := w.week(s.name, nickname, loc)
 		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].Week, tplData{
 			"hours":         hours,
 			"weekday":       int(weekday),
 			"timezone":      zone,
-			"streamer_link": w.streamerLink(nickname, affiliate),
+			"streamer_link": w.streamerLink(s.name, nickname, affiliate),
 		})
 		return
 	}
@@ -1163,9 +1272,9 @@ func (w *worker) showWeek(m receivedMessage, nickname string) {
 	loc, zone := w.chatLocation(user)
 	hoursMap, weekday := w.weekForStreamers(ids, now, loc)
 	statuses := w.db.UnconfirmedStatusesForUser(m.endpoint, m.userID)
-	statusMap := make(map[string]db.Streamer, len(statuses))
+	statusMap := make(map[int]db.Streamer, len(statuses))
 	for _, s := range statuses {
-		statusMap[s.Nickname] = s
+		statusMap[s.ID] = s
 	}
 	var weeks []tplData
 	var neverOnline []streamerListEntry
@@ -1174,11 +1283,11 @@ func (w *worker) showWeek(m receivedMessage, nickname string) {
 		hours := hoursMap[s.ID]
 		if !slices.Contains(hours, true) {
 			var td *timeDiff
-			if st, ok := statusMap[s.Nickname]; ok {
+			if st, ok := statusMap[s.ID]; ok {
 				td = w.streamerTimeDiff(st, nowUnix)
 			}
 			neverOnline = append(neverOnline, streamerListEntry{
-				Link:     link(s.Nickname),
+				Link:     link(s.Site, s.Nickname),
 				TimeDiff: td,
 			})
 			continue
@@ -1187,7 +1296,7 @@ func (w *worker) showWeek(m receivedMessage, nickname string) {
 			"hours":         hours,
 			"weekday":       int(weekday),
 			"timezone":      zone,
-			"streamer_link": link(s.Nickname),
+			"streamer_link": link(s.Site, s.Nickname),
 		})
 	}
 	for chunk := range slices.Chunk(weeks, 10) {
@@ -1206,7 +1315,7 @@ func (w *worker) addStreamer(m receivedMessage, nickname string, referral bool)
 		params := &renderParams{templates: w.tpl[m.endpoint], key: tr.Key}
 		msg := params.asDeferredText(true, tr.DisablePreview, tr.Parse)
 		// A private chat, the only place a web app button works.
-		if !w.checker.Capabilities().UsesFixedListOnline() && !isGroupOrChannel(m.chatID) {
+		if !w.defaultSite.checker.Capabilities().UsesFixedListOnline() && !isGroupOrChannel(m.chatID) {
 			msg.ReplyMarkup = &models.InlineKeyboardMarkup{
 				InlineKeyboard: [][]models.InlineKeyboardButton{{
 					{
@@ -1219,14 +1328,15 @@ func (w *worker) addStreamer(m receivedMessage, nickname string, referral bool)
 		w.replyMessage(m, db.PriorityHigh, msg)
 		return nil
 	}
-	nickname = w.checker.NicknamePreprocessing(nickname)
-	if !w.checker.NicknameRegexp().MatchString(nickname) {
-		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": nickname})
+	s, nickname := w.parseStreamer(nickname)
+	name := w.qualifiedName(s.name, nickname)
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": name})
 		return nil
 	}
 
-	if w.db.SubscribedOrPending(m.endpoint, m.userID, nickname) {
-		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].AlreadyAdded, tplData{"streamer": nickname})
+	if w.db.SubscribedOrPending(m.endpoint, m.userID, s.name, nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].AlreadyAdded, tplData{"streamer": name})
 		return nil
 	}
 	subscriptionsNumber := w.db.SubscribedOrPendingCount(m.endpoint, m.userID)
@@ -1236,15 +1346,15 @@ func (w *worker) addStreamer(m receivedMessage, nickname string, referral bool)
 		w.subscriptionUsage(m.next(), true)
 		return nil
 	}
-	streamer := w.db.MaybeStreamer(nickname)
+	streamer := w.db.MaybeStreamer(s.name, nickname)
 	if streamer == nil {
-		caps := w.checker.Capabilities()
+		caps := s.checker.Capabilities()
 		if !caps.SupportsQueryStatus && !caps.SupportsQueryFixedListStatuses {
-			w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].AddError, tplData{"streamer": nickname})
+			w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].AddError, tplData{"streamer": name})
 			return nil
 		}
 		// The confirmation lands later, so it takes the next number.
-		w.db.AddPendingSubscription(m.userID, nickname, m.endpoint, referral, m.command, m.replySeq+1)
+		w.db.AddPendingSubscription(m.userID, s.name, nickname, m.endpoint, referral, m.command, m.replySeq+1)
 		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].CheckingStreamer, nil)
 		return nil
 	}
@@ -1254,12 +1364,13 @@ func (w *worker) addStreamer(m receivedMessage, nickname string, referral bool)
 	}
 	w.db.AddSubscription(m.userID, streamer.ID, m.endpoint)
 	subscriptionsNumber++
-	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].StreamerAdded, tplData{"streamer": nickname})
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].StreamerAdded, tplData{"streamer": name})
 	m = m.next()
 	nots := []db.Notification{{
 		Endpoint:   m.endpoint,
 		UserID:     m.userID,
 		StreamerID: &streamer.ID,
+		Site:       s.name,
 		Nickname:   nickname,
 		Status:     confirmedStatus,
 		TimeDiff:   w.streamerDuration(*streamer, m.timestamp),
@@ -1521,19 +1632,32 @@ func (w *worker) settings(m receivedMessage) {
 	subscriptionsNumber := w.db.SubscribedOrPendingCount(m.endpoint, m.userID)
 	user := w.mustUserByID(m.userID)
 	_, zone := w.chatLocation(user)
+	quietHours := ""
+	if q, ok := storedQuietHours(user.QuietStart, user.QuietEnd); ok {
+		quietHours = q.String()
+	}
 	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].Settings, tplData{
 		"subscriptions_used":              subscriptionsNumber,
 		"total_subscriptions":             user.MaxSubs,
 		"show_images":                     user.ShowImages,
 		"offline_notifications_supported": w.cfg.OfflineNotifications,
 		"offline_notifications":           user.OfflineNotifications,
-		"subject_supported":               w.checker.Capabilities().SupportsSubject,
+		"subject_supported":               w.anySiteSupportsSubject(),
 		"show_subject":                    user.ShowSubject,
+		"subject_alerts":                  user.SubjectAlerts,
+		"live_messages":                   user.LiveMessages,
+		"digest":                          formatDigest(user.DigestPeriod, user.DigestTime),
 		"silent_messages":                 user.SilentMessages,
 		"in_group":                        isGroup(user),
 		"member_subscriptions":            user.MemberSubscriptions,
+		"forum":                           m.forum,
+		"topic_per_streamer":              user.TopicPerStreamer,
 		"timezone":                        zone,
 		"timezone_set":                    user.Timezone != nil,
+		"quiet_hours":                     quietHours,
+		"quiet_summary":                   user.QuietSummary,
+		"show_kinds_supported":            w.anySiteSupportsShowKind(),
+		"show_kind_alerts":                formatShowKindAlerts(user.ShowKindAlerts),
 		"can_manage_affiliate":            w.customAffiliateLinkEnabled() && isGroupOrChannel(user.ChatID),
 		"affiliate_params":                w.gatedAffiliate(user.AffiliateParams),
 	})
@@ -1603,18 +1727,23 @@ func (w *worker) zoneOrDefault(name string) (*time.Location, string, bool) {
 }
 
 // chatLocation resolves a chat's timezone, UTC when it set none.
-// A name it cannot resolve is reported and fallen back from, and left in the column.
-// Main goroutine only, as every caller is a command handler.
+// Main goroutine only, as every caller is a command handler or the notification path.
 func (w *worker) chatLocation(user db.User) (*time.Location, string) {
-	if user.Timezone == nil {
+	return w.timezoneLocation(user.UserID, user.Timezone)
+}
+
+// timezoneLocation is chatLocation for a caller holding the chat's zone column but not its row.
+// A name it cannot resolve is reported and fallen back from, and left in the column.
+func (w *worker) timezoneLocation(userID db.UserID, timezone *string) (*time.Location, string) {
+	if timezone == nil {
 		return time.UTC, utcZone
 	}
-	loc, zone, known := w.zoneOrDefault(*user.Timezone)
+	loc, zone, known := w.zoneOrDefault(*timezone)
 	if !known {
 		// Reported, never repaired: which names resolve turns on the tzdata this binary carries,
 		// so a rollback to one that predates a zone would have a listing destroy a chat's own choice.
 		lerr("stored timezone will not load, falling back: @uid = %d, timezone = %s",
-			user.UserID, *user.Timezone)
+			userID, *timezone)
 	}
 	return loc, zone
 }
@@ -1689,7 +1818,7 @@ func (w *worker) setAffiliate(m receivedMessage, arg string) {
 			w.affiliateStatusData(user.AffiliateParams))
 		return
 	}
-	params, ok := w.checker.ParseAffiliateParams(arg)
+	params, ok := w.defaultSite.checker.ParseAffiliateParams(arg)
 	if !ok {
 		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].AffiliateInvalid, nil)
 		return
@@ -1712,7 +1841,7 @@ func (w *worker) resetAffiliate(m receivedMessage) {
 // affiliateStatusData builds the affiliate status template data.
 func (w *worker) affiliateStatusData(params map[string]string) tplData {
 	return tplData{
-		"affiliate_id":    w.checker.AffiliateID(params),
+		"affiliate_id":    w.defaultSite.checker.AffiliateID(params),
 		"affiliate_base":  w.affiliateBase(),
 		"affiliate_query": affiliateQuery(params),
 	}
@@ -1746,6 +1875,10 @@ func senderOf(u *models.Update) sender {
 		s.senderChat = u.Message.SenderChat
 		s.from = u.Message.From
 	}
+	if u.CallbackQuery != nil {
+		// A tap on a button is the tapper's own, even under a channel post.
+		s.from = &u.CallbackQuery.From
+	}
 	return s
 }
 
@@ -1843,17 +1976,18 @@ func (w *worker) removeStreamer(m receivedMessage, nickname string) {
 		w.replyMessage(m, db.PriorityHigh, msg)
 		return
 	}
-	nickname = w.checker.NicknamePreprocessing(nickname)
-	if !w.checker.NicknameRegexp().MatchString(nickname) {
-		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": nickname})
+	s, nickname := w.parseStreamer(nickname)
+	name := w.qualifiedName(s.name, nickname)
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": name})
 		return
 	}
-	if !w.db.SubscribedOrPending(m.endpoint, m.userID, nickname) {
-		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].StreamerNotInList, tplData{"streamer": nickname})
+	if !w.db.SubscribedOrPending(m.endpoint, m.userID, s.name, nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].StreamerNotInList, tplData{"streamer": name})
 		return
 	}
-	w.db.RemoveSubscription(m.userID, nickname, m.endpoint)
-	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].StreamerRemoved, tplData{"streamer": nickname})
+	w.db.RemoveSubscription(m.userID, s.name, nickname, m.endpoint)
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].StreamerRemoved, tplData{"streamer": name})
 }
 
 func (w *worker) sureRemoveAll(m receivedMessage) {
@@ -1911,17 +2045,25 @@ func (w *worker) listStreamers(m receivedMessage) {
 		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].ZeroSubscriptions, nil)
 		return
 	}
+	favourites := w.db.FavouriteStreamers(m.endpoint, m.userID)
 	sort.SliceStable(statuses, func(i, j int) bool {
+		// Favourites go first in both the online and the offline part of the list.
+		if fi, fj := favourites[statuses[i].ID], favourites[statuses[j].ID]; fi != fj {
+			return fi
+		}
 		return listStreamersSortWeight(statuses[i].UnconfirmedStatus) < listStreamersSortWeight(statuses[j].UnconfirmedStatus)
 	})
 	link := w.streamerLinker(w.gatedAffiliateForUser(m))
+	muted := w.db.MutedStreamers(m.endpoint, m.userID, m.timestamp)
 	chunks := chunkStreamers(statuses, 50)
 	for _, chunk := range chunks {
 		var online, offline []streamerListEntry
 		for _, s := range chunk {
 			entry := streamerListEntry{
-				Link:     link(s.Nickname),
-				TimeDiff: w.streamerTimeDiff(s, m.timestamp),
+				Link:      link(s.Site, s.Nickname),
+				TimeDiff:  w.streamerTimeDiff(s, m.timestamp),
+				Muted:     muted[s.ID],
+				Favourite: favourites[s.ID],
 			}
 			switch s.UnconfirmedStatus {
 			case cmdlib.StatusOnline:
@@ -1991,14 +2133,19 @@ func (w *worker) downloadImageInternal(url string) ([]byte, error) {
 	return data, nil
 }
 
-func (w *worker) listOnlineStreamers(m receivedMessage) {
-	statuses := w.db.UnconfirmedStatusesForUser(m.endpoint, m.userID)
+// onlineSubscriptions returns a chat's subscriptions on an endpoint online as of the last poll.
+func (w *worker) onlineSubscriptions(endpoint string, userID db.UserID) []db.Streamer {
 	var online []db.Streamer
-	for _, s := range statuses {
+	for _, s := range w.db.UnconfirmedStatusesForUser(endpoint, userID) {
 		if s.UnconfirmedStatus == cmdlib.StatusOnline {
 			online = append(online, s)
 		}
 	}
+	return online
+}
+
+func (w *worker) listOnlineStreamers(m receivedMessage) {
+	online := w.onlineSubscriptions(m.endpoint, m.userID)
 	if len(online) == 0 {
 		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].NoOnlineStreamers, nil)
 		return
@@ -2012,12 +2159,13 @@ func (w *worker) listOnlineStreamers(m receivedMessage) {
 	user := w.mustUserByID(m.userID)
 	var nots []db.Notification
 	for _, s := range online {
-		info := w.unconfirmedOnlineStreamers[s.Nickname]
+		info := w.onlineInfo(s.Site, s.Nickname)
 		not := db.Notification{
 			Priority:   db.PriorityHigh,
 			Endpoint:   m.endpoint,
 			UserID:     m.userID,
 			StreamerID: &s.ID,
+			Site:       s.Site,
 			Nickname:   s.Nickname,
 			Status:     cmdlib.StatusOnline,
 			ImageURL:   info.ImageURL,
@@ -2041,8 +2189,8 @@ func (w *worker) listOnlineStreamers(m receivedMessage) {
 	w.storeNotifications(nots)
 }
 
-func (w *worker) week(nickname string, loc *time.Location) ([]bool, time.Weekday) {
-	streamer := w.db.MaybeStreamer(nickname)
+func (w *worker) week(siteName, nickname string, loc *time.Location) ([]bool, time.Weekday) {
+	streamer := w.db.MaybeStreamer(siteName, nickname)
 	if streamer == nil {
 		return nil, 0
 	}
@@ -2118,8 +2266,13 @@ func (w *worker) weekForStreamers(
 	now time.Time,
 	loc *time.Location,
 ) (map[int][]bool, time.Weekday) {
+	return weekGrid(&w.db, streamerIDs, now, loc)
+}
+
+// weekGrid builds the grid from d, which the caller owns.
+func weekGrid(d *db.Database, streamerIDs []int, now time.Time, loc *time.Location) (map[int][]bool, time.Weekday) {
 	from, to, weekday := weekWindow(now, loc)
-	changesMap := w.db.ChangesFromToForStreamers(streamerIDs, from, to)
+	changesMap := d.ChangesFromToForStreamers(streamerIDs, from, to)
 	return onlineCells(changesMap, from, to, 3600), weekday
 }
 
@@ -2273,7 +2426,7 @@ func (w *worker) sendPolledList(endpoint string, now int, seq int) {
 		var online, offline []streamerListEntry
 		for _, s := range chunk {
 			entry := streamerListEntry{
-				Link:     link(s.Nickname),
+				Link:     link(s.Site, s.Nickname),
 				TimeDiff: w.streamerTimeDiff(s, now),
 			}
 			switch s.UnconfirmedStatus {
@@ -2290,11 +2443,6 @@ func (w *worker) sendPolledList(endpoint string, now int, seq int) {
 }
 
 func (w *worker) poll(endpoint string, arguments string) {
-	caps := w.checker.Capabilities()
-	if caps.UsesFixedListOnline() || !caps.SupportsQueryStatus {
-		w.replyToOwner(endpoint, "checker does not support per-streamer polling")
-		return
-	}
 	parts := strings.Fields(arguments)
 	if len(parts) != 2 {
 		w.replyToOwner(endpoint, "expecting <streamer> <on|off>")
@@ -2303,8 +2451,13 @@ func (w *worker) poll(endpoint string, arguments string) {
 		}
 		return
 	}
-	nickname := w.checker.NicknamePreprocessing(parts[0])
-	if !w.checker.NicknameRegexp().MatchString(nickname) {
+	s, nickname := w.parseStreamer(parts[0])
+	caps := s.checker.Capabilities()
+	if caps.UsesFixedListOnline() || !caps.SupportsQueryStatus {
+		w.replyToOwner(endpoint, "checker does not support per-streamer polling")
+		return
+	}
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
 		w.replyToOwner(endpoint, "invalid nickname")
 		return
 	}
@@ -2318,7 +2471,7 @@ func (w *worker) poll(endpoint string, arguments string) {
 		w.replyToOwner(endpoint, "second argument must be on or off")
 		return
 	}
-	if !w.db.SetPoll(nickname, on) {
+	if !w.db.SetPoll(s.name, nickname, on) {
 		w.replyToOwner(endpoint, "no such streamer")
 		return
 	}
@@ -2473,19 +2626,27 @@ func (w *worker) handleRemovalApp(rw http.ResponseWriter, r *http.Request) {
 	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
 	tr := w.tr[endpoint]
 	data := struct {
-		Header          string
-		Placeholder     string
-		NoSubscriptions string
-		NoResults       string
-		Failed          string
-		FailedToRemove  string
+		Header            string
+		Placeholder       string
+		NoSubscriptions   string
+		NoResults         string
+		Failed            string
+		FailedToRemove    string
+		Filter            string
+		FilterPlaceholder string
+		FilterSave        string
+		FailedToFilter    string
 	}{
-		Header:          tr.RemovalAppHeader.Str,
-		Placeholder:     tr.RemovalAppPlaceholder.Str,
-		NoSubscriptions: tr.RemovalAppNoSubscriptions.Str,
-		NoResults:       tr.RemovalAppNoResults.Str,
-		Failed:          tr.RemovalAppFailed.Str,
-		FailedToRemove:  tr.RemovalAppFailedToRemove.Str,
+		Header:            tr.RemovalAppHeader.Str,
+		Placeholder:       tr.RemovalAppPlaceholder.Str,
+		NoSubscriptions:   tr.RemovalAppNoSubscriptions.Str,
+		NoResults:         tr.RemovalAppNoResults.Str,
+		Failed:            tr.RemovalAppFailed.Str,
+		FailedToRemove:    tr.RemovalAppFailedToRemove.Str,
+		Filter:            tr.RemovalAppFilter.Str,
+		FilterPlaceholder: tr.RemovalAppFilterPlaceholder.Str,
+		FilterSave:        tr.RemovalAppFilterSave.Str,
+		FailedToFilter:    tr.RemovalAppFailedToFilter.Str,
 	}
 	err := w.removalHTML.Execute(rw, data)
 	if err != nil {
@@ -2573,6 +2734,8 @@ func (w *worker) handleSearch(rw http.ResponseWriter, r *http.Request) {
 }
 
 func (w *worker) serveEndpoints() {
+	// Served from the start, unlike the web app, so a scrape sees a start stuck in migrations.
+	http.Handle("/metrics", metrics.Handler())
 	go func() {
 		err := http.ListenAndServe(w.cfg.ListenAddress, nil)
 		checkErr(err)
@@ -2768,7 +2931,17 @@ func (w *worker) performWebAppRemovalList(req webAppRemovalListRequest) {
 	}
 	res := webAppRemovalListResult{allowed: true}
 	if user, found := w.db.User(req.chatID); found {
-		res.nicknames = w.db.SubscribedOrPendingNicknames(req.endpoint, user.UserID)
+		// Qualified, so a tap hands removeStreamer back the name it parses.
+		for _, s := range w.db.SubscribedOrPendingNicknames(req.endpoint, user.UserID) {
+			res.nicknames = append(res.nicknames, w.qualifiedName(s.Site, s.Nickname))
+		}
+		// The page edits the filters too, so it is handed them in the words it sends back.
+		for _, s := range w.db.FilteredSubscriptions(req.endpoint, user.UserID) {
+			if res.filters == nil {
+				res.filters = map[string]string{}
+			}
+			res.filters[w.qualifiedName(s.Site, s.Nickname)] = formatFilter(s.Filter)
+		}
 	}
 	req.resultCh <- res
 }
@@ -2826,12 +2999,13 @@ func (w *worker) handleWebAppRemovalList(rw http.ResponseWriter, r *http.Request
 		http.Error(rw, "forbidden", http.StatusForbidden)
 		return
 	}
-	nicknames := res.nicknames
-	if nicknames == nil {
-		nicknames = []string{}
+	// Never nil, so a chat with no row reads as an empty list, not null.
+	items := make([]removalListItem, 0, len(res.nicknames))
+	for _, nickname := range res.nicknames {
+		items = append(items, removalListItem{Nickname: nickname, Filter: res.filters[nickname]})
 	}
 	rw.Header().Set("Content-Type", "application/json")
-	err := json.NewEncoder(rw).Encode(nicknames)
+	err := json.NewEncoder(rw).Encode(items)
 	if err != nil {
 		lerr("cannot write removal list response, %v", err)
 	}
@@ -2929,9 +3103,13 @@ func (w *worker) registerWebApp() {
 	http.HandleFunc("/apps/add/api/submit", w.handleWebAppAdd)
 	http.HandleFunc("/apps/timezone", w.handleTimezoneApp)
 	http.HandleFunc("/apps/timezone/api/submit", w.handleWebAppTimezone)
+	http.HandleFunc("/apps/quiet", w.handleQuietApp)
+	http.HandleFunc("/apps/quiet/api/submit", w.handleWebAppQuiet)
 	http.HandleFunc("/apps/remove", w.handleRemovalApp)
 	http.HandleFunc("/apps/remove/api/list", w.handleWebAppRemovalList)
 	http.HandleFunc("/apps/remove/api/submit", w.handleWebAppRemove)
+	http.HandleFunc("/apps/remove/api/filter", w.handleWebAppFilter)
+	w.registerAPI()
 }
 
 func (w *worker) logConfig() {
@@ -2940,9 +3118,11 @@ func (w *worker) logConfig() {
 	cfgString, err := json.MarshalIndent(w.cfg, "", "    ")
 	checkErr(err)
 	linf("bot config: " + string(cfgString))
-	checkerCfgString, err := json.MarshalIndent(w.checker.Config(), "", "    ")
-	checkErr(err)
-	linf("checker config: " + string(checkerCfgString))
+	for _, s := range w.sites {
+		checkerCfgString, err := json.MarshalIndent(s.checker.Config(), "", "    ")
+		checkErr(err)
+		linf("checker config for %s: %s", s.name, checkerCfgString)
+	}
 	for k, v := range w.trAds {
 		linf("ads for %s: %d", k, len(v))
 	}
@@ -2952,12 +3132,17 @@ func (w *worker) logConfig() {
 // so a handler cannot exist without its dispatch key.
 // The names stay off knownCommands, held so by TestOwnerCommandsStayOffKnownCommands.
 var ownerCommands = map[string]func(w *worker, endpoint, arguments string){
-	"performance":  (*worker).performanceStat,
-	"broadcast":    (*worker).broadcast,
-	"direct":       (*worker).direct,
-	"blacklist":    (*worker).blacklist,
-	"poll":         (*worker).poll,
-	"set_max_subs": (*worker).setMaxSubs,
+	"performance":    (*worker).performanceStat,
+	"broadcast":      (*worker).broadcast,
+	"direct":         (*worker).direct,
+	"blacklist":      (*worker).blacklist,
+	"poll":           (*worker).poll,
+	"set_max_subs":   (*worker).setMaxSubs,
+	"add_webhook":    (*worker).addHook,
+	"remove_webhook": (*worker).removeHook,
+	"webhooks":       (*worker).listHooks,
+	"add_discord":    (*worker).addDiscord,
+	"remove_discord": (*worker).removeDiscord,
 }
 
 // processOwnerMessage handles the owner's own commands.
@@ -3039,9 +3224,10 @@ func (w *worker) getChatMemberCount(endpoint string, chatID int64) *int {
 
 // refreshMemberCount records the chat's member count.
 // It skips the network round-trip during shutdown,
-// whose drain runs on a fixed budget.
+// whose drain runs on a fixed budget,
+// and after a Discord post, whose endpoint has no bot to ask.
 func (w *worker) refreshMemberCount(endpoint string, chatID int64, userID db.UserID) {
-	if w.shuttingDown.Load() {
+	if w.shuttingDown.Load() || !isTelegram(endpoint) {
 		return
 	}
 	if memberCount := w.getChatMemberCount(endpoint, chatID); memberCount != nil {
@@ -3094,7 +3280,8 @@ func (w *worker) start(m receivedMessage, referrer string, created bool) {
 	switch {
 	case strings.HasPrefix(referrer, modelPayloadPrefix):
 		nickname = referrer[len(modelPayloadPrefix):]
-		nickname = w.checker.NicknamePreprocessing(nickname)
+		// A deep link payload cannot hold the site separator, so it names a default site streamer.
+		nickname = w.defaultSite.checker.NicknamePreprocessing(nickname)
 		referrer = ""
 	case referrer != "":
 		referralID := w.db.ReferralID(m.userID)
@@ -3146,34 +3333,51 @@ var knownCommands = map[string]commandSpec{
 	"ad":                            {},
 	"add":                           {groupAdminOnly: true, memberSubscriptions: true},
 	"affiliate":                     {}, // admin-gated in commandGate while enabled
+	"board":                         {groupAdminOnly: true},
 	"buy_subs":                      {},
+	"digest":                        {groupAdminOnly: true},
 	"disable_images":                {groupAdminOnly: true},
+	"disable_live_messages":         {groupAdminOnly: true},
 	"disable_member_subscriptions":  {groupAdminOnly: true},
 	"disable_offline_notifications": {groupAdminOnly: true},
 	"disable_silent_messages":       {groupAdminOnly: true},
 	"disable_subject":               {groupAdminOnly: true},
+	"disable_subject_alerts":        {groupAdminOnly: true},
+	"disable_topic_per_streamer":    {groupAdminOnly: true},
 	"enable_images":                 {groupAdminOnly: true},
+	"enable_live_messages":          {groupAdminOnly: true},
 	"enable_member_subscriptions":   {groupAdminOnly: true},
 	"enable_offline_notifications":  {groupAdminOnly: true},
 	"enable_silent_messages":        {groupAdminOnly: true},
 	"enable_subject":                {groupAdminOnly: true},
+	"enable_subject_alerts":         {groupAdminOnly: true},
+	"enable_topic_per_streamer":     {groupAdminOnly: true},
 	"faq":                           {},
+	"favourite":                     {groupAdminOnly: true, memberSubscriptions: true},
 	"feedback":                      {},
+	"filter":                        {groupAdminOnly: true, memberSubscriptions: true},
 	"help":                          {},
 	"list":                          {},
+	"mute":                          {groupAdminOnly: true, memberSubscriptions: true},
+	"nodigest":                      {groupAdminOnly: true, memberSubscriptions: true},
 	"online":                        {},
 	"pics":                          {},
+	"quiet":                         {groupAdminOnly: true},
 	"referral":                      {},
 	"remove":                        {groupAdminOnly: true, memberSubscriptions: true},
 	"remove_all":                    {groupAdminOnly: true},
 	"reset_affiliate":               {}, // admin-gated in commandGate while enabled
+	"reset_quiet":                   {groupAdminOnly: true},
 	"reset_timezone":                {groupAdminOnly: true},
 	"settings":                      {groupAdminOnly: true},
+	"show_changes":                  {groupAdminOnly: true},
 	"social":                        {},
 	"start":                         {}, // the m- deep-link form is gated in commandGate
 	"stop":                          {groupAdminOnly: true},
 	"sure_remove_all":               {groupAdminOnly: true},
 	"timezone":                      {groupAdminOnly: true},
+	"topic":                         {groupAdminOnly: true},
+	"unmute":                        {groupAdminOnly: true, memberSubscriptions: true},
 	"version":                       {},
 	"want_more":                     {},
 	"week":                          {},
@@ -3203,7 +3407,7 @@ func (w *worker) commandGate(command, arguments string) commandSpec {
 		// The deep link adds a subscription, so it is gated as /add is,
 		// and a payload naming no model is left ungated, since start answers it with nothing.
 		name, ok := strings.CutPrefix(arguments, modelPayloadPrefix)
-		if !ok || w.checker.NicknamePreprocessing(name) == "" {
+		if !ok || w.defaultSite.checker.NicknamePreprocessing(name) == "" {
 			return commandSpec{}
 		}
 		return knownCommands["add"]
@@ -3269,6 +3473,9 @@ func (w *worker) processIncomingCommand(
 	case "remove":
 		arguments = strings.ReplaceAll(arguments, "—", "--")
 		w.removeStreamer(m, arguments)
+	case "filter":
+		arguments = strings.ReplaceAll(arguments, "—", "--")
+		w.setFilter(m, arguments)
 	case "list":
 		w.listStreamers(m)
 	case "pics", "online":
@@ -3317,6 +3524,14 @@ func (w *worker) processIncomingCommand(
 		w.enableSubject(m, true)
 	case "disable_subject":
 		w.enableSubject(m, false)
+	case "enable_subject_alerts":
+		w.enableSubjectAlerts(m, true)
+	case "disable_subject_alerts":
+		w.enableSubjectAlerts(m, false)
+	case "enable_live_messages":
+		w.enableLiveMessages(m, true)
+	case "disable_live_messages":
+		w.enableLiveMessages(m, false)
 	case "enable_silent_messages":
 		w.enableSilentMessages(m, true)
 	case "disable_silent_messages":
@@ -3325,6 +3540,14 @@ func (w *worker) processIncomingCommand(
 		w.enableMemberSubscriptions(m, true)
 	case "disable_member_subscriptions":
 		w.enableMemberSubscriptions(m, false)
+	case "enable_topic_per_streamer":
+		w.enableTopicPerStreamer(m, true)
+	case "disable_topic_per_streamer":
+		w.enableTopicPerStreamer(m, false)
+	case "topic":
+		w.setTopic(m, arguments)
+	case "board":
+		w.setBoard(m, arguments)
 	case "referral":
 		w.showReferral(m)
 	case "week":
@@ -3337,6 +3560,26 @@ func (w *worker) processIncomingCommand(
 		w.setTimezone(m, arguments)
 	case "reset_timezone":
 		w.resetTimezone(m)
+	case "quiet":
+		w.setQuiet(m, arguments)
+	case "reset_quiet":
+		w.resetQuiet(m)
+	case "digest":
+		w.setDigest(m, arguments)
+	case "nodigest":
+		w.setNoDigest(m, arguments)
+	case "mute":
+		w.setMute(m, arguments)
+	case "unmute":
+		w.unmute(m, arguments)
+	case "favourite":
+		w.setFavourite(m, arguments)
+	case "show_changes":
+		if !w.anySiteSupportsShowKind() {
+			unknown()
+			return
+		}
+		w.setShowChanges(m, arguments)
 	case "affiliate":
 		if !w.affiliateCommandAllowed(m) {
 			return
@@ -3356,37 +3599,44 @@ func (w *worker) processIncomingCommand(
 
 func (w *worker) periodic() {
 	w.pushOnlineRequest()
+	w.dropExpiredInlineStatuses(time.Now())
 }
 
 func (w *worker) pushOnlineRequest() {
-	caps := w.checker.Capabilities()
+	for _, s := range w.sites {
+		w.pushSiteOnlineRequest(s)
+	}
+}
+
+func (w *worker) pushSiteOnlineRequest(s *site) {
+	caps := s.checker.Capabilities()
 	var request cmdlib.StatusRequest
 	if caps.UsesFixedListOnline() {
 		request = &cmdlib.FixedListOnlineRequest{
-			ResultsCh: w.checkerResults,
-			Streamers: w.db.SubscribedStreamers(),
+			ResultsCh: s.checkerResults,
+			Streamers: w.db.SubscribedStreamers(s.name),
 		}
 	} else {
 		var poll []string
 		if caps.SupportsQueryStatus {
-			poll = w.db.StreamersToPoll()
+			poll = w.db.StreamersToPoll(s.name)
 		}
 		request = &cmdlib.OnlineListRequest{
-			ResultsCh: w.checkerResults,
+			ResultsCh: s.checkerResults,
 			Poll:      poll,
 		}
 	}
-	if err := w.checker.PushStatusRequest(request); err != nil {
-		lerr("%v", err)
+	if err := s.checker.PushStatusRequest(request); err != nil {
+		lerr("%s: %v", s.name, err)
 	}
 }
 
-func (w *worker) pushExistenceRequest(streamers map[string]bool) error {
-	caps := w.checker.Capabilities()
+func (w *worker) pushExistenceRequest(s *site, streamers map[string]bool) error {
+	caps := s.checker.Capabilities()
 	switch {
 	case caps.SupportsQueryFixedListStatuses:
-		err := w.checker.PushStatusRequest(&cmdlib.FixedListStatusRequest{
-			ResultsCh: w.existenceListResults,
+		err := s.checker.PushStatusRequest(&cmdlib.FixedListStatusRequest{
+			ResultsCh: s.existenceListResults,
 			Streamers: streamers,
 		})
 		if err != nil {
@@ -3395,8 +3645,8 @@ func (w *worker) pushExistenceRequest(streamers map[string]bool) error {
 		return err
 	case caps.SupportsQueryStatus:
 		for name := range streamers {
-			err := w.checker.PushStatusRequest(&cmdlib.SingleStatusRequest{
-				ResultsCh: w.existenceListResults,
+			err := s.checker.PushStatusRequest(&cmdlib.SingleStatusRequest{
+				ResultsCh: s.existenceListResults,
 				Streamer:  name,
 			})
 			if err != nil {
@@ -3420,24 +3670,38 @@ type processingResult struct {
 	upsertTimings           db.UpsertUnconfirmedTimings
 	confirmChangesMs        int
 	storeNotificationsMs    int
+
+	// The show-kind and subject changes of streamers online throughout, counted apart from status changes.
+	unconfirmedShowKindCount int
+	confirmedShowKindCount   int
+	confirmedSubjectCount    int
+	// heldOnlineList is set for an online list held as truncated.
+	heldOnlineList bool
 }
 
-func (w *worker) handleCheckerResults(result cmdlib.CheckerResults, now int) processingResult {
+func (w *worker) handleCheckerResults(s *site, result cmdlib.CheckerResults, now int) processingResult {
 	start := time.Now()
 	if result.Failed() {
 		return processingResult{}
 	}
 
 	var updates []db.StatusChange
+	var showKindUpdates []db.ShowKindChange
+	var subjectUpdates []db.SubjectChange
+	var viewers []db.ViewerCount
+	var samples []db.SessionSample
+	var held bool
 
 	switch r := result.(type) {
 	case *cmdlib.OnlineListResults:
 		if len(r.PollErrors) > 0 {
-			w.db.IncrementPollErrors(r.PollErrors)
+			w.db.IncrementPollErrors(s.name, r.PollErrors)
 		}
+		// A truncated list tells nothing of the streamers missing from it
+		held = w.guardOnlineList(s, len(r.Streamers), now)
 		// Went offline: was online but not in result
-		for nickname := range w.unconfirmedOnlineStreamers {
-			if _, inResult := r.Streamers[nickname]; !inResult {
+		for nickname := range s.unconfirmedOnlineStreamers {
+			if _, inResult := r.Streamers[nickname]; !inResult && !held {
 				updates = append(updates, db.StatusChange{
 					Nickname: nickname,
 					Status:   cmdlib.StatusOffline,
@@ -3445,20 +3709,29 @@ func (w *worker) handleCheckerResults(result cmdlib.CheckerResults, now int) pro
 			}
 		}
 		// Went online: in result but wasn't online
-		for nickname := range r.Streamers {
-			if _, wasOnline := w.unconfirmedOnlineStreamers[nickname]; !wasOnline {
+		for nickname, info := range r.Streamers {
+			if _, wasOnline := s.unconfirmedOnlineStreamers[nickname]; !wasOnline {
 				updates = append(updates, db.StatusChange{
 					Nickname: nickname,
 					Status:   cmdlib.StatusOnline,
+					ShowKind: info.ShowKind,
 				})
 			}
 		}
-		w.unconfirmedOnlineStreamers = r.Streamers
+		online := r.Streamers
+		if held {
+			online = heldOnlineStreamers(s.unconfirmedOnlineStreamers, r.Streamers)
+		}
+		showKindUpdates = showKindChanges(s.unconfirmedOnlineStreamers, online)
+		subjectUpdates = subjectChanges(s.unconfirmedOnlineStreamers, online)
+		viewers = viewerCounts(r.Streamers)
+		samples = sessionSamples(r.Streamers)
+		s.unconfirmedOnlineStreamers = online
 
 	case *cmdlib.FixedListOnlineResults:
 		// Went offline: was online but not in result (and was requested)
 		var maybeFirstOffline []string
-		for nickname := range w.unconfirmedOnlineStreamers {
+		for nickname := range s.unconfirmedOnlineStreamers {
 			if _, inResult := r.Streamers[nickname]; !inResult && r.RequestedStreamers[nickname] {
 				updates = append(updates, db.StatusChange{
 					Nickname: nickname,
@@ -3471,7 +3744,7 @@ func (w *worker) handleCheckerResults(result cmdlib.CheckerResults, now int) pro
 		// requested, not in result, and never seen online
 		for nickname := range r.RequestedStreamers {
 			_, inResult := r.Streamers[nickname]
-			_, inCache := w.unconfirmedOnlineStreamers[nickname]
+			_, inCache := s.unconfirmedOnlineStreamers[nickname]
 			if !inResult && !inCache {
 				maybeFirstOffline = append(maybeFirstOffline, nickname)
 			}
@@ -3479,7 +3752,7 @@ func (w *worker) handleCheckerResults(result cmdlib.CheckerResults, now int) pro
 
 		// First offline: exists in DB but never online, set offline if not already
 		if len(maybeFirstOffline) > 0 {
-			dbStatuses := w.db.UnconfirmedStatusesForStreamers(maybeFirstOffline)
+			dbStatuses := w.db.UnconfirmedStatusesForStreamers(s.name, maybeFirstOffline)
 			for _, nickname := range maybeFirstOffline {
 				if status, exists := dbStatuses[nickname]; exists && status.Status != cmdlib.StatusOffline {
 					updates = append(updates, db.StatusChange{
@@ -3491,21 +3764,26 @@ func (w *worker) handleCheckerResults(result cmdlib.CheckerResults, now int) pro
 		}
 
 		// Went online: in result but wasn't online
-		for nickname := range r.Streamers {
-			if _, inCache := w.unconfirmedOnlineStreamers[nickname]; !inCache {
+		for nickname, info := range r.Streamers {
+			if _, inCache := s.unconfirmedOnlineStreamers[nickname]; !inCache {
 				updates = append(updates, db.StatusChange{
 					Nickname: nickname,
 					Status:   cmdlib.StatusOnline,
+					ShowKind: info.ShowKind,
 				})
 			}
 		}
 
-		w.unconfirmedOnlineStreamers = r.Streamers
+		showKindUpdates = showKindChanges(s.unconfirmedOnlineStreamers, r.Streamers)
+		subjectUpdates = subjectChanges(s.unconfirmedOnlineStreamers, r.Streamers)
+		viewers = viewerCounts(r.Streamers)
+		samples = sessionSamples(r.Streamers)
+		s.unconfirmedOnlineStreamers = r.Streamers
 
 		// Set known streamers not in request to unknown
-		for nickname := range w.db.KnownStreamers() {
+		for nickname := range w.db.KnownStreamers(s.name) {
 			if !r.RequestedStreamers[nickname] {
-				delete(w.unconfirmedOnlineStreamers, nickname)
+				delete(s.unconfirmedOnlineStreamers, nickname)
 				updates = append(updates, db.StatusChange{
 					Nickname: nickname,
 					Status:   cmdlib.StatusUnknown,
@@ -3514,21 +3792,37 @@ func (w *worker) handleCheckerResults(result cmdlib.CheckerResults, now int) pro
 		}
 	}
 
-	upsertTimings := w.db.UpsertUnconfirmedStatusChanges(updates, now)
+	upsertTimings := w.db.UpsertUnconfirmedStatusChanges(s.name, updates, showKindUpdates, now)
+	// After the statuses, which make the streamers the subjects are set on.
+	if s.checker.Capabilities().SupportsSubject {
+		w.db.UpsertUnconfirmedSubjects(s.name, subjectUpdates, now)
+	}
+	w.db.RecordViewerPeaks(s.name, viewers, now)
 
 	confirmChangesStart := time.Now()
-	confirmedStatusChanges := w.db.ConfirmStatusChanges(
+	confirmedStatusChanges, confirmedShowKindChanges := w.db.ConfirmStatusChanges(
 		now,
 		w.cfg.StatusConfirmationSeconds.Online,
 		w.cfg.StatusConfirmationSeconds.Offline,
 	)
+	confirmedSubjectChanges := w.db.ConfirmSubjectChanges(now, w.cfg.SubjectChangeSeconds)
 	confirmChangesMs := int(time.Since(confirmChangesStart).Milliseconds())
+	// After the confirmation, so a session started in this poll counts it and one ended does not.
+	w.db.RecordSessionSamples(s.name, samples)
 
 	storeNotificationsStart := time.Now()
 	notifications := w.buildNotifications(confirmedStatusChanges)
+	notifications = append(notifications, w.buildShowKindNotifications(confirmedShowKindChanges)...)
+	notifications = append(notifications, w.buildSubjectNotifications(confirmedSubjectChanges)...)
+	notifications = append(notifications, w.buildLiveRefreshes(s, now)...)
 	w.storeNotifications(notifications)
+	w.markBoards(confirmedStatusChanges)
 	storeNotificationsMs := int(time.Since(storeNotificationsStart).Milliseconds())
 
+	for _, p := range w.buildHookPosts(confirmedStatusChanges) {
+		w.enqueueHookPost(p)
+	}
+
 	var offlineCount, onlineCount int
 	for _, u := range updates {
 		switch u.Status {
@@ -3549,6 +3843,11 @@ func (w *worker) handleCheckerResults(result cmdlib.CheckerResults, now int) pro
 		storeNotificationsMs:    storeNotificationsMs,
 		upsertTimings:           upsertTimings,
 		confirmChangesMs:        confirmChangesMs,
+
+		unconfirmedShowKindCount: len(showKindUpdates),
+		confirmedShowKindCount:   len(confirmedShowKindChanges),
+		confirmedSubjectCount:    len(confirmedSubjectChanges),
+		heldOnlineList:           held,
 	}
 }
 
@@ -3561,7 +3860,9 @@ func (w *worker) buildNotifications(
 	}
 
 	var notifications []db.Notification
-	usersForStreamers, endpointsForStreamers := w.db.UsersForStreamers(streamerIDs)
+	usersForStreamers, endpointsForStreamers, filtersForStreamers, favouritesForStreamers := w.db.UsersForStreamers(
+		streamerIDs, int(time.Now().Unix()))
+	liveMessages := w.db.LiveMessageKeys(streamerIDs)
 	for _, c := range confirmedStatusChanges {
 		// Skip unknown -> offline transitions
 		// They don't represent meaningful events to users
@@ -3570,25 +3871,45 @@ func (w *worker) buildNotifications(
 		}
 		users := usersForStreamers[c.StreamerID]
 		endpoints := endpointsForStreamers[c.StreamerID]
-		info := w.unconfirmedOnlineStreamers[c.Nickname]
+		filters := filtersForStreamers[c.StreamerID]
+		favourites := favouritesForStreamers[c.StreamerID]
+		// Confirmation runs over every site, so the streamer's own site holds its info.
+		info := w.onlineInfo(c.Site, c.Nickname)
+		subjects := w.siteReportsSubject(c.Site)
 		for i, user := range users {
+			// A live message ends in place, whatever the chat hears of an offline status otherwise.
+			live := db.LiveMessageKey{UserID: user.UserID, Endpoint: endpoints[i], StreamerID: c.StreamerID}
+			if c.Status == cmdlib.StatusOffline && liveMessages[live] {
+				notifications = append(notifications, liveEnd(c, user.UserID, endpoints[i]))
+				continue
+			}
+			// A filtered subscription hears of the starts it admits and of nothing else:
+			// an offline alert would close an online one that may never have been sent.
+			if filters[i] != nil && (c.Status != cmdlib.StatusOnline || !filterAdmits(filters[i], info, subjects)) {
+				continue
+			}
 			if (w.cfg.OfflineNotifications && user.OfflineNotifications) || c.Status != cmdlib.StatusOffline {
 				n := db.Notification{
 					Endpoint:   endpoints[i],
 					UserID:     user.UserID,
 					StreamerID: &c.StreamerID,
+					Site:       c.Site,
 					Nickname:   c.Nickname,
 					Status:     c.Status,
 					Social:     !isGroupOrChannel(user.ChatID),
 					Sound:      c.Status == cmdlib.StatusOnline,
 					Priority:   db.PriorityLow,
-					Kind:       db.NotificationPacket}
-				if user.ShowImages {
+					Kind:       db.NotificationPacket,
+					Favourite:  favourites[i],
+					Timestamp:  c.Timestamp}
+				// A favourite always shows its picture and subject.
+				if user.ShowImages || n.Favourite {
 					n.ImageURL = info.ImageURL
 				}
-				if user.ShowSubject {
+				if user.ShowSubject || n.Favourite {
 					n.Subject = info.Subject
 				}
+				n.Session = c.Session
 				notifications = append(notifications, n)
 			}
 		}
@@ -3734,6 +4055,10 @@ func (w *worker) migrateChat(fromID, toID int64) bool {
 func (w *worker) processTGUpdate(p incomingPacket) {
 	now := int(time.Now().Unix())
 	u := p.message
+	if u.InlineQuery != nil {
+		w.handleInlineQuery(p.endpoint, u.InlineQuery, time.Now())
+		return
+	}
 	if u.PreCheckoutQuery != nil {
 		w.handlePreCheckoutQuery(p.endpoint, u.PreCheckoutQuery)
 		return
@@ -3758,8 +4083,11 @@ func (w *worker) processTGUpdate(p incomingPacket) {
 		if _, err := w.bots[p.endpoint].AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: q.ID}); err != nil {
 			lerr("cannot answer callback query %s: %v", q.ID, err)
 		}
-		if strings.HasPrefix(q.Data, "buy:") {
+		switch {
+		case strings.HasPrefix(q.Data, "buy:"):
 			w.handleBuyCallback(p.endpoint, chatID, q.Data)
+		case strings.HasPrefix(q.Data, notificationCallbackPrefix):
+			w.handleNotificationCallback(p.endpoint, chatID, senderOf(u), q.Data)
 		}
 		return
 	}
@@ -3799,6 +4127,9 @@ func (w *worker) processTGUpdate(p incomingPacket) {
 		chatType = string(u.ChannelPost.Chat.Type)
 	}
 	m, created := w.newReceivedMessage(now, p.endpoint, chatID, chatType, loggedCommand)
+	if u.Message != nil {
+		m.forum, m.threadID = forumPlace(u.Message)
+	}
 	w.logReceived(m)
 	w.processIncomingCommand(m, senderOf(u), command, args, created)
 	w.refreshMemberCount(p.endpoint, chatID, m.userID)
@@ -3884,6 +4215,9 @@ func getOurIDs(c *botconfig.Config) []int64 {
 
 func (w *worker) maintainDB() {
 	w.db.MaintainBrinIndexes()
+	w.db.PruneViewerPeaks(int(time.Now().Add(-viewerPeaksRetention).Unix()))
+	w.db.PruneLiveMessages(int(time.Now().Add(-liveMessageRetention).Unix()))
+	w.db.ClearExpiredMutes(int(time.Now().Unix()))
 }
 
 // maintenanceReply handles an update that arrives while migrations run:
@@ -4000,7 +4334,7 @@ func (w *worker) waitForInflightSends(ctx context.Context) {
 }
 
 // handleSendResult runs the database bookkeeping for one send result:
-// block counters, chat-data migration, and the send log.
+// block counters, chat-data migration, the send log, and the live message it keeps or edits.
 // A maintenance send skips it all: it may run before the database exists.
 // The member-count lookup is a network call and deliberately not here —
 // completeSendResult runs it only after freeing the send slot.
@@ -4018,9 +4352,17 @@ func (w *worker) handleSendResult(r msgSendResult) {
 		// A same-id migrate degenerates inside MigrateChat; nothing to guard here.
 		w.migrateChatAndLog(r.timestamp, r.endpoint, r.chatID, r.migrateToChatID)
 	}
+	w.storeTopicOutcome(r)
 	w.db.LogSentMessage(
 		r.timestamp, r.userID, r.result, r.endpoint, r.priority, r.latency, r.tag.kind, r.tag.command,
 		r.tag.replySeq)
+	// A resent message has its result still to come.
+	if r.live != nil && r.resend == nil {
+		w.completeLiveSend(r)
+	}
+	if r.board != nil && r.resend == nil {
+		w.completeBoardSend(r)
+	}
 }
 
 // resolveResultUser resolves a non-maintenance result's user to the live one,
@@ -4053,6 +4395,7 @@ func (w *worker) completeSendResult(r msgSendResult) {
 	switch r.disposition() {
 	case dispFinalize:
 		w.finalizeNotification(r.notificationID)
+		w.finalizeBurst(r.burst)
 	case dispResend:
 		// Re-queue the original queued message:
 		// its seq keeps the queue position against later same-user messages,
@@ -4066,6 +4409,7 @@ func (w *worker) completeSendResult(r msgSendResult) {
 		// Re-arm the notification rather than finalizing it as sent;
 		// the next fetch retries it fresh.
 		w.db.RequeueNotification(r.notificationID)
+		w.requeueBurst(r.burst)
 	case dispLeave:
 		// A same-id reply migrate or any maintenance migrate (notificationID 0):
 		// no row to re-arm.
@@ -4096,6 +4440,7 @@ func (w *worker) drainSendResults() {
 			switch d := r.disposition(); {
 			case d == dispFinalize:
 				w.finalizeNotification(r.notificationID)
+				w.finalizeBurst(r.burst)
 			case d == dispResend && r.notificationID == 0:
 				// A postponed reply cannot re-arm (no row) and is not queued
 				// for logShutdownLoss to count, so note the drop here.
@@ -4122,7 +4467,7 @@ func (w *worker) logShutdownLoss(bufferedUpdates int) {
 				if q.notificationID == 0 {
 					messages++
 				} else {
-					notifications++
+					notifications += 1 + len(q.burst)
 				}
 			}
 		}
@@ -4233,29 +4578,41 @@ func (w *worker) handleSearchRequest(req searchRequest) {
 	w.fuzzySearchDB.LogReceivedMessage(
 		now, req.endpoint,
 		w.fuzzySearchDB.EnsureUser(req.chatID), searchCommand)
-	req.resultCh <- searchResult{streamers: w.fuzzySearchDB.SearchStreamers(req.term), allowed: true}
+	// The web app adds what it finds as typed, so it searches the default site alone.
+	streamers := w.fuzzySearchDB.SearchStreamers(w.defaultSite.name, req.term)
+	req.resultCh <- searchResult{streamers: streamers, allowed: true}
 }
 
 func (w *worker) queryUnconfirmedSubs() {
-	caps := w.checker.Capabilities()
+	for _, s := range w.sites {
+		w.querySiteUnconfirmedSubs(s)
+	}
+}
+
+func (w *worker) querySiteUnconfirmedSubs(s *site) {
+	caps := s.checker.Capabilities()
 	if !caps.SupportsQueryStatus && !caps.SupportsQueryFixedListStatuses {
 		return
 	}
 	unconfirmed := map[string]bool{}
 	var nickname string
-	w.db.MustQuery("select nickname from pending_subscriptions where not checking", nil, db.ScanTo{&nickname}, func() { unconfirmed[nickname] = true })
+	w.db.MustQuery(
+		"select nickname from pending_subscriptions where not checking and site = $1",
+		db.QueryParams{s.name},
+		db.ScanTo{&nickname},
+		func() { unconfirmed[nickname] = true })
 	if len(unconfirmed) > 0 {
-		w.db.MarkUnconfirmedAsChecking()
-		ldbg("queueing unconfirmed subscriptions check for %d streamers", len(unconfirmed))
-		if w.pushExistenceRequest(unconfirmed) != nil {
-			w.db.ResetCheckingToUnconfirmed()
+		w.db.MarkUnconfirmedAsChecking(s.name)
+		ldbg("queueing unconfirmed subscriptions check for %d streamers of %s", len(unconfirmed), s.name)
+		if w.pushExistenceRequest(s, unconfirmed) != nil {
+			w.db.ResetCheckingToUnconfirmed(s.name)
 		}
 	}
 }
 
-func (w *worker) processSubsConfirmations(res *cmdlib.ExistenceListResults) {
+func (w *worker) processSubsConfirmations(s *site, res *cmdlib.ExistenceListResults) {
 	streamersNumber := len(res.Streamers)
-	ldbg("processing subscription confirmations for %d streamers", streamersNumber)
+	ldbg("processing subscription confirmations for %d streamers of %s", streamersNumber, s.name)
 	nicknames := make([]string, 0, len(res.Streamers))
 	for n := range res.Streamers {
 		nicknames = append(nicknames, n)
@@ -4264,12 +4621,12 @@ func (w *worker) processSubsConfirmations(res *cmdlib.ExistenceListResults) {
 	var iter db.PendingSubscription
 	w.db.MustQuery(
 		`
-			select ps.endpoint, ps.nickname, ps.user_id, ps.referral, coalesce(ps.command, ''), ps.reply_seq
+			select ps.endpoint, ps.site, ps.nickname, ps.user_id, ps.referral, coalesce(ps.command, ''), ps.reply_seq
 			from pending_subscriptions ps
-			where ps.checking and ps.nickname = any($1)
+			where ps.checking and ps.site = $1 and ps.nickname = any($2)
 		`,
-		db.QueryParams{nicknames},
-		db.ScanTo{&iter.Endpoint, &iter.Nickname, &iter.UserID, &iter.Referral, &iter.Command, &iter.ReplySeq},
+		db.QueryParams{s.name, nicknames},
+		db.ScanTo{&iter.Endpoint, &iter.Site, &iter.Nickname, &iter.UserID, &iter.Referral, &iter.Command, &iter.ReplySeq},
 		func() { confirmationsInWork[iter.Nickname] = append(confirmationsInWork[iter.Nickname], iter) })
 	var nots []db.Notification
 	var confirmedNots []db.Notification
@@ -4292,6 +4649,7 @@ func (w *worker) processSubsConfirmations(res *cmdlib.ExistenceListResults) {
 					Endpoint:   sub.Endpoint,
 					UserID:     sub.UserID,
 					StreamerID: streamerID,
+					Site:       s.name,
 					Nickname:   nickname,
 					Status:     info.Status,
 					Social:     false,
@@ -4309,8 +4667,8 @@ func (w *worker) processSubsConfirmations(res *cmdlib.ExistenceListResults) {
 			}
 		}
 	} else {
-		lerr("confirmations query failed")
-		w.db.ResetCheckingToUnconfirmed()
+		lerr("confirmations query failed for %s", s.name)
+		w.db.ResetCheckingToUnconfirmed(s.name)
 	}
 	w.notifyOfAddResults(db.PriorityHigh, nots)
 	w.storeNotifications(confirmedNots)
@@ -4322,6 +4680,9 @@ type startupTimers struct {
 	maintainDB         <-chan time.Time
 	subsConfirm        <-chan time.Time
 	notificationSender <-chan time.Time
+	quietSummaries     <-chan time.Time
+	digests            <-chan time.Time
+	boards             <-chan time.Time
 }
 
 // finishStartup completes the loop-owned initialization
@@ -4336,11 +4697,20 @@ func (w *worker) finishStartup(
 		request:            time.NewTicker(time.Duration(w.cfg.PeriodSeconds) * time.Second).C,
 		subsConfirm:        time.NewTicker(time.Duration(w.cfg.SubsConfirmationPeriodSeconds) * time.Second).C,
 		notificationSender: time.NewTicker(time.Duration(w.cfg.NotificationsReadyPeriodSeconds) * time.Second).C,
+		quietSummaries:     time.NewTicker(quietSummaryPeriod).C,
+		digests:            time.NewTicker(digestCheckPeriod).C,
+		boards:             time.NewTicker(boardEditPeriod).C,
 	}
 	if w.cfg.MaintainDBPeriodSeconds != 0 {
 		timers.maintainDB = time.NewTicker(time.Duration(w.cfg.MaintainDBPeriodSeconds) * time.Second).C
 	}
-	checkers.StartCheckerDaemon(ctx, w.checker)
+	for _, s := range w.sites {
+		checkers.StartCheckerDaemon(ctx, s.checker)
+		go w.forwardSiteResults(ctx, s)
+	}
+	for range hookPosters {
+		go w.runHookPoster(ctx)
+	}
 	// Install signals only now: a SIGTERM during migrations
 	// should kill the process outright,
 	// not run shutdown against a half-migrated schema.
@@ -4392,6 +4762,11 @@ func main() {
 	cmdlib.SetVerbosity(cfg.Debug)
 	checker, err := checkers.Build(cfg.Website, *checkerCfgPath)
 	checkErr(err)
+	extraCheckers := map[string]checkers.Checker{}
+	for name, s := range cfg.Sites {
+		extraCheckers[name], err = checkers.Build(name, s.CheckerConfig)
+		checkErr(err)
+	}
 	if *printCfg {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetEscapeHTML(false)
@@ -4400,10 +4775,14 @@ func main() {
 		checkErr(enc.Encode(cfg))
 		fmt.Println("checker config:")
 		checkErr(enc.Encode(checker.Config()))
+		for name, c := range extraCheckers {
+			fmt.Printf("checker config for %s:\n", name)
+			checkErr(enc.Encode(c.Config()))
+		}
 		os.Exit(0)
 	}
 
-	w := newWorker(cfg, checker)
+	w := newWorker(cfg, checker, extraCheckers)
 	w.logConfig()
 	// Before Telegram is pointed at this process: a zone table it cannot work from
 	// stops a start rather than a bot that has already announced itself.
@@ -4417,6 +4796,9 @@ func main() {
 	incoming := w.incoming()
 	go w.notificationFetcher()
 	go w.fuzzySearchDaemon()
+	if w.apiEnabled() {
+		go w.apiDaemon()
+	}
 	// MaintenancePacket: the loop consumes its send result
 	// without touching the database, which is not created yet.
 	w.sendMaintenance(w.cfg.OwnerEndpoint, w.cfg.OwnerID, true, "bot started")
@@ -4431,7 +4813,7 @@ func main() {
 		w.ownerUserID = w.db.EnsureUser(w.cfg.OwnerID)
 		w.initCache()
 		w.db.ResetNotificationSending()
-		w.db.ResetCheckingToUnconfirmed()
+		w.db.ResetAllCheckingToUnconfirmed()
 		w.setProfilePhotos()
 		// Register the web app last:
 		// once its routes exist, requests can reach the loop's channels
@@ -4461,26 +4843,42 @@ func main() {
 			w.queryUnconfirmedSubs()
 		case <-timers.notificationSender:
 			w.sendReadyNotifications()
-		case result := <-w.checkerResults:
+		case now := <-timers.quietSummaries:
+			w.sendQuietSummaries(now)
+		case now := <-timers.digests:
+			w.sendDigests(now)
+		case <-timers.boards:
+			w.editBoards()
+		case r := <-w.checkerResults:
+			result := r.result
 			now := int(time.Now().Unix())
-			processed := w.handleCheckerResults(result, now)
+			processed := w.handleCheckerResults(r.site, result, now)
 			updateQueryFields := map[string]any{
+				"site":   r.site.name,
 				"failed": result.Failed(),
 				"count":  result.Count(),
 			}
 			maps.Copy(updateQueryFields, result.ExtraLogFields())
+			if processed.heldOnlineList {
+				updateQueryFields["held"] = true
+			}
 			w.db.LogPerformance(now, db.PerformanceLogUpdateQuery, int(result.Duration().Milliseconds()), updateQueryFields)
 			w.db.LogPerformance(now, db.PerformanceLogUpdateProcessing, int(processed.elapsed.Milliseconds()), map[string]any{
+				"site":                                 r.site.name,
 				"unconfirmed_count":                    processed.unconfirmedChangesCount,
 				"unconfirmed_offline_count":            processed.unconfirmedOfflineCount,
 				"unconfirmed_online_count":             processed.unconfirmedOnlineCount,
 				"confirmed_count":                      processed.confirmedChangesCount,
+				"unconfirmed_show_kind_count":          processed.unconfirmedShowKindCount,
+				"confirmed_show_kind_count":            processed.confirmedShowKindCount,
+				"confirmed_subject_count":              processed.confirmedSubjectCount,
 				"notifications_count":                  len(processed.notifications),
 				"upsert_unconfirmed_streamers_ms":      processed.upsertTimings.UpsertStreamersMs,
 				"insert_nicknames_ms":                  processed.upsertTimings.InsertNicknamesMs,
 				"insert_unconfirmed_status_changes_ms": processed.upsertTimings.InsertStatusChangesMs,
 				"commit_unconfirmed_ms":                processed.upsertTimings.CommitMs,
 				"summarize_brin_ms":                    processed.upsertTimings.SummarizeBrinMs,
+				"update_unconfirmed_show_kinds_ms":     processed.upsertTimings.UpdateShowKindsMs,
 				"confirm_changes_ms":                   processed.confirmChangesMs,
 				"store_notifications_ms":               processed.storeNotificationsMs,
 			})
@@ -4489,10 +4887,14 @@ func main() {
 			w.performWebAppAdd(req)
 		case req := <-w.webAppTimezoneRequests:
 			w.performWebAppTimezone(req)
+		case req := <-w.webAppQuietRequests:
+			w.performWebAppQuiet(req)
 		case req := <-w.webAppRemovalListRequests:
 			w.performWebAppRemovalList(req)
 		case req := <-w.webAppRemoveRequests:
 			w.performWebAppRemove(req)
+		case req := <-w.webAppFilterRequests:
+			w.performWebAppFilter(req)
 		case u := <-incoming:
 			if w.maintenance.Load() {
 				w.maintenanceReply(u, waitingUsers)
@@ -4508,11 +4910,14 @@ func main() {
 			w.onUserCooled(c)
 		case r := <-w.existenceListResults:
 			now := int(time.Now().Unix())
-			w.db.LogPerformance(now, db.PerformanceLogExistenceQuery, int(r.Duration().Milliseconds()), map[string]any{
-				"failed": r.Failed(),
-				"count":  r.Count(),
+			w.db.LogPerformance(now, db.PerformanceLogExistenceQuery, int(r.result.Duration().Milliseconds()), map[string]any{
+				"site":   r.site.name,
+				"failed": r.result.Failed(),
+				"count":  r.result.Count(),
 			})
-			w.processSubsConfirmations(r)
+			w.processSubsConfirmations(r.site, r.result)
+		case r := <-w.inlineResults:
+			w.processInlineResults(r.site, r.result, time.Now())
 		case batch := <-w.imagedNotifications:
 			w.enqueueNotifications(batch)
 		case r := <-w.imageDownloadLogs:
@@ -0,0 +1,104 @@
+// Mutes: /mute alice 8h silences a subscription for a while, or for good with forever,
+// and /unmute alice lets it alert again.
+// A muted subscription stays in /list, marked, and alerts of nothing;
+// the mute is kept on the subscription as muted_until, which maintainDB clears once it runs out.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bcmk/siren/v4/internal/db"
+)
+
+// muteForeverArgument mutes a subscription until it is unmuted.
+const muteForeverArgument = "forever"
+
+// muteUnits are the units a mute is given in, in seconds.
+var muteUnits = map[byte]int{
+	'h': 3600,
+	'd': 24 * 3600,
+}
+
+// maxMuteSeconds caps a timed mute at a year; a longer one is forever.
+const maxMuteSeconds = 366 * 24 * 3600
+
+// parseMuteDuration reads a mute as 8h, 1d or forever, in seconds, zero for forever.
+func parseMuteDuration(arg string) (seconds int, ok bool) {
+	arg = strings.ToLower(arg)
+	if arg == muteForeverArgument {
+		return 0, true
+	}
+	if len(arg) < 2 {
+		return 0, false
+	}
+	unit, found := muteUnits[arg[len(arg)-1]]
+	if !found {
+		return 0, false
+	}
+	n, err := strconv.Atoi(arg[:len(arg)-1])
+	if err != nil || n < 1 || n > maxMuteSeconds/unit {
+		return 0, false
+	}
+	return n * unit, true
+}
+
+// setMute mutes a subscription from alice 8h, alice 1d or alice forever.
+func (w *worker) setMute(m receivedMessage, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) != 2 {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].SyntaxMute, nil)
+		return
+	}
+	seconds, ok := parseMuteDuration(parts[1])
+	if !ok {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].SyntaxMute, nil)
+		return
+	}
+	s, nickname := w.parseStreamer(parts[0])
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols,
+			tplData{"streamer": w.qualifiedName(s.name, nickname)})
+		return
+	}
+	w.muteStreamer(m, s.name, nickname, seconds)
+}
+
+// muteStreamer silences a chat's subscription to a streamer for seconds, or for good for zero.
+func (w *worker) muteStreamer(m receivedMessage, siteName, nickname string, seconds int) {
+	name := w.qualifiedName(siteName, nickname)
+	until := db.MutedForever
+	if seconds != 0 {
+		until = m.timestamp + seconds
+	}
+	if !w.db.MuteSubscription(m.userID, siteName, nickname, m.endpoint, until) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].StreamerNotInList, tplData{"streamer": name})
+		return
+	}
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].Muted, tplData{
+		"streamer": name,
+		"duration": calcTimeDiff(seconds),
+		"forever":  seconds == 0,
+	})
+}
+
+// unmute lets a muted subscription alert again.
+func (w *worker) unmute(m receivedMessage, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) != 1 {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].SyntaxMute, nil)
+		return
+	}
+	s, nickname := w.parseStreamer(parts[0])
+	name := w.qualifiedName(s.name, nickname)
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": name})
+		return
+	}
+	if !w.db.MuteSubscription(m.userID, s.name, nickname, m.endpoint, 0) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].StreamerNotInList, tplData{"streamer": name})
+		return
+	}
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].Unmuted, tplData{"streamer": name})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestParseMuteDuration(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in      string
+		ok      bool
+		seconds int
+	}{
+		{"8h", true, 8 * 3600},
+		{"1d", true, 24 * 3600},
+		{"2D", true, 2 * 24 * 3600},
+		{"forever", true, 0},
+		{"FOREVER", true, 0},
+		{"0h", false, 0},
+		{"-1d", false, 0},
+		{"8", false, 0},
+		{"h", false, 0},
+		{"8m", false, 0},
+		{"400d", false, 0},
+		{"", false, 0},
+	}
+	for _, tc := range tests {
+		seconds, ok := parseMuteDuration(tc.in)
+		if ok != tc.ok || (ok && seconds != tc.seconds) {
+			t.Errorf("parseMuteDuration(%q) = %d, %v, want %d, %v", tc.in, seconds, ok, tc.seconds, tc.ok)
+		}
+	}
+}
+
+func TestMuteTemplatesRender(t *testing.T) {
+	t.Parallel()
+	list := tplData{
+		"online":  []streamerListEntry{{Link: "alice", Muted: true}},
+		"offline": []streamerListEntry{{Link: "bob"}},
+	}
+	assertTemplatesRender(t, []templateCase{
+		{"muted", "muted", tplData{"streamer": "alice", "duration": calcTimeDiff(8 * 3600), "forever": false}, "8", true},
+		{"muted forever", "muted", tplData{"streamer": "alice", "duration": calcTimeDiff(0), "forever": true}, "0", false},
+		{"unmuted", "unmuted", tplData{"streamer": "alice"}, "alice", true},
+		{"syntax", "syntax_mute", nil, "forever", true},
+		{"list marker", "list", list, "alice 🔕", true},
+		{"list unmarked", "list", list, "bob 🔕", false},
+		{"commands", "commands", nil, "mute", true},
+	})
+}
+
+// A muted subscription alerts of nothing until its mute runs out or it is unmuted,
+// and maintenance clears a mute once it has run out.
+func TestMutes(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	aID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	bID := insertTestStreamer(&w.db, db.Streamer{Nickname: "b"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 1, "b")
+	user, _ := w.db.User(1)
+
+	if !w.db.MuteSubscription(user.UserID, testSite, "a", "test", 1000) ||
+		!w.db.MuteSubscription(user.UserID, testSite, "b", "test", db.MutedForever) {
+		t.Fatal("the subscriptions to mute were not found")
+	}
+	if w.db.MuteSubscription(user.UserID, testSite, "c", "test", 1000) {
+		t.Error("muted a subscription the chat does not have")
+	}
+	if muted := w.db.MutedStreamers("test", user.UserID, 500); !muted[aID] || !muted[bID] {
+		t.Errorf("muted at 500 = %v, want both", muted)
+	}
+	if muted := w.db.MutedStreamers("test", user.UserID, 1000); muted[aID] || !muted[bID] {
+		t.Errorf("muted at 1000 = %v, want b alone", muted)
+	}
+
+	online := []db.ConfirmedStatusChange{
+		{StreamerID: aID, Site: testSite, Nickname: "a", Status: cmdlib.StatusOnline, PrevStatus: cmdlib.StatusOffline},
+		{StreamerID: bID, Site: testSite, Nickname: "b", Status: cmdlib.StatusOnline, PrevStatus: cmdlib.StatusOffline},
+	}
+	if alerted := w.buildNotifications(online); len(alerted) != 1 || alerted[0].Nickname != "a" {
+		t.Errorf("alerted %+v, want a alone, its mute run out", alerted)
+	}
+
+	w.db.ClearExpiredMutes(1000)
+	if muted := w.db.MutedStreamers("test", user.UserID, 0); muted[aID] || !muted[bID] {
+		t.Errorf("muted after maintenance = %v, want b alone", muted)
+	}
+	w.db.MuteSubscription(user.UserID, testSite, "b", "test", 0)
+	if alerted := w.buildNotifications(online); len(alerted) != 2 {
+		t.Errorf("alerted %+v once unmuted, want both", alerted)
+	}
+}
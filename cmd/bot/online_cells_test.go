@@ -147,8 +147,9 @@ func TestOnlineCells(t *testing.T) {
 			w.createDatabase()
 
 			for _, ins := range tc.inserts {
-				w.db.UpsertUnconfirmedStatusChanges(
+				w.db.UpsertUnconfirmedStatusChanges(testSite,
 					[]db.StatusChange{{Nickname: ins.nickname, Status: ins.status}},
+					nil,
 					ins.ts,
 				)
 			}
@@ -156,7 +157,7 @@ func TestOnlineCells(t *testing.T) {
 			ids := make([]int, len(tc.streamers))
 			nickToID := make(map[string]int)
 			for i, nick := range tc.streamers {
-				s := w.db.MaybeStreamer(nick)
+				s := w.db.MaybeStreamer(testSite, nick)
 				if s == nil {
 					t.Fatalf("streamer %s not found", nick)
 				}
@@ -127,7 +127,7 @@ func TestOnlinePicsClipALongSubject(t *testing.T) {
 	insertTestStreamer(&w.db, db.Streamer{Nickname: nickname, UnconfirmedStatus: cmdlib.StatusOnline})
 	insertSubscription(&w.db, "test", 1, nickname)
 	// Emoji, so the topic spends two units a character and overruns the limit twice over.
-	w.unconfirmedOnlineStreamers[nickname] = cmdlib.StreamerInfo{
+	w.defaultSite.unconfirmedOnlineStreamers[nickname] = cmdlib.StreamerInfo{
 		ImageURL: url,
 		Subject:  strings.Repeat("🟢", subjectLimit),
 	}
@@ -0,0 +1,441 @@
+// Quiet hours: a window of a chat's local day in which its status alerts stop ringing.
+// A chat keeping them silent is sent its alerts as usual, without sound.
+// A chat keeping them for a summary has its alerts held in held_notifications,
+// and once the window closes it gets one message collapsing what was held,
+// "alice was online 01:10–03:40".
+// Only the chat's own status alerts are quiet: command answers, webhooks and Discord are not.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+const (
+	// quietSummaryPeriod is how often held alerts are checked for a window that has closed.
+	quietSummaryPeriod = time.Minute
+	// quietSummaryChunk caps the lines of one summary message,
+	// keeping it well inside Telegram's 4096 characters.
+	quietSummaryChunk = 50
+	// quietSummaryArgument asks /quiet to hold alerts for a summary rather than send them silently.
+	quietSummaryArgument = "summary"
+)
+
+// quietHours is a window of the local day in minutes past midnight.
+// A start past the end wraps midnight, as 23:00-08:00 does.
+type quietHours struct {
+	start int
+	end   int
+}
+
+// parseClock reads an H:MM or HH:MM time of day as minutes past midnight.
+func parseClock(s string) (int, bool) {
+	hours, minutes, found := strings.Cut(strings.TrimSpace(s), ":")
+	if !found || len(hours) == 0 || len(hours) > 2 || len(minutes) != 2 {
+		return 0, false
+	}
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 23 {
+		return 0, false
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil || m < 0 || m > 59 {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// formatClock prints minutes past midnight as HH:MM.
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// parseQuietHours reads a window as 23:00-08:00, an en dash standing for the hyphen.
+// An empty window, starting where it ends, is refused.
+func parseQuietHours(s string) (quietHours, bool) {
+	from, to, found := strings.Cut(strings.ReplaceAll(s, "–", "-"), "-")
+	if !found {
+		return quietHours{}, false
+	}
+	start, ok := parseClock(from)
+	if !ok {
+		return quietHours{}, false
+	}
+	end, ok := parseClock(to)
+	if !ok || start == end {
+		return quietHours{}, false
+	}
+	return quietHours{start: start, end: end}, true
+}
+
+// String prints the window the way /quiet takes it.
+func (q quietHours) String() string {
+	return formatClock(q.start) + "-" + formatClock(q.end)
+}
+
+// contains reports whether a minute of the day falls in the window, its end excluded.
+func (q quietHours) contains(minute int) bool {
+	if q.start < q.end {
+		return minute >= q.start && minute < q.end
+	}
+	return minute >= q.start || minute < q.end
+}
+
+// at reports whether t falls in the window on the chat's clock.
+func (q quietHours) at(t time.Time, loc *time.Location) bool {
+	local := t.In(loc)
+	return q.contains(local.Hour()*60 + local.Minute())
+}
+
+// storedQuietHours reads a chat's window from its columns, false where it keeps none.
+func storedQuietHours(start, end *int) (quietHours, bool) {
+	if start == nil || end == nil {
+		return quietHours{}, false
+	}
+	return quietHours{start: *start, end: *end}, true
+}
+
+// quietAction is what quiet hours do to one notification.
+type quietAction int
+
+const (
+	quietOff quietAction = iota
+	quietSilent
+	quietHold
+)
+
+// isStreamerAlert reports whether a packet alerts a chat to a streamer unasked:
+// a status, show-kind or subject change.
+func isStreamerAlert(kind db.PacketKind) bool {
+	switch kind {
+	case db.NotificationPacket, db.ShowKindPacket, db.SubjectPacket:
+		return true
+	}
+	return false
+}
+
+// quietActionFor decides a notification's fate at now.
+// Only a streamer alert is quiet; a command's deferred answer was asked for.
+// Main goroutine only, as chatLocation is.
+func (w *worker) quietActionFor(n db.Notification, now time.Time) quietAction {
+	if !isStreamerAlert(n.Kind) || n.StreamerID == nil {
+		return quietOff
+	}
+	if n.Status != cmdlib.StatusOnline && n.Status != cmdlib.StatusOffline {
+		return quietOff
+	}
+	q, ok := storedQuietHours(n.QuietStart, n.QuietEnd)
+	if !ok {
+		return quietOff
+	}
+	loc, _ := w.timezoneLocation(n.UserID, n.Timezone)
+	if !q.at(now, loc) {
+		return quietOff
+	}
+	if n.QuietSummary {
+		return quietHold
+	}
+	return quietSilent
+}
+
+// holdNotification keeps an alert back for the chat's summary and clears its queue row.
+// The summary tells when the status changed, not when the queue got to the alert;
+// an alert queued before changes were stamped falls back to now.
+func (w *worker) holdNotification(n db.Notification, now time.Time) {
+	timestamp := n.Timestamp
+	if timestamp == 0 {
+		timestamp = int(now.Unix())
+	}
+	w.db.HoldNotification(db.HeldNotification{
+		UserID:     n.UserID,
+		Endpoint:   n.Endpoint,
+		StreamerID: *n.StreamerID,
+		Status:     n.Status,
+		Timestamp:  timestamp,
+	})
+	w.finalizeNotification(n.ID)
+}
+
+// quietSpan is a line of a summary: one stretch of one streamer's time online.
+// From is empty where the streamer was online before the chat's alerts were held,
+// To where they are online still.
+type quietSpan struct {
+	Link string
+	From string
+	To   string
+}
+
+// collapseHeld folds a chat's held alerts, ordered by time, into spans online.
+// An online alert opens a streamer's span and an offline one closes it;
+// a repeat of the status a span is already in adds nothing.
+// Spans keep the order their streamers first appear in.
+func collapseHeld(
+	held []db.HeldNotification,
+	loc *time.Location,
+	link func(siteName, nickname string) string,
+) []quietSpan {
+	var spans []quietSpan
+	open := map[int]int{}
+	clock := func(timestamp int) string {
+		local := time.Unix(int64(timestamp), 0).In(loc)
+		return formatClock(local.Hour()*60 + local.Minute())
+	}
+	for _, h := range held {
+		i, isOpen := open[h.StreamerID]
+		switch h.Status {
+		case cmdlib.StatusOnline:
+			if isOpen {
+				continue
+			}
+			open[h.StreamerID] = len(spans)
+			spans = append(spans, quietSpan{Link: link(h.Site, h.Nickname), From: clock(h.Timestamp)})
+		case cmdlib.StatusOffline:
+			if isOpen {
+				spans[i].To = clock(h.Timestamp)
+				delete(open, h.StreamerID)
+				continue
+			}
+			spans = append(spans, quietSpan{Link: link(h.Site, h.Nickname), To: clock(h.Timestamp)})
+		}
+	}
+	return spans
+}
+
+// sendQuietSummaries sends every chat whose window has closed the summary of what it held.
+// A chat that turned its quiet hours off, or to silent, is sent its summary at once.
+// Main goroutine only.
+func (w *worker) sendQuietSummaries(now time.Time) {
+	for _, user := range w.db.UsersWithHeldNotifications() {
+		loc, _ := w.chatLocation(user)
+		if q, ok := storedQuietHours(user.QuietStart, user.QuietEnd); ok && user.QuietSummary && q.at(now, loc) {
+			continue
+		}
+		held := w.db.TakeHeldNotifications(user.UserID)
+		link := w.streamerLinker(w.gatedAffiliate(user.AffiliateParams))
+		byEndpoint := map[string][]db.HeldNotification{}
+		var endpoints []string
+		for _, h := range held {
+			if _, seen := byEndpoint[h.Endpoint]; !seen {
+				endpoints = append(endpoints, h.Endpoint)
+			}
+			byEndpoint[h.Endpoint] = append(byEndpoint[h.Endpoint], h)
+		}
+		for _, endpoint := range endpoints {
+			if w.tr[endpoint] == nil {
+				// An endpoint dropped from the config; there is no one to tell.
+				lerr("dropping quiet hours summary for unknown endpoint %s", endpoint)
+				continue
+			}
+			spans := collapseHeld(byEndpoint[endpoint], loc, link)
+			for i := 0; i < len(spans); i += quietSummaryChunk {
+				w.sendTr(db.PriorityLow, endpoint, user.UserID, !user.SilentMessages, w.tr[endpoint].QuietSummary,
+					tplData{"spans": spans[i:min(i+quietSummaryChunk, len(spans))], "first": i == 0},
+					unprompted(db.NotificationPacket))
+			}
+		}
+	}
+}
+
+// quietAppURL carries the chat's window to the page, which has no way to ask for it.
+func (w *worker) quietAppURL(endpoint string, user db.User) string {
+	values := url.Values{"endpoint": {endpoint}}
+	if q, ok := storedQuietHours(user.QuietStart, user.QuietEnd); ok {
+		values.Set("current", q.String())
+		if user.QuietSummary {
+			values.Set(quietSummaryArgument, "1")
+		}
+	}
+	return w.webAppBase(endpoint) + "/apps/quiet?" + values.Encode()
+}
+
+// replyQuiet answers with the chat's quiet hours.
+// help carries the ways to change them and belongs to the bare command, as for replyTimezone.
+func (w *worker) replyQuiet(m receivedMessage, help bool) {
+	user := w.mustUserByID(m.userID)
+	_, zone := w.chatLocation(user)
+	data := tplData{"timezone": zone, "help": help, "summary": user.QuietSummary}
+	if q, ok := storedQuietHours(user.QuietStart, user.QuietEnd); ok {
+		data["quiet_hours"] = q.String()
+	}
+	tr := w.tr[m.endpoint].Quiet
+	params := &renderParams{templates: w.tpl[m.endpoint], key: tr.Key, data: data}
+	msg := params.asDeferredText(false, tr.DisablePreview, tr.Parse)
+	if help && !isGroupOrChannel(m.chatID) {
+		msg.ReplyMarkup = &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{{
+				{
+					Text:   w.tr[m.endpoint].QuietButton.Str,
+					WebApp: &models.WebAppInfo{URL: w.quietAppURL(m.endpoint, user)},
+				},
+			}},
+		}
+	}
+	w.replyMessage(m, db.PriorityHigh, msg)
+}
+
+// setQuiet shows the chat's quiet hours or sets them from 23:00-08:00,
+// followed by summary to hold the window's alerts for one message.
+func (w *worker) setQuiet(m receivedMessage, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) == 0 {
+		w.replyQuiet(m, true)
+		return
+	}
+	summary := len(parts) == 2 && strings.EqualFold(parts[1], quietSummaryArgument)
+	q, ok := parseQuietHours(parts[0])
+	if !ok || len(parts) > 2 || (len(parts) == 2 && !summary) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].QuietInvalid, nil)
+		return
+	}
+	w.db.SetQuietHours(m.userID, q.start, q.end, summary)
+	w.replyQuiet(m, false)
+}
+
+// resetQuiet clears the chat's quiet hours; anything held goes out with the next summary run.
+func (w *worker) resetQuiet(m receivedMessage) {
+	w.db.ResetQuietHours(m.userID)
+	w.replyQuiet(m, false)
+}
+
+// webAppQuietRequest carries quiet hours picked in the web app, off when hours is nil.
+type webAppQuietRequest struct {
+	endpoint string
+	chatID   int64
+	hours    *quietHours
+	summary  bool
+	// admittedCh reports whether the chat passed the whitelist, as for webAppTimezoneRequest.
+	admittedCh chan bool
+}
+
+// webAppQuietCommand names quiet hours set from the web app.
+const webAppQuietCommand = "web_app_quiet"
+
+func (w *worker) handleQuietApp(rw http.ResponseWriter, r *http.Request) {
+	endpoint := r.URL.Query().Get("endpoint")
+	if _, ok := w.cfg.Endpoints[endpoint]; !ok {
+		http.Error(rw, "bad endpoint", http.StatusBadRequest)
+		return
+	}
+	// A display hint, as the timezone page's current zone is.
+	from, to := "23:00", "08:00"
+	if q, ok := parseQuietHours(r.URL.Query().Get("current")); ok {
+		from, to = formatClock(q.start), formatClock(q.end)
+	}
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	tr := w.tr[endpoint]
+	data := struct {
+		Header  string
+		From    string
+		To      string
+		Summary string
+		Save    string
+		Off     string
+		Failed  string
+		Start   string
+		End     string
+		Hold    bool
+	}{
+		Header:  tr.QuietAppHeader.Str,
+		From:    tr.QuietAppFrom.Str,
+		To:      tr.QuietAppTo.Str,
+		Summary: tr.QuietAppSummary.Str,
+		Save:    tr.QuietAppSave.Str,
+		Off:     tr.QuietAppOff.Str,
+		Failed:  tr.QuietAppFailed.Str,
+		Start:   from,
+		End:     to,
+		Hold:    r.URL.Query().Get(quietSummaryArgument) != "",
+	}
+	if err := w.quietHTML.Execute(rw, data); err != nil {
+		lerr("cannot write quiet hours app response, %v", err)
+	}
+}
+
+// performWebAppQuiet stores quiet hours picked in the web app and answers in the chat.
+// Main goroutine only, as performWebAppTimezone is.
+func (w *worker) performWebAppQuiet(req webAppQuietRequest) {
+	if !w.admitChat("web app quiet hours", req.chatID) {
+		req.admittedCh <- false
+		return
+	}
+	m, _ := w.newReceivedMessage(int(time.Now().Unix()), req.endpoint, req.chatID, "", webAppQuietCommand)
+	w.logReceived(m)
+	if req.hours == nil {
+		w.db.ResetQuietHours(m.userID)
+	} else {
+		w.db.SetQuietHours(m.userID, req.hours.start, req.hours.end, req.summary)
+	}
+	w.replyQuiet(m, false)
+	req.admittedCh <- true
+}
+
+// submitWebAppQuiet hands a request to the main loop and waits for its verdict,
+// as submitWebAppTimezone does.
+func (w *worker) submitWebAppQuiet(req webAppQuietRequest) (admitted, alive bool) {
+	select {
+	case w.webAppQuietRequests <- req:
+	case <-w.shutdownCh:
+		return false, false
+	}
+	return <-req.admittedCh, true
+}
+
+func (w *worker) handleWebAppQuiet(rw http.ResponseWriter, r *http.Request) {
+	endpoint := r.URL.Query().Get("endpoint")
+	if _, ok := w.cfg.Endpoints[endpoint]; !ok {
+		lerr("web app quiet hours: bad endpoint %q", endpoint)
+		http.Error(rw, "bad endpoint", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		http.Error(rw, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	rw.Header().Set("Cache-Control", "no-store")
+	values, ok := w.parseInitData(r.Header.Get("X-Init-Data"), string(w.cfg.Endpoints[endpoint].BotToken))
+	if !ok {
+		lerr("web app quiet hours: invalid init data for endpoint %s", endpoint)
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req := webAppQuietRequest{
+		endpoint:   endpoint,
+		summary:    r.URL.Query().Get(quietSummaryArgument) != "",
+		admittedCh: make(chan bool, 1),
+	}
+	if proposed := r.URL.Query().Get("hours"); proposed != "off" {
+		q, ok := parseQuietHours(proposed)
+		if !ok {
+			lerr("web app quiet hours: refused hours %q for endpoint %s", proposed, endpoint)
+			http.Error(rw, "bad hours", http.StatusBadRequest)
+			return
+		}
+		req.hours = &q
+	}
+	if req.chatID, ok = webAppUserID(values); !ok {
+		lerr("web app quiet hours: missing user id")
+		http.Error(rw, "missing user id", http.StatusBadRequest)
+		return
+	}
+	admitted, alive := w.submitWebAppQuiet(req)
+	if !alive {
+		http.Error(rw, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if !admitted {
+		http.Error(rw, "forbidden", http.StatusForbidden)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want quietHours
+		ok   bool
+	}{
+		{"23:00-08:00", quietHours{start: 23 * 60, end: 8 * 60}, true},
+		{"9:30-17:45", quietHours{start: 9*60 + 30, end: 17*60 + 45}, true},
+		{"23:00–08:00", quietHours{start: 23 * 60, end: 8 * 60}, true},
+		{"00:00-23:59", quietHours{start: 0, end: 23*60 + 59}, true},
+		{"23:00", quietHours{}, false},
+		{"23-8", quietHours{}, false},
+		{"24:00-08:00", quietHours{}, false},
+		{"23:60-08:00", quietHours{}, false},
+		{"08:00-08:00", quietHours{}, false},
+		{"ab:cd-08:00", quietHours{}, false},
+		{"", quietHours{}, false},
+	}
+	for _, tc := range tests {
+		got, ok := parseQuietHours(tc.in)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("parseQuietHours(%q) = %v, %v, want %v, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+	if got, _ := parseQuietHours("9:30-8:05"); got.String() != "09:30-08:05" {
+		t.Errorf("the window prints as %q, want the form /quiet takes", got.String())
+	}
+}
+
+// A window past midnight holds both ends of the night, and no window holds its own end.
+func TestQuietHoursContains(t *testing.T) {
+	t.Parallel()
+	night := quietHours{start: 23 * 60, end: 8 * 60}
+	day := quietHours{start: 9 * 60, end: 17 * 60}
+	for _, tc := range []struct {
+		q      quietHours
+		minute int
+		want   bool
+	}{
+		{night, 23 * 60, true},
+		{night, 2 * 60, true},
+		{night, 8*60 - 1, true},
+		{night, 8 * 60, false},
+		{night, 12 * 60, false},
+		{day, 9 * 60, true},
+		{day, 17 * 60, false},
+		{day, 2 * 60, false},
+	} {
+		if got := tc.q.contains(tc.minute); got != tc.want {
+			t.Errorf("%v contains %s = %v, want %v", tc.q, formatClock(tc.minute), got, tc.want)
+		}
+	}
+	berlin := mustLoadZone("Europe/Berlin")
+	// 22:30 UTC is 23:30 in Berlin in winter.
+	at := time.Date(2026, 1, 10, 22, 30, 0, 0, time.UTC)
+	if !night.at(at, berlin) || night.at(at, time.UTC) {
+		t.Errorf("the window is not read on the chat's clock")
+	}
+}
+
+// Held alerts collapse into one line a stretch online, whatever the window cut off.
+func TestCollapseHeld(t *testing.T) {
+	t.Parallel()
+	at := func(hour, minute int) int {
+		return int(time.Date(2026, 1, 10, hour, minute, 0, 0, time.UTC).Unix())
+	}
+	held := func(streamerID int, nickname string, status cmdlib.StatusKind, timestamp int) db.HeldNotification {
+		return db.HeldNotification{StreamerID: streamerID, Nickname: nickname, Status: status, Timestamp: timestamp}
+	}
+	link := func(_, nickname string) string { return nickname }
+	got := collapseHeld([]db.HeldNotification{
+		held(1, "alice", cmdlib.StatusOnline, at(1, 10)),
+		held(2, "bob", cmdlib.StatusOffline, at(1, 30)),
+		held(1, "alice", cmdlib.StatusOnline, at(2, 0)),
+		held(1, "alice", cmdlib.StatusOffline, at(3, 40)),
+		held(3, "carol", cmdlib.StatusOnline, at(5, 0)),
+		held(1, "alice", cmdlib.StatusOnline, at(6, 50)),
+	}, time.UTC, link)
+	want := []quietSpan{
+		{Link: "alice", From: "01:10", To: "03:40"},
+		{Link: "bob", To: "01:30"},
+		{Link: "carol", From: "05:00"},
+		{Link: "alice", From: "06:50"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("span %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// templateCase renders one template key with data, checking whether the output carries want.
+type templateCase struct {
+	name    string
+	key     string
+	data    tplData
+	want    string
+	wantOut bool
+}
+
+// assertTemplatesRender renders each case in every real language,
+// failing an empty output, a missing field, or an output that carries want other than wantOut.
+// An empty want checks only that the case renders.
+func assertTemplatesRender(t *testing.T, cases []templateCase) {
+	t.Helper()
+	for _, lang := range realLangs {
+		for _, tc := range cases {
+			t.Run(lang+" "+tc.name, func(t *testing.T) {
+				t.Parallel()
+				params := &renderParams{templates: realTemplates(t, lang), key: tc.key, data: tc.data}
+				out := params.render("")
+				if out == "" {
+					t.Fatalf("%s rendered empty", tc.key)
+				}
+				if strings.Contains(out, "<no value>") {
+					t.Errorf("%s rendered a missing field: %q", tc.key, out)
+				}
+				if tc.want != "" && strings.Contains(out, tc.want) != tc.wantOut {
+					t.Errorf("%s contains %q = %v, want %v: %q",
+						tc.key, tc.want, !tc.wantOut, tc.wantOut, out)
+				}
+			})
+		}
+	}
+}
+
+// TestQuietTemplatesRender ties the call sites' data keys to the real templates.
+func TestQuietTemplatesRender(t *testing.T) {
+	t.Parallel()
+	spans := []quietSpan{
+		{Link: "alice", From: "01:10", To: "03:40"},
+		{Link: "bob", To: "01:30"},
+		{Link: "carol", From: "05:00"},
+	}
+	assertTemplatesRender(t, []templateCase{
+		{"off", "quiet", tplData{"timezone": "UTC"}, "23:00-08:00", false},
+		{
+			"set", "quiet",
+			tplData{"quiet_hours": "23:00-08:00", "summary": true, "timezone": "Europe/Berlin"},
+			"Europe/Berlin", true,
+		},
+		{"help", "quiet", tplData{"timezone": "UTC", "help": true}, "summary", true},
+		{"invalid", "quiet_invalid", nil, "23:00-08:00", true},
+		{"summary", "quiet_summary", tplData{"spans": spans, "first": true}, "01:10–03:40", true},
+		{"summary tail", "quiet_summary", tplData{"spans": spans, "first": false}, "🌙", false},
+		{
+			"settings with quiet hours", "settings",
+			func() tplData {
+				data := settingsData(false)
+				data["quiet_hours"] = "23:00-08:00"
+				return data
+			}(),
+			"reset_quiet", true,
+		},
+		{"settings without", "settings", settingsData(false), "reset_quiet", false},
+	})
+}
+
+// The page proposes, the bot decides, as for the timezone picker.
+func TestHandleWebAppQuietValidates(t *testing.T) {
+	t.Parallel()
+	const botToken = "123:test-token"
+	w := &worker{
+		cfg:                 searchConfig(t, botToken, nil),
+		webAppQuietRequests: make(chan webAppQuietRequest, 1),
+		shutdownCh:          make(chan struct{}),
+	}
+	send := func(method, query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/apps/quiet/api/submit?endpoint=test&"+query, nil)
+		r.Header.Set("X-Init-Data", initDataFor(botToken, 1))
+		rw := httptest.NewRecorder()
+		w.handleWebAppQuiet(rw, r)
+		return rw
+	}
+	if rw := send(http.MethodGet, "hours=23:00-08:00"); rw.Code != http.StatusMethodNotAllowed {
+		t.Errorf("a GET got %d, want %d", rw.Code, http.StatusMethodNotAllowed)
+	}
+	if rw := send(http.MethodPost, "hours=08:00-08:00"); rw.Code != http.StatusBadRequest {
+		t.Errorf("an empty window got %d, want %d", rw.Code, http.StatusBadRequest)
+	}
+	if queued := len(w.webAppQuietRequests); queued != 0 {
+		t.Errorf("a refused window still queued %d requests", queued)
+	}
+	serve := func(query string) webAppQuietRequest {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() { done <- send(http.MethodPost, query) }()
+		var req webAppQuietRequest
+		select {
+		case req = <-w.webAppQuietRequests:
+			req.admittedCh <- true
+		case <-time.After(time.Second):
+			t.Fatal("a valid window never reached the loop")
+		}
+		if rw := <-done; rw.Code != http.StatusOK {
+			t.Errorf("%s got %d, want %d", query, rw.Code, http.StatusOK)
+		}
+		return req
+	}
+	req := serve("hours=23:00-08:00&summary=1")
+	if req.chatID != 1 || req.hours == nil || *req.hours != (quietHours{start: 23 * 60, end: 8 * 60}) || !req.summary {
+		t.Errorf("queued %+v", req)
+	}
+	if req := serve("hours=off"); req.hours != nil {
+		t.Errorf("turning off queued hours %v", *req.hours)
+	}
+}
+
+// An alert in the window is held rather than sent, stamped with its status change,
+// and once the window is gone the chat gets one summary of it.
+func TestQuietHoursHoldAndSummarize(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	user, _ := w.db.User(1)
+	now := time.Now()
+	start, end := windowAround(now)
+	w.db.SetQuietHours(user.UserID, start, end, true)
+	user, _ = w.db.User(1)
+	// The queue gets to the alerts a while after the change.
+	changed := int(now.Add(-10 * time.Minute).Unix())
+
+	notification := func(status cmdlib.StatusKind) plannedNotification {
+		n := db.Notification{
+			Endpoint:     "test",
+			UserID:       user.UserID,
+			StreamerID:   &streamerID,
+			Site:         testSite,
+			Nickname:     "a",
+			Status:       status,
+			Kind:         db.NotificationPacket,
+			Timestamp:    changed,
+			QuietStart:   user.QuietStart,
+			QuietEnd:     user.QuietEnd,
+			QuietSummary: user.QuietSummary,
+		}
+		return plannedNotification{Notification: n, translation: w.statusTranslation("test", status)}
+	}
+	w.notifyOfStatus(notification(cmdlib.StatusOnline), nil)
+	w.notifyOfStatus(notification(cmdlib.StatusOffline), nil)
+	if got := w.sendQueue.Len(); got != 0 {
+		t.Fatalf("queued %d messages during quiet hours, want none", got)
+	}
+	if got := w.db.MustInt("select count(*) from held_notifications"); got != 2 {
+		t.Fatalf("held %d alerts, want 2", got)
+	}
+	if got := w.db.MustInt("select count(*) from held_notifications where timestamp = $1", changed); got != 2 {
+		t.Errorf("%d of 2 held alerts carry the time of their change", got)
+	}
+
+	w.sendQuietSummaries(now)
+	if got := w.sendQueue.Len(); got != 0 {
+		t.Fatalf("summarized %d messages inside the window", got)
+	}
+
+	w.db.ResetQuietHours(user.UserID)
+	w.sendQuietSummaries(now)
+	if got := w.sendQueue.Len(); got != 1 {
+		t.Fatalf("queued %d summaries, want 1", got)
+	}
+	queued := w.sendQueue.pop()
+	queued.message.render("")
+	if got := queued.message.(*messageParams).Text; got != "QuietSummary" {
+		t.Errorf("sent %q, want the summary", got)
+	}
+	if got := w.db.MustInt("select count(*) from held_notifications"); got != 0 {
+		t.Errorf("%d held alerts outlived their summary", got)
+	}
+}
+
+// windowAround is a UTC window holding now with an hour to spare either side.
+func windowAround(now time.Time) (start, end int) {
+	minute := now.UTC().Hour()*60 + now.UTC().Minute()
+	return (minute + 23*60) % (24 * 60), (minute + 60) % (24 * 60)
+}
+
+// A silent window sends the alert on time, without sound.
+func TestQuietHoursSilent(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	user, _ := w.db.User(1)
+	start, end := windowAround(time.Now())
+	p := plannedNotification{
+		Notification: db.Notification{
+			Endpoint:   "test",
+			UserID:     user.UserID,
+			StreamerID: &streamerID,
+			Site:       testSite,
+			Nickname:   "a",
+			Status:     cmdlib.StatusOnline,
+			Kind:       db.NotificationPacket,
+			QuietStart: &start,
+			QuietEnd:   &end,
+		},
+		translation: w.statusTranslation("test", cmdlib.StatusOnline),
+	}
+	w.notifyOfStatus(p, nil)
+	if got := w.sendQueue.Len(); got != 1 {
+		t.Fatalf("queued %d messages, want 1", got)
+	}
+	if msg := w.sendQueue.pop().message.(*messageParams); !msg.DisableNotification {
+		t.Errorf("a quiet-hours alert rang")
+	}
+}
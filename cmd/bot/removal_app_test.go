@@ -130,11 +130,16 @@ func TestHandleWebAppRemovalListAnswersFromTheLoop(t *testing.T) {
 		}
 	}
 
-	rw := serve(webAppRemovalListResult{nicknames: []string{"a_model", "b_model"}, allowed: true})
+	rw := serve(webAppRemovalListResult{
+		nicknames: []string{"a_model", "b_model"},
+		filters:   map[string]string{"b_model": "public"},
+		allowed:   true,
+	})
 	if rw.Code != http.StatusOK {
 		t.Errorf("a served list got %d, want %d", rw.Code, http.StatusOK)
 	}
-	if got := strings.TrimSpace(rw.Body.String()); got != `["a_model","b_model"]` {
+	want := `[{"nickname":"a_model"},{"nickname":"b_model","filter":"public"}]`
+	if got := strings.TrimSpace(rw.Body.String()); got != want {
 		t.Errorf("list body = %q", got)
 	}
 	// A chat with no row answers nil, which the page must read as an empty list, not null.
@@ -289,10 +294,17 @@ func TestPerformWebAppRemovalListIsWhitelistGated(t *testing.T) {
 	insertTestStreamer(&w.db, db.Streamer{Nickname: "b_model"})
 	insertSubscription(&w.db, "test", 5, "b_model")
 	insertPendingSubscription(&w.db, "test", 5, "a_model", false)
+	// The filters ride along, for the subscriptions that have one.
+	user, _ := w.db.User(5)
+	minViewers := 100
+	w.db.SetSubscriptionFilter(user.UserID, testSite, "b_model", "test", &db.SubscriptionFilter{MinViewers: &minViewers})
 	res = ask(5)
 	if !slices.Equal(res.nicknames, []string{"a_model", "b_model"}) {
 		t.Errorf("nicknames = %q, want [a_model b_model]", res.nicknames)
 	}
+	if len(res.filters) != 1 || res.filters["b_model"] != "viewers 100" {
+		t.Errorf("filters = %q, want b_model's alone", res.filters)
+	}
 	received := commandsInLog(w, "select command from received_message_log", nil)
 	if len(received) != 0 {
 		t.Errorf("a list read logged %q", received)
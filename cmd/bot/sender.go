@@ -1,6 +1,7 @@
 // Outgoing message scheduling. The main goroutine owns all scheduling state;
-// each send runs on a deliver goroutine that does I/O only.
+// each send runs on a deliver goroutine that does I/O only, over the endpoint's transport.
 // Each endpoint is its own bot token, rated separately by Telegram,
+// and Discord posts share one endpoint of their own,
 // so every limit here paces one endpoint: one send in flight per endpoint.
 
 package main
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/internal/metrics"
 	"github.com/bcmk/siren/v4/lib/cmdlib"
 )
 
@@ -78,6 +80,10 @@ type receivedMessage struct {
 	command   string
 	// replySeq is this reply's place in the answer, zero for the first.
 	replySeq int
+	// forum marks a chat with topics, and threadID is the topic the message came from,
+	// zero for the General one. Both are unset for anything but a Telegram message.
+	forum    bool
+	threadID int
 }
 
 // newReceivedMessage pairs the chat with its user, so the two cannot disagree,
@@ -174,14 +180,28 @@ type queuedMessage struct {
 	requestedAt    time.Time
 	seq            uint64
 	stalls         int
-}
-
-// messageLess orders one user's messages: priority, then FIFO by seq.
+	// live ties the message to a live message, nil for any other.
+	live *liveSend
+	// favourite marks an alert of a starred subscription.
+	favourite bool
+	// burst holds the notification rows of the alerts collapsed into this message beside its own.
+	burst []int
+	// topic is the forum topic of a status alert, the General one for anything else.
+	topic forumTopic
+	// board ties the message to the chat's board, nil for any other.
+	board *boardSend
+}
+
+// messageLess orders one user's messages: priority, then favourites, then FIFO by seq.
 // Stalls are absent on purpose: a newer status must not pass a stalled one.
+// A streamer's alerts are all favourites or none, so they keep their order among themselves.
 func messageLess(a, b *queuedMessage) bool {
 	if a.priority != b.priority {
 		return a.priority < b.priority
 	}
+	if a.favourite != b.favourite {
+		return a.favourite
+	}
 	return a.seq < b.seq
 }
 
@@ -381,19 +401,42 @@ func (w *worker) enqueueMessage(
 	userID db.UserID,
 	notificationID int,
 ) {
-	w.sendSeq++
-	w.enqueue(&queuedMessage{
+	w.enqueueNew(&queuedMessage{
 		userID:         userID,
 		endpoint:       endpoint,
 		message:        msg,
 		priority:       priority,
 		tag:            tag,
 		notificationID: notificationID,
-		requestedAt:    time.Now(),
-		seq:            w.sendSeq,
 	})
 }
 
+// enqueueNotification adds the message of a notification,
+// tied to a live message if live is not nil, and ahead of others if it is of a favourite.
+// Main goroutine only.
+func (w *worker) enqueueNotification(p plannedNotification, msg sendable, live *liveSend) {
+	w.enqueueNew(&queuedMessage{
+		userID:         p.UserID,
+		endpoint:       p.Endpoint,
+		message:        msg,
+		priority:       p.Priority,
+		tag:            notificationTag(p.Notification),
+		notificationID: p.ID,
+		live:           live,
+		favourite:      p.Favourite,
+		topic:          w.alertTopic(p),
+	})
+}
+
+// enqueueNew stamps a new message with its place in the queue and enqueues it.
+// Main goroutine only.
+func (w *worker) enqueueNew(q *queuedMessage) {
+	w.sendSeq++
+	q.seq = w.sendSeq
+	q.requestedAt = time.Now()
+	w.enqueue(q)
+}
+
 // enqueue inserts a prebuilt message and tries to start a send.
 // Main goroutine only.
 // A re-queued message (a fallback, postpone, or migrate) arrives here
@@ -410,10 +453,12 @@ func (w *worker) enqueue(q *queuedMessage) {
 		// an accepted, bounded loss when the queue overflows.
 		if q.notificationID != 0 {
 			w.db.RequeueNotification(q.notificationID)
+			w.requeueBurst(q.burst)
 		}
 		return
 	}
 	s.queue.push(q)
+	metrics.SenderQueueLength.WithLabelValues(q.endpoint).Set(float64(s.queue.Len()))
 	w.trySend(q.endpoint)
 }
 
@@ -436,6 +481,7 @@ func (w *worker) trySend(endpoint string) {
 		return
 	}
 	q := s.queue.pop()
+	metrics.SenderQueueLength.WithLabelValues(endpoint).Set(float64(s.queue.Len()))
 	if q.tag.kind != db.MaintenancePacket {
 		chatID, ok := w.db.ChatIDForUser(q.userID)
 		if !ok {
@@ -444,6 +490,7 @@ func (w *worker) trySend(endpoint string) {
 			// and it lands before the slot is claimed, so drop this send and carry on.
 			lerr("dropping send: no chat for user %d", q.userID)
 			w.finalizeNotification(q.notificationID)
+			w.finalizeBurst(q.burst)
 			w.trySend(endpoint)
 			return
 		}
@@ -455,6 +502,13 @@ func (w *worker) trySend(endpoint string) {
 		// and nothing threads one through the send path.
 		// The lookup is cheap; single-flight pacing bounds dispatch.
 		q.message.setChatID(chatID)
+		w.resolveTopic(q)
+		if w.cfg.NotificationBurst > 0 && isTelegram(endpoint) {
+			q = s.queue.collapseBurst(q, w.cfg.NotificationBurst, w.botMentionIfNeeded(endpoint, chatID))
+			metrics.SenderQueueLength.WithLabelValues(endpoint).Set(float64(s.queue.Len()))
+		}
+		// Set on every dispatch, as the chat is: a resend falling back from a closed topic clears it.
+		setThread(q.message, q.topic.threadID)
 	}
 	// Outside the branch above: a message tagged maintenance renders here too.
 	q.message.render(w.botMentionIfNeeded(q.endpoint, q.message.chatID()))
@@ -506,13 +560,16 @@ func (w *worker) deliver(q *queuedMessage) {
 	// but ownership by capture keeps the property structural.
 	// Capturing just the endpoint and the id also keeps the release closure
 	// from pinning the whole message, image payload included, for the whole pause.
-	// isGroup survives the fallback's payload swap: toText copies the chat id.
-	isGroup := isGroupOrChannel(q.message.chatID())
+	// chatID survives the fallback's payload swap: toText copies it.
+	chatID := q.message.chatID()
 	tag := q.tag
 	userID := q.userID
 	endpoint := q.endpoint
 	now := time.Now()
+	topic := w.openTopic(q)
 	result, migrateTo, retryAfter := w.sendMessageInternal(q.endpoint, q.message)
+	messageID, photo := sentAs(q.message)
+	metrics.SendResults.WithLabelValues(endpoint, sendResultName(result)).Inc()
 	latency := int(time.Since(q.requestedAt).Milliseconds())
 	// A 429, timeout, or network blip postpones the message:
 	// the main loop re-queues it,
@@ -551,6 +608,14 @@ func (w *worker) deliver(q *queuedMessage) {
 			q.message = p.toText()
 			resend = q
 		}
+		// An alert to a closed or deleted topic falls back to the General one the same way.
+		if threadID := q.topic.threadID; threadID != 0 && (result == messageTopicClosed || result == messageTopicDeleted) {
+			if result == messageTopicDeleted {
+				topic.gone = threadID
+			}
+			q.topic = forumTopic{}
+			resend = q
+		}
 	}
 	// Pace the endpoint's rate before releasing its slot.
 	// A 429 holds it a full second, so a rate limit backs off the whole endpoint,
@@ -575,7 +640,13 @@ func (w *worker) deliver(q *queuedMessage) {
 		latency:         latency,
 		tag:             tag,
 		notificationID:  q.notificationID,
+		burst:           q.burst,
+		topic:           topic,
 		resend:          resend,
+		live:            q.live,
+		board:           q.board,
+		messageID:       messageID,
+		photo:           photo,
 	}
 	if tag.kind == db.MaintenancePacket {
 		// A maintenance send cools no user, so there is nothing to release.
@@ -586,12 +657,8 @@ func (w *worker) deliver(q *queuedMessage) {
 	// not a pacing sleep.
 	// The timer spawns the release goroutine only when it fires,
 	// so a long postpone parks a timer entry, not a goroutine stack.
-	// A group or channel caps tighter than the 1s per-user gap, at 20 messages/min,
-	// so pace it slower to avoid self-triggering a 429.
-	cooldown := userCooldown
-	if isGroup {
-		cooldown = groupCooldown
-	}
+	// The transport names the gap its destination tolerates.
+	cooldown := w.transport(endpoint).userCooldown(chatID)
 	// A postponed message's user stays cooling for the whole postpone,
 	// so its re-queued send dispatches no sooner than that.
 	// A small retry_after must not undercut the chat's pacing gap,
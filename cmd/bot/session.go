@@ -0,0 +1,42 @@
+// Session summaries: an offline alert tells how long the session it closes lasted,
+// its peak and average viewers and the show kinds seen in it.
+// Every poll adds the streamers online to their sessions, kept on the streamers row;
+// ConfirmStatusChanges starts a session with its confirmed online status,
+// and records it in sessions as its offline status is confirmed.
+
+package main
+
+import (
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// sessionSamples lists what one poll tells of the streamers online toward their sessions.
+func sessionSamples(streamers map[string]cmdlib.StreamerInfo) []db.SessionSample {
+	samples := make([]db.SessionSample, 0, len(streamers))
+	for nickname, info := range streamers {
+		samples = append(samples, db.SessionSample{Nickname: nickname, Viewers: info.Viewers, ShowKind: info.ShowKind})
+	}
+	return samples
+}
+
+// sessionSummary is a session as the offline template reads it.
+type sessionSummary struct {
+	Duration       timeDiff
+	PeakViewers    *int
+	AverageViewers *int
+	ShowKinds      []cmdlib.ShowKind
+}
+
+// summarizeSession is nil for no session, so the template leaves the summary out.
+func summarizeSession(s *db.Session) *sessionSummary {
+	if s == nil {
+		return nil
+	}
+	return &sessionSummary{
+		Duration:       calcTimeDiff(max(s.End-s.Start, 0)),
+		PeakViewers:    s.PeakViewers,
+		AverageViewers: s.AverageViewers,
+		ShowKinds:      s.ShowKinds,
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestSummarizeSession(t *testing.T) {
+	t.Parallel()
+	if got := summarizeSession(nil); got != nil {
+		t.Errorf("no session summarized as %+v", got)
+	}
+	peak, average := 150, 90
+	got := summarizeSession(&db.Session{
+		Start: 1000, End: 6400, PeakViewers: &peak, AverageViewers: &average,
+		ShowKinds: []cmdlib.ShowKind{cmdlib.ShowPublic, cmdlib.ShowPrivate},
+	})
+	if got.Duration != calcTimeDiff(5400) || *got.PeakViewers != 150 || *got.AverageViewers != 90 || len(got.ShowKinds) != 2 {
+		t.Errorf("summary = %+v, want 1h 30m, 150 at the peak, 90 on average, two show kinds", got)
+	}
+}
+
+func TestSessionTemplatesRender(t *testing.T) {
+	t.Parallel()
+	peak, average := 150, 90
+	full := &sessionSummary{
+		Duration: calcTimeDiff(5400), PeakViewers: &peak, AverageViewers: &average,
+		ShowKinds: []cmdlib.ShowKind{cmdlib.ShowPublic, cmdlib.ShowPrivate},
+	}
+	bare := &sessionSummary{Duration: calcTimeDiff(600)}
+	alice := func(session *sessionSummary) tplData { return tplData{"streamer_link": "alice", "session": session} }
+	assertTemplatesRender(t, []templateCase{
+		{"streamer", "offline", alice(bare), "alice", true},
+		{"peak", "offline", alice(full), "150", true},
+		{"average", "offline", alice(full), "90", true},
+		{"show kinds", "offline", alice(full), ", ", true},
+		{"no viewers", "offline", alice(bare), "\n", true},
+		{"no viewers line", "offline", alice(bare), "150", false},
+		{"no session", "offline", alice(summarizeSession(nil)), "\n", false},
+	})
+}
+
+// A session runs from the confirmed online status to the confirmed offline one,
+// a streamer back before its offline status held staying in it,
+// and the offline change carries what the polls in between reported.
+func TestSessionSummaries(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+
+	viewers := func(n int) *int { return &n }
+	change := func(now int, status cmdlib.StatusKind) {
+		w.db.UpsertUnconfirmedStatusChanges(testSite, []db.StatusChange{
+			{Nickname: "a", Status: status, Timestamp: now},
+		}, nil, now)
+	}
+	confirm := func(now int, samples ...db.SessionSample) []db.ConfirmedStatusChange {
+		changes, _ := w.db.ConfirmStatusChanges(now, 0, 50)
+		w.db.RecordSessionSamples(testSite, samples)
+		return changes
+	}
+
+	change(100, cmdlib.StatusOnline)
+	confirm(100, db.SessionSample{Nickname: "a", Viewers: viewers(10), ShowKind: cmdlib.ShowPublic})
+	confirm(200, db.SessionSample{Nickname: "a", Viewers: viewers(30), ShowKind: cmdlib.ShowPrivate})
+	confirm(300, db.SessionSample{Nickname: "a", ShowKind: cmdlib.ShowPrivate})
+	// Gone for less than the offline status takes to hold.
+	change(400, cmdlib.StatusOffline)
+	confirm(400)
+	change(420, cmdlib.StatusOnline)
+	confirm(420, db.SessionSample{Nickname: "a", Viewers: viewers(20), ShowKind: cmdlib.ShowPublic})
+	change(500, cmdlib.StatusOffline)
+	confirm(500)
+	changes := confirm(600)
+
+	if len(changes) != 1 || changes[0].StreamerID != streamerID || changes[0].Session == nil {
+		t.Fatalf("changes = %+v, want the offline one with its session", changes)
+	}
+	session := changes[0].Session
+	if session.Start != 100 || session.End != 500 {
+		t.Errorf("session from %d to %d, want from 100 to 500", session.Start, session.End)
+	}
+	if session.PeakViewers == nil || *session.PeakViewers != 30 || session.AverageViewers == nil || *session.AverageViewers != 20 {
+		t.Errorf("session viewers %v at the peak, %v on average, want 30 and 20", session.PeakViewers, session.AverageViewers)
+	}
+	if !slices.Equal(session.ShowKinds, []cmdlib.ShowKind{cmdlib.ShowPublic, cmdlib.ShowPrivate}) {
+		t.Errorf("session show kinds %v, want public and private", session.ShowKinds)
+	}
+
+	// The next session starts afresh and is summarized without viewers none reported.
+	change(700, cmdlib.StatusOnline)
+	confirm(700, db.SessionSample{Nickname: "a"})
+	change(800, cmdlib.StatusOffline)
+	confirm(800)
+	changes = confirm(900)
+	if len(changes) != 1 || changes[0].Session == nil || changes[0].Session.Start != 700 ||
+		changes[0].Session.PeakViewers != nil || changes[0].Session.ShowKinds != nil {
+		t.Errorf("changes = %+v, want a session from 700 with no viewers and no show kinds", changes)
+	}
+}
@@ -0,0 +1,178 @@
+// Show-kind alerts: a chat may opt in to hearing when a streamer it follows,
+// online all along, goes private, is back in a public show or goes away.
+// The show kind is confirmed in the status pipeline as the online status is,
+// and a confirmed change is alerted as text alone, apart from status notifications:
+// it is not mirrored, takes no link turn and has no place in a quiet-hours summary.
+
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// The alert classes, stored as bits of users.show_kind_alerts.
+const (
+	// showKindAlertPrivate covers a private, hidden, group or ticket show.
+	showKindAlertPrivate = 1 << iota
+	// showKindAlertPublic covers a return to a public show.
+	showKindAlertPublic
+	// showKindAlertAway covers a streamer gone away.
+	showKindAlertAway
+)
+
+// showKindAlertArguments names the alert classes as /show_changes takes them, in bit order.
+var showKindAlertArguments = []struct {
+	name string
+	bit  int
+}{
+	{"private", showKindAlertPrivate},
+	{"public", showKindAlertPublic},
+	{"away", showKindAlertAway},
+}
+
+// showKindAlertsOffArgument turns every show-kind alert off.
+const showKindAlertsOffArgument = "off"
+
+// showKindAlertClass is the class of a confirmed change from prev to cur, zero for none.
+// A show turning unknown says nothing, and a public show is news only after some other one.
+func showKindAlertClass(prev, cur cmdlib.ShowKind) int {
+	switch cur {
+	case cmdlib.ShowPrivate, cmdlib.ShowHidden, cmdlib.ShowGroup, cmdlib.ShowTicket:
+		return showKindAlertPrivate
+	case cmdlib.ShowPublic:
+		if prev != cmdlib.ShowUnknown {
+			return showKindAlertPublic
+		}
+	case cmdlib.ShowAway:
+		return showKindAlertAway
+	}
+	return 0
+}
+
+// parseShowKindAlerts reads the alert classes /show_changes is given, each at most once.
+// off reads as none.
+func parseShowKindAlerts(args []string) (alerts int, ok bool) {
+	if len(args) == 1 && strings.EqualFold(args[0], showKindAlertsOffArgument) {
+		return 0, true
+	}
+	if len(args) == 0 {
+		return 0, false
+	}
+	for _, arg := range args {
+		bit := 0
+		for _, a := range showKindAlertArguments {
+			if strings.EqualFold(arg, a.name) {
+				bit = a.bit
+			}
+		}
+		if bit == 0 || alerts&bit != 0 {
+			return 0, false
+		}
+		alerts |= bit
+	}
+	return alerts, true
+}
+
+// formatShowKindAlerts lists the alert classes in /show_changes' words, empty for none.
+func formatShowKindAlerts(alerts int) string {
+	var names []string
+	for _, a := range showKindAlertArguments {
+		if alerts&a.bit != 0 {
+			names = append(names, a.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// buildShowKindNotifications alerts the chats that asked for it to confirmed show-kind changes.
+// A subscription filter judges starts alone, so it holds none of these back.
+func (w *worker) buildShowKindNotifications(changes []db.ConfirmedShowKindChange) []db.Notification {
+	var streamerIDs []int
+	for _, c := range changes {
+		if showKindAlertClass(c.PrevShowKind, c.ShowKind) != 0 {
+			streamerIDs = append(streamerIDs, c.StreamerID)
+		}
+	}
+	if len(streamerIDs) == 0 {
+		return nil
+	}
+	var notifications []db.Notification
+	usersForStreamers, endpointsForStreamers, _, favouritesForStreamers := w.db.UsersForStreamers(
+		streamerIDs, int(time.Now().Unix()))
+	for _, c := range changes {
+		class := showKindAlertClass(c.PrevShowKind, c.ShowKind)
+		if class == 0 {
+			continue
+		}
+		endpoints := endpointsForStreamers[c.StreamerID]
+		favourites := favouritesForStreamers[c.StreamerID]
+		for i, user := range usersForStreamers[c.StreamerID] {
+			if user.ShowKindAlerts&class == 0 {
+				continue
+			}
+			notifications = append(notifications, db.Notification{
+				Endpoint:   endpoints[i],
+				UserID:     user.UserID,
+				StreamerID: &c.StreamerID,
+				Site:       c.Site,
+				Nickname:   c.Nickname,
+				Status:     cmdlib.StatusOnline,
+				ShowKind:   c.ShowKind,
+				Sound:      true,
+				Priority:   db.PriorityLow,
+				Favourite:  favourites[i],
+				Kind:       db.ShowKindPacket,
+			})
+		}
+	}
+	return notifications
+}
+
+// showKindTranslation is the message of a show-kind alert, nil where the endpoint has none.
+func (w *worker) showKindTranslation(endpoint string) *cmdlib.Translation {
+	tr := w.tr[endpoint]
+	if tr == nil {
+		return nil
+	}
+	return tr.ShowKindChange
+}
+
+// replyShowChanges tells the chat which show-kind changes it is alerted to.
+func (w *worker) replyShowChanges(m receivedMessage, help bool) {
+	user := w.mustUserByID(m.userID)
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].ShowChanges, tplData{
+		"alerts": formatShowKindAlerts(user.ShowKindAlerts),
+		"help":   help,
+	})
+}
+
+// setShowChanges shows the chat's show-kind alerts or sets them from private, public and away.
+func (w *worker) setShowChanges(m receivedMessage, arguments string) {
+	parts := strings.Fields(arguments)
+	if len(parts) == 0 {
+		w.replyShowChanges(m, true)
+		return
+	}
+	alerts, ok := parseShowKindAlerts(parts)
+	if !ok {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].ShowChangesInvalid, nil)
+		return
+	}
+	w.db.SetShowKindAlerts(m.userID, alerts)
+	w.replyShowChanges(m, false)
+}
+
+// showKindChanges lists the streamers online before and after whose show kind changed.
+func showKindChanges(before, after map[string]cmdlib.StreamerInfo) []db.ShowKindChange {
+	var changes []db.ShowKindChange
+	for nickname, info := range after {
+		if prev, wasOnline := before[nickname]; wasOnline && prev.ShowKind != info.ShowKind {
+			changes = append(changes, db.ShowKindChange{Nickname: nickname, ShowKind: info.ShowKind})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestShowKindAlertClass(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		prev cmdlib.ShowKind
+		cur  cmdlib.ShowKind
+		want int
+	}{
+		{cmdlib.ShowPublic, cmdlib.ShowPrivate, showKindAlertPrivate},
+		{cmdlib.ShowPublic, cmdlib.ShowHidden, showKindAlertPrivate},
+		{cmdlib.ShowPublic, cmdlib.ShowGroup, showKindAlertPrivate},
+		{cmdlib.ShowGroup, cmdlib.ShowTicket, showKindAlertPrivate},
+		{cmdlib.ShowPrivate, cmdlib.ShowPublic, showKindAlertPublic},
+		{cmdlib.ShowAway, cmdlib.ShowPublic, showKindAlertPublic},
+		{cmdlib.ShowUnknown, cmdlib.ShowPublic, 0},
+		{cmdlib.ShowPublic, cmdlib.ShowAway, showKindAlertAway},
+		{cmdlib.ShowPrivate, cmdlib.ShowUnknown, 0},
+	}
+	for _, tc := range tests {
+		if got := showKindAlertClass(tc.prev, tc.cur); got != tc.want {
+			t.Errorf("%v -> %v: class = %d, want %d", tc.prev, tc.cur, got, tc.want)
+		}
+	}
+}
+
+func TestParseShowKindAlerts(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		ok   bool
+		want string
+	}{
+		{"private", true, "private"},
+		{"away PUBLIC", true, "public, away"},
+		{"private public away", true, "private, public, away"},
+		{"off", true, ""},
+		{"OFF", true, ""},
+		{"", false, ""},
+		{"private private", false, ""},
+		{"private off", false, ""},
+		{"group", false, ""},
+	}
+	for _, tc := range tests {
+		alerts, ok := parseShowKindAlerts(strings.Fields(tc.in))
+		if ok != tc.ok {
+			t.Errorf("parseShowKindAlerts(%q) ok = %v, want %v", tc.in, ok, tc.ok)
+			continue
+		}
+		if got := formatShowKindAlerts(alerts); ok && got != tc.want {
+			t.Errorf("parseShowKindAlerts(%q) reads back as %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestShowKindChanges(t *testing.T) {
+	t.Parallel()
+	before := map[string]cmdlib.StreamerInfo{
+		"a": {ShowKind: cmdlib.ShowPublic},
+		"b": {ShowKind: cmdlib.ShowPublic},
+		"c": {ShowKind: cmdlib.ShowPublic},
+	}
+	after := map[string]cmdlib.StreamerInfo{
+		"a": {ShowKind: cmdlib.ShowPrivate},
+		"b": {ShowKind: cmdlib.ShowPublic},
+		"d": {ShowKind: cmdlib.ShowAway},
+	}
+	changes := showKindChanges(before, after)
+	if len(changes) != 1 || changes[0] != (db.ShowKindChange{Nickname: "a", ShowKind: cmdlib.ShowPrivate}) {
+		t.Errorf("changes = %+v, want a going private alone", changes)
+	}
+}
+
+func TestShowKindTemplatesRender(t *testing.T) {
+	t.Parallel()
+	settings := settingsData(false)
+	settings["show_kind_alerts"] = "private, away"
+	assertTemplatesRender(t, []templateCase{
+		{"private", "show_kind_change", tplData{"streamer_link": "alice", "show_kind": cmdlib.ShowPrivate}, "🔒", true},
+		{"public", "show_kind_change", tplData{"streamer_link": "alice", "show_kind": cmdlib.ShowPublic}, "🟢", true},
+		{"away", "show_kind_change", tplData{"streamer_link": "alice", "show_kind": cmdlib.ShowAway}, "💤", true},
+		{"off", "show_changes", tplData{"alerts": ""}, "away", false},
+		{"help", "show_changes", tplData{"alerts": "", "help": true}, "away", true},
+		{"set", "show_changes", tplData{"alerts": "private, away"}, "private, away", true},
+		{"invalid", "show_changes_invalid", nil, "off", true},
+		{"settings", "settings", settings, "private, away", true},
+		{
+			"settings without show kinds", "settings",
+			func() tplData {
+				data := settingsData(false)
+				data["show_kinds_supported"] = false
+				return data
+			}(),
+			"show_changes", false,
+		},
+	})
+}
+
+// A show kind is confirmed along with the status, alerted only once it changes mid-show,
+// and only to the chats that asked for its class.
+func TestShowKindAlerts(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 2, "a")
+	user, _ := w.db.User(1)
+	w.db.SetShowKindAlerts(user.UserID, showKindAlertPrivate|showKindAlertPublic)
+
+	poll := func(now int, kind cmdlib.ShowKind, online bool) (alerted []db.Notification) {
+		result := &cmdlib.OnlineListResults{Streamers: map[string]cmdlib.StreamerInfo{}}
+		if online {
+			result.Streamers["a"] = cmdlib.StreamerInfo{ShowKind: kind}
+		}
+		for _, n := range w.handleCheckerResults(w.defaultSite, result, now).notifications {
+			if n.Kind == db.ShowKindPacket {
+				alerted = append(alerted, n)
+			}
+		}
+		return
+	}
+	confirmed := func() int {
+		return w.db.MustInt("select confirmed_show_kind from streamers where nickname = 'a'")
+	}
+
+	if alerted := poll(100, cmdlib.ShowPublic, true); len(alerted) != 0 {
+		t.Errorf("going online alerted %d show changes", len(alerted))
+	}
+	if got := confirmed(); got != int(cmdlib.ShowPublic) {
+		t.Errorf("confirmed show kind %d after going online, want public", got)
+	}
+	alerted := poll(101, cmdlib.ShowPrivate, true)
+	if len(alerted) != 1 || alerted[0].UserID != user.UserID || alerted[0].ShowKind != cmdlib.ShowPrivate {
+		t.Errorf("going private alerted %+v, want chat 1 alone", alerted)
+	}
+	if alerted := poll(102, cmdlib.ShowAway, true); len(alerted) != 0 {
+		t.Errorf("going away alerted %d chats, none asked", len(alerted))
+	}
+	if alerted := poll(103, cmdlib.ShowPublic, true); len(alerted) != 1 {
+		t.Errorf("back to public alerted %d chats, want 1", len(alerted))
+	}
+	poll(104, cmdlib.ShowUnknown, false)
+	poll(110, cmdlib.ShowUnknown, false)
+	if got := confirmed(); got != int(cmdlib.ShowUnknown) {
+		t.Errorf("confirmed show kind %d after going offline, want unknown", got)
+	}
+}
+
+// A show-kind alert goes out silently in a silent window,
+// and is dropped in a summarized one, the summary telling of time online alone.
+func TestShowKindAlertsInQuietHours(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	user, _ := w.db.User(1)
+	start, end := windowAround(time.Now())
+	plan := func(summary bool) plannedNotification {
+		return w.planNotifications([]db.Notification{{
+			Endpoint:     "test",
+			UserID:       user.UserID,
+			StreamerID:   &streamerID,
+			Site:         testSite,
+			Nickname:     "a",
+			Status:       cmdlib.StatusOnline,
+			ShowKind:     cmdlib.ShowPrivate,
+			Kind:         db.ShowKindPacket,
+			QuietStart:   &start,
+			QuietEnd:     &end,
+			QuietSummary: summary,
+		}})[0]
+	}
+
+	w.notifyOfStatus(plan(true), nil)
+	if got := w.sendQueue.Len(); got != 0 {
+		t.Errorf("queued %d messages in a summarized window, want none", got)
+	}
+	if got := w.db.MustInt("select count(*) from held_notifications"); got != 0 {
+		t.Errorf("held %d show changes for the summary", got)
+	}
+
+	w.notifyOfStatus(plan(false), nil)
+	if got := w.sendQueue.Len(); got != 1 {
+		t.Fatalf("queued %d messages in a silent window, want 1", got)
+	}
+	queued := w.sendQueue.pop()
+	queued.message.render("")
+	msg := queued.message.(*messageParams)
+	if msg.Text != "ShowKindChange" || !msg.DisableNotification {
+		t.Errorf("sent %q ringing = %v, want a silent show change", msg.Text, !msg.DisableNotification)
+	}
+}
+
+// /show_changes reads back what it stores, and off clears it.
+func TestShowChangesCommand(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	reply := func(arguments string) string {
+		w.setShowChanges(testMessage(w, 1, "show_changes", 100), arguments)
+		queued := w.sendQueue.pop()
+		queued.message.render("")
+		return queued.message.(*messageParams).Text
+	}
+	alerts := func() int {
+		user, _ := w.db.User(1)
+		return user.ShowKindAlerts
+	}
+	if got := reply("private away"); got != "ShowChanges" {
+		t.Errorf("setting alerts answered %q", got)
+	}
+	if got := alerts(); got != showKindAlertPrivate|showKindAlertAway {
+		t.Errorf("stored alerts %d", got)
+	}
+	if got := reply("group"); got != "ShowChangesInvalid" {
+		t.Errorf("a bad class answered %q", got)
+	}
+	if got := alerts(); got != showKindAlertPrivate|showKindAlertAway {
+		t.Errorf("a bad class changed the alerts to %d", got)
+	}
+	reply("off")
+	if got := alerts(); got != 0 {
+		t.Errorf("off left alerts %d", got)
+	}
+}
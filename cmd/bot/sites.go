@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/bcmk/siren/v4/internal/botconfig"
+	"github.com/bcmk/siren/v4/internal/checkers"
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// siteSeparator splits a streamer of a site other than the default from its nickname,
+// as in twitch:bob.
+const siteSeparator = ":"
+
+// site is one checker the bot serves, with what the main loop keeps for it alone.
+type site struct {
+	name    string
+	checker checkers.Checker
+	// affiliateBase is the site's affiliate_base without its trailing slash.
+	affiliateBase string
+	// legacyAffiliateTpl is the affiliate_link template, which only the default site has.
+	legacyAffiliateTpl         *texttemplate.Template
+	unconfirmedOnlineStreamers map[string]cmdlib.StreamerInfo
+	// checkerResults and existenceListResults are the site's own result channels,
+	// forwarded to the main loop tagged with the site.
+	checkerResults       chan cmdlib.CheckerResults
+	existenceListResults chan *cmdlib.ExistenceListResults
+	// inlineResults brings the statuses looked up for inline queries.
+	inlineResults chan *cmdlib.ExistenceListResults
+	// inlineStatuses keeps those statuses for inlineStatusTTL,
+	// and pendingInline holds the inline queries waiting for one, by nickname.
+	inlineStatuses map[string]inlineStatus
+	pendingInline  map[string][]pendingInlineQuery
+
+	// heldOnlineLists counts the site's online lists held as truncated in a row.
+	heldOnlineLists int
+	// onlineCounts are the counts of the site's latest believed online lists, oldest first.
+	// The site's first poll reads them from performance_log and sets onlineCountsLoaded;
+	// every poll after keeps them in memory.
+	onlineCounts       []db.OnlineCount
+	onlineCountsLoaded bool
+}
+
+// siteCheckerResults is an online result tagged with the site it came from.
+type siteCheckerResults struct {
+	site   *site
+	result cmdlib.CheckerResults
+}
+
+// siteExistenceResults is an existence result tagged with the site it came from.
+type siteExistenceResults struct {
+	site   *site
+	result *cmdlib.ExistenceListResults
+}
+
+func newSite(name string, checker checkers.Checker, affiliateBase string) *site {
+	return &site{
+		name:                       name,
+		checker:                    checker,
+		affiliateBase:              strings.TrimRight(affiliateBase, "/"),
+		unconfirmedOnlineStreamers: map[string]cmdlib.StreamerInfo{},
+		checkerResults:             make(chan cmdlib.CheckerResults),
+		existenceListResults:       make(chan *cmdlib.ExistenceListResults),
+		inlineResults:              make(chan *cmdlib.ExistenceListResults),
+		inlineStatuses:             map[string]inlineStatus{},
+		pendingInline:              map[string][]pendingInlineQuery{},
+	}
+}
+
+// buildSites makes the default site from website and one more for every configured site.
+// extra holds the checkers of cfg.Sites, keyed alike.
+func buildSites(
+	cfg *botconfig.Config,
+	checker checkers.Checker,
+	extra map[string]checkers.Checker,
+) (map[string]*site, *site) {
+	def := newSite(cfg.Website, checker, cfg.AffiliateBase)
+	// A standalone template: affiliate_link gets no translation func or named template.
+	if cfg.AffiliateLink != "" {
+		def.legacyAffiliateTpl = texttemplate.Must(texttemplate.New("affiliate_link").Parse(cfg.AffiliateLink))
+	}
+	sites := map[string]*site{def.name: def}
+	for name, c := range extra {
+		sites[name] = newSite(name, c, cfg.Sites[name].AffiliateBase)
+	}
+	return sites, def
+}
+
+// parseStreamer splits a streamer a chat typed into its site and preprocessed nickname.
+// Only a configured site name counts as a prefix,
+// so a link like https://… still reaches the default site's preprocessing whole.
+// The caller validates the nickname against the returned site's checker.
+func (w *worker) parseStreamer(input string) (*site, string) {
+	s := w.defaultSite
+	if name, rest, found := strings.Cut(input, siteSeparator); found {
+		if named, ok := w.sites[strings.ToLower(name)]; ok {
+			s, input = named, rest
+		}
+	}
+	return s, s.checker.NicknamePreprocessing(input)
+}
+
+// qualifiedName is how a chat sees a streamer:
+// the bare nickname on the default site, site:nickname elsewhere.
+// parseStreamer reads it back.
+func (w *worker) qualifiedName(siteName, nickname string) string {
+	if siteName == w.defaultSite.name {
+		return nickname
+	}
+	return siteName + siteSeparator + nickname
+}
+
+// forwardSiteResults tags a site's checker results and hands them to the main loop
+// until ctx is done.
+func (w *worker) forwardSiteResults(ctx context.Context, s *site) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r := <-s.checkerResults:
+			select {
+			case w.checkerResults <- siteCheckerResults{site: s, result: r}:
+			case <-ctx.Done():
+				return
+			}
+		case r := <-s.existenceListResults:
+			select {
+			case w.existenceListResults <- siteExistenceResults{site: s, result: r}:
+			case <-ctx.Done():
+				return
+			}
+		case r := <-s.inlineResults:
+			select {
+			case w.inlineResults <- siteExistenceResults{site: s, result: r}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// onlineInfo is what the last online list said of a streamer,
+// empty for a streamer not in it or of a site dropped from the config.
+func (w *worker) onlineInfo(siteName, nickname string) cmdlib.StreamerInfo {
+	if s := w.sites[siteName]; s != nil {
+		return s.unconfirmedOnlineStreamers[nickname]
+	}
+	return cmdlib.StreamerInfo{}
+}
+
+// anySiteSupportsSubject reports whether some site reports a room subject,
+// so a chat following that site can turn it on.
+func (w *worker) anySiteSupportsSubject() bool {
+	for _, s := range w.sites {
+		if s.checker.Capabilities().SupportsSubject {
+			return true
+		}
+	}
+	return false
+}
+
+// anySiteSupportsShowKind reports whether some site reports a show kind,
+// so a chat following that site can be alerted to its changes.
+func (w *worker) anySiteSupportsShowKind() bool {
+	for _, s := range w.sites {
+		if s.checker.Capabilities().SupportsShowKind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+// Subject alerts: a chat may opt in to hearing when a streamer it follows changes the room subject while online.
+// A subject is compared by its words alone, so a ticking token count or a new emoji is no change.
+// A change is confirmed once it has held for subject_change_seconds,
+// so a run of quick edits is alerted once, as its last,
+// and every confirmed subject is kept in subject_changes.
+
+package main
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// subjectWords reduces a subject to its lowercased words, dropping digits, punctuation and emoji.
+func subjectWords(subject string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(subject), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ")
+}
+
+// subjectChanged reports whether two subjects differ in their words.
+func subjectChanged(prev, cur string) bool {
+	return subjectWords(prev) != subjectWords(cur)
+}
+
+// subjectChanges lists the subjects of the streamers online after:
+// the first one of a streamer gone online, and a changed one of a streamer online before.
+func subjectChanges(before, after map[string]cmdlib.StreamerInfo) []db.SubjectChange {
+	var changes []db.SubjectChange
+	for nickname, info := range after {
+		prev, wasOnline := before[nickname]
+		switch {
+		case !wasOnline:
+			changes = append(changes, db.SubjectChange{Nickname: nickname, Subject: info.Subject, Started: true})
+		case subjectChanged(prev.Subject, info.Subject):
+			changes = append(changes, db.SubjectChange{Nickname: nickname, Subject: info.Subject})
+		}
+	}
+	return changes
+}
+
+// subjectAlerted reports whether a confirmed change is worth an alert.
+// A subject cleared says nothing, nor does one edited back to the words it had, give or take an emoji.
+func subjectAlerted(c db.ConfirmedSubjectChange) bool {
+	return subjectWords(c.Subject) != "" && subjectChanged(c.PrevSubject, c.Subject)
+}
+
+// buildSubjectNotifications alerts the chats that asked for it to confirmed subject changes.
+// A subscription filter judges starts alone, so it holds none of these back.
+func (w *worker) buildSubjectNotifications(changes []db.ConfirmedSubjectChange) []db.Notification {
+	var streamerIDs []int
+	for _, c := range changes {
+		if subjectAlerted(c) {
+			streamerIDs = append(streamerIDs, c.StreamerID)
+		}
+	}
+	if len(streamerIDs) == 0 {
+		return nil
+	}
+	var notifications []db.Notification
+	usersForStreamers, endpointsForStreamers, _, favouritesForStreamers := w.db.UsersForStreamers(
+		streamerIDs, int(time.Now().Unix()))
+	for _, c := range changes {
+		if !subjectAlerted(c) {
+			continue
+		}
+		endpoints := endpointsForStreamers[c.StreamerID]
+		favourites := favouritesForStreamers[c.StreamerID]
+		for i, user := range usersForStreamers[c.StreamerID] {
+			if !user.SubjectAlerts {
+				continue
+			}
+			notifications = append(notifications, db.Notification{
+				Endpoint:   endpoints[i],
+				UserID:     user.UserID,
+				StreamerID: &c.StreamerID,
+				Site:       c.Site,
+				Nickname:   c.Nickname,
+				Status:     cmdlib.StatusOnline,
+				Subject:    c.Subject,
+				Sound:      true,
+				Priority:   db.PriorityLow,
+				Favourite:  favourites[i],
+				Kind:       db.SubjectPacket,
+			})
+		}
+	}
+	return notifications
+}
+
+// subjectTranslation is the message of a subject alert, nil where the endpoint has none.
+func (w *worker) subjectTranslation(endpoint string) *cmdlib.Translation {
+	tr := w.tr[endpoint]
+	if tr == nil {
+		return nil
+	}
+	return tr.SubjectChange
+}
+
+func (w *worker) enableSubjectAlerts(m receivedMessage, subjectAlerts bool) {
+	w.db.SetSubjectAlerts(m.userID, subjectAlerts)
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].OK, nil)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestSubjectChanged(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		prev string
+		cur  string
+		want bool
+	}{
+		{"Dance party", "dance PARTY!", false},
+		{"Goal: 100 tokens", "Goal: 250 tokens", false},
+		{"hello 💋", "hello 🔥🔥", false},
+		{"hello", "hello world", true},
+		{"", "hello", true},
+		{"hello", "", true},
+		{"Привет всем", "привет, всем!", false},
+	}
+	for _, tc := range tests {
+		if got := subjectChanged(tc.prev, tc.cur); got != tc.want {
+			t.Errorf("subjectChanged(%q, %q) = %v, want %v", tc.prev, tc.cur, got, tc.want)
+		}
+	}
+}
+
+func TestSubjectChanges(t *testing.T) {
+	t.Parallel()
+	before := map[string]cmdlib.StreamerInfo{
+		"a": {Subject: "dance"},
+		"b": {Subject: "goal 10"},
+		"c": {Subject: "chat"},
+	}
+	after := map[string]cmdlib.StreamerInfo{
+		"a": {Subject: "sing"},
+		"b": {Subject: "goal 20"},
+		"d": {Subject: "hi"},
+	}
+	got := map[string]db.SubjectChange{}
+	for _, c := range subjectChanges(before, after) {
+		got[c.Nickname] = c
+	}
+	want := map[string]db.SubjectChange{
+		"a": {Nickname: "a", Subject: "sing"},
+		"d": {Nickname: "d", Subject: "hi", Started: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("changes = %+v, want %+v", got, want)
+	}
+	for nickname, c := range want {
+		if got[nickname] != c {
+			t.Errorf("change of %s = %+v, want %+v", nickname, got[nickname], c)
+		}
+	}
+}
+
+func TestSubjectTemplatesRender(t *testing.T) {
+	t.Parallel()
+	settings := settingsData(false)
+	settings["subject_alerts"] = true
+	assertTemplatesRender(t, []templateCase{
+		{"change", "subject_change", tplData{"streamer_link": "alice", "subject": "dance"}, "dance", true},
+		{"clipped", "subject_change", tplData{"streamer_link": "alice", "subject": "dance", "subject_clipped": true}, "…", true},
+		{"settings on", "settings", settings, "disable_subject_alerts", true},
+		{"settings off", "settings", settingsData(false), "enable_subject_alerts", true},
+		{
+			"settings without subjects", "settings",
+			func() tplData {
+				data := settingsData(false)
+				data["subject_supported"] = false
+				return data
+			}(),
+			"subject_alerts", false,
+		},
+	})
+}
+
+// A session's first subject is recorded as the streamer goes online and alerts no one,
+// a run of edits is confirmed as its last once it holds,
+// and only the chats that asked for it hear of the change.
+func TestSubjectAlerts(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	streamerID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 2, "a")
+	user, _ := w.db.User(1)
+	w.db.SetSubjectAlerts(user.UserID, true)
+
+	const stable = 60
+	confirm := func(now int) []db.Notification {
+		w.db.ConfirmStatusChanges(now, 0, 0)
+		return w.buildSubjectNotifications(w.db.ConfirmSubjectChanges(now, stable))
+	}
+
+	w.db.UpsertUnconfirmedStatusChanges(testSite, []db.StatusChange{
+		{Nickname: "a", Status: cmdlib.StatusOnline, Timestamp: 100},
+	}, nil, 100)
+	w.db.UpsertUnconfirmedSubjects(testSite, []db.SubjectChange{{Nickname: "a", Subject: "hello", Started: true}}, 100)
+	if alerted := confirm(100); len(alerted) != 0 {
+		t.Errorf("going online alerted %d subject changes", len(alerted))
+	}
+
+	w.db.UpsertUnconfirmedSubjects(testSite, []db.SubjectChange{{Nickname: "a", Subject: "dance"}}, 110)
+	w.db.UpsertUnconfirmedSubjects(testSite, []db.SubjectChange{{Nickname: "a", Subject: "dance & sing"}}, 120)
+	if alerted := confirm(150); len(alerted) != 0 {
+		t.Errorf("an edit not yet held alerted %d chats", len(alerted))
+	}
+	alerted := confirm(180)
+	if len(alerted) != 1 || alerted[0].UserID != user.UserID || alerted[0].Subject != "dance & sing" {
+		t.Errorf("the held edit alerted %+v, want chat 1 alone of the last subject", alerted)
+	}
+	if alerted := confirm(300); len(alerted) != 0 {
+		t.Errorf("a confirmed subject alerted again, %d chats", len(alerted))
+	}
+
+	history := w.db.SubjectsFromTo(streamerID, 0, 1000)
+	if len(history) != 2 || history[0] != (db.HistoricSubject{Subject: "hello", Timestamp: 100}) ||
+		history[1] != (db.HistoricSubject{Subject: "dance & sing", Timestamp: 180}) {
+		t.Errorf("history = %+v, want the first subject and the held edit", history)
+	}
+	if history := w.db.SubjectsFromTo(streamerID, 150, 1000); len(history) != 2 || history[0].Subject != "hello" {
+		t.Errorf("a window from 150 = %+v, want it led by the subject before it", history)
+	}
+}
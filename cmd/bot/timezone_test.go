@@ -356,11 +356,18 @@ func settingsData(timezoneSet bool) tplData {
 		"offline_notifications":           true,
 		"subject_supported":               true,
 		"show_subject":                    true,
+		"subject_alerts":                  false,
+		"live_messages":                   false,
+		"digest":                          "",
 		"silent_messages":                 false,
 		"in_group":                        false,
 		"member_subscriptions":            false,
 		"timezone":                        "Europe/Berlin",
 		"timezone_set":                    timezoneSet,
+		"quiet_hours":                     "",
+		"quiet_summary":                   false,
+		"show_kinds_supported":            true,
+		"show_kind_alerts":                "",
 		"can_manage_affiliate":            false,
 		"affiliate_params":                nil,
 	}
@@ -0,0 +1,184 @@
+// Forum topics: in a supergroup with topics, /topic sent in a topic routes the chat's alerts there,
+// and /topic alice routes alice's alone. Sent in the General topic, it routes them back.
+// /enable_topic_per_streamer opens a topic for each streamer on its first alert instead.
+// An alert to a closed or deleted topic falls back to the General one,
+// and a deleted topic is forgotten, so the next alerts go there too.
+// Only status alerts are routed: a reply goes where Telegram puts it, the General topic.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/bcmk/siren/v4/internal/db"
+)
+
+// forumTopicTimeout caps opening a topic, which holds the endpoint's send slot.
+const forumTopicTimeout = 5 * time.Second
+
+// forumTopic is where in a forum an alert goes.
+type forumTopic struct {
+	// threadID is the topic, zero for the General one.
+	// With open set, it is where the alert goes should opening fail.
+	threadID int
+	// open names the topic to open for the streamer, empty for none.
+	open       string
+	streamerID int
+}
+
+// topicOutcome is what a send learned of the chat's topics, for the main loop to store.
+type topicOutcome struct {
+	streamerID int
+	// opened is the topic opened for the streamer, zero for none.
+	opened int
+	// refused marks a chat that would not open one, which stops asking it.
+	refused bool
+	// gone is a deleted topic the alert fell back from, zero for none.
+	gone int
+}
+
+// forumPlace reads the forum a message came from:
+// whether its chat has topics, and its topic, zero for the General one.
+// A reply thread outside a forum is no topic.
+func forumPlace(msg *models.Message) (forum bool, threadID int) {
+	if msg.IsTopicMessage {
+		threadID = msg.MessageThreadID
+	}
+	return msg.Chat.IsForum, threadID
+}
+
+// alertTopic routes a status alert: to its streamer's topic, to one opened for it,
+// or to the chat's. Anything else, and any alert to Discord, goes to no topic.
+func (w *worker) alertTopic(p plannedNotification) forumTopic {
+	if p.Kind != db.NotificationPacket || !isTelegram(p.Endpoint) || p.StreamerID == nil {
+		return forumTopic{}
+	}
+	switch {
+	case p.StreamerTopic != 0:
+		return forumTopic{threadID: p.StreamerTopic}
+	case p.TopicPerStreamer:
+		return forumTopic{threadID: p.ChatTopic, open: w.qualifiedName(p.Site, p.Nickname), streamerID: *p.StreamerID}
+	}
+	return forumTopic{threadID: p.ChatTopic}
+}
+
+// resolveTopic takes up a topic an earlier alert of the streamer opened,
+// so two alerts fetched before it was do not open one each.
+// It runs at dispatch: the user is single-flight, so the earlier send's outcome is stored by then.
+func (w *worker) resolveTopic(q *queuedMessage) {
+	if q.topic.open == "" {
+		return
+	}
+	if topic := w.db.SubscriptionTopic(q.userID, q.endpoint, q.topic.streamerID); topic != 0 {
+		q.topic = forumTopic{threadID: topic}
+	}
+}
+
+// setThread addresses a message to a forum topic, zero for the General one.
+// An edit keeps the topic of the message it edits.
+func setThread(msg sendable, threadID int) {
+	switch m := msg.(type) {
+	case *messageParams:
+		m.MessageThreadID = threadID
+	case *photoParams:
+		m.MessageThreadID = threadID
+	}
+}
+
+// openTopic opens the topic an alert asks for and addresses the alert to it.
+// It runs on the deliver goroutine; the outcome goes back with the send's result.
+// A failed opening is not retried for this alert, which goes to its fallback topic.
+func (w *worker) openTopic(q *queuedMessage) topicOutcome {
+	name := q.topic.open
+	if name == "" {
+		return topicOutcome{}
+	}
+	q.topic.open = ""
+	outcome := topicOutcome{streamerID: q.topic.streamerID}
+	threadID, err := w.createForumTopic(q.endpoint, q.message.chatID(), name)
+	if err != nil {
+		// A chat without topics or without the right to manage them answers with one of these.
+		outcome.refused = errors.Is(err, bot.ErrorBadRequest) || errors.Is(err, bot.ErrorForbidden)
+		ldbg("cannot open a topic for %s: %v", name, err)
+		return outcome
+	}
+	q.topic.threadID = threadID
+	setThread(q.message, threadID)
+	outcome.opened = threadID
+	return outcome
+}
+
+// postForumTopic opens a forum topic, and is what worker.createForumTopic holds outside tests.
+func (w *worker) postForumTopic(endpoint string, chatID int64, name string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), forumTopicTimeout)
+	defer cancel()
+	topic, err := w.bots[endpoint].CreateForumTopic(ctx, &bot.CreateForumTopicParams{ChatID: chatID, Name: name})
+	if err != nil {
+		return 0, err
+	}
+	return topic.MessageThreadID, nil
+}
+
+// storeTopicOutcome keeps what a send learned of the chat's topics.
+func (w *worker) storeTopicOutcome(r msgSendResult) {
+	t := r.topic
+	if t.opened != 0 {
+		w.db.SetOpenedTopic(r.userID, r.endpoint, t.streamerID, t.opened)
+	}
+	if t.refused {
+		linf("a chat refused to open a topic, topic per streamer turned off: chat = %d", r.chatID)
+		w.db.SetTopicPerStreamer(r.userID, false)
+	}
+	if t.gone != 0 {
+		w.db.ForgetTopic(r.userID, t.gone)
+	}
+}
+
+// setTopic routes the chat's alerts, or with a streamer named that streamer's,
+// to the topic the command is sent in.
+func (w *worker) setTopic(m receivedMessage, arguments string) {
+	if !m.forum {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].TopicForumsOnly, nil)
+		return
+	}
+	parts := strings.Fields(arguments)
+	if len(parts) > 1 {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].SyntaxTopic, nil)
+		return
+	}
+	if len(parts) == 0 {
+		w.db.SetChatTopic(m.userID, m.threadID)
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].Topic, tplData{"in_topic": m.threadID != 0})
+		return
+	}
+	s, nickname := w.parseStreamer(parts[0])
+	name := w.qualifiedName(s.name, nickname)
+	if !s.checker.NicknameRegexp().MatchString(nickname) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].InvalidSymbols, tplData{"streamer": name})
+		return
+	}
+	if !w.db.SetSubscriptionTopic(m.userID, s.name, nickname, m.endpoint, m.threadID) {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].StreamerNotInList, tplData{"streamer": name})
+		return
+	}
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].Topic, tplData{
+		"streamer": name,
+		"in_topic": m.threadID != 0,
+	})
+}
+
+// enableTopicPerStreamer turns opening a topic for each streamer on or off.
+func (w *worker) enableTopicPerStreamer(m receivedMessage, topicPerStreamer bool) {
+	if topicPerStreamer && !m.forum {
+		w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].TopicForumsOnly, nil)
+		return
+	}
+	w.db.SetTopicPerStreamer(m.userID, topicPerStreamer)
+	w.replyTr(m, db.PriorityHigh, false, w.tr[m.endpoint].OK, nil)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/bcmk/siren/v4/internal/checkers"
+	"github.com/bcmk/siren/v4/internal/db"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestForumPlace(t *testing.T) {
+	t.Parallel()
+	forum := models.Chat{ID: -100, Type: models.ChatTypeSupergroup, IsForum: true}
+	if f, thread := forumPlace(&models.Message{Chat: forum, MessageThreadID: 7, IsTopicMessage: true}); !f || thread != 7 {
+		t.Errorf("a topic message read as forum %v, topic %d", f, thread)
+	}
+	if f, thread := forumPlace(&models.Message{Chat: forum}); !f || thread != 0 {
+		t.Errorf("a General message read as forum %v, topic %d", f, thread)
+	}
+	group := models.Chat{ID: -100, Type: models.ChatTypeSupergroup}
+	if f, thread := forumPlace(&models.Message{Chat: group, MessageThreadID: 7}); f || thread != 0 {
+		t.Errorf("a reply thread read as forum %v, topic %d", f, thread)
+	}
+}
+
+func TestAlertTopic(t *testing.T) {
+	t.Parallel()
+	w := &worker{}
+	w.sites, w.defaultSite = buildSites(
+		&testConfig,
+		&checkers.RandomChecker{BaseChecker: checkers.NewBaseChecker(&checkers.TestCheckerConfig{})},
+		nil)
+	streamerID := 5
+	alert := func(n db.Notification) plannedNotification {
+		n.Endpoint = "test"
+		n.StreamerID = &streamerID
+		n.Site = testSite
+		n.Nickname = "alice"
+		if n.Kind == 0 {
+			n.Kind = db.NotificationPacket
+		}
+		return plannedNotification{Notification: n}
+	}
+	cases := []struct {
+		name string
+		p    plannedNotification
+		want forumTopic
+	}{
+		{"no topics", alert(db.Notification{}), forumTopic{}},
+		{"chat topic", alert(db.Notification{ChatTopic: 7}), forumTopic{threadID: 7}},
+		{"streamer topic", alert(db.Notification{ChatTopic: 7, StreamerTopic: 9}), forumTopic{threadID: 9}},
+		{
+			"topic per streamer",
+			alert(db.Notification{ChatTopic: 7, TopicPerStreamer: true}),
+			forumTopic{threadID: 7, open: "alice", streamerID: streamerID},
+		},
+		{
+			"opened topic",
+			alert(db.Notification{ChatTopic: 7, StreamerTopic: 9, TopicPerStreamer: true}),
+			forumTopic{threadID: 9},
+		},
+		{"reply", alert(db.Notification{Kind: db.ReplyPacket, ChatTopic: 7}), forumTopic{}},
+	}
+	for _, c := range cases {
+		if got := w.alertTopic(c.p); got != c.want {
+			t.Errorf("%s: topic = %+v, want %+v", c.name, got, c.want)
+		}
+	}
+	discord := alert(db.Notification{ChatTopic: 7})
+	discord.Endpoint = discordEndpoint
+	if got := w.alertTopic(discord); got != (forumTopic{}) {
+		t.Errorf("a Discord alert was routed to %+v", got)
+	}
+}
+
+func TestOpenTopic(t *testing.T) {
+	t.Parallel()
+	queued := func() *queuedMessage {
+		q := &queuedMessage{
+			endpoint: "test",
+			message:  textMessage("alert", false, false, cmdlib.ParseRaw),
+			topic:    forumTopic{threadID: 7, open: "alice", streamerID: 5},
+		}
+		q.message.setChatID(-100)
+		setThread(q.message, q.topic.threadID)
+		return q
+	}
+
+	w := &worker{}
+	var opened []string
+	w.createForumTopic = func(_ string, chatID int64, name string) (int, error) {
+		opened = append(opened, fmt.Sprintf("%d %s", chatID, name))
+		return 11, nil
+	}
+	q := queued()
+	outcome := w.openTopic(q)
+	if outcome != (topicOutcome{streamerID: 5, opened: 11}) || len(opened) != 1 || opened[0] != "-100 alice" {
+		t.Errorf("outcome = %+v after opening %v", outcome, opened)
+	}
+	if thread := q.message.(*messageParams).MessageThreadID; thread != 11 || q.topic.open != "" {
+		t.Errorf("the alert goes to topic %d, still to open %q", thread, q.topic.open)
+	}
+	if outcome := w.openTopic(q); outcome != (topicOutcome{}) || len(opened) != 1 {
+		t.Errorf("a resend opened another topic: %+v", outcome)
+	}
+
+	w.createForumTopic = func(string, int64, string) (int, error) {
+		return 0, fmt.Errorf("%w, the chat is not a forum", bot.ErrorBadRequest)
+	}
+	q = queued()
+	if outcome := w.openTopic(q); !outcome.refused || outcome.opened != 0 {
+		t.Errorf("a refusal came back as %+v", outcome)
+	}
+	if thread := q.message.(*messageParams).MessageThreadID; thread != 7 {
+		t.Errorf("a refused alert goes to topic %d, want its fallback", thread)
+	}
+
+	w.createForumTopic = func(string, int64, string) (int, error) { return 0, errors.New("timeout") }
+	if outcome := w.openTopic(queued()); outcome.refused {
+		t.Error("a network failure turned the chat's topics off")
+	}
+}
+
+func TestCollapseBurstKeepsTopics(t *testing.T) {
+	t.Parallel()
+	tpl := texttemplate.New("")
+	texttemplate.Must(tpl.New("online").Parse("{{ .name }} online"))
+	s := newSendQueue()
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		q := burstAlert(tpl, i+1, name, false)
+		if i%2 == 1 {
+			q.topic = forumTopic{threadID: 9}
+		}
+		s.push(q)
+	}
+	q := s.pop()
+	s.startCooling(q.userID)
+	q.message.setChatID(-100)
+	if q = s.collapseBurst(q, 2, ""); len(q.burst) != 2 || s.Len() != 2 {
+		t.Errorf("burst = %v with %d left, want the alerts of the General topic alone", q.burst, s.Len())
+	}
+}
+
+func TestTopicTemplatesRender(t *testing.T) {
+	t.Parallel()
+	for _, lang := range realLangs {
+		templates := realTemplates(t, lang)
+		for _, c := range []struct {
+			key  string
+			data tplData
+		}{
+			{"topic", tplData{"in_topic": true}},
+			{"topic", tplData{"in_topic": false}},
+			{"topic", tplData{"streamer": "alice", "in_topic": true}},
+			{"topic", tplData{"streamer": "alice", "in_topic": false}},
+			{"syntax_topic", nil},
+			{"topic_forums_only", nil},
+		} {
+			out := (&renderParams{templates: templates, key: c.key, data: c.data}).render("")
+			if out == "" || strings.Contains(out, "<no value>") {
+				t.Errorf("%s %s with %v rendered %q", lang, c.key, c.data, out)
+			}
+		}
+	}
+}
+
+func TestForumTopics(t *testing.T) {
+	t.Parallel()
+	w := newTestWorker()
+	defer w.terminate()
+	w.createDatabase()
+	w.initCache()
+
+	aID := insertTestStreamer(&w.db, db.Streamer{Nickname: "a"})
+	bID := insertTestStreamer(&w.db, db.Streamer{Nickname: "b"})
+	insertSubscription(&w.db, "test", 1, "a")
+	insertSubscription(&w.db, "test", 1, "b")
+	user, _ := w.db.User(1)
+
+	w.db.SetChatTopic(user.UserID, 7)
+	if !w.db.SetSubscriptionTopic(user.UserID, testSite, "b", "test", 9) {
+		t.Fatal("the subscription to route was not found")
+	}
+	if w.db.SetSubscriptionTopic(user.UserID, testSite, "c", "test", 9) {
+		t.Error("routed a subscription the chat does not have")
+	}
+	topics := func() map[int]forumTopic {
+		w.db.StoreNotifications([]db.Notification{
+			{Endpoint: "test", UserID: user.UserID, StreamerID: &aID, Status: cmdlib.StatusOnline, Kind: db.NotificationPacket},
+			{Endpoint: "test", UserID: user.UserID, StreamerID: &bID, Status: cmdlib.StatusOnline, Kind: db.NotificationPacket},
+		})
+		result := map[int]forumTopic{}
+		for _, n := range w.db.NewNotifications() {
+			result[*n.StreamerID] = w.alertTopic(plannedNotification{Notification: n})
+			w.db.DeleteNotification(n.ID)
+		}
+		return result
+	}
+	if got := topics(); got[aID] != (forumTopic{threadID: 7}) || got[bID] != (forumTopic{threadID: 9}) {
+		t.Errorf("topics = %+v, want a in the chat's and b in its own", got)
+	}
+
+	w.db.SetTopicPerStreamer(user.UserID, true)
+	if user, _ := w.db.User(1); user.ForumTopic != 7 || !user.TopicPerStreamer {
+		t.Errorf("chat settings read back as topic %d, per streamer %v", user.ForumTopic, user.TopicPerStreamer)
+	}
+	if got := topics(); got[aID] != (forumTopic{threadID: 7, open: "a", streamerID: aID}) {
+		t.Errorf("a goes to %+v, want a topic opened for it", got[aID])
+	}
+
+	w.storeTopicOutcome(msgSendResult{endpoint: "test", userID: user.UserID, topic: topicOutcome{streamerID: aID, opened: 11}})
+	q := &queuedMessage{userID: user.UserID, endpoint: "test", topic: forumTopic{threadID: 7, open: "a", streamerID: aID}}
+	w.resolveTopic(q)
+	if q.topic != (forumTopic{threadID: 11}) {
+		t.Errorf("a second alert goes to %+v, want the topic the first opened", q.topic)
+	}
+
+	w.storeTopicOutcome(msgSendResult{endpoint: "test", userID: user.UserID, topic: topicOutcome{gone: 11}})
+	w.storeTopicOutcome(msgSendResult{endpoint: "test", userID: user.UserID, topic: topicOutcome{gone: 7}})
+	if topic := w.db.SubscriptionTopic(user.UserID, "test", aID); topic != 0 {
+		t.Errorf("a deleted topic is still a's: %d", topic)
+	}
+	if user, _ := w.db.User(1); user.ForumTopic != 0 {
+		t.Errorf("a deleted topic is still the chat's: %d", user.ForumTopic)
+	}
+	if topic := w.db.SubscriptionTopic(user.UserID, "test", bID); topic != 9 {
+		t.Errorf("b lost its topic to another's deletion: %d", topic)
+	}
+
+	w.storeTopicOutcome(msgSendResult{endpoint: "test", userID: user.UserID, topic: topicOutcome{streamerID: aID, refused: true}})
+	if user, _ := w.db.User(1); user.TopicPerStreamer {
+		t.Error("a chat refusing to open topics is still asked to")
+	}
+}
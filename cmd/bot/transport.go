@@ -0,0 +1,129 @@
+// Transports carry a dispatched message to where its endpoint delivers:
+// a Telegram bot for a configured endpoint, Discord webhooks for discordEndpoint.
+// The scheduling in sender.go is the same for both;
+// a transport only sends and names the pacing its destination asks for.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// transport sends one rendered message and classifies the outcome.
+// It runs on a deliver goroutine and touches no shared state.
+type transport interface {
+	// send returns a message* result, the chat a group migrated to for messageMigrate,
+	// and the requested pause in seconds for messageTooManyRequests.
+	send(ctx context.Context, msg sendable) (result int, migrateTo int64, retryAfter int)
+	// userCooldown is the minimum gap between two sends to one user of the endpoint.
+	userCooldown(chatID int64) time.Duration
+}
+
+// telegramSendable is a message the Telegram transport can send.
+type telegramSendable interface {
+	sendable
+	sendTelegram(ctx context.Context, b *bot.Bot) (*models.Message, error)
+}
+
+// telegramTransport sends through one endpoint's bot.
+type telegramTransport struct {
+	bot *bot.Bot
+}
+
+// transport returns the endpoint's transport.
+// A Telegram one is built per call around the endpoint's bot,
+// so a test swapping w.bots swaps the transport too.
+func (w *worker) transport(endpoint string) transport {
+	if endpoint == discordEndpoint {
+		return w.discord
+	}
+	return telegramTransport{bot: w.bots[endpoint]}
+}
+
+// isTelegram reports whether the endpoint is a Telegram bot,
+// the only kind with chats the bot can query or commands it receives.
+func isTelegram(endpoint string) bool {
+	return endpoint != discordEndpoint
+}
+
+// A group or channel caps tighter than the 1s per-user gap, at 20 messages/min,
+// so pace it slower to avoid self-triggering a 429.
+func (t telegramTransport) userCooldown(chatID int64) time.Duration {
+	if isGroupOrChannel(chatID) {
+		return groupCooldown
+	}
+	return userCooldown
+}
+
+func (t telegramTransport) send(ctx context.Context, msg sendable) (result int, migrateTo int64, retryAfter int) {
+	m, ok := msg.(telegramSendable)
+	if !ok {
+		panic("a message Telegram cannot send")
+	}
+	chatID := m.chatID()
+	if _, err := m.sendTelegram(ctx, t.bot); err != nil {
+		var migrateErr *bot.MigrateError
+		if errors.As(err, &migrateErr) {
+			ldbg("cannot send a message, group migration")
+			// The library builds a MigrateError only around a set target, never a zero.
+			return messageMigrate, int64(migrateErr.MigrateToChatID), 0
+		}
+		var tooManyErr *bot.TooManyRequestsError
+		if errors.As(err, &tooManyErr) {
+			ldbg("cannot send a message, too many requests: retry_after = %d", tooManyErr.RetryAfter)
+			return messageTooManyRequests, 0, tooManyErr.RetryAfter
+		}
+		if errors.Is(err, bot.ErrorForbidden) {
+			ldbg("cannot send a message, bot blocked")
+			return messageBlocked, 0, 0
+		}
+		if errors.Is(err, bot.ErrorBadRequest) {
+			if strings.Contains(err.Error(), "chat not found") {
+				ldbg("cannot send a message, chat not found")
+				return messageChatNotFound, 0, 0
+			}
+			if strings.Contains(err.Error(), "not enough rights to send photos") {
+				ldbg("cannot send a message, no photo rights")
+				return messageNoPhotoRights, 0, 0
+			}
+			if strings.Contains(err.Error(), "not enough rights to send text messages") {
+				ldbg("cannot send a message, no text rights")
+				return messageNoTextRights, 0, 0
+			}
+			if strings.Contains(err.Error(), "message is not modified") {
+				// An edit to what the message already shows is as good as made.
+				ldbg("edited a message to what it already was")
+				return messageSent, 0, 0
+			}
+			if strings.Contains(err.Error(), "TOPIC_CLOSED") {
+				ldbg("cannot send a message, topic closed")
+				return messageTopicClosed, 0, 0
+			}
+			if strings.Contains(err.Error(), "message thread not found") || strings.Contains(err.Error(), "TOPIC_DELETED") {
+				ldbg("cannot send a message, topic deleted")
+				return messageTopicDeleted, 0, 0
+			}
+			lerr("cannot send a message, bad request, error: %v", err)
+			return messageBadRequest, 0, 0
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			if netErr.Timeout() {
+				ldbg("cannot send a message, timeout")
+				return messageTimeout, 0, 0
+			}
+			lerr("cannot send a message, unknown network error")
+			return messageUnknownNetworkError, 0, 0
+		}
+		lerr("unexpected error type while sending a message to %d, %v", chatID, err)
+		return messageUnknownError, 0, 0
+	}
+	return messageSent, 0, 0
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -62,7 +63,7 @@ func main() {
 		fmt.Println("invalid model ID")
 		return
 	}
-	info, err := checker.QueryStatus(modelID)
+	info, err := checker.QueryStatus(context.Background(), modelID)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// config is the daemon's runtime configuration. Loading mirrors adapter-mfc:
+// JSON file plus optional dev override, with XRN_-prefixed env overrides.
+// The checker's own settings, proxies included, stay in <site>-checker.json.
+type config struct {
+	Debug         bool   `mapstructure:"debug"`
+	Trace         bool   `mapstructure:"trace"`
+	ListenAddress string `mapstructure:"listen_address"`
+	// Site is the checker to run, as named in the bot's website setting.
+	Site string `mapstructure:"site"`
+	// CheckerConfig is the path to <site>-checker.json, empty for the default search.
+	CheckerConfig string `mapstructure:"checker_config"`
+	// PeriodSeconds is how often the online list is polled.
+	// Match the bots' period_seconds, or they see the same list twice.
+	PeriodSeconds int `mapstructure:"period_seconds"`
+	// StatusTimeoutSeconds caps how long /status waits for its turn in the checker's queue and the answer.
+	StatusTimeoutSeconds int `mapstructure:"status_timeout_seconds"`
+}
+
+var cfgPath = pflag.StringP("config", "c", "", "path to a config file (overrides default search)")
+
+func readConfig() *config {
+	pflag.Parse()
+
+	type cfgFile struct {
+		name     string
+		required bool
+	}
+	files := []cfgFile{
+		{"siren-checkerd.json", false},
+		{"siren-checkerd.dev.ignore.json", false},
+	}
+	if *cfgPath != "" {
+		files = []cfgFile{{*cfgPath, true}}
+	}
+
+	v := viper.New()
+	v.SetConfigType("json")
+	for _, f := range files {
+		v.SetConfigFile(f.name)
+		if err := v.MergeInConfig(); err != nil {
+			if errors.Is(err, fs.ErrNotExist) && !f.required {
+				cmdlib.Linf("skip config %q", f.name)
+				continue
+			}
+			cmdlib.Lfatalf("error reading %q: %v", f.name, err)
+		}
+		cmdlib.Linf("successfully read config %q", f.name)
+	}
+
+	v.SetEnvPrefix("XRN")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	cfg := &config{
+		PeriodSeconds:        60,
+		StatusTimeoutSeconds: 20,
+	}
+	cmdlib.BindEnvForConfig(v, cfg)
+	cmdlib.CheckErr(v.Unmarshal(cfg, cmdlib.StrictConfigDecoder))
+
+	if cfg.ListenAddress == "" {
+		cmdlib.Lfatalf("configure listen_address")
+	}
+	if cfg.Site == "" {
+		cmdlib.Lfatalf("configure site")
+	}
+	if cfg.PeriodSeconds <= 0 {
+		cmdlib.Lfatalf("configure period_seconds")
+	}
+	if cfg.StatusTimeoutSeconds <= 0 {
+		cmdlib.Lfatalf("configure status_timeout_seconds")
+	}
+	return cfg
+}
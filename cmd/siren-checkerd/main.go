@@ -0,0 +1,268 @@
+// Package main implements siren-checkerd:
+// a daemon that runs any site checker built by checkers.Build and serves its online list
+// over the HTTP protocol OnlineListAdapter consumes, the one adapter-mfc speaks for MyFreeCams.
+// Bots reading one siren-checkerd share its upstream polling and its proxy budget:
+// a bot's <site>-checker.json setting base_url to the daemon reads the site from it.
+//
+// The checker runs under checkers.StartCheckerDaemon, so the polls and the /status lookups
+// share its queue and are paced by its min_request_interval like a bot's own.
+//
+// HTTP routes:
+//   - GET /online   — the latest online list as OnlineListResults JSON. Always 200;
+//     until the first poll lands, and while the latest one failed, the body has failed=true,
+//     so the caller tells a failed upstream from a failed daemon (5xx).
+//   - GET /status?name=<name> — the streamer's cmdlib.StreamerInfoWithStatus from the checker's QueryStatus.
+//     Returns 400 on a missing or malformed name, 501 for a site without status queries,
+//     503 when the checker's queue is full, 504 when no answer comes within status_timeout_seconds,
+//     and 502 when the checker cannot tell.
+//   - GET /healthz  — liveness probe; always 200 "ok" while the process is
+//     responsive. Readiness is signalled by /online's failed flag.
+//   - GET /version  — the build's cmdlib.Version string.
+//   - GET /metrics  — the checker's metrics in the Prometheus exposition format.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/checkers"
+	"github.com/bcmk/siren/v4/internal/metrics"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+const (
+	httpReadTimeout     = 10 * time.Second
+	httpShutdownTimeout = 5 * time.Second
+	// httpWriteMargin is what the write timeout leaves a /status answer past its lookup.
+	httpWriteMargin = 10 * time.Second
+)
+
+func main() {
+	cfg := readConfig()
+	switch {
+	case cfg.Trace:
+		cmdlib.Verbosity = cmdlib.TraceVerbosity
+	case cfg.Debug:
+		cmdlib.Verbosity = cmdlib.DbgVerbosity
+	default:
+		cmdlib.Verbosity = cmdlib.InfVerbosity
+	}
+
+	checker, err := checkers.Build(cfg.Site, cfg.CheckerConfig)
+	if err != nil {
+		cmdlib.Lfatalf("%v", err)
+	}
+	if !checker.Capabilities().SupportsQueryOnlineStreamers {
+		cmdlib.Lfatalf("%s has no online list to serve", cfg.Site)
+	}
+
+	rootCtx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := runDaemon(rootCtx, cfg, checker); err != nil {
+		cmdlib.Lerr("%v", err)
+		os.Exit(1)
+	}
+}
+
+// onlineCache holds the latest online list, marshalled once per poll and served as is.
+type onlineCache struct {
+	body atomic.Pointer[[]byte]
+}
+
+func newOnlineCache() *onlineCache {
+	c := &onlineCache{}
+	c.store(cmdlib.NewOnlineListResultsFailed())
+	return c
+}
+
+func (c *onlineCache) store(result *cmdlib.OnlineListResults) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		cmdlib.Lerr("marshal online list: %v", err)
+		body = []byte(`{"streamers":null,"duration":0,"failed":true}`)
+	}
+	c.body.Store(&body)
+}
+
+func (c *onlineCache) load() []byte {
+	return *c.body.Load()
+}
+
+// runDaemon runs the checker, the poller and the HTTP server. It derives an
+// internal context from parentCtx so that an http listen failure also stops
+// the checker and the poller. Returns nil on graceful shutdown,
+// or the http listen error otherwise.
+func runDaemon(parentCtx context.Context, cfg *config, checker checkers.Checker) error {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	cache := newOnlineCache()
+	checkers.StartCheckerDaemon(ctx, checker)
+
+	statusTimeout := time.Duration(cfg.StatusTimeoutSeconds) * time.Second
+	srv := &http.Server{
+		Addr:         cfg.ListenAddress,
+		Handler:      buildMux(cache, checker, statusTimeout),
+		ReadTimeout:  httpReadTimeout,
+		WriteTimeout: statusTimeout + httpWriteMargin,
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runPoller(ctx, checker, cache, time.Duration(cfg.PeriodSeconds)*time.Second)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer shutdownCancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	cmdlib.Linf("serving %s on %s", checker.Site(), cfg.ListenAddress)
+	err := srv.ListenAndServe()
+	cancel()
+	wg.Wait()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return fmt.Errorf("http server, %w", err)
+}
+
+// runPoller queries the online list every period and caches each result, a failed one included:
+// the bots reading it see a failing upstream as they would polling it themselves.
+// A poll still running at the next tick delays it rather than queueing another.
+func runPoller(ctx context.Context, checker checkers.Checker, cache *onlineCache, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		pollOnline(ctx, checker, cache)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func pollOnline(ctx context.Context, checker checkers.Checker, cache *onlineCache) {
+	// Buffered, so the checker never blocks on a result the poller stopped waiting for.
+	resultsCh := make(chan cmdlib.CheckerResults, 1)
+	if err := checker.PushStatusRequest(&cmdlib.OnlineListRequest{ResultsCh: resultsCh}); err != nil {
+		cmdlib.Lerr("cannot queue an online list request, %v", err)
+		return
+	}
+	var results cmdlib.CheckerResults
+	select {
+	case <-ctx.Done():
+		return
+	case results = <-resultsCh:
+	}
+	online, ok := results.(*cmdlib.OnlineListResults)
+	if !ok {
+		panic("an online list request answered with other results")
+	}
+	if online.Failed() {
+		cmdlib.Lerr("online list query failed")
+	} else {
+		cmdlib.Ldbg("online list: %d streamers in %v", online.Count(), online.Duration())
+	}
+	cache.store(online)
+}
+
+// buildMux wires up the HTTP routes. All routes are read-only and accept
+// GET (and HEAD, which net/http handles for free); other methods get 405.
+func buildMux(cache *onlineCache, checker checkers.Checker, statusTimeout time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/online", getOnly(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(cache.load())
+	}))
+	mux.HandleFunc("/status", getOnly(handleStatus(checker, statusTimeout)))
+	mux.HandleFunc("/healthz", getOnly(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	}))
+	mux.HandleFunc("/version", getOnly(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintln(w, cmdlib.Version)
+	}))
+	mux.Handle("/metrics", metrics.Handler())
+	return mux
+}
+
+// handleStatus answers GET /status?name=<name> by queueing a single status request on the checker.
+// The name comes canonical from the adapter, so it is validated, not preprocessed.
+// A StatusUnknown answer is the checker failing, and surfaces as 502,
+// which the adapter maps back to StatusUnknown.
+func handleStatus(checker checkers.Checker, timeout time.Duration) http.HandlerFunc {
+	supported := checker.Capabilities().SupportsQueryStatus
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !supported {
+			http.Error(w, "status queries not supported", http.StatusNotImplemented)
+			return
+		}
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "missing name", http.StatusBadRequest)
+			return
+		}
+		if !checker.NicknameRegexp().MatchString(name) {
+			http.Error(w, "invalid name", http.StatusBadRequest)
+			return
+		}
+		// Buffered, so the checker never blocks on a result the handler stopped waiting for.
+		resultsCh := make(chan *cmdlib.ExistenceListResults, 1)
+		err := checker.PushStatusRequest(&cmdlib.SingleStatusRequest{Streamer: name, ResultsCh: resultsCh})
+		if err != nil {
+			http.Error(w, "queue is full", http.StatusServiceUnavailable)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		var results *cmdlib.ExistenceListResults
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				http.Error(w, "lookup timeout", http.StatusGatewayTimeout)
+				return
+			}
+			// 499: nginx's Client Closed Request (no stdlib constant).
+			http.Error(w, "client cancelled", 499)
+			return
+		case results = <-resultsCh:
+		}
+		info, ok := results.Streamers[name]
+		if results.Failed() || !ok || info.Status == cmdlib.StatusUnknown {
+			http.Error(w, "lookup failed", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	}
+}
+
+// getOnly rejects requests with methods other than GET or HEAD, advertising
+// the allowed set per RFC 9110 § 10.2.1.
+func getOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+}
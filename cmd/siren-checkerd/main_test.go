@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bcmk/siren/v4/internal/checkers"
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// fakeChecker answers each status request with answer, or never when answer is nil.
+// The methods the handlers do not call are left to the nil embedded Checker.
+type fakeChecker struct {
+	checkers.Checker
+	noStatus bool
+	full     bool
+	answer   *cmdlib.ExistenceListResults
+}
+
+func (c *fakeChecker) Capabilities() checkers.Capabilities {
+	return checkers.Capabilities{SupportsQueryOnlineStreamers: true, SupportsQueryStatus: !c.noStatus}
+}
+
+func (c *fakeChecker) NicknameRegexp() *regexp.Regexp { return regexp.MustCompile(`^[a-z0-9_]+$`) }
+
+func (c *fakeChecker) PushStatusRequest(request cmdlib.StatusRequest) error {
+	if c.full {
+		return checkers.ErrFullQueue
+	}
+	if c.answer != nil {
+		request.(*cmdlib.SingleStatusRequest).ResultsCh <- c.answer
+	}
+	return nil
+}
+
+func serve(t *testing.T, h http.Handler, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestHandleStatus(t *testing.T) {
+	t.Parallel()
+	answer := func(status cmdlib.StatusKind) *cmdlib.ExistenceListResults {
+		return cmdlib.NewExistenceListResults(map[string]cmdlib.StreamerInfoWithStatus{"a": {Status: status}}, 0)
+	}
+	tests := []struct {
+		name    string
+		checker *fakeChecker
+		method  string
+		target  string
+		want    int
+	}{
+		{"online", &fakeChecker{answer: answer(cmdlib.StatusOnline)}, http.MethodGet, "/status?name=a", http.StatusOK},
+		{"offline", &fakeChecker{answer: answer(cmdlib.StatusOffline)}, http.MethodGet, "/status?name=a", http.StatusOK},
+		{"not a GET", &fakeChecker{answer: answer(cmdlib.StatusOnline)}, http.MethodPost, "/status?name=a", http.StatusMethodNotAllowed},
+		{"no status queries", &fakeChecker{noStatus: true}, http.MethodGet, "/status?name=a", http.StatusNotImplemented},
+		{"missing name", &fakeChecker{}, http.MethodGet, "/status", http.StatusBadRequest},
+		{"malformed name", &fakeChecker{}, http.MethodGet, "/status?name=A!", http.StatusBadRequest},
+		{"queue full", &fakeChecker{full: true}, http.MethodGet, "/status?name=a", http.StatusServiceUnavailable},
+		{"no answer", &fakeChecker{}, http.MethodGet, "/status?name=a", http.StatusGatewayTimeout},
+		{"failed lookup", &fakeChecker{answer: cmdlib.NewExistenceListResultsFailed()}, http.MethodGet, "/status?name=a", http.StatusBadGateway},
+		{"unknown status", &fakeChecker{answer: answer(cmdlib.StatusUnknown)}, http.MethodGet, "/status?name=a", http.StatusBadGateway},
+		{"name not answered", &fakeChecker{answer: answer(cmdlib.StatusOnline)}, http.MethodGet, "/status?name=b", http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mux := buildMux(newOnlineCache(), tt.checker, 10*time.Millisecond)
+			rec := serve(t, mux, tt.method, tt.target)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var info cmdlib.StreamerInfoWithStatus
+			if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+				t.Fatalf("cannot parse the answer, %v", err)
+			}
+			if info.Status != tt.checker.answer.Streamers["a"].Status {
+				t.Errorf("answered %v, want %v", info.Status, tt.checker.answer.Streamers["a"].Status)
+			}
+		})
+	}
+}
+
+// Until the first poll lands, /online reports a failed list rather than an empty one.
+func TestOnlineCacheFailsBeforeFirstPoll(t *testing.T) {
+	t.Parallel()
+	cache := newOnlineCache()
+	mux := buildMux(cache, &fakeChecker{}, time.Second)
+	read := func() (result struct {
+		Streamers map[string]cmdlib.StreamerInfo `json:"streamers"`
+		Failed    bool                           `json:"failed"`
+	}) {
+		rec := serve(t, mux, http.MethodGet, "/online")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("/online answered %d", rec.Code)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("cannot parse /online, %v", err)
+		}
+		return result
+	}
+
+	if got := read(); !got.Failed || len(got.Streamers) != 0 {
+		t.Errorf("before the first poll /online = %+v, want a failed list", got)
+	}
+	cache.store(cmdlib.NewOnlineListResults(map[string]cmdlib.StreamerInfo{"a": {}}, time.Second))
+	if got := read(); got.Failed || len(got.Streamers) != 1 {
+		t.Errorf("after a poll /online = %+v, want its one streamer", got)
+	}
+}
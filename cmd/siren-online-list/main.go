@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -55,7 +56,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
-	streamers, err := checker.QueryOnlineStreamers()
+	streamers, err := checker.QueryOnlineStreamers(context.Background())
 	if err != nil {
 		fmt.Printf("error occurred: %v\n", err)
 		return
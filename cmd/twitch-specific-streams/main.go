@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -51,7 +52,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
-	channels, err := checker.QueryFixedListOnlineStreamers(flag.Args(), cmdlib.CheckStatuses)
+	channels, err := checker.QueryFixedListOnlineStreamers(context.Background(), flag.Args(), cmdlib.CheckStatuses)
 	if err != nil {
 		fmt.Printf("error occurred: %v\n", err)
 		return
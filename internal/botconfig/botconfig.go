@@ -25,6 +25,22 @@ type endpoint struct {
 	MaintenanceResponse string        `mapstructure:"maintenance_response"` // the maintenance response
 }
 
+// DiscordEndpoint is the endpoint name Discord deliveries are scheduled under,
+// so no Telegram endpoint can take it.
+const DiscordEndpoint = "discord"
+
+// Site configures a site served beside the one named by website.
+type Site struct {
+	CheckerConfig string `mapstructure:"checker_config"` // the path to the site's checker config file
+	AffiliateBase string `mapstructure:"affiliate_base"` // affiliate redirect base for the site's streamer links, plain names without it
+}
+
+// NotificationWebhook is an HTTP target every confirmed status change is posted to.
+type NotificationWebhook struct {
+	URL    string        `mapstructure:"url"`    // the http or https URL to post to
+	Secret cmdlib.Secret `mapstructure:"secret"` // the HMAC-SHA256 key the payload is signed with
+}
+
 // StatusConfirmationSeconds represents configuration of confirmation durations
 type StatusConfirmationSeconds struct {
 	Offline int `mapstructure:"offline"`
@@ -44,6 +60,7 @@ type Config struct {
 	ListenAddress                   string                    `mapstructure:"listen_address"`                     // the address to listen to
 	Website                         string                    `mapstructure:"website"`                            // a checker's Site name from the registry in internal/checkers/factory.go
 	WebsiteLink                     string                    `mapstructure:"website_link"`                       // legacy affiliate link to website, superseded by affiliate_base
+	Sites                           map[string]Site           `mapstructure:"sites"`                              // more sites served by the same bot, keyed by checker Site name
 	PeriodSeconds                   int                       `mapstructure:"period_seconds"`                     // the period of querying streamer statuses
 	MaintainDBPeriodSeconds         int                       `mapstructure:"maintain_db_period_seconds"`         // the maintain DB period
 	MaxSubs                         int                       `mapstructure:"max_subs"`                           // maximum subscriptions per user
@@ -55,6 +72,11 @@ type Config struct {
 	ReferralBonus                   int                       `mapstructure:"referral_bonus"`                     // number of additional subscriptions for a referrer
 	FollowerBonus                   int                       `mapstructure:"follower_bonus"`                     // number of additional subscriptions for a new user registered by a referral link
 	StatusConfirmationSeconds       StatusConfirmationSeconds `mapstructure:"status_confirmation_seconds"`        // a status is confirmed only if it lasts for at least this number of seconds
+	SubjectChangeSeconds            int                       `mapstructure:"subject_change_seconds"`             // a changed room subject is confirmed and alerted once it holds this long, defaults to 300
+	OnlineDropPercent               int                       `mapstructure:"online_drop_percent"`                // an online list this many percent below its recent median is held as truncated, defaults to 50
+	OnlineDropMinCount              int                       `mapstructure:"online_drop_min_count"`              // a site with a recent median below this is not guarded, defaults to 100
+	LiveMessageRefreshSeconds       int                       `mapstructure:"live_message_refresh_seconds"`       // a live message is edited at most this often while the session lasts, defaults to 300
+	NotificationBurst               int                       `mapstructure:"notification_burst"`                 // a chat with more status alerts than this queued gets them in one message, 0 disables, defaults to 3
 	OfflineNotifications            bool                      `mapstructure:"offline_notifications"`              // enable offline notifications
 	SQLPrelude                      []string                  `mapstructure:"sql_prelude"`                        // run these SQL commands before any other
 	EnableWeek                      bool                      `mapstructure:"enable_week"`                        // enable week command
@@ -71,6 +93,9 @@ type Config struct {
 	BotLinkPeriod                   int                       `mapstructure:"bot_link_period"`                    // channel bot-link cadence in alerts, 0 disables, defaults to 12
 	WhitelistChats                  []int64                   `mapstructure:"whitelist_chats"`                    // if set, only these chats are processed
 	SubsTiers                       []SubsTier                `mapstructure:"subs_tiers"`                         // fixed Stars packages, ascending Count; empty disables buying
+	NotificationWebhooks            []NotificationWebhook     `mapstructure:"notification_webhooks"`              // HTTP targets receiving every confirmed status change
+	WebhookTimeoutSeconds           int                       `mapstructure:"webhook_timeout_seconds"`            // the timeout for a notification webhook post, defaults to 10
+	APIKeys                         []cmdlib.Secret           `mapstructure:"api_keys"`                           // bearer tokens of the read-only REST API, empty disables it
 }
 
 // ReadConfig reads the bot config from cfgPath. cfgPath must be non-empty.
@@ -86,7 +111,7 @@ func ReadConfig(cfgPath string) *Config {
 	v.SetEnvPrefix("XRN")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
-	cfg := &Config{ShowImages: true, AdChancePercent: 20, EnableCustomAffiliateLink: true, BotLinkPeriod: 12}
+	cfg := &Config{ShowImages: true, AdChancePercent: 20, EnableCustomAffiliateLink: true, BotLinkPeriod: 12, NotificationBurst: 3}
 	cmdlib.BindEnvForConfig(v, cfg)
 	checkErr(v.Unmarshal(&cfg, cmdlib.StrictConfigDecoder))
 	checkErr(checkConfig(cfg))
@@ -95,7 +120,11 @@ func ReadConfig(cfgPath string) *Config {
 }
 
 func checkConfig(cfg *Config) error {
-	for _, x := range cfg.Endpoints {
+	for name, x := range cfg.Endpoints {
+		// Discord posts queue under this name, beside the Telegram endpoints.
+		if name == DiscordEndpoint {
+			return fmt.Errorf("endpoints: %s is reserved for Discord delivery", name)
+		}
 		if x.ListenPath == "" {
 			return errors.New("configure listen_path")
 		}
@@ -148,6 +177,18 @@ func checkConfig(cfg *Config) error {
 	if cfg.WebsiteLink == "" && cfg.AffiliateBase == "" {
 		return errors.New("configure affiliate_base or website_link")
 	}
+	for name, x := range cfg.Sites {
+		if name == cfg.Website {
+			return fmt.Errorf("sites: %s is already served as website", name)
+		}
+		// A streamer of another site is written site:nickname, so the name cannot hold the separator.
+		if name == "" || strings.Contains(name, ":") {
+			return fmt.Errorf("sites: invalid site name %q", name)
+		}
+		if x.CheckerConfig == "" {
+			return fmt.Errorf("sites: configure checker_config for %s", name)
+		}
+	}
 	if cfg.HeavyUserRemainder == 0 {
 		return errors.New("configure heavy_user_remainder")
 	}
@@ -175,6 +216,49 @@ func checkConfig(cfg *Config) error {
 	if err := validateSubsTiers(cfg.SubsTiers); err != nil {
 		return err
 	}
+	for i, x := range cfg.NotificationWebhooks {
+		if !ValidWebhookURL(x.URL) {
+			return fmt.Errorf("notification_webhooks[%d]: configure an http or https url", i)
+		}
+		if x.Secret == "" {
+			return fmt.Errorf("notification_webhooks[%d]: configure secret", i)
+		}
+	}
+	for i, key := range cfg.APIKeys {
+		if key == "" {
+			return fmt.Errorf("api_keys[%d]: configure a non-empty key", i)
+		}
+	}
+	if cfg.WebhookTimeoutSeconds == 0 {
+		cfg.WebhookTimeoutSeconds = 10
+	}
+	if cfg.SubjectChangeSeconds < 0 {
+		return errors.New("configure a non-negative subject_change_seconds")
+	}
+	if cfg.SubjectChangeSeconds == 0 {
+		cfg.SubjectChangeSeconds = 300
+	}
+	if cfg.OnlineDropPercent < 0 || cfg.OnlineDropPercent > 99 {
+		return errors.New("configure online_drop_percent from 1 to 99")
+	}
+	if cfg.OnlineDropPercent == 0 {
+		cfg.OnlineDropPercent = 50
+	}
+	if cfg.OnlineDropMinCount < 0 {
+		return errors.New("configure a non-negative online_drop_min_count")
+	}
+	if cfg.OnlineDropMinCount == 0 {
+		cfg.OnlineDropMinCount = 100
+	}
+	if cfg.LiveMessageRefreshSeconds < 0 {
+		return errors.New("configure a non-negative live_message_refresh_seconds")
+	}
+	if cfg.LiveMessageRefreshSeconds == 0 {
+		cfg.LiveMessageRefreshSeconds = 300
+	}
+	if cfg.NotificationBurst < 0 {
+		return errors.New("configure a non-negative notification_burst")
+	}
 
 	return nil
 }
@@ -197,6 +281,12 @@ func validateSubsTiers(tiers []SubsTier) error {
 	return nil
 }
 
+// ValidWebhookURL reports whether rawURL is an absolute http or https URL a webhook can post to.
+func ValidWebhookURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
 // BuySubsEnabled reports whether buying subscriptions with Stars is configured.
 func (c *Config) BuySubsEnabled() bool {
 	return len(c.SubsTiers) > 0
@@ -212,6 +302,11 @@ func (c *Config) ImageDownloadTimeout() time.Duration {
 	return time.Duration(c.ImageDownloadTimeoutSeconds) * time.Second
 }
 
+// WebhookTimeout returns the configured notification webhook HTTP timeout.
+func (c *Config) WebhookTimeout() time.Duration {
+	return time.Duration(c.WebhookTimeoutSeconds) * time.Second
+}
+
 // ChatWhitelisted returns true if the chat is whitelisted or if no whitelist
 // is configured.
 func (c *Config) ChatWhitelisted(chatID int64) bool {
@@ -1,6 +1,10 @@
 package botconfig
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
 
 func TestValidateSubsTiers(t *testing.T) {
 	tests := []struct {
@@ -166,3 +170,132 @@ func TestCheckConfigRequiresEndpointFields(t *testing.T) {
 		})
 	}
 }
+
+// TestCheckConfigSites pins what an extra site cannot start without.
+func TestCheckConfigSites(t *testing.T) {
+	valid := Site{CheckerConfig: "twitch-checker.json"}
+	tests := []struct {
+		name    string
+		sites   map[string]Site
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "complete", sites: map[string]Site{"twitch": valid}},
+		{
+			name:  "with affiliate_base",
+			sites: map[string]Site{"twitch": {CheckerConfig: "c.json", AffiliateBase: "https://siren.chat/out/tw"}},
+		},
+		// The primary site is served by website; naming it again would run it twice.
+		{name: "the primary site again", sites: map[string]Site{"siren": valid}, wantErr: true},
+		{name: "a name holding the separator", sites: map[string]Site{"a:b": valid}, wantErr: true},
+		{name: "an empty name", sites: map[string]Site{"": valid}, wantErr: true},
+		{name: "no checker_config", sites: map[string]Site{"twitch": {AffiliateBase: "https://siren.chat/out/tw"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(validEndpoint())
+			cfg.Sites = tt.sites
+			if err := checkConfig(cfg); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckConfigNotificationWebhooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		webhooks []NotificationWebhook
+		wantErr  bool
+	}{
+		{name: "none"},
+		{name: "https", webhooks: []NotificationWebhook{{URL: "https://hooks.example.com/siren", Secret: "s"}}},
+		{name: "http", webhooks: []NotificationWebhook{{URL: "http://10.0.0.5:8080/", Secret: "s"}}},
+		{name: "no secret", webhooks: []NotificationWebhook{{URL: "https://hooks.example.com/"}}, wantErr: true},
+		{name: "no scheme", webhooks: []NotificationWebhook{{URL: "hooks.example.com/siren", Secret: "s"}}, wantErr: true},
+		{name: "another scheme", webhooks: []NotificationWebhook{{URL: "ftp://hooks.example.com/", Secret: "s"}}, wantErr: true},
+		{name: "no host", webhooks: []NotificationWebhook{{URL: "https:///siren", Secret: "s"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(validEndpoint())
+			cfg.NotificationWebhooks = tt.webhooks
+			if err := checkConfig(cfg); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Discord posts are scheduled under their own endpoint name, so a bot cannot take it.
+func TestCheckConfigReservesDiscordEndpoint(t *testing.T) {
+	cfg := validConfig(validEndpoint())
+	cfg.Endpoints[DiscordEndpoint] = validEndpoint()
+	if err := checkConfig(cfg); err == nil {
+		t.Fatal("an endpoint named discord was accepted")
+	}
+}
+
+func TestCheckConfigAPIKeys(t *testing.T) {
+	cfg := validConfig(validEndpoint())
+	cfg.APIKeys = []cmdlib.Secret{"key"}
+	if err := checkConfig(cfg); err != nil {
+		t.Fatalf("a key was rejected, %v", err)
+	}
+	cfg.APIKeys = append(cfg.APIKeys, "")
+	if err := checkConfig(cfg); err == nil {
+		t.Fatal("an empty key was accepted")
+	}
+}
+
+func TestCheckConfigSubjectChangeSeconds(t *testing.T) {
+	cfg := validConfig(validEndpoint())
+	if err := checkConfig(cfg); err != nil || cfg.SubjectChangeSeconds != 300 {
+		t.Fatalf("an unset subject_change_seconds read %d, %v; want the default", cfg.SubjectChangeSeconds, err)
+	}
+	cfg.SubjectChangeSeconds = -1
+	if err := checkConfig(cfg); err == nil {
+		t.Fatal("a negative subject_change_seconds was accepted")
+	}
+}
+
+func TestCheckConfigOnlineDropGuard(t *testing.T) {
+	cfg := validConfig(validEndpoint())
+	if err := checkConfig(cfg); err != nil || cfg.OnlineDropPercent != 50 || cfg.OnlineDropMinCount != 100 {
+		t.Fatalf("an unset guard read %d%% of %d, %v; want the defaults", cfg.OnlineDropPercent, cfg.OnlineDropMinCount, err)
+	}
+	for _, percent := range []int{-1, 100} {
+		cfg := validConfig(validEndpoint())
+		cfg.OnlineDropPercent = percent
+		if err := checkConfig(cfg); err == nil {
+			t.Errorf("online_drop_percent %d was accepted", percent)
+		}
+	}
+	cfg = validConfig(validEndpoint())
+	cfg.OnlineDropMinCount = -1
+	if err := checkConfig(cfg); err == nil {
+		t.Error("a negative online_drop_min_count was accepted")
+	}
+}
+
+func TestCheckConfigLiveMessageRefreshSeconds(t *testing.T) {
+	cfg := validConfig(validEndpoint())
+	if err := checkConfig(cfg); err != nil || cfg.LiveMessageRefreshSeconds != 300 {
+		t.Fatalf("an unset live_message_refresh_seconds read %d, %v; want the default", cfg.LiveMessageRefreshSeconds, err)
+	}
+	cfg.LiveMessageRefreshSeconds = -1
+	if err := checkConfig(cfg); err == nil {
+		t.Fatal("a negative live_message_refresh_seconds was accepted")
+	}
+}
+
+func TestCheckConfigNotificationBurst(t *testing.T) {
+	cfg := validConfig(validEndpoint())
+	if err := checkConfig(cfg); err != nil {
+		t.Fatalf("an unset notification_burst was rejected: %v", err)
+	}
+	cfg.NotificationBurst = -1
+	if err := checkConfig(cfg); err == nil {
+		t.Fatal("a negative notification_burst was accepted")
+	}
+}
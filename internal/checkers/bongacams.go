@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -43,8 +44,8 @@ type bongacamsModel struct {
 }
 
 // QueryStatus checks BongaCams model status
-func (c *BongaCamsChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithStatus, error) {
-	code := c.QueryStatusCode(fmt.Sprintf("https://en.bongacams.com/%s", modelID), c.Cfg.Headers)
+func (c *BongaCamsChecker) QueryStatus(ctx context.Context, modelID string) (cmdlib.StreamerInfoWithStatus, error) {
+	code := c.QueryStatusCode(ctx, fmt.Sprintf("https://en.bongacams.com/%s", modelID), c.Cfg.Headers)
 	switch code {
 	case 200:
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusOnline}, nil
@@ -57,10 +58,10 @@ func (c *BongaCamsChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithS
 }
 
 // QueryOnlineStreamers returns BongaCams online models
-func (c *BongaCamsChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *BongaCamsChecker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	streamers := map[string]cmdlib.StreamerInfo{}
 
-	resp, buf, err := cmdlib.OnlineQuery(c.Cfg.UsersOnlineEndpoint, c.Client, c.Cfg.Headers)
+	resp, buf, err := cmdlib.OnlineQuery(ctx, c.Cfg.UsersOnlineEndpoint, c.Client, c.Cfg.Headers)
 	if err != nil {
 		return nil, fmt.Errorf("cannot send a query, %v", err)
 	}
@@ -91,7 +92,7 @@ func (c *BongaCamsChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInf
 }
 
 // QueryFixedListOnlineStreamers is not implemented for online list checkers
-func (c *BongaCamsChecker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *BongaCamsChecker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -2,6 +2,7 @@ package checkers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -65,9 +66,9 @@ type cam4Response struct {
 }
 
 // QueryStatus checks CAM4 model status
-func (c *Cam4Checker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithStatus, error) {
+func (c *Cam4Checker) QueryStatus(ctx context.Context, modelID string) (cmdlib.StreamerInfoWithStatus, error) {
 	url := fmt.Sprintf("https://www.cam4.com/rest/v1.0/profile/%s/info", modelID)
-	resp := c.DoGetRequest(url, c.Cfg.Headers)
+	resp := c.DoGetRequest(ctx, url, c.Cfg.Headers)
 	if resp == nil {
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}, nil
 	}
@@ -107,9 +108,9 @@ func cam4ShowKind(showType string) cmdlib.ShowKind {
 }
 
 // QueryOnlineStreamers returns CAM4 online models
-func (c *Cam4Checker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *Cam4Checker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	streamers := map[string]cmdlib.StreamerInfo{}
-	resp, buf, err := cmdlib.OnlineQuery(c.Cfg.UsersOnlineEndpoint, c.Client, c.Cfg.Headers)
+	resp, buf, err := cmdlib.OnlineQuery(ctx, c.Cfg.UsersOnlineEndpoint, c.Client, c.Cfg.Headers)
 	if err != nil {
 		return nil, fmt.Errorf("cannot send a query, %v", err)
 	}
@@ -138,7 +139,7 @@ func (c *Cam4Checker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, er
 }
 
 // QueryFixedListOnlineStreamers is not implemented for online list checkers
-func (c *Cam4Checker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *Cam4Checker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -150,5 +151,6 @@ func (*Cam4Checker) Capabilities() Capabilities {
 		SupportsQueryFixedListStatuses:        false,
 		SupportsQueryStatus:                   true,
 		SupportsCLI:                           true,
+		SupportsShowKind:                      true,
 	}
 }
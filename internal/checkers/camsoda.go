@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -57,8 +58,8 @@ func camSodaShowKind(status string) cmdlib.ShowKind {
 }
 
 // QueryStatus checks CamSoda model status
-func (c *CamSodaChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithStatus, error) {
-	code := c.QueryStatusCode(fmt.Sprintf("https://www.camsoda.com/%s", modelID), c.Cfg.Headers)
+func (c *CamSodaChecker) QueryStatus(ctx context.Context, modelID string) (cmdlib.StreamerInfoWithStatus, error) {
+	code := c.QueryStatusCode(ctx, fmt.Sprintf("https://www.camsoda.com/%s", modelID), c.Cfg.Headers)
 	switch code {
 	case 200:
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusOnline | cmdlib.StatusOffline}, nil
@@ -69,9 +70,9 @@ func (c *CamSodaChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithSta
 }
 
 // QueryOnlineStreamers returns CamSoda online models
-func (c *CamSodaChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *CamSodaChecker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	streamers := map[string]cmdlib.StreamerInfo{}
-	resp, buf, err := cmdlib.OnlineQuery(c.Cfg.UsersOnlineEndpoint, c.Client, c.Cfg.Headers)
+	resp, buf, err := cmdlib.OnlineQuery(ctx, c.Cfg.UsersOnlineEndpoint, c.Client, c.Cfg.Headers)
 	if err != nil {
 		return nil, fmt.Errorf("cannot send a query, %v", err)
 	}
@@ -104,7 +105,7 @@ func (c *CamSodaChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo,
 }
 
 // QueryFixedListOnlineStreamers is not implemented for online list checkers
-func (c *CamSodaChecker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *CamSodaChecker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -117,5 +118,6 @@ func (*CamSodaChecker) Capabilities() Capabilities {
 		SupportsQueryStatus:                   true,
 		SupportsCLI:                           true,
 		SupportsSubject:                       true,
+		SupportsShowKind:                      true,
 	}
 }
@@ -2,6 +2,7 @@ package checkers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,7 +10,6 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
-	"sync/atomic"
 
 	"github.com/bcmk/siren/v4/lib/cmdlib"
 )
@@ -17,9 +17,8 @@ import (
 // ChaturbateCheckerConfig is the per-site config for Chaturbate.
 type ChaturbateCheckerConfig struct {
 	BaseCheckerConfig   `mapstructure:",squash"`
-	UsersOnlineEndpoint string          `mapstructure:"users_online_endpoint"`
-	Headers             [][2]string     `mapstructure:"headers"`
-	Proxies             []cmdlib.Secret `mapstructure:"proxies"`
+	UsersOnlineEndpoint string      `mapstructure:"users_online_endpoint"`
+	Headers             [][2]string `mapstructure:"headers"`
 }
 
 func (c *ChaturbateCheckerConfig) validate() error {
@@ -32,22 +31,14 @@ func (c *ChaturbateCheckerConfig) validate() error {
 	if len(c.Proxies) == 0 {
 		return errors.New("configure proxies")
 	}
-	for i, p := range c.Proxies {
-		if p == "" {
-			return fmt.Errorf("proxies[%d] is empty", i)
-		}
-		if _, err := url.Parse(string(p)); err != nil {
-			return fmt.Errorf("proxies[%d]: invalid URL, %v", i, err)
-		}
-	}
 	return nil
 }
 
-// ChaturbateChecker implements a checker for Chaturbate
+// ChaturbateChecker implements a checker for Chaturbate.
+// Status queries go through the proxies; the online list comes from the affiliate API directly.
 type ChaturbateChecker struct {
 	BaseChecker[*ChaturbateCheckerConfig]
-	proxyClients []*http.Client
-	nextProxy    atomic.Uint64
+	direct *http.Client
 }
 
 var _ Checker = &ChaturbateChecker{}
@@ -65,13 +56,8 @@ func (c *ChaturbateChecker) Init(checkerCfgPath string) error {
 		return err
 	}
 	c.BaseChecker = NewBaseChecker(cfg)
-	for i, p := range cfg.Proxies {
-		u, err := url.Parse(string(p))
-		if err != nil {
-			return fmt.Errorf("proxies[%d]: invalid URL, %v", i, err)
-		}
-		c.proxyClients = append(c.proxyClients, cmdlib.HTTPClientWithProxy(cfg.Timeout(), u))
-	}
+	c.proxies.siteAnswer = chaturbateSiteAnswer
+	c.direct = c.withRetries(cmdlib.HTTPClientWithTimeout(cfg.Timeout()), nil)
 	return nil
 }
 
@@ -100,20 +86,21 @@ type chaturbateResponse struct {
 	Code       string `json:"code"`
 }
 
-func (c *ChaturbateChecker) pickProxyClient() *http.Client {
-	idx := c.nextProxy.Add(1) - 1
-	return c.proxyClients[idx%uint64(len(c.proxyClients))]
+// chaturbateSiteAnswer tells a room's own denial, which carries a JSON status,
+// from Chaturbate turning a proxy away.
+func chaturbateSiteAnswer(_ *http.Response, body []byte) bool {
+	parsed := &chaturbateResponse{}
+	return json.Unmarshal(body, parsed) == nil && parsed.Status != nil
 }
 
 // QueryStatus checks Chaturbate model status
-func (c *ChaturbateChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithStatus, error) {
-	client := c.pickProxyClient()
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://chaturbate.com/api/biocontext/%s/?", modelID), nil)
+func (c *ChaturbateChecker) QueryStatus(ctx context.Context, modelID string) (cmdlib.StreamerInfoWithStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://chaturbate.com/api/biocontext/%s/?", modelID), nil)
 	cmdlib.CheckErr(err)
 	for _, h := range c.Cfg.Headers {
 		req.Header.Set(h[0], h[1])
 	}
-	resp, err := client.Do(req)
+	resp, err := c.Client.Do(req)
 	if err != nil {
 		cmdlib.Lerr("cannot send a query, %v", err)
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}, nil
@@ -188,9 +175,9 @@ func chaturbateShowKind(currentShow string) cmdlib.ShowKind {
 }
 
 // QueryOnlineStreamers returns Chaturbate online models
-func (c *ChaturbateChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *ChaturbateChecker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	streamers := map[string]cmdlib.StreamerInfo{}
-	resp, buf, err := cmdlib.OnlineQuery(c.Cfg.UsersOnlineEndpoint, c.Client, c.Cfg.Headers)
+	resp, buf, err := cmdlib.OnlineQuery(ctx, c.Cfg.UsersOnlineEndpoint, c.direct, c.Cfg.Headers)
 	if err != nil {
 		return nil, fmt.Errorf("cannot send a query, %v", err)
 	}
@@ -220,7 +207,7 @@ func (c *ChaturbateChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerIn
 }
 
 // QueryFixedListOnlineStreamers is not implemented for online list checkers
-func (c *ChaturbateChecker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *ChaturbateChecker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -233,6 +220,7 @@ func (*ChaturbateChecker) Capabilities() Capabilities {
 		SupportsQueryStatus:                   true,
 		SupportsCLI:                           true,
 		SupportsSubject:                       true,
+		SupportsShowKind:                      true,
 		SupportsCustomAffiliateLink:           true,
 	}
 }
@@ -3,10 +3,16 @@ package checkers
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/bcmk/siren/v4/internal/metrics"
 	"github.com/bcmk/siren/v4/lib/cmdlib"
 )
 
@@ -16,6 +22,16 @@ type BaseCheckerConfig struct {
 	TimeoutSeconds       int `mapstructure:"timeout_seconds"`         // HTTP timeout
 	MinRequestIntervalMs int `mapstructure:"min_request_interval_ms"` // minimum interval between requests for rate-limited upstreams
 	RequestQueueSize     int `mapstructure:"queue_size"`              // status-request queue size; 0 means defaultQueueSize
+	RequestConcurrency   int `mapstructure:"poll_concurrency"`        // status queries in flight at once when polling; 0 means defaultPollConcurrency
+	RetryMaxAttempts     int `mapstructure:"retry_max_attempts"`      // attempts per request, the first included; 0 means defaultRetryMaxAttempts
+	RetryBackoffMs       int `mapstructure:"retry_backoff_ms"`        // wait before the first retry, doubling for each further one; 0 means defaultRetryBackoff
+	RetryMaxBackoffMs    int `mapstructure:"retry_max_backoff_ms"`    // cap on the wait, a longer Retry-After gives up; 0 means defaultRetryMaxBackoff
+	// RetryStatusCodes are the answers worth another attempt, beside transport errors;
+	// empty means defaultRetryStatusCodes.
+	RetryStatusCodes []int `mapstructure:"retry_status_codes"`
+	// Proxies route the checker's requests, taken in turn; see proxyPool.
+	// Without them, requests go out directly.
+	Proxies []cmdlib.Secret `mapstructure:"proxies"`
 }
 
 // validateBase checks the universal HTTP settings.
@@ -27,6 +43,20 @@ func (b *BaseCheckerConfig) validateBase() error {
 	if b.MinRequestIntervalMs == 0 {
 		return errors.New("configure min_request_interval_ms")
 	}
+	if b.RequestConcurrency < 0 {
+		return errors.New("poll_concurrency cannot be negative")
+	}
+	if b.RetryMaxAttempts < 0 || b.RetryBackoffMs < 0 || b.RetryMaxBackoffMs < 0 {
+		return errors.New("retry settings cannot be negative")
+	}
+	for i, p := range b.Proxies {
+		if p == "" {
+			return fmt.Errorf("proxies[%d] is empty", i)
+		}
+		if _, err := url.Parse(string(p)); err != nil {
+			return fmt.Errorf("proxies[%d]: invalid URL, %v", i, err)
+		}
+	}
 	return nil
 }
 
@@ -49,6 +79,45 @@ func (b *BaseCheckerConfig) QueueSize() int {
 	return b.RequestQueueSize
 }
 
+// PollConcurrency returns how many status queries a poll keeps in flight,
+// falling back to defaultPollConcurrency when unset.
+func (b *BaseCheckerConfig) PollConcurrency() int {
+	if b.RequestConcurrency == 0 {
+		return defaultPollConcurrency
+	}
+	return b.RequestConcurrency
+}
+
+// Retry returns the retry policy, each unset setting falling back to its default.
+func (b *BaseCheckerConfig) Retry() RetryPolicy {
+	p := RetryPolicy{
+		MaxAttempts: b.RetryMaxAttempts,
+		Backoff:     time.Duration(b.RetryBackoffMs) * time.Millisecond,
+		MaxBackoff:  time.Duration(b.RetryMaxBackoffMs) * time.Millisecond,
+		StatusCodes: b.RetryStatusCodes,
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if p.Backoff == 0 {
+		p.Backoff = defaultRetryBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if len(p.StatusCodes) == 0 {
+		p.StatusCodes = defaultRetryStatusCodes
+	}
+	return p
+}
+
+// RequestLanes returns how many upstream routes the checker spreads its status queries over,
+// each paced by MinRequestInterval on its own: one per proxy, or one without proxies.
+func (b *BaseCheckerConfig) RequestLanes() int { return max(len(b.Proxies), 1) }
+
+// ProxyURLs returns the configured proxies.
+func (b *BaseCheckerConfig) ProxyURLs() []cmdlib.Secret { return b.Proxies }
+
 // CheckerConfig is satisfied by every per-site config (via the
 // embedded BaseCheckerConfig). BaseChecker[T] stores it typed, so
 // site code reads c.Cfg.Field directly while Config() exposes it as
@@ -57,12 +126,35 @@ type CheckerConfig interface {
 	Timeout() time.Duration
 	MinRequestInterval() time.Duration
 	QueueSize() int
+	PollConcurrency() int
+	RequestLanes() int
+	ProxyURLs() []cmdlib.Secret
+	Retry() RetryPolicy
 }
 
 // defaultQueueSize buffers BaseChecker's status-request channel
 // when the config doesn't override it.
 const defaultQueueSize = 1000
 
+// Defaults of the retry policy when the config doesn't override them.
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBackoff     = time.Second
+	defaultRetryMaxBackoff  = 30 * time.Second
+)
+
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// defaultPollConcurrency bounds the status queries of a poll in flight at once
+// when the config doesn't override it. The rate limiter, not this, sets their pace.
+const defaultPollConcurrency = 8
+
 // Capabilities reports which surfaces dispatchers should use
 // and which invocation contexts the checker fits.
 // A Supports* flag may be false even when the method is defined —
@@ -75,6 +167,7 @@ type Capabilities struct {
 	SupportsQueryStatus                   bool
 	SupportsCLI                           bool // true when the checker fits standalone CLI tools.
 	SupportsSubject                       bool // true when room subjects are surfaced.
+	SupportsShowKind                      bool // true when show kinds are surfaced.
 	SupportsCustomAffiliateLink           bool // true when a chat can set its own affiliate link.
 }
 
@@ -93,11 +186,21 @@ func (c Capabilities) UsesFixedListOnline() bool {
 // Checker is the interface for a per-site checker.
 // Init must be called before any Query* method;
 // Site() and Capabilities() work on an uninitialised checker.
+// Every Query* method makes its upstream requests under ctx,
+// so cancelling it aborts them, and its deadline caps them on top of timeout_seconds.
 type Checker interface {
-	QueryStatus(nickname string) (cmdlib.StreamerInfoWithStatus, error)
-	QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error)
-	QueryFixedListOnlineStreamers(streamers []string, checkMode cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error)
-	QueryFixedListStatuses(streamers []string, checkMode cmdlib.CheckMode) (map[string]cmdlib.StreamerInfoWithStatus, error)
+	QueryStatus(ctx context.Context, nickname string) (cmdlib.StreamerInfoWithStatus, error)
+	QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error)
+	QueryFixedListOnlineStreamers(
+		ctx context.Context,
+		streamers []string,
+		checkMode cmdlib.CheckMode,
+	) (map[string]cmdlib.StreamerInfo, error)
+	QueryFixedListStatuses(
+		ctx context.Context,
+		streamers []string,
+		checkMode cmdlib.CheckMode,
+	) (map[string]cmdlib.StreamerInfoWithStatus, error)
 	Capabilities() Capabilities
 	Config() CheckerConfig
 	Site() string
@@ -109,13 +212,22 @@ type Checker interface {
 	ParseAffiliateParams(input string) (map[string]string, bool)
 	// AffiliateID returns the affiliate ID from parsed params, or "".
 	AffiliateID(params map[string]string) string
+	// TakeHTTPStats returns the record of the upstream requests since the previous call.
+	TakeHTTPStats() cmdlib.HTTPStats
+	requestLimiter() *rateLimiter
 }
 
 // BaseChecker holds the runtime state shared by every site checker.
 // Cfg is the typed per-site config; site code reads c.Cfg.Field directly.
+// Client retries requests by the config's retry policy
+// and sends them through the configured proxies, if any.
 type BaseChecker[T CheckerConfig] struct {
-	Cfg            T
-	Client         *http.Client
+	Cfg      T
+	Client   *http.Client
+	proxies  *proxyPool
+	counters *httpCounters
+	// limiter paces the checker's polls and retries; see StartCheckerDaemon.
+	limiter        *rateLimiter
 	statusRequests chan cmdlib.StatusRequest
 }
 
@@ -146,7 +258,11 @@ func (c *BaseChecker[T]) PushStatusRequest(request cmdlib.StatusRequest) error {
 
 // QueryFixedListStatuses returns ErrNotImplemented by default.
 // Checkers that support querying streamer existence should override this.
-func (c *BaseChecker[T]) QueryFixedListStatuses(_ []string, _ cmdlib.CheckMode) (map[string]cmdlib.StreamerInfoWithStatus, error) {
+func (c *BaseChecker[T]) QueryFixedListStatuses(
+	context.Context,
+	[]string,
+	cmdlib.CheckMode,
+) (map[string]cmdlib.StreamerInfoWithStatus, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -160,13 +276,56 @@ func (c *BaseChecker[T]) ParseAffiliateParams(string) (map[string]string, bool)
 // AffiliateID returns "": a site without custom affiliate has no ID.
 func (c *BaseChecker[T]) AffiliateID(map[string]string) string { return "" }
 
+// TakeHTTPStats returns the record of the upstream requests since the previous call.
+func (c *BaseChecker[T]) TakeHTTPStats() cmdlib.HTTPStats {
+	stats := cmdlib.HTTPStats{
+		Attempts: int(c.counters.attempts.Swap(0)),
+		Retries:  int(c.counters.retries.Swap(0)),
+	}
+	if c.proxies != nil {
+		stats.Proxies = c.proxies.takeStats()
+	}
+	return stats
+}
+
+func (c *BaseChecker[T]) requestLimiter() *rateLimiter { return c.limiter }
+
+// withRetries returns a client sending requests by the config's retry policy
+// through direct, or through the pool when it is not nil, counted in the checker's HTTP stats.
+// It has no timeout of its own: each attempt runs under its client's.
+func (c *BaseChecker[T]) withRetries(direct *http.Client, pool *proxyPool) *http.Client {
+	return &http.Client{
+		CheckRedirect: cmdlib.NoRedirect,
+		Transport: &retryTransport{
+			direct:   direct,
+			pool:     pool,
+			policy:   c.Cfg.Retry(),
+			limiter:  c.limiter,
+			counters: c.counters,
+		},
+	}
+}
+
 // NewBaseChecker builds a BaseChecker for a per-site checker.
+// The proxy URLs must have passed validateBase.
 func NewBaseChecker[T CheckerConfig](cfg T) BaseChecker[T] {
-	return BaseChecker[T]{
+	// The polls and retries share one bucket, refilled once per interval for each lane.
+	lanes := max(cfg.RequestLanes(), 1)
+	b := BaseChecker[T]{
 		Cfg:            cfg,
-		Client:         cmdlib.HTTPClientWithTimeout(cfg.Timeout()),
+		counters:       &httpCounters{},
+		limiter:        newRateLimiter(cfg.MinRequestInterval()/time.Duration(lanes), lanes),
 		statusRequests: make(chan cmdlib.StatusRequest, cfg.QueueSize()),
 	}
+	if proxies := cfg.ProxyURLs(); len(proxies) > 0 {
+		pool, err := newProxyPool(proxies, cfg.Timeout(), cfg.MinRequestInterval())
+		cmdlib.CheckErr(err)
+		b.proxies = pool
+		b.Client = b.withRetries(nil, pool)
+	} else {
+		b.Client = b.withRetries(cmdlib.HTTPClientWithTimeout(cfg.Timeout()), nil)
+	}
+	return b
 }
 
 // StatusRequestsQueue returns the receive end of the status-request
@@ -201,11 +360,31 @@ func sleepCtx(ctx context.Context, d time.Duration) bool {
 	}
 }
 
+// requestKind names a status request's type for the metrics.
+func requestKind(request cmdlib.StatusRequest) string {
+	switch request.(type) {
+	case *cmdlib.OnlineListRequest:
+		return "online_list"
+	case *cmdlib.FixedListOnlineRequest:
+		return "fixed_list_online"
+	case *cmdlib.FixedListStatusRequest:
+		return "fixed_list_status"
+	case *cmdlib.SingleStatusRequest:
+		return "single_status"
+	}
+	return "unknown"
+}
+
 // StartCheckerDaemon starts a checker daemon.
-// The goroutine exits when ctx is cancelled.
+// The goroutine exits when ctx is cancelled. The queries run under ctx,
+// so cancelling it aborts the one in flight, whose result is dropped rather than reported as failed.
 func StartCheckerDaemon(ctx context.Context, checker Checker) {
-	interval := checker.Config().MinRequestInterval()
+	cfg := checker.Config()
+	interval := cfg.MinRequestInterval()
+	limiter := checker.requestLimiter()
 	queue := checker.StatusRequestsQueue()
+	site := checker.Site()
+	metrics.RegisterQueueDepth(site, queue)
 	go func() {
 		for {
 			var request cmdlib.StatusRequest
@@ -215,50 +394,59 @@ func StartCheckerDaemon(ctx context.Context, checker Checker) {
 			case request = <-queue:
 			}
 			start := time.Now()
+			kind := requestKind(request)
+			failed := false
 
 			// The failed-result cases send a failed result and break out
 			// of the switch (not continue) so the rate-limit sleep below
 			// still runs before the next request.
 			switch req := request.(type) {
 			case *cmdlib.OnlineListRequest:
-				onlineStreamers, err := checker.QueryOnlineStreamers()
+				// The bulk query spends a slot, so the first poll keeps its distance from it.
+				limiter.reserve(time.Now())
+				onlineStreamers, err := checker.QueryOnlineStreamers(ctx)
+				if ctx.Err() != nil {
+					return
+				}
 				if err != nil {
 					cmdlib.Lerr("%v", err)
-					req.ResultsCh <- cmdlib.NewOnlineListResultsFailed()
+					failed = true
+					result := cmdlib.NewOnlineListResultsFailed()
+					result.HTTP = checker.TakeHTTPStats()
+					req.ResultsCh <- result
 					break
 				}
 				delete(onlineStreamers, "")
-				var pollErrors []string
+				var toPoll []string
 				for _, nickname := range req.Poll {
-					if _, inBulk := onlineStreamers[nickname]; inBulk {
-						continue
-					}
-					if !sleepCtx(ctx, interval) {
-						return
-					}
-					info, err := checker.QueryStatus(nickname)
-					if err != nil {
-						cmdlib.Lerr("%v", err)
-					}
-					if err != nil || info.Status == cmdlib.StatusUnknown {
-						pollErrors = append(pollErrors, nickname)
-						continue
-					}
-					if info.Status == cmdlib.StatusOnline {
-						onlineStreamers[nickname] = info.StreamerInfo
+					if _, inBulk := onlineStreamers[nickname]; !inBulk {
+						toPoll = append(toPoll, nickname)
 					}
 				}
+				polled, pollErrors := pollStatuses(ctx, checker, limiter, cfg.PollConcurrency(), toPoll)
+				if ctx.Err() != nil {
+					return
+				}
+				maps.Copy(onlineStreamers, polled)
 				elapsed := time.Since(start)
 				cmdlib.Ldbg("got statuses: %d", len(onlineStreamers))
 				result := cmdlib.NewOnlineListResults(onlineStreamers, elapsed)
 				result.PollCount = len(req.Poll)
 				result.PollErrors = pollErrors
+				result.HTTP = checker.TakeHTTPStats()
+				metrics.PollErrors.WithLabelValues(site).Add(float64(len(pollErrors)))
 				req.ResultsCh <- result
 			case *cmdlib.FixedListOnlineRequest:
-				streamers, err := checker.QueryFixedListOnlineStreamers(setToSlice(req.Streamers), cmdlib.CheckOnline)
+				streamers, err := checker.QueryFixedListOnlineStreamers(ctx, setToSlice(req.Streamers), cmdlib.CheckOnline)
+				if ctx.Err() != nil {
+					return
+				}
 				if err != nil {
 					cmdlib.Lerr("%v", err)
-					req.ResultsCh <- cmdlib.NewFixedListOnlineResultsFailed()
+					failed = true
+					result := cmdlib.NewFixedListOnlineResultsFailed()
+					result.HTTP = checker.TakeHTTPStats()
+					req.ResultsCh <- result
 					break
 				}
 				delete(streamers, "")
@@ -270,11 +458,17 @@ func StartCheckerDaemon(ctx context.Context, checker Checker) {
 				}
 				elapsed := time.Since(start)
 				cmdlib.Ldbg("got statuses: upstream = %d, returned = %d", len(streamers), len(filtered))
-				req.ResultsCh <- cmdlib.NewFixedListOnlineResults(req.Streamers, filtered, elapsed)
+				result := cmdlib.NewFixedListOnlineResults(req.Streamers, filtered, elapsed)
+				result.HTTP = checker.TakeHTTPStats()
+				req.ResultsCh <- result
 			case *cmdlib.FixedListStatusRequest:
-				streamers, err := checker.QueryFixedListStatuses(setToSlice(req.Streamers), cmdlib.CheckStatuses)
+				streamers, err := checker.QueryFixedListStatuses(ctx, setToSlice(req.Streamers), cmdlib.CheckStatuses)
+				if ctx.Err() != nil {
+					return
+				}
 				if err != nil {
 					cmdlib.Lerr("%v", err)
+					failed = true
 					req.ResultsCh <- cmdlib.NewExistenceListResultsFailed()
 					break
 				}
@@ -289,9 +483,13 @@ func StartCheckerDaemon(ctx context.Context, checker Checker) {
 				cmdlib.Ldbg("got statuses: upstream = %d, returned = %d", len(streamers), len(filtered))
 				req.ResultsCh <- cmdlib.NewExistenceListResults(filtered, elapsed)
 			case *cmdlib.SingleStatusRequest:
-				info, err := checker.QueryStatus(req.Streamer)
+				info, err := checker.QueryStatus(ctx, req.Streamer)
+				if ctx.Err() != nil {
+					return
+				}
 				if err != nil {
 					cmdlib.Lerr("%v", err)
+					failed = true
 					info = cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}
 				}
 				elapsed := time.Since(start)
@@ -301,6 +499,7 @@ func StartCheckerDaemon(ctx context.Context, checker Checker) {
 					elapsed,
 				)
 			}
+			metrics.ObserveCheckerQuery(site, kind, time.Since(start), failed)
 			if !sleepCtx(ctx, interval) {
 				return
 			}
@@ -308,9 +507,65 @@ func StartCheckerDaemon(ctx context.Context, checker Checker) {
 	}()
 }
 
-// DoGetRequest performs a GET request with the given headers.
-func (c *BaseChecker[T]) DoGetRequest(url string, headers [][2]string) *http.Response {
-	req, err := http.NewRequest("GET", url, nil)
+// pollStatuses queries the statuses of streamers one by one on a pool of at most workers goroutines,
+// each query waiting its slot in limiter, and returns those found online
+// and, sorted, those whose status the checker could not tell.
+// Results of a poll cut short by ctx are partial; the caller drops them.
+func pollStatuses(
+	ctx context.Context,
+	checker Checker,
+	limiter *rateLimiter,
+	workers int,
+	nicknames []string,
+) (online map[string]cmdlib.StreamerInfo, pollErrors []string) {
+	online = map[string]cmdlib.StreamerInfo{}
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range min(workers, len(nicknames)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for nickname := range jobs {
+				if !limiter.wait(ctx) {
+					continue
+				}
+				info, err := checker.QueryStatus(ctx, nickname)
+				if ctx.Err() != nil {
+					continue
+				}
+				if err != nil {
+					cmdlib.Lerr("%v", err)
+				}
+				mu.Lock()
+				switch {
+				case err != nil || info.Status == cmdlib.StatusUnknown:
+					pollErrors = append(pollErrors, nickname)
+				case info.Status == cmdlib.StatusOnline:
+					online[nickname] = info.StreamerInfo
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, nickname := range nicknames {
+		select {
+		case jobs <- nickname:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	slices.Sort(pollErrors)
+	return online, pollErrors
+}
+
+// DoGetRequest performs a GET request with the given headers under ctx,
+// retried by the retry policy. Returns nil when no attempt got an answer.
+func (c *BaseChecker[T]) DoGetRequest(ctx context.Context, url string, headers [][2]string) *http.Response {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	cmdlib.CheckErr(err)
 	for _, h := range headers {
 		req.Header.Set(h[0], h[1])
@@ -324,9 +579,9 @@ func (c *BaseChecker[T]) DoGetRequest(url string, headers [][2]string) *http.Res
 	return resp
 }
 
-// QueryStatusCode performs a GET request and returns only the status code.
-func (c *BaseChecker[T]) QueryStatusCode(url string, headers [][2]string) int {
-	resp := c.DoGetRequest(url, headers)
+// QueryStatusCode performs a GET request under ctx and returns only the status code.
+func (c *BaseChecker[T]) QueryStatusCode(ctx context.Context, url string, headers [][2]string) int {
+	resp := c.DoGetRequest(ctx, url, headers)
 	if resp == nil {
 		return -1
 	}
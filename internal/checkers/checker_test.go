@@ -1,10 +1,15 @@
 package checkers
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/bcmk/siren/v4/lib/cmdlib"
 )
@@ -27,7 +32,7 @@ type stubConfig struct {
 	BaseCheckerConfig
 }
 
-func (c *testChecker) QueryStatus(string) (cmdlib.StreamerInfoWithStatus, error) {
+func (c *testChecker) QueryStatus(context.Context, string) (cmdlib.StreamerInfoWithStatus, error) {
 	return cmdlib.StreamerInfoWithStatus{StreamerInfo: c.info, Status: c.status}, nil
 }
 
@@ -45,7 +50,7 @@ func (*testChecker) Capabilities() Capabilities {
 	}
 }
 
-func (c *testOnlineListChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *testOnlineListChecker) QueryOnlineStreamers(context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	if c.err != nil {
 		return nil, c.err
 	}
@@ -56,7 +61,11 @@ func (c *testOnlineListChecker) QueryOnlineStreamers() (map[string]cmdlib.Stream
 	return streamers, nil
 }
 
-func (c *testOnlineListChecker) QueryFixedListOnlineStreamers(streamers []string, _ cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *testOnlineListChecker) QueryFixedListOnlineStreamers(
+	_ context.Context,
+	streamers []string,
+	_ cmdlib.CheckMode,
+) (map[string]cmdlib.StreamerInfo, error) {
 	if c.err != nil {
 		return nil, c.err
 	}
@@ -158,7 +167,13 @@ type testPolledChecker struct {
 	BaseChecker[*stubConfig]
 	online     map[string]bool
 	individual map[string]cmdlib.StatusKind
+	mu         sync.Mutex
 	queryCalls []string
+	// inFlight and maxInFlight count the concurrent QueryStatus calls;
+	// each call waits for hold to be closed, when it is set.
+	inFlight    int
+	maxInFlight int
+	hold        chan struct{}
 }
 
 func (*testPolledChecker) Site() string { return "test" }
@@ -167,8 +182,18 @@ func (*testPolledChecker) Site() string { return "test" }
 // directly via NewBaseChecker, bypassing the production Init path.
 func (*testPolledChecker) Init(_ string) error { return nil }
 
-func (c *testPolledChecker) QueryStatus(nickname string) (cmdlib.StreamerInfoWithStatus, error) {
+func (c *testPolledChecker) QueryStatus(_ context.Context, nickname string) (cmdlib.StreamerInfoWithStatus, error) {
+	c.mu.Lock()
 	c.queryCalls = append(c.queryCalls, nickname)
+	c.inFlight++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.mu.Unlock()
+	if c.hold != nil {
+		<-c.hold
+	}
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
 	status, ok := c.individual[nickname]
 	if !ok {
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusOffline}, nil
@@ -176,7 +201,7 @@ func (c *testPolledChecker) QueryStatus(nickname string) (cmdlib.StreamerInfoWit
 	return cmdlib.StreamerInfoWithStatus{Status: status}, nil
 }
 
-func (c *testPolledChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *testPolledChecker) QueryOnlineStreamers(context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	out := map[string]cmdlib.StreamerInfo{}
 	for k := range c.online {
 		out[k] = cmdlib.StreamerInfo{}
@@ -184,7 +209,7 @@ func (c *testPolledChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerIn
 	return out, nil
 }
 
-func (*testPolledChecker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (*testPolledChecker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -267,6 +292,53 @@ func TestOnlineListCheckerPollsAdditionalStreamers(t *testing.T) {
 	}
 }
 
+func TestOnlineListCheckerPollsConcurrently(t *testing.T) {
+	checker := &testPolledChecker{
+		online:     toSet("a"),
+		individual: map[string]cmdlib.StatusKind{"b": cmdlib.StatusOnline, "c": cmdlib.StatusUnknown},
+		hold:       make(chan struct{}),
+	}
+	checker.BaseChecker = NewBaseChecker(&stubConfig{BaseCheckerConfig{RequestConcurrency: 3}})
+	resultsCh := make(chan cmdlib.CheckerResults, 1)
+	StartCheckerDaemon(t.Context(), checker)
+
+	if err := checker.PushStatusRequest(&cmdlib.OnlineListRequest{
+		ResultsCh: resultsCh,
+		Poll:      []string{"a", "b", "c", "d", "e", "f"},
+	}); err != nil {
+		t.Fatalf("cannot push request: %v", err)
+	}
+	// The calls hold until three are in flight at once, the pool's bound.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		checker.mu.Lock()
+		inFlight := checker.inFlight
+		checker.mu.Unlock()
+		if inFlight == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d queries in flight, want 3", inFlight)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(checker.hold)
+	result := (<-resultsCh).(*cmdlib.OnlineListResults)
+	if checker.maxInFlight != 3 {
+		t.Errorf("max queries in flight = %d, want 3", checker.maxInFlight)
+	}
+	gotOnline := map[string]bool{}
+	for k := range result.Streamers {
+		gotOnline[k] = true
+	}
+	if !reflect.DeepEqual(gotOnline, toSet("a", "b")) {
+		t.Errorf("online: got %v, want a from the bulk and b polled", gotOnline)
+	}
+	if !reflect.DeepEqual(result.PollErrors, []string{"c"}) || result.PollCount != 6 {
+		t.Errorf("poll errors = %v of %d, want c of 6", result.PollErrors, result.PollCount)
+	}
+}
+
 func TestOnlineListCheckerError(t *testing.T) {
 	checker := &testOnlineListChecker{}
 	checker.BaseChecker = NewBaseChecker(&stubConfig{})
@@ -284,6 +356,68 @@ func TestOnlineListCheckerError(t *testing.T) {
 	}
 }
 
+// testBlockingChecker answers an online list query only when its context is done.
+type testBlockingChecker struct {
+	testOnlineListChecker
+	started  chan struct{}
+	returned chan struct{}
+}
+
+func (c *testBlockingChecker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
+	close(c.started)
+	defer close(c.returned)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCancelAbortsQueryInFlight(t *testing.T) {
+	checker := &testBlockingChecker{started: make(chan struct{}), returned: make(chan struct{})}
+	checker.BaseChecker = NewBaseChecker(&stubConfig{})
+	resultsCh := make(chan cmdlib.CheckerResults, 1)
+	ctx, cancel := context.WithCancel(t.Context())
+	StartCheckerDaemon(ctx, checker)
+
+	if err := checker.PushStatusRequest(&cmdlib.OnlineListRequest{ResultsCh: resultsCh}); err != nil {
+		t.Fatalf("cannot push request, %v", err)
+	}
+	<-checker.started
+	cancel()
+	select {
+	case <-checker.returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query outlived the daemon's context")
+	}
+	select {
+	case result := <-resultsCh:
+		t.Errorf("an aborted query was reported: failed = %v", result.Failed())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDoGetRequestHonoursDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	checker := &testChecker{}
+	checker.BaseChecker = NewBaseChecker(&stubConfig{})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if code := checker.QueryStatusCode(ctx, server.URL, nil); code != -1 {
+		t.Errorf("status code = %d, want -1 for an aborted request", code)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the request took %v past its deadline", elapsed)
+	}
+}
+
 func toSet(xs ...string) map[string]bool {
 	result := map[string]bool{}
 	for _, x := range xs {
@@ -41,34 +41,54 @@ func readCheckerConfig(
 	cfg validatedCheckerConfig,
 	website, checkerCfgPath string,
 ) error {
+	v, resolvedPath, err := loadCheckerConfig(website, checkerCfgPath)
+	if err != nil {
+		return err
+	}
+	cmdlib.Linf("successfully read checker config %q", resolvedPath)
+	cmdlib.BindEnvForConfig(v, cfg)
+
+	if err := v.Unmarshal(cfg, cmdlib.StrictConfigDecoder); err != nil {
+		return fmt.Errorf("parsing %q: %w", resolvedPath, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("validating %q: %w", resolvedPath, err)
+	}
+	return nil
+}
+
+// checkerConfigBaseURL returns the base_url <website>-checker.json sets, empty when it sets none.
+// A base_url points the site at a siren-checkerd rather than at the site itself.
+func checkerConfigBaseURL(website, checkerCfgPath string) (string, error) {
+	v, _, err := loadCheckerConfig(website, checkerCfgPath)
+	if err != nil {
+		return "", err
+	}
+	return v.GetString("base_url"), nil
+}
+
+// loadCheckerConfig finds and reads <website>-checker.json with the XRN_ env overrides wired,
+// returning it with the path it was read from.
+func loadCheckerConfig(website, checkerCfgPath string) (*viper.Viper, string, error) {
 	resolvedPath := checkerCfgPath
 	if resolvedPath == "" {
 		var err error
 		resolvedPath, err = findCheckerConfig(website)
 		if err != nil {
-			return err
+			return nil, "", err
 		}
 	}
 
 	v := viper.New()
 	v.SetConfigFile(resolvedPath)
 	if err := v.ReadInConfig(); err != nil {
-		return fmt.Errorf("reading %q: %w", resolvedPath, err)
+		return nil, "", fmt.Errorf("reading %q: %w", resolvedPath, err)
 	}
-	cmdlib.Linf("successfully read checker config %q", resolvedPath)
 
 	v.SetEnvPrefix("XRN")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
-	cmdlib.BindEnvForConfig(v, cfg)
-
-	if err := v.Unmarshal(cfg, cmdlib.StrictConfigDecoder); err != nil {
-		return fmt.Errorf("parsing %q: %w", resolvedPath, err)
-	}
-	if err := cfg.validate(); err != nil {
-		return fmt.Errorf("validating %q: %w", resolvedPath, err)
-	}
-	return nil
+	return v, resolvedPath, nil
 }
 
 func findCheckerConfig(website string) (string, error) {
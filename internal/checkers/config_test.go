@@ -72,3 +72,59 @@ func TestReadCheckerConfigEnvOverride(t *testing.T) {
 		})
 	}
 }
+
+// TestBuildReadsCheckerdOnBaseURL checks that a checker config setting base_url
+// builds the site's adapter, which keeps the site's own nickname handling,
+// and that one without it builds the site's own checker.
+func TestBuildReadsCheckerdOnBaseURL(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	remote := write("remote.json", `{
+	"timeout_seconds": 10,
+	"min_request_interval_ms": 100,
+	"base_url": "http://siren-checkerd:8080/"
+}`)
+	local := write("local.json", `{
+	"timeout_seconds": 10,
+	"min_request_interval_ms": 100,
+	"users_online_endpoint": "https://example.test/online",
+	"proxies": ["http://proxy.test:3128"]
+}`)
+
+	checker, err := Build("chaturbate", remote)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	adapter, ok := checker.(*OnlineListAdapter)
+	if !ok {
+		t.Fatalf("Build with base_url = %T, want *OnlineListAdapter", checker)
+	}
+	if adapter.Site() != "chaturbate" || adapter.Cfg.BaseURL != "http://siren-checkerd:8080" {
+		t.Errorf("adapter reads %s from %q", adapter.Site(), adapter.Cfg.BaseURL)
+	}
+	if got := adapter.NicknamePreprocessing("https://chaturbate.com/Model_1/"); got != "model_1" {
+		t.Errorf("NicknamePreprocessing = %q, want model_1", got)
+	}
+	caps := adapter.Capabilities()
+	if !caps.SupportsSubject || !caps.SupportsShowKind || !caps.SupportsQueryStatus {
+		t.Errorf("adapter capabilities %+v lost the site's own", caps)
+	}
+
+	checker, err = Build("chaturbate", local)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if _, ok := checker.(*ChaturbateChecker); !ok {
+		t.Errorf("Build without base_url = %T, want *ChaturbateChecker", checker)
+	}
+
+	if _, err := Build("twitch", remote); err == nil {
+		t.Error("Build let a site without an online list read siren-checkerd")
+	}
+}
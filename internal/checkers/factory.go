@@ -32,11 +32,22 @@ func New(site string) (Checker, error) {
 }
 
 // Build is New + Init.
+// A checker config setting base_url builds the site's OnlineListAdapter instead,
+// reading the site from the siren-checkerd there.
 func Build(site, checkerCfgPath string) (Checker, error) {
 	checker, err := New(site)
 	if err != nil {
 		return nil, err
 	}
+	baseURL, err := checkerConfigBaseURL(site, checkerCfgPath)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL != "" {
+		if checker, err = NewSiteAdapter(checker); err != nil {
+			return nil, err
+		}
+	}
 	if err := checker.Init(checkerCfgPath); err != nil {
 		return nil, err
 	}
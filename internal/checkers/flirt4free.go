@@ -2,6 +2,7 @@ package checkers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -55,8 +56,8 @@ type flirt4FreeOnlineResponse struct {
 }
 
 // QueryStatus checks Flirt4Free model status
-func (c *Flirt4FreeChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithStatus, error) {
-	resp := c.DoGetRequest(fmt.Sprintf("https://ws.vs3.com/rooms/check-model-status.php?model_name=%s", modelID), c.Cfg.Headers)
+func (c *Flirt4FreeChecker) QueryStatus(ctx context.Context, modelID string) (cmdlib.StreamerInfoWithStatus, error) {
+	resp := c.DoGetRequest(ctx, fmt.Sprintf("https://ws.vs3.com/rooms/check-model-status.php?model_name=%s", modelID), c.Cfg.Headers)
 	if resp == nil {
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}, nil
 	}
@@ -121,9 +122,9 @@ func (c *Flirt4FreeChecker) NicknamePreprocessing(name string) string {
 }
 
 // QueryOnlineStreamers returns Flirt4Free online models
-func (c *Flirt4FreeChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *Flirt4FreeChecker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	streamers := map[string]cmdlib.StreamerInfo{}
-	resp, buf, err := cmdlib.OnlineQuery(c.Cfg.UsersOnlineEndpoint, c.Client, c.Cfg.Headers)
+	resp, buf, err := cmdlib.OnlineQuery(ctx, c.Cfg.UsersOnlineEndpoint, c.Client, c.Cfg.Headers)
 	if err != nil {
 		return nil, fmt.Errorf("cannot send a query, %v", err)
 	}
@@ -167,7 +168,7 @@ func (c *Flirt4FreeChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerIn
 }
 
 // QueryFixedListOnlineStreamers is not implemented for online list checkers
-func (c *Flirt4FreeChecker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *Flirt4FreeChecker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -179,5 +180,6 @@ func (*Flirt4FreeChecker) Capabilities() Capabilities {
 		SupportsQueryFixedListStatuses:        false,
 		SupportsQueryStatus:                   true,
 		SupportsCLI:                           true,
+		SupportsShowKind:                      true,
 	}
 }
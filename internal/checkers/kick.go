@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -88,17 +89,23 @@ func (c *KickChecker) NicknameRegexp() *regexp.Regexp {
 	return KickChannelIDRegexp
 }
 
-func (c *KickChecker) requestAccessToken(httpClient *http.Client) (string, error) {
+func (c *KickChecker) requestAccessToken(ctx context.Context, httpClient *http.Client) (string, error) {
 	data := url.Values{
 		"grant_type":    {"client_credentials"},
 		"client_id":     {string(c.Cfg.ClientID)},
 		"client_secret": {string(c.Cfg.ClientSecret)},
 	}
-	resp, err := httpClient.Post(
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
 		"https://id.kick.com/oauth/token",
-		"application/x-www-form-urlencoded",
 		strings.NewReader(data.Encode()),
 	)
+	if err != nil {
+		return "", fmt.Errorf("creating kick token request, %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("requesting kick access token, %w", err)
 	}
@@ -114,6 +121,7 @@ func (c *KickChecker) requestAccessToken(httpClient *http.Client) (string, error
 }
 
 func (c *KickChecker) queryChannels(
+	ctx context.Context,
 	httpClient *http.Client,
 	token string,
 	slugs []string,
@@ -123,7 +131,7 @@ func (c *KickChecker) queryChannels(
 		params.Add("slug", s)
 	}
 	reqURL := "https://api.kick.com/public/v1/channels?" + params.Encode()
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating kick request, %w", err)
 	}
@@ -144,13 +152,13 @@ func (c *KickChecker) queryChannels(
 }
 
 // QueryStatus checks Kick channel status
-func (c *KickChecker) QueryStatus(channelID string) (cmdlib.StreamerInfoWithStatus, error) {
-	token, err := c.requestAccessToken(c.Client)
+func (c *KickChecker) QueryStatus(ctx context.Context, channelID string) (cmdlib.StreamerInfoWithStatus, error) {
+	token, err := c.requestAccessToken(ctx, c.Client)
 	if err != nil {
 		cmdlib.Lerr("%v", err)
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}, nil
 	}
-	channels, err := c.queryChannels(c.Client, token, []string{channelID})
+	channels, err := c.queryChannels(ctx, c.Client, token, []string{channelID})
 	if err != nil {
 		cmdlib.Lerr("%v", err)
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}, nil
@@ -174,23 +182,24 @@ func (c *KickChecker) QueryStatus(channelID string) (cmdlib.StreamerInfoWithStat
 }
 
 // QueryOnlineStreamers returns all online Kick channels
-func (c *KickChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *KickChecker) QueryOnlineStreamers(context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
 // QueryFixedListOnlineStreamers returns statuses for specific Kick channels
 func (c *KickChecker) QueryFixedListOnlineStreamers(
+	ctx context.Context,
 	channelIDs []string,
 	_ cmdlib.CheckMode,
 ) (map[string]cmdlib.StreamerInfo, error) {
-	token, err := c.requestAccessToken(c.Client)
+	token, err := c.requestAccessToken(ctx, c.Client)
 	if err != nil {
 		return nil, err
 	}
 	result := map[string]cmdlib.StreamerInfo{}
 	// Kick API allows up to 50 slugs per request
 	for _, chunk := range chunks(channelIDs, 50) {
-		channels, err := c.queryChannels(c.Client, token, chunk)
+		channels, err := c.queryChannels(ctx, c.Client, token, chunk)
 		if err != nil {
 			return nil, err
 		}
@@ -212,10 +221,11 @@ func (c *KickChecker) QueryFixedListOnlineStreamers(
 
 // QueryFixedListStatuses checks if specific Kick channels exist
 func (c *KickChecker) QueryFixedListStatuses(
+	ctx context.Context,
 	channelIDs []string,
 	_ cmdlib.CheckMode,
 ) (map[string]cmdlib.StreamerInfoWithStatus, error) {
-	token, err := c.requestAccessToken(c.Client)
+	token, err := c.requestAccessToken(ctx, c.Client)
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +235,7 @@ func (c *KickChecker) QueryFixedListStatuses(
 	}
 	// Kick API allows up to 50 slugs per request
 	for _, chunk := range chunks(channelIDs, 50) {
-		channels, err := c.queryChannels(c.Client, token, chunk)
+		channels, err := c.queryChannels(ctx, c.Client, token, chunk)
 		if err != nil {
 			return nil, err
 		}
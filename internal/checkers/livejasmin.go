@@ -2,6 +2,7 @@ package checkers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -82,11 +83,11 @@ type liveJasminResponse struct {
 }
 
 // QueryStatus checks LiveJasmin model status
-func (c *LiveJasminChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithStatus, error) {
+func (c *LiveJasminChecker) QueryStatus(ctx context.Context, modelID string) (cmdlib.StreamerInfoWithStatus, error) {
 	psID := string(c.Cfg.PsID)
 	accessKey := string(c.Cfg.AccessKey)
 	url := fmt.Sprintf("https://pt.potawe.com/api/model/status?performerId=%s&psId=%s&accessKey=%s&legacyRedirect=1", modelID, psID, accessKey)
-	resp := c.DoGetRequest(url, c.Cfg.Headers)
+	resp := c.DoGetRequest(ctx, url, c.Cfg.Headers)
 	if resp == nil {
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}, nil
 	}
@@ -134,9 +135,9 @@ func liveJasminShowKind(status string) cmdlib.ShowKind {
 }
 
 // checkEndpoint returns LiveJasmin online models
-func (c *LiveJasminChecker) checkEndpoint(endpoint string) (map[string]cmdlib.StreamerInfo, error) {
+func (c *LiveJasminChecker) checkEndpoint(ctx context.Context, endpoint string) (map[string]cmdlib.StreamerInfo, error) {
 	streamers := map[string]cmdlib.StreamerInfo{}
-	resp, buf, err := cmdlib.OnlineQuery(endpoint, c.Client, c.Cfg.Headers)
+	resp, buf, err := cmdlib.OnlineQuery(ctx, endpoint, c.Client, c.Cfg.Headers)
 	if err != nil {
 		return nil, fmt.Errorf("cannot send a query, %v", err)
 	}
@@ -165,10 +166,10 @@ func (c *LiveJasminChecker) checkEndpoint(endpoint string) (map[string]cmdlib.St
 }
 
 // QueryOnlineStreamers returns LiveJasmin online models
-func (c *LiveJasminChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *LiveJasminChecker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	streamers := map[string]cmdlib.StreamerInfo{}
 	for _, endpoint := range c.Cfg.UsersOnlineEndpoints {
-		endpointStreamers, err := c.checkEndpoint(endpoint)
+		endpointStreamers, err := c.checkEndpoint(ctx, endpoint)
 		if err != nil {
 			return nil, err
 		}
@@ -184,7 +185,7 @@ func (c *LiveJasminChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerIn
 }
 
 // QueryFixedListOnlineStreamers is not implemented for online list checkers
-func (c *LiveJasminChecker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *LiveJasminChecker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -197,5 +198,6 @@ func (*LiveJasminChecker) Capabilities() Capabilities {
 		SupportsQueryStatus:                   true,
 		SupportsCLI:                           true,
 		SupportsSubject:                       true,
+		SupportsShowKind:                      true,
 	}
 }
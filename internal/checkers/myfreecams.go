@@ -18,5 +18,6 @@ func NewMyFreeCamsChecker() *OnlineListAdapter {
 		NicknameRegex:     myFreeCamsModelRegexp,
 		NicknameValidator: myFreeCamsNicknameRegexp,
 		SupportsSubject:   true,
+		SupportsShowKind:  true,
 	}
 }
@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -43,12 +44,17 @@ func (c *AdapterCheckerConfig) validate() error {
 // implementation can serve any site that has a backing daemon.
 //
 // Construction is two-phase: a per-site constructor (e.g.
-// NewMyFreeCamsChecker) sets siteName and the nickname/subject fields,
+// NewMyFreeCamsChecker, or NewSiteAdapter for a site served by
+// siren-checkerd) sets siteName and the nickname/subject fields,
 // then Init populates the embedded BaseChecker from the checker config.
 // Fields set by the constructor survive Init.
 type OnlineListAdapter struct {
 	BaseChecker[*AdapterCheckerConfig]
 	siteName string
+	// native is the site's own checker, left uninitialised, when the
+	// daemon is siren-checkerd running it; nickname handling and the
+	// status surface are taken from it. Nil for a dedicated daemon.
+	native Checker
 	// NicknameRegex extracts the model nickname from a URL (used by
 	// NicknamePreprocessing). Optional — if nil, input is used as-is.
 	NicknameRegex *regexp.Regexp
@@ -59,10 +65,31 @@ type OnlineListAdapter struct {
 	// SupportsSubject is set by the constructor when the backing daemon
 	// surfaces room subjects; surfaced via Capabilities.
 	SupportsSubject bool
+	// SupportsShowKind is set likewise when the daemon surfaces show kinds.
+	SupportsShowKind bool
 }
 
 var _ Checker = &OnlineListAdapter{}
 
+// NewSiteAdapter returns an OnlineListAdapter reading the site of native
+// from a siren-checkerd running native's checker. A checker that is an
+// adapter already is returned as is.
+func NewSiteAdapter(native Checker) (*OnlineListAdapter, error) {
+	if adapter, ok := native.(*OnlineListAdapter); ok {
+		return adapter, nil
+	}
+	caps := native.Capabilities()
+	if !caps.SupportsQueryOnlineStreamers {
+		return nil, fmt.Errorf("%s has no online list for siren-checkerd to serve", native.Site())
+	}
+	return &OnlineListAdapter{
+		siteName:         native.Site(),
+		native:           native,
+		SupportsSubject:  caps.SupportsSubject,
+		SupportsShowKind: caps.SupportsShowKind,
+	}, nil
+}
+
 // Site returns the site name.
 func (c *OnlineListAdapter) Site() string { return c.siteName }
 
@@ -82,6 +109,9 @@ func (c *OnlineListAdapter) Init(checkerCfgPath string) error {
 // NicknamePreprocessing extracts a canonical nickname from a URL or raw name
 // and lowercases it (all sites we adapt to are case-insensitive).
 func (c *OnlineListAdapter) NicknamePreprocessing(name string) string {
+	if c.native != nil {
+		return c.native.NicknamePreprocessing(name)
+	}
 	if c.NicknameRegex != nil {
 		if m := c.NicknameRegex.FindStringSubmatch(name); len(m) == 2 {
 			name = m[1]
@@ -93,29 +123,34 @@ func (c *OnlineListAdapter) NicknamePreprocessing(name string) string {
 // NicknameRegexp returns the validator the bot uses to reject malformed
 // nicknames before issuing status queries.
 func (c *OnlineListAdapter) NicknameRegexp() *regexp.Regexp {
+	if c.native != nil {
+		return c.native.NicknameRegexp()
+	}
 	return c.NicknameValidator
 }
 
 // Capabilities lists the surfaces the adapter exposes for dispatch.
-// SupportsSubject reads the instance field set by the per-site constructor.
+// SupportsSubject and SupportsShowKind read the instance fields set by the per-site constructor.
+// Status queries are there unless siren-checkerd runs a checker without them, which answers 501.
 func (c *OnlineListAdapter) Capabilities() Capabilities {
 	return Capabilities{
 		SupportsQueryOnlineStreamers:          true,
 		SupportsQueryFixedListOnlineStreamers: false,
 		SupportsQueryFixedListStatuses:        false,
-		SupportsQueryStatus:                   true,
+		SupportsQueryStatus:                   c.native == nil || c.native.Capabilities().SupportsQueryStatus,
 		SupportsCLI:                           false,
 		SupportsSubject:                       c.SupportsSubject,
+		SupportsShowKind:                      c.SupportsShowKind,
 	}
 }
 
 // QueryOnlineStreamers fetches the online list from the daemon.
-func (c *OnlineListAdapter) QueryOnlineStreamers() (
+func (c *OnlineListAdapter) QueryOnlineStreamers(ctx context.Context) (
 	map[string]cmdlib.StreamerInfo,
 	error,
 ) {
 	endpoint := c.Cfg.BaseURL + "/online"
-	resp, buf, err := cmdlib.OnlineQuery(endpoint, c.Client, nil)
+	resp, buf, err := cmdlib.OnlineQuery(ctx, endpoint, c.Client, nil)
 	if err != nil {
 		return nil, fmt.Errorf("cannot query %s, %v", endpoint, err)
 	}
@@ -145,9 +180,9 @@ func (c *OnlineListAdapter) QueryOnlineStreamers() (
 // returns the StreamerInfoWithStatus it reports. Per checker convention
 // any transport or parse failure is logged and surfaced as StatusUnknown
 // rather than an error so the bot's status loop keeps running.
-func (c *OnlineListAdapter) QueryStatus(nickname string) (cmdlib.StreamerInfoWithStatus, error) {
+func (c *OnlineListAdapter) QueryStatus(ctx context.Context, nickname string) (cmdlib.StreamerInfoWithStatus, error) {
 	endpoint := c.Cfg.BaseURL + "/status?name=" + url.QueryEscape(nickname)
-	resp, buf, err := cmdlib.OnlineQuery(endpoint, c.Client, nil)
+	resp, buf, err := cmdlib.OnlineQuery(ctx, endpoint, c.Client, nil)
 	if err != nil {
 		cmdlib.Lerr("cannot query %s, %v", endpoint, err)
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}, nil
@@ -167,6 +202,7 @@ func (c *OnlineListAdapter) QueryStatus(nickname string) (cmdlib.StreamerInfoWit
 
 // QueryFixedListOnlineStreamers is not implemented for online-list adapters.
 func (c *OnlineListAdapter) QueryFixedListOnlineStreamers(
+	_ context.Context,
 	_ []string,
 	_ cmdlib.CheckMode,
 ) (map[string]cmdlib.StreamerInfo, error) {
@@ -0,0 +1,165 @@
+package checkers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+const (
+	// proxyBackoffBase is how long a proxy leaves the rotation after its first failure in a row.
+	// Each further failure in a row doubles it up to proxyBackoffMax.
+	proxyBackoffBase = 10 * time.Second
+	proxyBackoffMax  = 10 * time.Minute
+	// maxPeek bounds the body read to tell a site's answer from the site turning a proxy away.
+	maxPeek = 64 << 10
+)
+
+// proxyPool spreads a checker's requests over proxies in turn; retryTransport sends them.
+// A proxy failing a request, by a transport error such as a timeout
+// or by a 403 or 429 answer that is not the site's own (see siteAnswer),
+// leaves the rotation for a backoff growing with its failures in a row,
+// and the request's retry goes to the next healthy proxy.
+// With every proxy out of the rotation, the one due back first takes the request.
+type proxyPool struct {
+	proxies []*proxy
+	// siteAnswer tells whether a 403 or 429 with the given body is the site's own answer to the request,
+	// such as a room denied to everyone, rather than the site turning the proxy away.
+	// Nil takes every such answer as the proxy's fault. Set before the first request.
+	siteAnswer func(resp *http.Response, body []byte) bool
+	mu         sync.Mutex
+	next       int
+	now        func() time.Time
+}
+
+type proxy struct {
+	// label names the proxy in logs without its credentials.
+	label  string
+	client *http.Client
+	// limiter paces the proxy, which concurrent polls would otherwise hit in bursts.
+	limiter *rateLimiter
+
+	// The fields below are guarded by the pool's mutex.
+	failuresInRow  int
+	unhealthyUntil time.Time
+	successes      int
+	failures       int
+	latency        time.Duration
+}
+
+func newProxyPool(proxies []cmdlib.Secret, timeout, interval time.Duration) (*proxyPool, error) {
+	p := &proxyPool{now: time.Now}
+	for i, s := range proxies {
+		u, err := url.Parse(string(s))
+		if err != nil {
+			return nil, fmt.Errorf("proxies[%d]: invalid URL, %v", i, err)
+		}
+		p.proxies = append(p.proxies, &proxy{
+			label:   u.Host,
+			client:  cmdlib.HTTPClientWithProxy(timeout, u),
+			limiter: newRateLimiter(interval, 1),
+		})
+	}
+	return p, nil
+}
+
+// pick returns the next healthy proxy not in failed, or nil when there is none.
+// With no failure yet, the request takes the proxy due back first when none is healthy.
+func (p *proxyPool) pick(failed map[*proxy]bool) *proxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	var soonest *proxy
+	for range p.proxies {
+		x := p.proxies[p.next]
+		p.next = (p.next + 1) % len(p.proxies)
+		if failed[x] {
+			continue
+		}
+		if !x.unhealthyUntil.After(now) {
+			return x
+		}
+		if soonest == nil || x.unhealthyUntil.Before(soonest.unhealthyUntil) {
+			soonest = x
+		}
+	}
+	if len(failed) > 0 {
+		return nil
+	}
+	return soonest
+}
+
+// record updates the proxy's health and counters with an attempt's outcome.
+func (p *proxyPool) record(x *proxy, ok bool, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ok {
+		x.successes++
+		x.latency += latency
+		x.failuresInRow = 0
+		x.unhealthyUntil = time.Time{}
+		return
+	}
+	x.failures++
+	x.failuresInRow++
+	backoff := proxyBackoffMax
+	if x.failuresInRow <= 10 {
+		backoff = min(proxyBackoffBase<<(x.failuresInRow-1), proxyBackoffMax)
+	}
+	x.unhealthyUntil = p.now().Add(backoff)
+	cmdlib.Lerr("proxy %s is out of the rotation for %v", x.label, backoff)
+}
+
+// proxyFailed tells whether a response means the proxy, not the request, is at fault.
+func (p *proxyPool) proxyFailed(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	return p.siteAnswer == nil || !p.siteAnswer(resp, peekBody(resp))
+}
+
+// peekBody returns the start of the response's body, leaving the body to read in full.
+func peekBody(resp *http.Response) []byte {
+	// A read error surfaces again to whoever reads the rest.
+	peek, _ := io.ReadAll(io.LimitReader(resp.Body, maxPeek))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+	return peek
+}
+
+// send sends a request through the proxy once its limiter lets it.
+func (x *proxy) send(req *http.Request) (*http.Response, error) {
+	if !x.limiter.wait(req.Context()) {
+		return nil, req.Context().Err()
+	}
+	return x.client.Do(req)
+}
+
+// takeStats returns each proxy's counters since the previous call and resets them.
+func (p *proxyPool) takeStats() []cmdlib.ProxyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	stats := make([]cmdlib.ProxyStats, len(p.proxies))
+	for i, x := range p.proxies {
+		stats[i] = cmdlib.ProxyStats{
+			Proxy:     x.label,
+			Successes: x.successes,
+			Failures:  x.failures,
+			Healthy:   !x.unhealthyUntil.After(now),
+		}
+		if x.successes > 0 {
+			stats[i].LatencyMs = int((x.latency / time.Duration(x.successes)).Milliseconds())
+		}
+		x.successes, x.failures, x.latency = 0, 0, 0
+	}
+	return stats
+}
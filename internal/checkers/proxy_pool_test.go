@@ -0,0 +1,148 @@
+package checkers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// testProxy is an HTTP proxy answering every request itself with status.
+func testProxy(t *testing.T, status *atomic.Int32, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// proxiedChecker builds a checker sending its requests through the given proxies
+// without pacing and with retries a millisecond apart.
+func proxiedChecker(proxies ...string) *testChecker {
+	cfg := &stubConfig{BaseCheckerConfig{TimeoutSeconds: 1, RetryBackoffMs: 1}}
+	for _, p := range proxies {
+		cfg.Proxies = append(cfg.Proxies, cmdlib.Secret(p))
+	}
+	checker := &testChecker{}
+	checker.BaseChecker = NewBaseChecker(cfg)
+	return checker
+}
+
+func TestProxyPoolFailover(t *testing.T) {
+	var badStatus, goodStatus, badHits, goodHits atomic.Int32
+	badStatus.Store(http.StatusTooManyRequests)
+	goodStatus.Store(http.StatusOK)
+	bad := testProxy(t, &badStatus, &badHits)
+	good := testProxy(t, &goodStatus, &goodHits)
+	checker := proxiedChecker(bad.URL, good.URL)
+	pool := checker.proxies
+	now := time.Unix(1000, 0)
+	pool.now = func() time.Time { return now }
+	client := checker.Client
+
+	get := func() int {
+		t.Helper()
+		resp, err := client.Get("http://upstream.test/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmdlib.CloseBody(resp.Body)
+		return resp.StatusCode
+	}
+
+	if code := get(); code != http.StatusOK || badHits.Load() != 1 || goodHits.Load() != 1 {
+		t.Fatalf("got %d with %d bad and %d good hits, want a retry on the good proxy", code, badHits.Load(), goodHits.Load())
+	}
+	get()
+	get()
+	if badHits.Load() != 1 {
+		t.Errorf("the failed proxy took %d requests while out of the rotation", badHits.Load()-1)
+	}
+	stats := pool.takeStats()
+	if stats[0].Failures != 1 || stats[0].Healthy || stats[1].Successes != 3 || !stats[1].Healthy {
+		t.Errorf("stats = %+v, want one failure on an unhealthy first proxy and three successes on the second", stats)
+	}
+	if stats := pool.takeStats(); stats[1].Successes != 0 {
+		t.Errorf("stats were not reset: %+v", stats)
+	}
+
+	// Past its backoff, the proxy is back and fails again for twice as long.
+	now = now.Add(proxyBackoffBase)
+	get()
+	get()
+	if badHits.Load() != 2 {
+		t.Errorf("the proxy took %d requests after its backoff, want 1", badHits.Load()-1)
+	}
+	now = now.Add(proxyBackoffBase)
+	get()
+	if badHits.Load() != 2 {
+		t.Error("the backoff did not grow with a second failure in a row")
+	}
+
+	// With every proxy failing, the last answer comes back.
+	goodStatus.Store(http.StatusForbidden)
+	if code := get(); code != http.StatusForbidden {
+		t.Errorf("got %d, want the last proxy's 403", code)
+	}
+}
+
+func TestProxyStatsReachOnlineListResults(t *testing.T) {
+	var status, hits atomic.Int32
+	status.Store(http.StatusOK)
+	srv := testProxy(t, &status, &hits)
+	checker := &testOnlineListChecker{}
+	checker.BaseChecker = NewBaseChecker(&stubConfig{BaseCheckerConfig{
+		TimeoutSeconds: 1,
+		Proxies:        []cmdlib.Secret{cmdlib.Secret(srv.URL)},
+	}})
+	if lanes := checker.Cfg.RequestLanes(); lanes != 1 {
+		t.Errorf("request lanes = %d, want one per proxy", lanes)
+	}
+	if code := checker.QueryStatusCode(t.Context(), "http://upstream.test/", nil); code != http.StatusOK || hits.Load() != 1 {
+		t.Fatalf("status code = %d with %d proxy hits, want 200 through the proxy", code, hits.Load())
+	}
+	resultsCh := make(chan cmdlib.CheckerResults, 1)
+	StartCheckerDaemon(t.Context(), checker)
+	if err := checker.PushStatusRequest(&cmdlib.OnlineListRequest{ResultsCh: resultsCh}); err != nil {
+		t.Fatalf("cannot push request: %v", err)
+	}
+	result := (<-resultsCh).(*cmdlib.OnlineListResults)
+	if len(result.HTTP.Proxies) != 1 || result.HTTP.Proxies[0].Successes != 1 || result.ExtraLogFields()["proxies"] == nil {
+		t.Errorf("proxy stats = %+v, want the one success logged", result.HTTP.Proxies)
+	}
+}
+
+func TestProxyPoolKeepsSiteDenial(t *testing.T) {
+	var hits atomic.Int32
+	deny := func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"status": 403, "code": "access-denied"}`))
+	}
+	a := httptest.NewServer(http.HandlerFunc(deny))
+	defer a.Close()
+	b := httptest.NewServer(http.HandlerFunc(deny))
+	defer b.Close()
+	checker := proxiedChecker(a.URL, b.URL)
+	pool := checker.proxies
+	pool.siteAnswer = chaturbateSiteAnswer
+
+	resp, body, err := cmdlib.OnlineQuery(t.Context(), "http://upstream.test/", checker.Client, nil)
+	if err != nil || resp.StatusCode != http.StatusForbidden || !strings.Contains(body.String(), "access-denied") {
+		t.Fatalf("got %v, %v, want the denial with its body", resp, err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("a denied room was tried on %d proxies, want 1", hits.Load())
+	}
+	for _, stats := range pool.takeStats() {
+		if !stats.Healthy || stats.Failures != 0 {
+			t.Errorf("a site's denial benched a proxy: %+v", stats)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"math/rand"
 	"time"
 
@@ -39,7 +40,7 @@ func (c *RandomChecker) Init(checkerCfgPath string) error {
 var _ Checker = &RandomChecker{}
 
 // QueryStatus mimics checker
-func (c *RandomChecker) QueryStatus(_ string) (cmdlib.StreamerInfoWithStatus, error) {
+func (c *RandomChecker) QueryStatus(context.Context, string) (cmdlib.StreamerInfoWithStatus, error) {
 	return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusOnline}, nil
 }
 
@@ -55,7 +56,7 @@ func randString(n int) string {
 }
 
 // QueryOnlineStreamers returns Random online streamers
-func (c *RandomChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *RandomChecker) QueryOnlineStreamers(context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	now := time.Now()
 	seconds := now.Sub(now.Truncate(time.Minute))
 	streamers := map[string]cmdlib.StreamerInfo{}
@@ -70,7 +71,7 @@ func (c *RandomChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo,
 }
 
 // QueryFixedListOnlineStreamers is not implemented for online list checkers
-func (c *RandomChecker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *RandomChecker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -0,0 +1,44 @@
+package checkers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by concurrent requests:
+// it admits burst requests at once and one more every interval.
+// A zero interval admits every request at once.
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+	mu       sync.Mutex
+	// next is when the next request is due; a full bucket has it burst-1 intervals in the past.
+	next time.Time
+}
+
+func newRateLimiter(interval time.Duration, burst int) *rateLimiter {
+	return &rateLimiter{interval: interval, burst: max(burst, 1)}
+}
+
+// reserve takes the next slot and returns how long to wait for it.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// A bucket idle for long is full, not holding every token it has missed.
+	if full := now.Add(-time.Duration(l.burst-1) * l.interval); l.next.Before(full) {
+		l.next = full
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	return max(wait, 0)
+}
+
+// wait blocks until the request's slot or until ctx is cancelled.
+// Returns false if cancelled; the slot is spent either way.
+func (l *rateLimiter) wait(ctx context.Context) bool {
+	if l.interval == 0 {
+		return ctx.Err() == nil
+	}
+	return sleepCtx(ctx, l.reserve(time.Now()))
+}
@@ -0,0 +1,39 @@
+package checkers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := newRateLimiter(time.Second, 2)
+	now := time.Unix(1000, 0)
+	for i, want := range []time.Duration{0, 0, time.Second, 2 * time.Second} {
+		if got := l.reserve(now); got != want {
+			t.Errorf("reservation %d waits %v, want %v", i, got, want)
+		}
+	}
+	// Idle long enough, the bucket refills to its burst and no further.
+	now = now.Add(time.Hour)
+	for i, want := range []time.Duration{0, 0, time.Second} {
+		if got := l.reserve(now); got != want {
+			t.Errorf("reservation %d after idling waits %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	l := newRateLimiter(time.Hour, 1)
+	if !l.wait(t.Context()) {
+		t.Fatal("the first request was not admitted at once")
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if l.wait(ctx) {
+		t.Error("a cancelled wait was admitted")
+	}
+	if !newRateLimiter(0, 1).wait(t.Context()) {
+		t.Error("a limiter without an interval held a request")
+	}
+}
@@ -0,0 +1,173 @@
+package checkers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+// RetryPolicy tells how a checker's requests are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // attempts per request, the first included
+	Backoff     time.Duration // wait before the first retry, doubling for each further one
+	MaxBackoff  time.Duration // cap on the wait; a longer Retry-After gives up instead
+	StatusCodes []int         // answers worth another attempt, beside transport errors
+}
+
+// backoff returns the wait before the attempt following the given one.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	for range attempt - 1 {
+		if d >= p.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	return min(d, p.MaxBackoff)
+}
+
+// httpCounters counts a checker's upstream attempts for its HTTP stats.
+type httpCounters struct {
+	attempts atomic.Int64
+	retries  atomic.Int64
+}
+
+// retryTransport is an http.RoundTripper sending each request directly or through the proxy pool
+// until an attempt neither fails in transport nor gets one of the policy's status codes,
+// or the policy's attempts are spent. Proxy rotation happens here, as retries, not in the pool:
+// a proxy failing an attempt leaves the rotation and the retry goes at once to the next healthy proxy,
+// while other failures wait the backoff, or the answer's Retry-After when it names a wait.
+// Every retry then waits its turn in the checker's limiter, so it counts against the site's pace.
+type retryTransport struct {
+	direct   *http.Client // sends the attempts of a checker without proxies
+	pool     *proxyPool   // sends the attempts through proxies; nil without them
+	policy   RetryPolicy
+	limiter  *rateLimiter
+	counters *httpCounters
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+// Returns false when the header is missing or malformed.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// replay returns a copy of req to send again, with a fresh body.
+// Returns false when the body cannot be replayed.
+func replay(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req.Clone(req.Context()), true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, true
+}
+
+// RoundTrip sends the request, retrying it by the policy.
+// Returns the last attempt's answer or error when no retry is left or worth it.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	failed := map[*proxy]bool{}
+	var x *proxy
+	if t.pool != nil {
+		x = t.pool.pick(failed)
+	}
+	attempt := req
+	for n := 1; ; n++ {
+		t.counters.attempts.Add(1)
+		if n > 1 {
+			t.counters.retries.Add(1)
+		}
+		cmdlib.Ldbg("attempt %d of %d: %s %s", n, t.policy.MaxAttempts, req.Method, req.URL.Redacted())
+		start := time.Now()
+		resp, err := t.send(attempt, x)
+		if err != nil {
+			// The client wraps the error with the method and URL, which the outer client adds again.
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+		}
+		if ctx.Err() != nil {
+			return resp, err
+		}
+		wait := t.policy.backoff(n)
+		switch {
+		case err != nil:
+			cmdlib.Lerr("attempt %d of %d to %s failed, %v", n, t.policy.MaxAttempts, req.URL.Host, err)
+			if x != nil {
+				t.pool.record(x, false, 0)
+				failed[x] = true
+				wait = 0
+			}
+		case x != nil && t.pool.proxyFailed(resp):
+			cmdlib.Lerr("attempt %d of %d to %s got %d through proxy %s", n, t.policy.MaxAttempts, req.URL.Host, resp.StatusCode, x.label)
+			t.pool.record(x, false, 0)
+			failed[x] = true
+			wait = 0
+		default:
+			if x != nil {
+				t.pool.record(x, true, time.Since(start))
+			}
+			if !slices.Contains(t.policy.StatusCodes, resp.StatusCode) {
+				return resp, nil
+			}
+			cmdlib.Lerr("attempt %d of %d to %s got %d", n, t.policy.MaxAttempts, req.URL.Host, resp.StatusCode)
+			if d, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if d > t.policy.MaxBackoff {
+					return resp, nil
+				}
+				wait = d
+			}
+		}
+		if n >= t.policy.MaxAttempts {
+			return resp, err
+		}
+		if t.pool != nil {
+			if x = t.pool.pick(failed); x == nil {
+				return resp, err
+			}
+		}
+		next, ok := replay(req)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			cmdlib.CloseBody(resp.Body)
+		}
+		if !sleepCtx(ctx, wait) || !t.limiter.wait(ctx) {
+			return nil, ctx.Err()
+		}
+		attempt = next
+	}
+}
+
+// send sends an attempt through the proxy, or directly when it is nil.
+func (t *retryTransport) send(req *http.Request, x *proxy) (*http.Response, error) {
+	if x == nil {
+		return t.direct.Do(req)
+	}
+	return x.send(req)
+}
@@ -0,0 +1,124 @@
+package checkers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bcmk/siren/v4/lib/cmdlib"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 40: 5 * time.Second} {
+		if got := p.backoff(attempt); got != want {
+			t.Errorf("backoff after attempt %d = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for header, want := range map[string]time.Duration{
+		"3":                             3 * time.Second,
+		"Thu, 01 Jan 2026 00:00:10 GMT": 10 * time.Second,
+	} {
+		if got, ok := retryAfter(header, now); !ok || got != want {
+			t.Errorf("Retry-After %q = %v, %v, want %v", header, got, ok, want)
+		}
+	}
+	for _, header := range []string{"", "soon", "-1"} {
+		if _, ok := retryAfter(header, now); ok {
+			t.Errorf("Retry-After %q was taken", header)
+		}
+	}
+}
+
+func TestDoGetRequestRetries(t *testing.T) {
+	var hits atomic.Int32
+	codes := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := hits.Add(1)
+		if n == 2 {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(codes[min(int(n), len(codes))-1])
+	}))
+	defer server.Close()
+	checker := &testChecker{}
+	checker.BaseChecker = NewBaseChecker(&stubConfig{BaseCheckerConfig{RetryBackoffMs: 1}})
+
+	if code := checker.QueryStatusCode(t.Context(), server.URL, nil); code != http.StatusOK || hits.Load() != 3 {
+		t.Errorf("status code = %d after %d attempts, want 200 after 3", code, hits.Load())
+	}
+	stats := checker.TakeHTTPStats()
+	if stats.Attempts != 3 || stats.Retries != 2 {
+		t.Errorf("stats = %+v, want 3 attempts and 2 retries", stats)
+	}
+
+	// Attempts run out on the last retryable answer, and other answers are not retried.
+	hits.Store(0)
+	checker.BaseChecker = NewBaseChecker(&stubConfig{BaseCheckerConfig{RetryBackoffMs: 1, RetryMaxAttempts: 2}})
+	if code := checker.QueryStatusCode(t.Context(), server.URL, nil); code != http.StatusTooManyRequests {
+		t.Errorf("status code = %d, want the second attempt's 429", code)
+	}
+	codes[0] = http.StatusNotFound
+	hits.Store(0)
+	if code := checker.QueryStatusCode(t.Context(), server.URL, nil); code != http.StatusNotFound || hits.Load() != 1 {
+		t.Errorf("status code = %d after %d attempts, want 404 at once", code, hits.Load())
+	}
+}
+
+func TestRetryGivesUpOnLongRetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	checker := &testChecker{}
+	checker.BaseChecker = NewBaseChecker(&stubConfig{})
+
+	resp, _, err := cmdlib.OnlineQuery(t.Context(), server.URL, checker.Client, nil)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable || hits.Load() != 1 {
+		t.Errorf("got %v after %d attempts, want the 503 at once", err, hits.Load())
+	}
+}
+
+func TestRateLimitedRequestHits(t *testing.T) {
+	var status, hits atomic.Int32
+	status.Store(http.StatusTooManyRequests)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	// Without proxies, the attempts are spent on the site, the retries paced by the checker's limiter.
+	checker := &testChecker{}
+	checker.BaseChecker = NewBaseChecker(&stubConfig{BaseCheckerConfig{MinRequestIntervalMs: 50, RetryBackoffMs: 1}})
+	start := time.Now()
+	if code := checker.QueryStatusCode(t.Context(), server.URL, nil); code != http.StatusTooManyRequests || hits.Load() != 3 {
+		t.Errorf("status code = %d after %d hits, want 429 after 3", code, hits.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("3 attempts took %v, want the second retry to wait its turn", elapsed)
+	}
+
+	// Through proxies, each attempt benches the proxy it went through and the next goes to another,
+	// so the attempts stay the policy's, however many proxies there are.
+	for proxies, want := range map[int]int32{2: 2, 4: 3} {
+		var urls []string
+		for range proxies {
+			urls = append(urls, testProxy(t, &status, &hits).URL)
+		}
+		hits.Store(0)
+		checker := proxiedChecker(urls...)
+		if code := checker.QueryStatusCode(t.Context(), "http://upstream.test/", nil); code != http.StatusTooManyRequests || hits.Load() != want {
+			t.Errorf("with %d proxies: status code = %d after %d hits, want 429 after %d", proxies, code, hits.Load(), want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package checkers
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -144,7 +145,7 @@ func streamateShowKind(m performerResponse) cmdlib.ShowKind {
 }
 
 // QueryStatus checks Streamate model status
-func (c *StreamateChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithStatus, error) {
+func (c *StreamateChecker) QueryStatus(ctx context.Context, modelID string) (cmdlib.StreamerInfoWithStatus, error) {
 	reqData := streamateRequest{
 		Options: optionsRequest{MaxResults: 1},
 		AvailablePerformers: availablePerformersRequest{
@@ -159,7 +160,7 @@ func (c *StreamateChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithS
 	output, err := xml.MarshalIndent(&reqData, "", "    ")
 	cmdlib.CheckErr(err)
 	reqString := fmt.Sprintf("%s%s\n", xml.Header, string(output))
-	req, err := http.NewRequest("POST", "https://affiliate.streamate.com/SMLive/SMLResult.xml", strings.NewReader(reqString))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://affiliate.streamate.com/SMLive/SMLResult.xml", strings.NewReader(reqString))
 	cmdlib.CheckErr(err)
 	for _, h := range c.Cfg.Headers {
 		req.Header.Set(h[0], h[1])
@@ -202,7 +203,7 @@ func (c *StreamateChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithS
 }
 
 // QueryOnlineStreamers returns Streamate online models
-func (c *StreamateChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *StreamateChecker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	streamers := map[string]cmdlib.StreamerInfo{}
 	endpoint := c.Cfg.UsersOnlineEndpoint
 	// Somehow 500 doesn't work well
@@ -229,7 +230,7 @@ func (c *StreamateChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInf
 		output, err := xml.MarshalIndent(&reqData, "", "    ")
 		cmdlib.CheckErr(err)
 		reqString := fmt.Sprintf("%s%s\n", xml.Header, string(output))
-		req, err := http.NewRequest("POST", endpoint, strings.NewReader(reqString))
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(reqString))
 		cmdlib.CheckErr(err)
 		for _, h := range c.Cfg.Headers {
 			req.Header.Set(h[0], h[1])
@@ -271,7 +272,7 @@ func (c *StreamateChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInf
 }
 
 // QueryFixedListOnlineStreamers is not implemented for online list checkers
-func (c *StreamateChecker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *StreamateChecker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -283,5 +284,6 @@ func (*StreamateChecker) Capabilities() Capabilities {
 		SupportsQueryFixedListStatuses:        false,
 		SupportsQueryStatus:                   true,
 		SupportsCLI:                           true,
+		SupportsShowKind:                      true,
 	}
 }
@@ -2,6 +2,7 @@ package checkers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -108,9 +109,9 @@ type stripchatCamResponse struct {
 }
 
 // QueryStatus checks Stripchat model status via the per-model cam endpoint.
-func (c *StripchatChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithStatus, error) {
+func (c *StripchatChecker) QueryStatus(ctx context.Context, modelID string) (cmdlib.StreamerInfoWithStatus, error) {
 	endpoint := fmt.Sprintf("https://stripchat.com/api/front/v2/models/username/%s/cam", url.PathEscape(modelID))
-	resp := c.DoGetRequest(endpoint, c.Cfg.Headers)
+	resp := c.DoGetRequest(ctx, endpoint, c.Cfg.Headers)
 	if resp == nil {
 		return cmdlib.StreamerInfoWithStatus{Status: cmdlib.StatusUnknown}, nil
 	}
@@ -149,7 +150,7 @@ func (c *StripchatChecker) QueryStatus(modelID string) (cmdlib.StreamerInfoWithS
 	}, nil
 }
 
-func (c *StripchatChecker) checkOnlyOnline() (map[string]cmdlib.StreamerInfo, error) {
+func (c *StripchatChecker) checkOnlyOnline(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	endpoint := c.Cfg.UsersOnlineEndpoint
 	userID := string(c.Cfg.UserID)
 	streamers := map[string]cmdlib.StreamerInfo{}
@@ -164,7 +165,7 @@ func (c *StripchatChecker) checkOnlyOnline() (map[string]cmdlib.StreamerInfo, er
 
 	request.RawQuery = q.Encode()
 
-	resp, buf, err := cmdlib.OnlineQuery(request.String(), c.Client, c.Cfg.Headers)
+	resp, buf, err := cmdlib.OnlineQuery(ctx, request.String(), c.Client, c.Cfg.Headers)
 	if err != nil {
 		return nil, fmt.Errorf("cannot send a query, %v", err)
 	}
@@ -190,9 +191,9 @@ func (c *StripchatChecker) checkOnlyOnline() (map[string]cmdlib.StreamerInfo, er
 }
 
 // QueryOnlineStreamers returns Stripchat online models
-func (c *StripchatChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
+func (c *StripchatChecker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
 	endpoint := c.Cfg.UsersOnlineEndpoint
-	streamers, err := c.checkOnlyOnline()
+	streamers, err := c.checkOnlyOnline(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("cannot check online models, %v", err)
 	}
@@ -216,7 +217,7 @@ func (c *StripchatChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInf
 
 		request.RawQuery = q.Encode()
 
-		resp, buf, err := cmdlib.OnlineQuery(request.String(), c.Client, c.Cfg.Headers)
+		resp, buf, err := cmdlib.OnlineQuery(ctx, request.String(), c.Client, c.Cfg.Headers)
 		if err != nil {
 			return nil, fmt.Errorf("cannot send a query, %v", err)
 		}
@@ -249,7 +250,7 @@ func (c *StripchatChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInf
 }
 
 // QueryFixedListOnlineStreamers is not implemented for online list checkers
-func (c *StripchatChecker) QueryFixedListOnlineStreamers([]string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
+func (c *StripchatChecker) QueryFixedListOnlineStreamers(context.Context, []string, cmdlib.CheckMode) (map[string]cmdlib.StreamerInfo, error) {
 	return nil, ErrNotImplemented
 }
 
@@ -261,6 +262,7 @@ func (*StripchatChecker) Capabilities() Capabilities {
 		SupportsQueryFixedListStatuses:        false,
 		SupportsQueryStatus:                   true,
 		SupportsCLI:                           true,
+		SupportsShowKind:                      true,
 		SupportsCustomAffiliateLink:           true,
 	}
 }
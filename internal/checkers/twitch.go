@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -67,8 +68,8 @@ func (c *TwitchChecker) NicknameRegexp() *regexp.Regexp {
 }
 
 // QueryStatus checks Twitch channel status
-func (c *TwitchChecker) QueryStatus(channelID string) (cmdlib.StreamerInfoWithStatus, error) {
-	helixClient, err := helix.NewClient(&helix.Options{
+func (c *TwitchChecker) QueryStatus(ctx context.Context, channelID string) (cmdlib.StreamerInfoWithStatus, error) {
+	helixClient, err := helix.NewClientWithContext(ctx, &helix.Options{
 		ClientID:     string(c.Cfg.ClientID),
 		ClientSecret: string(c.Cfg.ClientSecret),
 		HTTPClient:   c.Client,
@@ -125,8 +126,8 @@ func (c *TwitchChecker) QueryStatus(channelID string) (cmdlib.StreamerInfoWithSt
 }
 
 // QueryOnlineStreamers returns all online Twitch channels
-func (c *TwitchChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo, error) {
-	helixClient, err := helix.NewClient(&helix.Options{
+func (c *TwitchChecker) QueryOnlineStreamers(ctx context.Context) (map[string]cmdlib.StreamerInfo, error) {
+	helixClient, err := helix.NewClientWithContext(ctx, &helix.Options{
 		ClientID:     string(c.Cfg.ClientID),
 		ClientSecret: string(c.Cfg.ClientSecret),
 		HTTPClient:   c.Client,
@@ -146,10 +147,11 @@ func (c *TwitchChecker) QueryOnlineStreamers() (map[string]cmdlib.StreamerInfo,
 
 // QueryFixedListOnlineStreamers returns statuses for specific Twitch channels
 func (c *TwitchChecker) QueryFixedListOnlineStreamers(
+	ctx context.Context,
 	channelIDs []string,
 	_ cmdlib.CheckMode,
 ) (map[string]cmdlib.StreamerInfo, error) {
-	helixClient, err := helix.NewClient(&helix.Options{
+	helixClient, err := helix.NewClientWithContext(ctx, &helix.Options{
 		ClientID:     string(c.Cfg.ClientID),
 		ClientSecret: string(c.Cfg.ClientSecret),
 		HTTPClient:   c.Client,
@@ -168,8 +170,12 @@ func (c *TwitchChecker) QueryFixedListOnlineStreamers(
 }
 
 // QueryFixedListStatuses checks if specific Twitch channels exist
-func (c *TwitchChecker) QueryFixedListStatuses(channelIDs []string, _ cmdlib.CheckMode) (map[string]cmdlib.StreamerInfoWithStatus, error) {
-	helixClient, err := helix.NewClient(&helix.Options{
+func (c *TwitchChecker) QueryFixedListStatuses(
+	ctx context.Context,
+	channelIDs []string,
+	_ cmdlib.CheckMode,
+) (map[string]cmdlib.StreamerInfoWithStatus, error) {
+	helixClient, err := helix.NewClientWithContext(ctx, &helix.Options{
 		ClientID:     string(c.Cfg.ClientID),
 		ClientSecret: string(c.Cfg.ClientSecret),
 		HTTPClient:   c.Client,
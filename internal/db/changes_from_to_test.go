@@ -10,7 +10,7 @@ import (
 
 func streamerID(t *testing.T, d *Database, nickname string) int {
 	t.Helper()
-	s := d.MaybeStreamer(nickname)
+	s := d.MaybeStreamer(testSite, nickname)
 	if s == nil {
 		t.Fatalf("streamer %s not found", nickname)
 	}
@@ -152,7 +152,9 @@ func TestChangesFromToForStreamers(t *testing.T) {
 
 			for _, ins := range tc.inserts {
 				db.UpsertUnconfirmedStatusChanges(
+					testSite,
 					[]StatusChange{{Nickname: ins.nickname, Status: ins.status}},
+					nil,
 					ins.ts,
 				)
 			}
@@ -215,7 +217,9 @@ func TestChangesFromToForStreamersConsistency(t *testing.T) {
 			status = cmdlib.StatusOnline
 		}
 		db.UpsertUnconfirmedStatusChanges(
+			testSite,
 			[]StatusChange{{Nickname: "a", Status: status}},
+			nil,
 			100+i*10,
 		)
 	}
@@ -7,8 +7,8 @@ import (
 
 func (d *Database) ensureStreamer(nickname string) {
 	d.MustExec(
-		"insert into streamers (nickname) values ($1) on conflict (nickname) do nothing",
-		nickname)
+		"insert into streamers (site, nickname) values ($1, $2) on conflict (site, nickname) do nothing",
+		testSite, nickname)
 }
 
 // addSub inserts a subscription directly, creating the streamer if needed.
@@ -24,9 +24,9 @@ func (d *Database) addSub(chatID int64, nickname, endpoint string) {
 
 func (d *Database) addPending(chatID int64, nickname, endpoint string) {
 	d.MustExec(`
-		insert into pending_subscriptions (user_id, nickname, endpoint)
-		select u.id, $2, $3 from users u where u.chat_id = $1`,
-		chatID, nickname, endpoint)
+		insert into pending_subscriptions (user_id, site, nickname, endpoint)
+		select u.id, $4, $2, $3 from users u where u.chat_id = $1`,
+		chatID, nickname, endpoint, testSite)
 }
 
 func (d *Database) addBlock(chatID int64, endpoint string, count int) {
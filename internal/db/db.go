@@ -7,8 +7,10 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/bcmk/siren/v4/internal/metrics"
 	"github.com/bcmk/siren/v4/lib/cmdlib"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -93,22 +95,84 @@ func (d *Database) SuspendGIDCheck() { d.gidCheckSuspended = true }
 // ResumeGIDCheck restores the check after the startup handoff.
 func (d *Database) ResumeGIDCheck() { d.gidCheckSuspended = false }
 
-// Measure measures query duration
-func (d *Database) Measure(query string) func() {
+// Measure measures the duration of a query described by a short name,
+// its key in Durations and its metrics label.
+func (d *Database) Measure(name string) func() { return d.measure(name, name) }
+
+// measureSQL measures the duration of SQL run by a query wrapper such as MustExec.
+// Durations keeps it under its text; the metrics label it after the wrapper's caller,
+// which stays short and stable as the SQL changes.
+func (d *Database) measureSQL(query string) func() { return d.measure("db: "+query, sqlLabel()) }
+
+func (d *Database) measure(key, label string) func() {
 	now := time.Now()
 	d.checkTID()
 	return func() {
 		elapsed := time.Since(now).Seconds()
-		data := d.Durations[query]
+		data := d.Durations[key]
 		data.Avg = (data.Avg*float64(data.Count) + elapsed) / float64(data.Count+1)
 		data.Count++
-		d.Durations[query] = data
+		d.Durations[key] = data
+		metrics.DBQueryDuration.WithLabelValues(label).Observe(elapsed)
+	}
+}
+
+// queryWrappers are the functions from sqlLabel up to a query's caller.
+var queryWrappers = map[string]bool{
+	"db.sqlLabel":             true,
+	"db.Database.measureSQL":  true,
+	"db.Database.MustExec":    true,
+	"db.Database.MustInt":     true,
+	"db.Database.MustBool":    true,
+	"db.Database.MaybeRecord": true,
+	"db.Database.MustStrings": true,
+	"db.Database.MustQuery":   true,
+}
+
+// receiverMarks strips the pointer receiver marks from a function name.
+var receiverMarks = strings.NewReplacer("(*", "", ")", "")
+
+// frameNames caches the functions at a return PC, innermost first, as sqlLabel names them,
+// so each call site is resolved once.
+var frameNames sync.Map // uintptr -> []string
+
+// namesAt returns the functions at a return PC, inlined ones included, without their module path.
+func namesAt(pc uintptr) []string {
+	if names, ok := frameNames.Load(pc); ok {
+		return names.([]string)
+	}
+	var names []string
+	frames := runtime.CallersFrames([]uintptr{pc})
+	for {
+		frame, more := frames.Next()
+		names = append(names, receiverMarks.Replace(frame.Function[strings.LastIndex(frame.Function, "/")+1:]))
+		if !more {
+			break
+		}
+	}
+	frameNames.Store(pc, names)
+	return names
+}
+
+// sqlLabel names the first caller past the query wrappers without its module path,
+// e.g. "db.Database.AddUser" or "main.worker.processSubs".
+func sqlLabel() string {
+	var pcs [16]uintptr
+	var name string
+	// Skipping runtime.Callers alone, which is never inlined, leaves every PC a whole frame.
+	for _, pc := range pcs[:runtime.Callers(1, pcs[:])] {
+		for _, name = range namesAt(pc) {
+			if !queryWrappers[name] {
+				return name
+			}
+		}
 	}
+	return name
 }
 
 // MustExec executes the query and returns rows affected.
 func (d *Database) MustExec(query string, args ...interface{}) int64 {
-	defer d.Measure("db: " + query)()
+	defer d.measureSQL(query)()
 	tag, err := d.db.Exec(context.Background(), query, args...)
 	checkErr(err)
 	return tag.RowsAffected()
@@ -144,7 +208,7 @@ func (d *Database) ResetQueryStats() {
 
 // MustInt executes the query and returns single integer
 func (d *Database) MustInt(query string, args ...interface{}) (result int) {
-	defer d.Measure("db: " + query)()
+	defer d.measureSQL(query)()
 	row := d.db.QueryRow(context.Background(), query, args...)
 	checkErr(row.Scan(&result))
 	return result
@@ -152,7 +216,7 @@ func (d *Database) MustInt(query string, args ...interface{}) (result int) {
 
 // MustBool executes the query and returns single boolean
 func (d *Database) MustBool(query string, args ...interface{}) (result bool) {
-	defer d.Measure("db: " + query)()
+	defer d.measureSQL(query)()
 	row := d.db.QueryRow(context.Background(), query, args...)
 	checkErr(row.Scan(&result))
 	return result
@@ -160,7 +224,7 @@ func (d *Database) MustBool(query string, args ...interface{}) (result bool) {
 
 // MaybeRecord executes the query and returns single record on no records
 func (d *Database) MaybeRecord(query string, args QueryParams, record ScanTo) bool {
-	defer d.Measure("db: " + query)()
+	defer d.measureSQL(query)()
 	row := d.db.QueryRow(context.Background(), query, args...)
 	err := row.Scan(record...)
 	if err == pgx.ErrNoRows {
@@ -179,7 +243,7 @@ func (d *Database) MustStrings(queryString string, args ...interface{}) (result
 
 // MustQuery executes the query and stores data using store function
 func (d *Database) MustQuery(queryString string, args QueryParams, record ScanTo, store func()) {
-	defer d.Measure("db: " + queryString)()
+	defer d.measureSQL(queryString)()
 	query, err := d.db.Query(context.Background(), queryString, args...)
 	checkErr(err)
 	for query.Next() {
@@ -20,6 +20,9 @@ import (
 // rather than a container start plus 60-odd migrations.
 const templateDBName = "test"
 
+// testSite is the site every streamer the tests write belongs to.
+const testSite = "test"
+
 var (
 	// pgOnce starts the container on first use, so a run with no database test
 	// — go test -run TestGIDCheckSuspend — pays nothing for it.
@@ -141,3 +144,21 @@ func TestTimezoneNamesFiltering(t *testing.T) {
 		}
 	}
 }
+
+// sqlLabel skips the query wrappers to name the query after its caller.
+func TestSQLLabel(t *testing.T) {
+	t.Parallel()
+	for range 2 {
+		if got := sqlLabel(); got != "db.TestSQLLabel" {
+			t.Errorf("sqlLabel() = %q, want db.TestSQLLabel", got)
+		}
+	}
+}
+
+// Once a call site is resolved, labelling its queries allocates nothing.
+// Not parallel, as AllocsPerRun refuses to run beside other tests.
+func TestSQLLabelAllocs(t *testing.T) {
+	if allocs := testing.AllocsPerRun(10, func() { sqlLabel() }); allocs != 0 {
+		t.Errorf("a resolved call site allocated %v times", allocs)
+	}
+}
@@ -12,6 +12,7 @@ type Notification struct {
 	StreamerID *int
 
 	// Only populated when joined with streamers
+	Site     string
 	Nickname string
 
 	Status   cmdlib.StatusKind
@@ -34,6 +35,13 @@ type Notification struct {
 	// which carries the customization hint where the chat is one that can act on it.
 	FieldsHint bool
 	Subject    string
+	// Session is the summary of the session an offline alert closes, nil for any other.
+	Session *Session
+	// Favourite marks an alert of a starred subscription, sent ahead of the chat's other alerts.
+	Favourite bool
+	// Timestamp is when the status of a status alert changed, zero for any other notification.
+	// An alert held through quiet hours keeps it, however late the queue gets to it.
+	Timestamp int
 
 	// These travel one way: out of the database, never into it.
 	// notification_queue has no such columns, so the fetch joins users to fill them.
@@ -43,6 +51,18 @@ type Notification struct {
 	AffiliateParams map[string]string
 	// Reports is the chat's count of queued alerts, this notification's own included.
 	Reports int
+	// The chat's zone and quiet hours, as in User.
+	Timezone     *string
+	QuietStart   *int
+	QuietEnd     *int
+	QuietSummary bool
+	// LiveMessages is the chat's live message mode, as in User.
+	LiveMessages bool
+	// StreamerTopic is the forum topic of the streamer's alerts, zero to follow ChatTopic.
+	// ChatTopic and TopicPerStreamer are as in User.
+	StreamerTopic    int
+	ChatTopic        int
+	TopicPerStreamer bool
 }
 
 // UserID is a user's stable surrogate id (users.id), distinct from the mutable
@@ -80,6 +100,19 @@ const (
 	// Its send results skip all database bookkeeping,
 	// so it can be sent while migrations run.
 	MaintenancePacket PacketKind = 4
+
+	// ShowKindPacket represents a show-kind change alert
+	ShowKindPacket PacketKind = 5
+
+	// SubjectPacket represents a subject change alert
+	SubjectPacket PacketKind = 6
+
+	// LivePacket represents an edit of a live message:
+	// a refresh while the session lasts, or its end
+	LivePacket PacketKind = 7
+
+	// BoardPacket represents a chat's pinned board, its pin, or an edit of it
+	BoardPacket PacketKind = 8
 )
 
 // PerformanceLogKind represents a performance log entry kind
@@ -120,11 +153,139 @@ type User struct {
 
 	// Timezone is the chat's IANA zone name, nil for UTC.
 	Timezone *string
+
+	// QuietStart and QuietEnd bound the chat's quiet hours in minutes past its local midnight,
+	// both nil when it keeps none.
+	QuietStart *int
+	QuietEnd   *int
+	// QuietSummary holds the window's alerts back for one summary instead of sending them silently.
+	QuietSummary bool
+
+	// ShowKindAlerts is the set of show-kind changes the chat is alerted to,
+	// a bitmask of the bot's alert classes, zero for none.
+	ShowKindAlerts int
+
+	// SubjectAlerts alerts the chat to a subject changed while online.
+	SubjectAlerts bool
+
+	// DigestPeriod is the chat's digest, DigestOff for none.
+	// DigestTime is when it is sent, in minutes past the chat's local midnight,
+	// and DigestSentAt the end of the window the last one covered.
+	DigestPeriod DigestPeriod
+	DigestTime   int
+	DigestSentAt int
+
+	// LiveMessages edits the chat's online alert in place while the session lasts
+	// instead of sending an offline alert after it.
+	LiveMessages bool
+
+	// ForumTopic is the forum topic the chat's alerts go to, zero for the General one.
+	// TopicPerStreamer opens a topic for each streamer on its first alert instead.
+	ForumTopic       int
+	TopicPerStreamer bool
+}
+
+// DigestPeriod is how often a chat is sent its digest
+type DigestPeriod int
+
+// Digest periods
+const (
+	DigestOff    DigestPeriod = 0
+	DigestDaily  DigestPeriod = 1
+	DigestWeekly DigestPeriod = 2
+)
+
+// MutedForever is the muted_until of a subscription muted until it is unmuted,
+// the last timestamp the column holds
+const MutedForever = 1<<31 - 1
+
+// DigestSubscription is a subscription read of in its chat's digest rather than alerted live
+type DigestSubscription struct {
+	StreamerID int
+	Site       string
+	Nickname   string
+	Endpoint   string
+}
+
+// ViewerCount is the viewers a streamer online has in one poll
+type ViewerCount struct {
+	Nickname string
+	Viewers  int
+}
+
+// OnlineCount is the length of a site's online list in one poll
+type OnlineCount struct {
+	Timestamp int
+	Count     int
+}
+
+// LiveMessageKey names the live message of a chat for a streamer on an endpoint
+type LiveMessageKey struct {
+	UserID     UserID
+	Endpoint   string
+	StreamerID int
+}
+
+// Board is the pinned message a chat keeps listing its online subscriptions on an endpoint
+type Board struct {
+	UserID   UserID
+	Endpoint string
+	// MessageID is zero until the board is sent
+	MessageID int
+}
+
+// LiveMessage is the online alert a chat keeps editing for a streamer
+type LiveMessage struct {
+	LiveMessageKey
+	MessageID int
+	Photo     bool
+	StartedAt int
+}
+
+// LiveRefresh is a live message due an edit, with what its chat shows in alerts
+type LiveRefresh struct {
+	LiveMessage
+	Site        string
+	Nickname    string
+	ShowImages  bool
+	ShowSubject bool
+}
+
+// SessionSample is what one poll tells of a streamer online toward its session
+type SessionSample struct {
+	Nickname string
+	Viewers  *int
+	ShowKind cmdlib.ShowKind
+}
+
+// Session is a streamer's time online from its confirmed start to its confirmed end.
+// The viewers are nil where no poll reported them.
+type Session struct {
+	Start          int               `json:"start"`
+	End            int               `json:"end"`
+	PeakViewers    *int              `json:"peak_viewers,omitempty"`
+	AverageViewers *int              `json:"average_viewers,omitempty"`
+	ShowKinds      []cmdlib.ShowKind `json:"show_kinds,omitempty"`
+}
+
+// HeldNotification is a status alert held back during a chat's quiet hours
+type HeldNotification struct {
+	UserID     UserID
+	Endpoint   string
+	StreamerID int
+
+	// Only populated when joined with streamers
+	Site     string
+	Nickname string
+
+	Status    cmdlib.StatusKind
+	Timestamp int
 }
 
 // Streamer represents a streamer
 type Streamer struct {
 	ID                       int
+	Site                     string
 	Nickname                 string
 	ConfirmedStatus          cmdlib.StatusKind
 	UnconfirmedStatus        cmdlib.StatusKind
@@ -138,20 +299,100 @@ type StatusChange struct {
 	Nickname  string
 	Status    cmdlib.StatusKind
 	Timestamp int
+	// ShowKind is the show the streamer went online in, unknown for any other status.
+	ShowKind cmdlib.ShowKind
+}
+
+// ShowKindChange represents a show-kind change of a streamer online before and after
+type ShowKindChange struct {
+	Nickname string
+	ShowKind cmdlib.ShowKind
 }
 
 // ConfirmedStatusChange represents a confirmed status change with previous status
 type ConfirmedStatusChange struct {
 	StreamerID int
+	Site       string
 	Nickname   string
 	Status     cmdlib.StatusKind
 	PrevStatus cmdlib.StatusKind
 	Timestamp  int
+	// Session is the session a confirmed offline status ends, nil for any other change
+	// and for a session that began before sessions were kept.
+	Session *Session
+}
+
+// ConfirmedShowKindChange represents a confirmed show-kind change with previous show kind
+type ConfirmedShowKindChange struct {
+	StreamerID   int
+	Site         string
+	Nickname     string
+	ShowKind     cmdlib.ShowKind
+	PrevShowKind cmdlib.ShowKind
+	Timestamp    int
+}
+
+// SubjectChange represents a room subject seen for an online streamer
+type SubjectChange struct {
+	Nickname string
+	Subject  string
+	// Started marks the subject of a streamer just gone online,
+	// confirmed at once as the session's first, with nothing to alert.
+	Started bool
+}
+
+// ConfirmedSubjectChange represents a confirmed subject change with previous subject
+type ConfirmedSubjectChange struct {
+	StreamerID  int
+	Site        string
+	Nickname    string
+	Subject     string
+	PrevSubject string
+	Timestamp   int
+}
+
+// HistoricSubject represents a confirmed subject in a streamer's history
+type HistoricSubject struct {
+	Subject   string
+	Timestamp int
+}
+
+// NotificationWebhook is an HTTP target a chat's status changes are posted to
+type NotificationWebhook struct {
+	UserID UserID
+	ChatID int64
+	URL    string
+	Secret string
+}
+
+// SubscribedWebhook is a chat's webhook with the filter of one of its subscriptions to a streamer.
+type SubscribedWebhook struct {
+	NotificationWebhook
+	Filter *SubscriptionFilter
+}
+
+// SubscriptionFilter narrows a subscription's alerts to the starts worth one.
+// Every condition set must hold; a subscription without a filter is alerted of every start.
+type SubscriptionFilter struct {
+	// PublicOnly drops a start into a private, group, ticket, hidden or away show.
+	PublicOnly bool `json:"public_only,omitempty"`
+	// MinViewers drops a start with fewer viewers.
+	MinViewers *int `json:"min_viewers,omitempty"`
+	// Keyword drops a start whose subject does not contain it, case-insensitively.
+	Keyword string `json:"keyword,omitempty"`
+}
+
+// FilteredSubscription is a subscription with its alert filter
+type FilteredSubscription struct {
+	Site     string
+	Nickname string
+	Filter   *SubscriptionFilter
 }
 
 // PendingSubscription represents an unconfirmed subscription
 type PendingSubscription struct {
 	UserID   UserID
+	Site     string
 	Nickname string
 	Endpoint string
 	Referral bool
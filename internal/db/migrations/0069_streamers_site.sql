@@ -0,0 +1,22 @@
+-- Existing rows belong to the one site the bot ran before,
+-- whose name only the config knows; ClaimUnsitedRows names them at startup.
+alter table streamers add column site text collate "C" not null default '';
+alter table streamers alter column site drop default;
+drop index ix_streamers_nickname;
+create unique index ix_streamers_site_nickname on streamers (site, nickname) include (id);
+
+alter table pending_subscriptions add column site text collate "C" not null default '';
+alter table pending_subscriptions alter column site drop default;
+alter table pending_subscriptions drop constraint pending_subscriptions_pkey;
+alter table pending_subscriptions
+add constraint pending_subscriptions_pkey
+primary key (user_id, site, nickname, endpoint);
+
+alter table nicknames add column site text collate "C" not null default '';
+alter table nicknames alter column site drop default;
+
+drop index ix_streamers_status_mismatch;
+create index ix_streamers_status_mismatch
+on streamers (id)
+include (site, nickname, unconfirmed_status, unconfirmed_timestamp, confirmed_status)
+where confirmed_status != unconfirmed_status;
@@ -0,0 +1,7 @@
+-- HTTP targets a chat's status changes are posted to, beside its Telegram messages.
+create table notification_webhooks (
+    user_id bigint not null references users(id) on delete cascade,
+    url text not null,
+    secret text not null,
+    primary key (user_id, url)
+);
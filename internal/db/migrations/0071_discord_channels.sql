@@ -0,0 +1,6 @@
+-- Discord channels, by their webhook URL, a chat's status notifications are mirrored to.
+create table discord_channels (
+    user_id bigint not null references users(id) on delete cascade,
+    webhook_url text not null,
+    primary key (user_id, webhook_url)
+);
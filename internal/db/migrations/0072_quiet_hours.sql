@@ -0,0 +1,21 @@
+-- A chat's quiet hours, minutes past its local midnight, both null when it keeps none.
+-- quiet_summary holds the alerts of the window back for one summary at its end
+-- rather than sending them silently.
+alter table users add column quiet_start smallint;
+alter table users add column quiet_end smallint;
+alter table users add column quiet_summary boolean not null default false;
+
+-- Status alerts held back during a chat's quiet hours, awaiting its summary.
+create table held_notifications (
+    id serial primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    endpoint text not null,
+    streamer_id integer not null references streamers(id) on delete cascade,
+    status integer not null,
+    timestamp integer not null
+);
+create index held_notifications_user_id_idx on held_notifications (user_id);
+
+-- When a queued status alert's status changed, zero for any other notification,
+-- so an alert held through quiet hours is summarized at the time of the change.
+alter table notification_queue add column timestamp integer not null default 0;
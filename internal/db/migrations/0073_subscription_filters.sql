@@ -0,0 +1,4 @@
+-- A subscription's alert filter, null when every start is alerted.
+-- It holds the show kind, viewer and subject conditions a start must meet,
+-- as in db.SubscriptionFilter.
+alter table subscriptions add column filter jsonb;
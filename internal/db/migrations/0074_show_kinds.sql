@@ -0,0 +1,14 @@
+-- A streamer's show kind, tracked alongside its status and confirmed as an online status is.
+-- Values are cmdlib.ShowKind, 0 where the site reports none or the streamer is not online.
+alter table streamers add column unconfirmed_show_kind smallint not null default 0;
+alter table streamers add column unconfirmed_show_kind_timestamp integer not null default 0;
+alter table streamers add column confirmed_show_kind smallint not null default 0;
+
+create index ix_streamers_show_kind_mismatch
+on streamers (id)
+include (site, nickname, unconfirmed_show_kind, unconfirmed_show_kind_timestamp, confirmed_show_kind)
+where confirmed_show_kind != unconfirmed_show_kind;
+
+-- The show-kind changes a chat is alerted to, a bitmask of the bot's alert classes:
+-- 1 went private, 2 back to public, 4 went away. 0 for none.
+alter table users add column show_kind_alerts smallint not null default 0;
@@ -0,0 +1,21 @@
+-- A streamer's room subject while online, confirmed once it has held for subject_change_seconds,
+-- so a run of quick edits is confirmed, and alerted, as its last one.
+alter table streamers add column unconfirmed_subject text not null default '';
+alter table streamers add column unconfirmed_subject_timestamp integer not null default 0;
+alter table streamers add column confirmed_subject text not null default '';
+
+create index ix_streamers_subject_mismatch
+on streamers (id)
+where confirmed_subject != unconfirmed_subject;
+
+-- The confirmed subjects of each streamer, kept beside status_changes.
+-- A session's first subject is recorded as it goes online, the later ones as they are confirmed.
+create table subject_changes (
+    streamer_id integer not null references streamers(id) on delete cascade,
+    subject text not null,
+    timestamp integer not null
+);
+create index ix_subject_changes_streamer_id_timestamp on subject_changes (streamer_id, timestamp);
+
+-- Whether a chat is alerted when a streamer it follows changes the subject while online.
+alter table users add column subject_alerts boolean not null default false;
@@ -0,0 +1,20 @@
+-- A chat's digest: one scheduled message of who was online, in place of live alerts.
+-- digest_period is 0 for none, 1 daily, 2 weekly; digest_time is minutes past the chat's local midnight;
+-- digest_sent_at is the end of the window the last digest covered.
+alter table users add column digest_period smallint not null default 0;
+alter table users add column digest_time smallint not null default 0;
+alter table users add column digest_sent_at integer not null default 0;
+
+create index ix_users_digest on users (id) where digest_period != 0;
+
+-- A subscription kept out of its chat's digest, alerted as usual.
+alter table subscriptions add column no_digest boolean not null default false;
+
+-- The peak viewers of a streamer in each hour it was seen online,
+-- kept for the streamers someone reads of in a digest.
+create table viewer_peaks (
+    streamer_id integer not null references streamers(id) on delete cascade,
+    hour integer not null,
+    viewers integer not null,
+    primary key (streamer_id, hour)
+);
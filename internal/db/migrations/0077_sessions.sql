@@ -0,0 +1,24 @@
+-- The session a streamer is in, or last was in, from its confirmed start on:
+-- the viewers summed over the polls that reported them and how many did, their peak,
+-- and the show kinds seen, a bitmask of 1 << cmdlib.ShowKind.
+-- session_start is 0 for a session that began before these were kept.
+alter table streamers add column session_start integer not null default 0;
+alter table streamers add column session_viewers_sum bigint not null default 0;
+alter table streamers add column session_viewers_samples integer not null default 0;
+alter table streamers add column session_viewers_peak integer not null default 0;
+alter table streamers add column session_show_kinds integer not null default 0;
+
+-- A finished session, recorded as its end is confirmed.
+-- The viewers are null where no poll reported them.
+create table sessions (
+    streamer_id integer not null references streamers(id) on delete cascade,
+    start_timestamp integer not null,
+    end_timestamp integer not null,
+    peak_viewers integer,
+    average_viewers integer,
+    show_kinds integer not null,
+    primary key (streamer_id, start_timestamp)
+);
+
+-- The summary of the session an offline alert closes, as in db.Session.
+alter table notification_queue add column session jsonb;
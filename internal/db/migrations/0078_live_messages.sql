@@ -0,0 +1,19 @@
+-- Live messages: a chat's online alert is edited in place while the session lasts,
+-- and edited once more at its end in place of a new offline alert.
+alter table users add column live_messages boolean not null default false;
+
+-- The online alert a chat keeps editing for a streamer, by its Telegram message id.
+-- photo marks an alert sent as a picture, edited by its media or caption rather than its text.
+-- started_at is when the session began, updated_at when the last edit was queued.
+create table live_messages (
+    user_id bigint not null references users(id) on delete cascade,
+    endpoint text not null,
+    streamer_id integer not null references streamers(id) on delete cascade,
+    message_id integer not null,
+    photo boolean not null,
+    started_at integer not null,
+    updated_at integer not null,
+    primary key (user_id, endpoint, streamer_id)
+);
+
+create index ix_live_messages_updated_at on live_messages (updated_at);
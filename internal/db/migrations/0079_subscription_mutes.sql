@@ -0,0 +1,5 @@
+-- A subscription may be muted for a while: it stays in the list and alerts of nothing until muted_until.
+-- Zero is not muted.
+alter table subscriptions add column muted_until integer not null default 0;
+-- The few muted subscriptions, found by the maintenance that unmutes them once their mute runs out.
+create index ix_subscriptions_muted_until on subscriptions (muted_until) where muted_until <> 0;
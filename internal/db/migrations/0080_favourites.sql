@@ -0,0 +1,6 @@
+-- Favourites: a starred subscription alerts with sound, with its picture and subject,
+-- ahead of the chat's other alerts, and live while the chat is on a digest.
+alter table subscriptions add column favourite boolean not null default false;
+
+-- A queued alert of a favourite, to go out ahead of the chat's other alerts.
+alter table notification_queue add column favourite boolean not null default false;
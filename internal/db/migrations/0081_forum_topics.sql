@@ -0,0 +1,9 @@
+-- Forum topics: a supergroup with topics routes its alerts to a topic of its choice.
+-- A zero topic is the General one.
+alter table users add column forum_topic integer not null default 0;
+
+-- Open a topic for each streamer on its first alert, kept in subscriptions.forum_topic.
+alter table users add column topic_per_streamer boolean not null default false;
+
+-- The topic of one streamer's alerts, zero to follow the chat's.
+alter table subscriptions add column forum_topic integer not null default 0;
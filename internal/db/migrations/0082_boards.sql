@@ -0,0 +1,11 @@
+-- Boards: a group or channel keeps one pinned message listing its online subscriptions,
+-- edited after the confirmed changes of its streamers.
+-- message_id is the board's Telegram message, zero until it is sent,
+-- and dirty marks a board with changes not yet edited in.
+create table boards (
+    user_id bigint not null references users(id) on delete cascade,
+    endpoint text not null,
+    message_id integer not null default 0,
+    dirty boolean not null default false,
+    primary key (user_id, endpoint)
+);
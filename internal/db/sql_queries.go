@@ -26,13 +26,17 @@ func (d *Database) NewNotifications() []Notification {
 	var iter Notification
 	d.MustQuery(`
 		select
-			n.id, n.endpoint, u.id, n.streamer_id, s.nickname, n.status,
+			n.id, n.endpoint, u.id, n.streamer_id, s.site, s.nickname, n.status,
 			n.time_diff, n.image_url, n.viewers, n.show_kind, n.social, n.priority,
 			n.sound, n.kind, coalesce(n.command, ''), n.reply_seq, n.fields_hint,
-			n.subject, u.silent_messages, u.chat_id, u.chat_type, u.affiliate_params, u.reports
+			n.subject, n.session, n.favourite, n.timestamp, u.silent_messages, u.chat_id, u.chat_type, u.affiliate_params, u.reports,
+			u.timezone, u.quiet_start, u.quiet_end, u.quiet_summary, u.live_messages,
+			coalesce(sub.forum_topic, 0), u.forum_topic, u.topic_per_streamer
 		from notification_queue n
 		join users u on u.id = n.user_id
 		join streamers s on s.id = n.streamer_id
+		left join subscriptions sub
+			on sub.user_id = n.user_id and sub.streamer_id = n.streamer_id and sub.endpoint = n.endpoint
 		where n.sending = 0
 		order by n.id`,
 		nil,
@@ -41,6 +45,7 @@ func (d *Database) NewNotifications() []Notification {
 			&iter.Endpoint,
 			&iter.UserID,
 			&iter.StreamerID,
+			&iter.Site,
 			&iter.Nickname,
 			&iter.Status,
 			&iter.TimeDiff,
@@ -55,11 +60,22 @@ func (d *Database) NewNotifications() []Notification {
 			&iter.ReplySeq,
 			&iter.FieldsHint,
 			&iter.Subject,
+			&iter.Session,
+			&iter.Favourite,
+			&iter.Timestamp,
 			&iter.SilentMessages,
 			&iter.ChatID,
 			&iter.ChatType,
 			&iter.AffiliateParams,
 			&iter.Reports,
+			&iter.Timezone,
+			&iter.QuietStart,
+			&iter.QuietEnd,
+			&iter.QuietSummary,
+			&iter.LiveMessages,
+			&iter.StreamerTopic,
+			&iter.ChatTopic,
+			&iter.TopicPerStreamer,
 		},
 		func() { nots = append(nots, iter) },
 	)
@@ -90,21 +106,34 @@ func (d *Database) StoreNotifications(nots []Notification) {
 				command,
 				reply_seq,
 				fields_hint,
-				subject
+				subject,
+				session,
+				favourite,
+				timestamp
 			)
-			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`,
 			n.Endpoint, int64(n.UserID), n.StreamerID, n.Status, n.TimeDiff, n.ImageURL, n.Viewers,
 			n.ShowKind, n.Social, n.Priority, n.Sound, n.Kind, nullableCommand(n.Command), n.ReplySeq,
-			n.FieldsHint, n.Subject,
+			n.FieldsHint, n.Subject, n.Session, n.Favourite, n.Timestamp,
 		)
 	}
 	d.SendBatch(batch)
 }
 
-// UsersForStreamers returns users subscribed to particular streamers
-func (d *Database) UsersForStreamers(streamerIDs []int) (users map[int][]User, endpoints map[int][]string) {
+// UsersForStreamers returns users alerted live of particular streamers,
+// with the endpoint, filter and favourite mark of each subscription at the same index.
+// A chat on a digest is alerted of its live and favourite subscriptions alone,
+// and a subscription muted past now is alerted of nothing
+func (d *Database) UsersForStreamers(streamerIDs []int, now int) (
+	users map[int][]User,
+	endpoints map[int][]string,
+	filters map[int][]*SubscriptionFilter,
+	favourites map[int][]bool,
+) {
 	users = map[int][]User{}
 	endpoints = make(map[int][]string)
+	filters = make(map[int][]*SubscriptionFilter)
+	favourites = make(map[int][]bool)
 	var streamerID int
 	var chatID int64
 	var userID int64
@@ -112,6 +141,11 @@ func (d *Database) UsersForStreamers(streamerIDs []int) (users map[int][]User, e
 	var offlineNotifications bool
 	var showImages bool
 	var showSubject bool
+	var showKindAlerts int
+	var subjectAlerts bool
+	var liveMessages bool
+	var filter *SubscriptionFilter
+	var favourite bool
 	d.MustQuery(`
 		select
 			sub.streamer_id,
@@ -120,12 +154,23 @@ func (d *Database) UsersForStreamers(streamerIDs []int) (users map[int][]User, e
 			sub.endpoint,
 			u.offline_notifications,
 			u.show_images,
-			u.show_subject
+			u.show_subject,
+			u.show_kind_alerts,
+			u.subject_alerts,
+			u.live_messages,
+			sub.filter,
+			sub.favourite
 		from subscriptions sub
 		join users u on u.id = sub.user_id
-		where sub.streamer_id = any($1)`,
-		QueryParams{streamerIDs},
-		ScanTo{&streamerID, &chatID, &userID, &endpoint, &offlineNotifications, &showImages, &showSubject},
+		where sub.streamer_id = any($1)
+		and (u.digest_period = 0 or sub.no_digest or sub.favourite)
+		and sub.muted_until <= $2`,
+		QueryParams{streamerIDs, now},
+		ScanTo{
+			&streamerID, &chatID, &userID, &endpoint,
+			&offlineNotifications, &showImages, &showSubject, &showKindAlerts, &subjectAlerts, &liveMessages, &filter,
+			&favourite,
+		},
 		func() {
 			users[streamerID] = append(users[streamerID], User{
 				ChatID:               chatID,
@@ -133,12 +178,110 @@ func (d *Database) UsersForStreamers(streamerIDs []int) (users map[int][]User, e
 				OfflineNotifications: offlineNotifications,
 				ShowImages:           showImages,
 				ShowSubject:          showSubject,
+				ShowKindAlerts:       showKindAlerts,
+				SubjectAlerts:        subjectAlerts,
+				LiveMessages:         liveMessages,
 			})
 			endpoints[streamerID] = append(endpoints[streamerID], endpoint)
+			filters[streamerID] = append(filters[streamerID], filter)
+			favourites[streamerID] = append(favourites[streamerID], favourite)
+			// Scanned into afresh for each row, so the one appended is not overwritten.
+			filter = nil
 		})
 	return
 }
 
+// NotificationWebhooksForStreamers returns the webhooks of the chats subscribed to the streamers,
+// once for each subscription not muted past now, with its filter.
+// A chat subscribed through several endpoints has a hook listed for each.
+func (d *Database) NotificationWebhooksForStreamers(streamerIDs []int, now int) map[int][]SubscribedWebhook {
+	result := map[int][]SubscribedWebhook{}
+	var streamerID int
+	var iter SubscribedWebhook
+	d.MustQuery(`
+		select sub.streamer_id, u.id, u.chat_id, h.url, h.secret, sub.filter
+		from subscriptions sub
+		join users u on u.id = sub.user_id
+		join notification_webhooks h on h.user_id = sub.user_id
+		where sub.streamer_id = any($1)
+		and sub.muted_until <= $2`,
+		QueryParams{streamerIDs, now},
+		ScanTo{&streamerID, &iter.UserID, &iter.ChatID, &iter.URL, &iter.Secret, &iter.Filter},
+		func() {
+			result[streamerID] = append(result[streamerID], iter)
+			// Scanned into afresh for each row, so the one appended is not overwritten.
+			iter.Filter = nil
+		})
+	return result
+}
+
+// NotificationWebhooks returns the chat's webhooks
+func (d *Database) NotificationWebhooks(userID UserID) []NotificationWebhook {
+	var result []NotificationWebhook
+	var iter NotificationWebhook
+	d.MustQuery(`
+		select u.id, u.chat_id, h.url, h.secret
+		from notification_webhooks h
+		join users u on u.id = h.user_id
+		where h.user_id = $1
+		order by h.url`,
+		QueryParams{userID},
+		ScanTo{&iter.UserID, &iter.ChatID, &iter.URL, &iter.Secret},
+		func() { result = append(result, iter) })
+	return result
+}
+
+// SetNotificationWebhook adds a webhook to the chat or replaces the secret of the one it has
+func (d *Database) SetNotificationWebhook(userID UserID, url string, secret string) {
+	d.MustExec(`
+		insert into notification_webhooks (user_id, url, secret)
+		values ($1, $2, $3)
+		on conflict (user_id, url) do update set secret = excluded.secret`,
+		userID,
+		url,
+		secret)
+}
+
+// RemoveNotificationWebhook removes the chat's webhook and reports whether it had one
+func (d *Database) RemoveNotificationWebhook(userID UserID, url string) bool {
+	return d.MustExec("delete from notification_webhooks where user_id = $1 and url = $2", userID, url) > 0
+}
+
+// DiscordChannelsForUsers returns the webhook URLs of the Discord channels the chats mirror to
+func (d *Database) DiscordChannelsForUsers(userIDs []UserID) map[UserID][]string {
+	ids := make([]int64, len(userIDs))
+	for i, userID := range userIDs {
+		ids[i] = int64(userID)
+	}
+	result := map[UserID][]string{}
+	var userID int64
+	var webhookURL string
+	d.MustQuery(`
+		select user_id, webhook_url
+		from discord_channels
+		where user_id = any($1)
+		order by user_id, webhook_url`,
+		QueryParams{ids},
+		ScanTo{&userID, &webhookURL},
+		func() { result[UserID(userID)] = append(result[UserID(userID)], webhookURL) })
+	return result
+}
+
+// AddDiscordChannel mirrors the chat to a Discord channel and reports whether it was new
+func (d *Database) AddDiscordChannel(userID UserID, webhookURL string) bool {
+	return d.MustExec(`
+		insert into discord_channels (user_id, webhook_url)
+		values ($1, $2)
+		on conflict do nothing`,
+		userID,
+		webhookURL) > 0
+}
+
+// RemoveDiscordChannel stops mirroring the chat to a Discord channel and reports whether it did
+func (d *Database) RemoveDiscordChannel(userID UserID, webhookURL string) bool {
+	return d.MustExec("delete from discord_channels where user_id = $1 and webhook_url = $2", userID, webhookURL) > 0
+}
+
 // BroadcastUsers returns the users to broadcast to on an endpoint:
 // its private subscribers (chat_id > 0 excludes groups and channels).
 // trySend resolves each user's current chat id at dispatch.
@@ -160,13 +303,13 @@ func (d *Database) BroadcastUsers(endpoint string) (users []UserID) {
 func (d *Database) StreamersForUser(endpoint string, userID UserID) (streamers []Streamer) {
 	var iter Streamer
 	d.MustQuery(`
-		select s.id, s.nickname
+		select s.id, s.site, s.nickname
 		from subscriptions sub
 		join streamers s on s.id = sub.streamer_id
 		where sub.user_id = $1 and sub.endpoint = $2
-		order by s.nickname`,
+		order by s.site, s.nickname`,
 		QueryParams{int64(userID), endpoint},
-		ScanTo{&iter.ID, &iter.Nickname},
+		ScanTo{&iter.ID, &iter.Site, &iter.Nickname},
 		func() { streamers = append(streamers, iter) })
 	return
 }
@@ -178,6 +321,7 @@ func (d *Database) UnconfirmedStatusesForUser(endpoint string, userID UserID) (s
 	d.MustQuery(`
 		select
 			s.id,
+			s.site,
 			s.nickname,
 			s.unconfirmed_status,
 			s.unconfirmed_timestamp,
@@ -186,10 +330,11 @@ func (d *Database) UnconfirmedStatusesForUser(endpoint string, userID UserID) (s
 		from subscriptions sub
 		join streamers s on s.id = sub.streamer_id
 		where sub.user_id = $1 and sub.endpoint = $2
-		order by s.nickname`,
+		order by s.site, s.nickname`,
 		QueryParams{int64(userID), endpoint},
 		ScanTo{
 			&iter.ID,
+			&iter.Site,
 			&iter.Nickname,
 			&iter.UnconfirmedStatus,
 			&iter.UnconfirmedTimestamp,
@@ -201,36 +346,37 @@ func (d *Database) UnconfirmedStatusesForUser(endpoint string, userID UserID) (s
 }
 
 // SubscribedOrPending checks if a subscription or pending subscription exists
-func (d *Database) SubscribedOrPending(endpoint string, userID UserID, nickname string) bool {
+func (d *Database) SubscribedOrPending(endpoint string, userID UserID, site string, nickname string) bool {
 	return d.MustBool(`
 		select exists(
 			select 1 from subscriptions sub
 			join streamers s on s.id = sub.streamer_id
-			where sub.user_id = $1 and s.nickname = $2 and sub.endpoint = $3
+			where sub.user_id = $1 and s.site = $4 and s.nickname = $2 and sub.endpoint = $3
 			union all
 			select 1 from pending_subscriptions ps
-			where ps.user_id = $1 and ps.nickname = $2 and ps.endpoint = $3
+			where ps.user_id = $1 and ps.site = $4 and ps.nickname = $2 and ps.endpoint = $3
 		)`,
-		int64(userID), nickname, endpoint)
+		int64(userID), nickname, endpoint, site)
 }
 
-// SubscribedOrPendingNicknames returns the nicknames a user can remove:
-// subscriptions and pending subscriptions alike, as RemoveSubscription deletes both
-func (d *Database) SubscribedOrPendingNicknames(endpoint string, userID UserID) (nicknames []string) {
-	var iter string
+// SubscribedOrPendingNicknames returns the streamers a user can remove:
+// subscriptions and pending subscriptions alike, as RemoveSubscription deletes both.
+// Only Site and Nickname are filled.
+func (d *Database) SubscribedOrPendingNicknames(endpoint string, userID UserID) (streamers []Streamer) {
+	var iter Streamer
 	d.MustQuery(`
-		select s.nickname
+		select s.site, s.nickname
 		from subscriptions sub
 		join streamers s on s.id = sub.streamer_id
 		where sub.user_id = $1 and sub.endpoint = $2
 		union
-		select ps.nickname
+		select ps.site, ps.nickname
 		from pending_subscriptions ps
 		where ps.user_id = $1 and ps.endpoint = $2
-		order by nickname`,
+		order by site, nickname`,
 		QueryParams{int64(userID), endpoint},
-		ScanTo{&iter},
-		func() { nicknames = append(nicknames, iter) })
+		ScanTo{&iter.Site, &iter.Nickname},
+		func() { streamers = append(streamers, iter) })
 	return
 }
 
@@ -273,7 +419,18 @@ func (d *Database) User(chatID int64) (user User, found bool) {
 			member_count,
 			affiliate_params,
 			member_subscriptions,
-			timezone
+			timezone,
+			quiet_start,
+			quiet_end,
+			quiet_summary,
+			show_kind_alerts,
+			subject_alerts,
+			digest_period,
+			digest_time,
+			digest_sent_at,
+			live_messages,
+			forum_topic,
+			topic_per_streamer
 		from users
 		where id = (select id from chain where migrated_to is null)
 	`,
@@ -294,6 +451,17 @@ func (d *Database) User(chatID int64) (user User, found bool) {
 			&user.AffiliateParams,
 			&user.MemberSubscriptions,
 			&user.Timezone,
+			&user.QuietStart,
+			&user.QuietEnd,
+			&user.QuietSummary,
+			&user.ShowKindAlerts,
+			&user.SubjectAlerts,
+			&user.DigestPeriod,
+			&user.DigestTime,
+			&user.DigestSentAt,
+			&user.LiveMessages,
+			&user.ForumTopic,
+			&user.TopicPerStreamer,
 		})
 	return
 }
@@ -316,7 +484,18 @@ func (d *Database) UserByID(userID UserID) (user User, found bool) {
 			member_count,
 			affiliate_params,
 			member_subscriptions,
-			timezone
+			timezone,
+			quiet_start,
+			quiet_end,
+			quiet_summary,
+			show_kind_alerts,
+			subject_alerts,
+			digest_period,
+			digest_time,
+			digest_sent_at,
+			live_messages,
+			forum_topic,
+			topic_per_streamer
 		from users
 		where id = $1
 	`,
@@ -337,6 +516,17 @@ func (d *Database) UserByID(userID UserID) (user User, found bool) {
 			&user.AffiliateParams,
 			&user.MemberSubscriptions,
 			&user.Timezone,
+			&user.QuietStart,
+			&user.QuietEnd,
+			&user.QuietSummary,
+			&user.ShowKindAlerts,
+			&user.SubjectAlerts,
+			&user.DigestPeriod,
+			&user.DigestTime,
+			&user.DigestSentAt,
+			&user.LiveMessages,
+			&user.ForumTopic,
+			&user.TopicPerStreamer,
 		})
 	return
 }
@@ -541,6 +731,12 @@ func (d *Database) MigrateChat(fromID, toID int64) *ChatMigration {
 		_, err = tx.Exec(ctx,
 			"update users set chat_id = $1, chat_type = 'supergroup' where id = $2", toID, srcID)
 		checkErr(err)
+		// The old chat's messages stay behind, so none of them can be edited from the new one.
+		_, err = tx.Exec(ctx, "delete from live_messages where user_id = $1", srcID)
+		checkErr(err)
+		// A board is sent anew to the new chat.
+		_, err = tx.Exec(ctx, "update boards set message_id = 0, dirty = true where user_id = $1", srcID)
+		checkErr(err)
 		checkErr(tx.Commit(ctx))
 		return &ChatMigration{Renamed: true}
 	}
@@ -556,7 +752,16 @@ func (d *Database) MigrateChat(fromID, toID int64) *ChatMigration {
 		max_subs = greatest(d.max_subs, s.max_subs),
 		affiliate_params = coalesce(d.affiliate_params, s.affiliate_params),
 		member_subscriptions = d.member_subscriptions or s.member_subscriptions,
-		timezone = coalesce(d.timezone, s.timezone)
+		timezone = coalesce(d.timezone, s.timezone),
+		quiet_start = case when d.quiet_start is null then s.quiet_start else d.quiet_start end,
+		quiet_end = case when d.quiet_start is null then s.quiet_end else d.quiet_end end,
+		quiet_summary = case when d.quiet_start is null then s.quiet_summary else d.quiet_summary end,
+		show_kind_alerts = d.show_kind_alerts | s.show_kind_alerts,
+		subject_alerts = d.subject_alerts or s.subject_alerts,
+		digest_period = case when d.digest_period = 0 then s.digest_period else d.digest_period end,
+		digest_time = case when d.digest_period = 0 then s.digest_time else d.digest_time end,
+		digest_sent_at = case when d.digest_period = 0 then s.digest_sent_at else d.digest_sent_at end,
+		live_messages = d.live_messages or s.live_messages
 		from users s
 		where d.id = $1 and s.id = $2`,
 		dstID, srcID)
@@ -574,6 +779,9 @@ func (d *Database) MigrateChat(fromID, toID int64) *ChatMigration {
 	// The tombstone still resolves to the destination chat,
 	// so a re-armed row redelivers there.
 	del("delete from notification_queue where user_id = $1 and sending = 0")
+	del("delete from held_notifications where user_id = $1")
+	del("delete from live_messages where user_id = $1")
+	del("delete from boards where user_id = $1")
 	// Keep the source's referral key when the destination has none:
 	// move it, so links shared for the old chat still credit the merged user.
 	// Otherwise drop it, since a user has a single key.
@@ -599,11 +807,12 @@ func (d *Database) MigrateChat(fromID, toID int64) *ChatMigration {
 }
 
 // MaybeStreamer returns a streamer if exists
-func (d *Database) MaybeStreamer(nickname string) *Streamer {
+func (d *Database) MaybeStreamer(site string, nickname string) *Streamer {
 	var result Streamer
 	if d.MaybeRecord(`
 		select
 			id,
+			site,
 			nickname,
 			confirmed_status,
 			unconfirmed_status,
@@ -611,10 +820,11 @@ func (d *Database) MaybeStreamer(nickname string) *Streamer {
 			prev_unconfirmed_status,
 			prev_unconfirmed_timestamp
 		from streamers
-		where nickname = $1`,
-		QueryParams{nickname},
+		where site = $1 and nickname = $2`,
+		QueryParams{site, nickname},
 		ScanTo{
 			&result.ID,
+			&result.Site,
 			&result.Nickname,
 			&result.ConfirmedStatus,
 			&result.UnconfirmedStatus,
@@ -627,6 +837,15 @@ func (d *Database) MaybeStreamer(nickname string) *Streamer {
 	return nil
 }
 
+// StreamerName returns the site and nickname of a streamer by its id
+func (d *Database) StreamerName(streamerID int) (site string, nickname string, found bool) {
+	found = d.MaybeRecord(
+		"select site, nickname from streamers where id = $1",
+		QueryParams{streamerID},
+		ScanTo{&site, &nickname})
+	return
+}
+
 // ChangesFromToForStreamers returns all changes for multiple streamers in specified period
 func (d *Database) ChangesFromToForStreamers(streamerIDs []int, from int, to int) map[int][]StatusChange {
 	result := make(map[int][]StatusChange)
@@ -737,20 +956,20 @@ func (d *Database) ConfirmSub(sub PendingSubscription) int {
 	var streamerID int
 	err = tx.QueryRow(context.Background(), `
 		with new_streamer as (
-			insert into streamers (nickname)
-			values ($1)
-			on conflict(nickname) do nothing
+			insert into streamers (site, nickname)
+			values ($1, $2)
+			on conflict(site, nickname) do nothing
 			returning id
 		),
 		new_nickname as (
-			insert into nicknames (nickname)
-			select $1 where exists (select 1 from new_streamer)
+			insert into nicknames (site, nickname)
+			select $1, $2 where exists (select 1 from new_streamer)
 		)
 		select id from new_streamer
 		union all
-		select id from streamers where nickname = $1
+		select id from streamers where site = $1 and nickname = $2
 		limit 1`,
-		sub.Nickname).Scan(&streamerID)
+		sub.Site, sub.Nickname).Scan(&streamerID)
 	checkErr(err)
 	_, err = tx.Exec(context.Background(), `
 		insert into subscriptions (user_id, streamer_id, endpoint)
@@ -759,8 +978,8 @@ func (d *Database) ConfirmSub(sub PendingSubscription) int {
 	checkErr(err)
 	_, err = tx.Exec(context.Background(), `
 		delete from pending_subscriptions
-		where user_id = $1 and endpoint = $2 and nickname = $3`,
-		int64(sub.UserID), sub.Endpoint, sub.Nickname)
+		where user_id = $1 and endpoint = $2 and site = $3 and nickname = $4`,
+		int64(sub.UserID), sub.Endpoint, sub.Site, sub.Nickname)
 	checkErr(err)
 	checkErr(tx.Commit(context.Background()))
 	return streamerID
@@ -770,44 +989,45 @@ func (d *Database) ConfirmSub(sub PendingSubscription) int {
 func (d *Database) DenySub(sub PendingSubscription) {
 	d.MustExec(`
 		delete from pending_subscriptions
-		where user_id = $1 and endpoint = $2 and nickname = $3`,
-		int64(sub.UserID), sub.Endpoint, sub.Nickname)
+		where user_id = $1 and endpoint = $2 and site = $3 and nickname = $4`,
+		int64(sub.UserID), sub.Endpoint, sub.Site, sub.Nickname)
 }
 
-// UnconfirmedStatusesForStreamers returns unconfirmed statuses for specific streamers
-func (d *Database) UnconfirmedStatusesForStreamers(nicknames []string) map[string]StatusChange {
+// UnconfirmedStatusesForStreamers returns unconfirmed statuses for specific streamers of a site
+func (d *Database) UnconfirmedStatusesForStreamers(site string, nicknames []string) map[string]StatusChange {
 	statusChanges := map[string]StatusChange{}
 	var statusChange StatusChange
 	d.MustQuery(`
 		select nickname, unconfirmed_status, unconfirmed_timestamp
 		from streamers
-		where nickname = any($1)`,
-		QueryParams{nicknames},
+		where site = $1 and nickname = any($2)`,
+		QueryParams{site, nicknames},
 		ScanTo{&statusChange.Nickname, &statusChange.Status, &statusChange.Timestamp},
 		func() { statusChanges[statusChange.Nickname] = statusChange })
 	return statusChanges
 }
 
-// StreamersToPoll returns nicknames flagged for per-streamer polling.
-func (d *Database) StreamersToPoll() []string {
+// StreamersToPoll returns nicknames of a site flagged for per-streamer polling.
+func (d *Database) StreamersToPoll(site string) []string {
 	var streamers []string
 	var nickname string
 	d.MustQuery(
-		`select nickname from streamers where poll`,
-		nil,
+		`select nickname from streamers where poll and site = $1`,
+		QueryParams{site},
 		ScanTo{&nickname},
 		func() { streamers = append(streamers, nickname) })
 	return streamers
 }
 
 // PolledStreamersWithStatus returns full streamer rows flagged for
-// per-streamer polling, ordered by nickname.
+// per-streamer polling, ordered by site and nickname.
 func (d *Database) PolledStreamersWithStatus() []Streamer {
 	var out []Streamer
 	var iter Streamer
 	d.MustQuery(`
 		select
 			id,
+			site,
 			nickname,
 			confirmed_status,
 			unconfirmed_status,
@@ -816,10 +1036,11 @@ func (d *Database) PolledStreamersWithStatus() []Streamer {
 			prev_unconfirmed_timestamp
 		from streamers
 		where poll
-		order by nickname`,
+		order by site, nickname`,
 		nil,
 		ScanTo{
 			&iter.ID,
+			&iter.Site,
 			&iter.Nickname,
 			&iter.ConfirmedStatus,
 			&iter.UnconfirmedStatus,
@@ -834,78 +1055,80 @@ func (d *Database) PolledStreamersWithStatus() []Streamer {
 // IncrementPollErrors bumps poll_error_count for the given nicknames.
 // Used by the bot to surface streamers whose polled checks fail
 // repeatedly so admins can spot typos or sites that block them.
-func (d *Database) IncrementPollErrors(nicknames []string) {
+func (d *Database) IncrementPollErrors(site string, nicknames []string) {
 	d.MustExec(
-		`update streamers set poll_error_count = poll_error_count + 1 where nickname = any($1)`,
-		nicknames)
+		`update streamers set poll_error_count = poll_error_count + 1 where site = $1 and nickname = any($2)`,
+		site, nicknames)
 }
 
 // SetPoll toggles the poll flag, upserting the streamer when on=true.
 // Disabling a missing streamer returns false so admins catch typos.
-func (d *Database) SetPoll(nickname string, on bool) bool {
+func (d *Database) SetPoll(site string, nickname string, on bool) bool {
 	if !on {
-		return d.MustExec(`update streamers set poll = false where nickname = $1`, nickname) > 0
+		return d.MustExec(`update streamers set poll = false where site = $1 and nickname = $2`, site, nickname) > 0
 	}
 	d.MustExec(`
 		with new_streamer as (
-			insert into streamers (nickname, poll) values ($1, true)
-			on conflict(nickname) do update set poll = true
+			insert into streamers (site, nickname, poll) values ($1, $2, true)
+			on conflict(site, nickname) do update set poll = true
 			returning (xmax = 0) as is_new
 		)
-		insert into nicknames (nickname)
-		select $1 from new_streamer where is_new`,
-		nickname)
+		insert into nicknames (site, nickname)
+		select $1, $2 from new_streamer where is_new`,
+		site, nickname)
 	return true
 }
 
-// SubscribedStreamers returns all subscribed streamers
-func (d *Database) SubscribedStreamers() map[string]bool {
+// SubscribedStreamers returns all subscribed streamers of a site
+func (d *Database) SubscribedStreamers(site string) map[string]bool {
 	streamers := map[string]bool{}
 	var nickname string
 	d.MustQuery(`
 		select distinct s.nickname
 		from subscriptions sub
-		join streamers s on s.id = sub.streamer_id`,
-		nil,
+		join streamers s on s.id = sub.streamer_id
+		where s.site = $1`,
+		QueryParams{site},
 		ScanTo{&nickname},
 		func() { streamers[nickname] = true })
 	return streamers
 }
 
-// QueryLastSubscriptionStatuses returns latest statuses for subscriptions
-func (d *Database) QueryLastSubscriptionStatuses() map[string]cmdlib.StatusKind {
+// QueryLastSubscriptionStatuses returns latest statuses for subscriptions to a site
+func (d *Database) QueryLastSubscriptionStatuses(site string) map[string]cmdlib.StatusKind {
 	statuses := map[string]cmdlib.StatusKind{}
 	var nickname string
 	var status cmdlib.StatusKind
 	d.MustQuery(`
 		select s.nickname, s.unconfirmed_status
 		from (select distinct streamer_id from subscriptions) sub
-		join streamers s on s.id = sub.streamer_id`,
-		nil,
+		join streamers s on s.id = sub.streamer_id
+		where s.site = $1`,
+		QueryParams{site},
 		ScanTo{&nickname, &status},
 		func() { statuses[nickname] = status })
 	return statuses
 }
 
-// QueryLastOnlineStreamers queries latest online streamers
-func (d *Database) QueryLastOnlineStreamers() map[string]bool {
+// QueryLastOnlineStreamers queries latest online streamers of a site
+func (d *Database) QueryLastOnlineStreamers(site string) map[string]bool {
 	onlineStreamers := map[string]bool{}
 	var nickname string
 	d.MustQuery(
-		`select nickname from streamers where unconfirmed_status = $1`,
-		QueryParams{cmdlib.StatusOnline},
+		`select nickname from streamers where site = $1 and unconfirmed_status = $2`,
+		QueryParams{site, cmdlib.StatusOnline},
 		ScanTo{&nickname},
 		func() { onlineStreamers[nickname] = true })
 	return onlineStreamers
 }
 
-// KnownStreamers returns all streamers with known status (not unknown).
-func (d *Database) KnownStreamers() map[string]bool {
+// KnownStreamers returns all streamers of a site with known status (not unknown).
+func (d *Database) KnownStreamers(site string) map[string]bool {
 	streamers := map[string]bool{}
 	var nickname string
 	d.MustQuery(
-		`select nickname from streamers where unconfirmed_status != 0`,
-		nil,
+		`select nickname from streamers where site = $1 and unconfirmed_status != 0`,
+		QueryParams{site},
 		ScanTo{&nickname},
 		func() { streamers[nickname] = true })
 	return streamers
@@ -917,7 +1140,8 @@ func (d *Database) KnownStreamers() map[string]bool {
 // (%>, forced GIN bitmap), and functional index legs
 // for patterns with long non-alnum or repeated char runs.
 // Results are deduplicated and sorted by trigram distance.
-func (d *Database) SearchStreamers(term string) []string {
+// Only streamers of the given site are searched.
+func (d *Database) SearchStreamers(site string, term string) []string {
 	done := d.Measure("db: search streamers")
 	defer done()
 
@@ -976,9 +1200,9 @@ func (d *Database) SearchStreamers(term string) []string {
 		`
 			create temp table _search_results on commit drop as
 			select nickname from streamers
-			where nickname = $1
+			where site = $1 and nickname = $2
 		`,
-		pgx.QueryExecModeExec, term)
+		pgx.QueryExecModeExec, site, term)
 	checkErr(err)
 
 	// GIN LIKE infix — substring search via GIN trigram index.
@@ -1001,9 +1225,10 @@ func (d *Database) SearchStreamers(term string) []string {
 				insert into _search_results
 				select nickname from nicknames
 				where nickname like '%' || $1 || '%'
+				and site = $2
 				limit 100
 			`,
-			pgx.QueryExecModeSimpleProtocol, escaped)
+			pgx.QueryExecModeSimpleProtocol, escaped, site)
 		checkErr(err)
 	}
 
@@ -1025,9 +1250,10 @@ func (d *Database) SearchStreamers(term string) []string {
 				insert into _search_results
 				select nickname from nicknames
 				where nickname %> $1
+				and site = $2
 				limit 100
 			`,
-			pgx.QueryExecModeSimpleProtocol, term)
+			pgx.QueryExecModeSimpleProtocol, term, site)
 		checkErr(err)
 	}
 
@@ -1051,10 +1277,11 @@ func (d *Database) SearchStreamers(term string) []string {
 				select nickname from nicknames
 				where max_repeated_alnum_run(nickname) >= $1
 				and nickname like '%' || $2 || '%'
+				and site = $3
 				limit 100
 			`,
 			pgx.QueryExecModeSimpleProtocol,
-			maxRepeatedAlnumRun, escaped)
+			maxRepeatedAlnumRun, escaped, site)
 		checkErr(err)
 	}
 
@@ -1074,10 +1301,11 @@ func (d *Database) SearchStreamers(term string) []string {
 				select nickname from nicknames
 				where max_nonalnum_run(nickname) >= $1
 				and nickname like '%' || $2 || '%'
+				and site = $3
 				limit 100
 			`,
 			pgx.QueryExecModeSimpleProtocol,
-			maxNonalnumRun, escaped)
+			maxNonalnumRun, escaped, site)
 		checkErr(err)
 	}
 
@@ -1095,10 +1323,10 @@ func (d *Database) SearchStreamers(term string) []string {
 				set local enable_bitmapscan = off;
 				insert into _search_results
 				select nickname from streamers
-				where nickname like $1 || '%'
+				where site = $1 and nickname like $2 || '%'
 				limit 100
 			`,
-			pgx.QueryExecModeSimpleProtocol, escaped)
+			pgx.QueryExecModeSimpleProtocol, site, escaped)
 		checkErr(err)
 	}
 
@@ -1198,18 +1426,23 @@ type UpsertUnconfirmedTimings struct {
 	InsertStatusChangesMs int
 	CommitMs              int
 	SummarizeBrinMs       int
+	UpdateShowKindsMs     int
 }
 
-// UpsertUnconfirmedStatusChanges upserts streamers to obtain integer IDs,
+// UpsertUnconfirmedStatusChanges upserts streamers of a site to obtain integer IDs,
 // then bulk inserts into status_changes with those IDs.
+// A status change sets the streamer's unconfirmed show kind with it;
+// changedShowKinds sets it for streamers online before and after, whose status is unchanged.
 func (d *Database) UpsertUnconfirmedStatusChanges(
+	site string,
 	changedStatuses []StatusChange,
+	changedShowKinds []ShowKindChange,
 	timestamp int,
 ) UpsertUnconfirmedTimings {
 	statusDone := d.Measure("db: insert unconfirmed status updates")
 	defer statusDone()
 
-	if len(changedStatuses) == 0 {
+	if len(changedStatuses) == 0 && len(changedShowKinds) == 0 {
 		return UpsertUnconfirmedTimings{}
 	}
 
@@ -1221,23 +1454,29 @@ func (d *Database) UpsertUnconfirmedStatusChanges(
 	upsertStart := time.Now()
 	nicknames := make([]string, len(changedStatuses))
 	statuses := make([]int, len(changedStatuses))
+	showKinds := make([]int, len(changedStatuses))
 	for i, sc := range changedStatuses {
 		nicknames[i] = sc.Nickname
 		statuses[i] = int(sc.Status)
+		showKinds[i] = int(sc.ShowKind)
 	}
 	rows, err := tx.Query(
 		context.Background(),
 		`
-			insert into streamers (nickname, unconfirmed_status, unconfirmed_timestamp)
-			select unnest($1::text[]), unnest($2::int[]), $3
-			on conflict(nickname) do update set
+			insert into streamers (
+				site, nickname, unconfirmed_status, unconfirmed_timestamp,
+				unconfirmed_show_kind, unconfirmed_show_kind_timestamp)
+			select $4, unnest($1::text[]), unnest($2::int[]), $3, unnest($5::int[]), $3
+			on conflict(site, nickname) do update set
 				prev_unconfirmed_status = streamers.unconfirmed_status,
 				prev_unconfirmed_timestamp = streamers.unconfirmed_timestamp,
 				unconfirmed_status = excluded.unconfirmed_status,
-				unconfirmed_timestamp = excluded.unconfirmed_timestamp
+				unconfirmed_timestamp = excluded.unconfirmed_timestamp,
+				unconfirmed_show_kind = excluded.unconfirmed_show_kind,
+				unconfirmed_show_kind_timestamp = excluded.unconfirmed_show_kind_timestamp
 			returning id, nickname, (xmax = 0) as is_new
 		`,
-		nicknames, statuses, timestamp,
+		nicknames, statuses, timestamp, site, showKinds,
 	)
 	checkErr(err)
 	idMap := make(map[string]int, len(changedStatuses))
@@ -1264,8 +1503,8 @@ func (d *Database) UpsertUnconfirmedStatusChanges(
 		insertNicknamesStart := time.Now()
 		_, err = tx.Exec(
 			context.Background(),
-			`insert into nicknames (nickname) select unnest($1::text[])`,
-			newNicknames)
+			`insert into nicknames (site, nickname) select $1, unnest($2::text[])`,
+			site, newNicknames)
 		checkErr(err)
 		timings.InsertNicknamesMs = int(time.Since(insertNicknamesStart).Milliseconds())
 	}
@@ -1285,6 +1524,28 @@ func (d *Database) UpsertUnconfirmedStatusChanges(
 	checkErr(err)
 	timings.InsertStatusChangesMs = int(time.Since(insertStart).Milliseconds())
 
+	// A show kind changing mid-show leaves the status and its history alone.
+	if len(changedShowKinds) > 0 {
+		showKindsStart := time.Now()
+		showKindNicknames := make([]string, len(changedShowKinds))
+		changedKinds := make([]int, len(changedShowKinds))
+		for i, c := range changedShowKinds {
+			showKindNicknames[i] = c.Nickname
+			changedKinds[i] = int(c.ShowKind)
+		}
+		_, err = tx.Exec(
+			context.Background(),
+			`
+				update streamers s
+				set unconfirmed_show_kind = c.show_kind, unconfirmed_show_kind_timestamp = $3
+				from unnest($1::text[], $2::int[]) as c(nickname, show_kind)
+				where s.site = $4 and s.nickname = c.nickname
+			`,
+			showKindNicknames, changedKinds, timestamp, site)
+		checkErr(err)
+		timings.UpdateShowKindsMs = int(time.Since(showKindsStart).Milliseconds())
+	}
+
 	commitStart := time.Now()
 	checkErr(tx.Commit(context.Background()))
 	timings.CommitMs = int(time.Since(commitStart).Milliseconds())
@@ -1307,6 +1568,7 @@ func (d *Database) AddSubscription(userID UserID, streamerID int, endpoint strin
 // AddPendingSubscription inserts a pending subscription for an unknown streamer
 func (d *Database) AddPendingSubscription(
 	userID UserID,
+	site string,
 	nickname string,
 	endpoint string,
 	referral bool,
@@ -1314,9 +1576,10 @@ func (d *Database) AddPendingSubscription(
 	replySeq int,
 ) {
 	d.MustExec(`
-		insert into pending_subscriptions (user_id, nickname, endpoint, referral, command, reply_seq)
-		values ($1, $2, $3, $4, $5, $6)`,
+		insert into pending_subscriptions (user_id, site, nickname, endpoint, referral, command, reply_seq)
+		values ($1, $2, $3, $4, $5, $6, $7)`,
 		int64(userID),
+		site,
 		nickname,
 		endpoint,
 		referral,
@@ -1339,6 +1602,443 @@ func (d *Database) SetShowSubject(userID UserID, showSubject bool) {
 	d.MustExec("update users set show_subject = $1 where id = $2", showSubject, int64(userID))
 }
 
+// SetShowKindAlerts updates the show_kind_alerts setting for a user
+func (d *Database) SetShowKindAlerts(userID UserID, showKindAlerts int) {
+	d.MustExec("update users set show_kind_alerts = $1 where id = $2", showKindAlerts, int64(userID))
+}
+
+// SetLiveMessages updates the live_messages setting for a user.
+// Turning it off lets go of the chat's live messages, which stay as last edited
+func (d *Database) SetLiveMessages(userID UserID, liveMessages bool) {
+	d.MustExec("update users set live_messages = $1 where id = $2", liveMessages, int64(userID))
+	if !liveMessages {
+		d.MustExec("delete from live_messages where user_id = $1", int64(userID))
+	}
+}
+
+// SetLiveMessage records the online alert a chat is to keep editing, replacing any earlier one
+func (d *Database) SetLiveMessage(m LiveMessage, now int) {
+	d.MustExec(`
+		insert into live_messages (user_id, endpoint, streamer_id, message_id, photo, started_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7)
+		on conflict (user_id, endpoint, streamer_id) do update
+		set message_id = excluded.message_id, photo = excluded.photo,
+			started_at = excluded.started_at, updated_at = excluded.updated_at`,
+		int64(m.UserID), m.Endpoint, m.StreamerID, m.MessageID, m.Photo, m.StartedAt, now)
+}
+
+// LiveMessage returns the live message of a chat for a streamer
+func (d *Database) LiveMessage(key LiveMessageKey) (m LiveMessage, found bool) {
+	m.LiveMessageKey = key
+	found = d.MaybeRecord(`
+		select message_id, photo, started_at
+		from live_messages
+		where user_id = $1 and endpoint = $2 and streamer_id = $3`,
+		QueryParams{int64(key.UserID), key.Endpoint, key.StreamerID},
+		ScanTo{&m.MessageID, &m.Photo, &m.StartedAt})
+	return
+}
+
+// LiveMessageKeys returns the live messages kept for streamers
+func (d *Database) LiveMessageKeys(streamerIDs []int) map[LiveMessageKey]bool {
+	result := map[LiveMessageKey]bool{}
+	var key LiveMessageKey
+	d.MustQuery(`
+		select user_id, endpoint, streamer_id
+		from live_messages
+		where streamer_id = any($1)`,
+		QueryParams{streamerIDs},
+		ScanTo{&key.UserID, &key.Endpoint, &key.StreamerID},
+		func() { result[key] = true })
+	return result
+}
+
+// DeleteLiveMessage lets go of a live message
+func (d *Database) DeleteLiveMessage(key LiveMessageKey) {
+	d.MustExec(
+		"delete from live_messages where user_id = $1 and endpoint = $2 and streamer_id = $3",
+		int64(key.UserID), key.Endpoint, key.StreamerID)
+}
+
+// TakeLiveRefreshes returns the live messages of a site's streamers still online
+// last edited at or before a timestamp, marking them edited now, so a refresh is queued once per period
+func (d *Database) TakeLiveRefreshes(site string, before int, now int) []LiveRefresh {
+	var result []LiveRefresh
+	var iter LiveRefresh
+	d.MustQuery(`
+		update live_messages l
+		set updated_at = $2
+		from streamers s, users u
+		where s.id = l.streamer_id and u.id = l.user_id
+		and s.site = $3 and l.updated_at <= $1 and s.confirmed_status = 2 and u.live_messages
+		returning
+			l.user_id, l.endpoint, l.streamer_id, l.message_id, l.photo, l.started_at,
+			s.site, s.nickname, u.show_images, u.show_subject`,
+		QueryParams{before, now, site},
+		ScanTo{
+			&iter.UserID, &iter.Endpoint, &iter.StreamerID, &iter.MessageID, &iter.Photo, &iter.StartedAt,
+			&iter.Site, &iter.Nickname, &iter.ShowImages, &iter.ShowSubject,
+		},
+		func() { result = append(result, iter) })
+	return result
+}
+
+// StartBoard gives a chat a fresh board on an endpoint, to be sent,
+// returning the message of the board it replaces, zero for none
+func (d *Database) StartBoard(userID UserID, endpoint string) (replaced int) {
+	d.MaybeRecord(`
+		with old as (select message_id from boards where user_id = $1 and endpoint = $2)
+		insert into boards (user_id, endpoint) values ($1, $2)
+		on conflict (user_id, endpoint) do update set message_id = 0, dirty = false
+		returning coalesce((select message_id from old), 0)`,
+		QueryParams{int64(userID), endpoint},
+		ScanTo{&replaced})
+	return
+}
+
+// StopBoard takes a chat's board away, returning its message, zero for none
+func (d *Database) StopBoard(userID UserID, endpoint string) (messageID int) {
+	d.MaybeRecord(
+		"delete from boards where user_id = $1 and endpoint = $2 returning message_id",
+		QueryParams{int64(userID), endpoint},
+		ScanTo{&messageID})
+	return
+}
+
+// SetBoardMessage keeps the message a board was sent as,
+// returning false when the board has been replaced or stopped meanwhile
+func (d *Database) SetBoardMessage(userID UserID, endpoint string, messageID int) bool {
+	return d.MustExec(
+		"update boards set message_id = $3 where user_id = $1 and endpoint = $2 and message_id = 0",
+		int64(userID), endpoint, messageID) > 0
+}
+
+// LoseBoardMessage forgets a board message that can no longer be edited, so the board is sent anew
+func (d *Database) LoseBoardMessage(userID UserID, endpoint string, messageID int) {
+	d.MustExec(
+		"update boards set message_id = 0, dirty = true where user_id = $1 and endpoint = $2 and message_id = $3",
+		int64(userID), endpoint, messageID)
+}
+
+// MarkBoardsDirty marks the boards of the chats subscribed to any of the streamers
+func (d *Database) MarkBoardsDirty(streamerIDs []int) {
+	d.MustExec(`
+		update boards b set dirty = true
+		from subscriptions sub
+		where sub.user_id = b.user_id and sub.endpoint = b.endpoint
+		and sub.streamer_id = any($1) and not b.dirty`,
+		streamerIDs)
+}
+
+// TakeDirtyBoards returns the boards marked dirty and clears the marks
+func (d *Database) TakeDirtyBoards() []Board {
+	var result []Board
+	var iter Board
+	d.MustQuery(
+		"update boards set dirty = false where dirty returning user_id, endpoint, message_id",
+		nil,
+		ScanTo{&iter.UserID, &iter.Endpoint, &iter.MessageID},
+		func() { result = append(result, iter) })
+	return result
+}
+
+// PruneLiveMessages lets go of the live messages last edited before a timestamp,
+// those of streamers that left without an offline status confirmed
+func (d *Database) PruneLiveMessages(before int) {
+	d.MustExec("delete from live_messages where updated_at < $1", before)
+}
+
+// SetSubjectAlerts updates the subject_alerts setting for a user
+func (d *Database) SetSubjectAlerts(userID UserID, subjectAlerts bool) {
+	d.MustExec("update users set subject_alerts = $1 where id = $2", subjectAlerts, int64(userID))
+}
+
+// SetDigest puts a user on a digest, the next one due after sentAt
+func (d *Database) SetDigest(userID UserID, period DigestPeriod, minute int, sentAt int) {
+	d.MustExec(
+		"update users set digest_period = $1, digest_time = $2, digest_sent_at = $3 where id = $4",
+		period, minute, sentAt, int64(userID))
+}
+
+// ResetDigest takes a user off its digest
+func (d *Database) ResetDigest(userID UserID) {
+	d.MustExec("update users set digest_period = 0 where id = $1", int64(userID))
+}
+
+// MarkDigestSent records the end of the window a user's last digest covered
+func (d *Database) MarkDigestSent(userID UserID, sentAt int) {
+	d.MustExec("update users set digest_sent_at = $1 where id = $2", sentAt, int64(userID))
+}
+
+// UsersWithDigests returns the users on a digest, with their schedule
+func (d *Database) UsersWithDigests() []User {
+	var users []User
+	var iter User
+	d.MustQuery(`
+		select
+			id, chat_id, silent_messages, affiliate_params, timezone,
+			digest_period, digest_time, digest_sent_at
+		from users
+		where digest_period != 0
+		order by id`,
+		nil,
+		ScanTo{
+			&iter.UserID, &iter.ChatID, &iter.SilentMessages, &iter.AffiliateParams, &iter.Timezone,
+			&iter.DigestPeriod, &iter.DigestTime, &iter.DigestSentAt,
+		},
+		func() { users = append(users, iter) })
+	return users
+}
+
+// DigestSubscriptions returns a user's subscriptions that are not alerted live
+func (d *Database) DigestSubscriptions(userID UserID) []DigestSubscription {
+	var subs []DigestSubscription
+	var iter DigestSubscription
+	d.MustQuery(`
+		select s.id, s.site, s.nickname, sub.endpoint
+		from subscriptions sub
+		join streamers s on s.id = sub.streamer_id
+		where sub.user_id = $1 and not sub.no_digest and not sub.favourite
+		order by s.site, s.nickname`,
+		QueryParams{int64(userID)},
+		ScanTo{&iter.StreamerID, &iter.Site, &iter.Nickname, &iter.Endpoint},
+		func() { subs = append(subs, iter) })
+	return subs
+}
+
+// SetSubscriptionNoDigest keeps a confirmed subscription out of its chat's digest, alerted as usual,
+// returning whether there is such a subscription
+func (d *Database) SetSubscriptionNoDigest(userID UserID, site string, nickname string, endpoint string, exempt bool) bool {
+	return d.MustExec(`
+		update subscriptions sub set no_digest = $5
+		from streamers s
+		where sub.streamer_id = s.id
+		and sub.user_id = $1 and s.site = $4 and s.nickname = $2 and sub.endpoint = $3`,
+		int64(userID), nickname, endpoint, site, exempt) > 0
+}
+
+// MuteSubscription silences a confirmed subscription until a timestamp, MutedForever for good,
+// letting go of its live message, which would otherwise never be ended,
+// and returns whether there is such a subscription.
+// A zero timestamp unmutes it
+func (d *Database) MuteSubscription(userID UserID, site string, nickname string, endpoint string, until int) bool {
+	found := d.MustExec(`
+		update subscriptions sub set muted_until = $5
+		from streamers s
+		where sub.streamer_id = s.id
+		and sub.user_id = $1 and s.site = $4 and s.nickname = $2 and sub.endpoint = $3`,
+		int64(userID), nickname, endpoint, site, until) > 0
+	if found && until != 0 {
+		d.MustExec(`
+			delete from live_messages l
+			using streamers s
+			where l.streamer_id = s.id
+			and l.user_id = $1 and s.site = $4 and s.nickname = $2 and l.endpoint = $3`,
+			int64(userID), nickname, endpoint, site)
+	}
+	return found
+}
+
+// SetSubscriptionFavourite stars a confirmed subscription as a favourite or unstars it,
+// returning whether there is such a subscription
+func (d *Database) SetSubscriptionFavourite(
+	userID UserID,
+	site string,
+	nickname string,
+	endpoint string,
+	favourite bool,
+) bool {
+	return d.MustExec(`
+		update subscriptions sub set favourite = $5
+		from streamers s
+		where sub.streamer_id = s.id
+		and sub.user_id = $1 and s.site = $4 and s.nickname = $2 and sub.endpoint = $3`,
+		int64(userID), nickname, endpoint, site, favourite) > 0
+}
+
+// FavouriteStreamers returns the streamers a user has starred on an endpoint
+func (d *Database) FavouriteStreamers(endpoint string, userID UserID) map[int]bool {
+	result := map[int]bool{}
+	var streamerID int
+	d.MustQuery(`
+		select streamer_id
+		from subscriptions
+		where user_id = $1 and endpoint = $2 and favourite`,
+		QueryParams{int64(userID), endpoint},
+		ScanTo{&streamerID},
+		func() { result[streamerID] = true })
+	return result
+}
+
+// SetChatTopic routes a chat's alerts to a forum topic, zero for the General one
+func (d *Database) SetChatTopic(userID UserID, topic int) {
+	d.MustExec("update users set forum_topic = $1 where id = $2", topic, int64(userID))
+}
+
+// SetTopicPerStreamer updates the topic_per_streamer setting for a user
+func (d *Database) SetTopicPerStreamer(userID UserID, topicPerStreamer bool) {
+	d.MustExec("update users set topic_per_streamer = $1 where id = $2", topicPerStreamer, int64(userID))
+}
+
+// SetSubscriptionTopic routes the alerts of a confirmed subscription to a forum topic,
+// zero to follow the chat's, returning whether there is such a subscription
+func (d *Database) SetSubscriptionTopic(
+	userID UserID,
+	site string,
+	nickname string,
+	endpoint string,
+	topic int,
+) bool {
+	return d.MustExec(`
+		update subscriptions sub set forum_topic = $5
+		from streamers s
+		where sub.streamer_id = s.id
+		and sub.user_id = $1 and s.site = $4 and s.nickname = $2 and sub.endpoint = $3`,
+		int64(userID), nickname, endpoint, site, topic) > 0
+}
+
+// SetOpenedTopic stores the topic opened for a streamer's alerts,
+// unless the subscription has been routed meanwhile
+func (d *Database) SetOpenedTopic(userID UserID, endpoint string, streamerID int, topic int) {
+	d.MustExec(`
+		update subscriptions set forum_topic = $4
+		where user_id = $1 and endpoint = $2 and streamer_id = $3 and forum_topic = 0`,
+		int64(userID), endpoint, streamerID, topic)
+}
+
+// SubscriptionTopic returns the forum topic of a subscription's alerts, zero for none
+func (d *Database) SubscriptionTopic(userID UserID, endpoint string, streamerID int) int {
+	var topic int
+	d.MaybeRecord(`
+		select forum_topic
+		from subscriptions
+		where user_id = $1 and endpoint = $2 and streamer_id = $3`,
+		QueryParams{int64(userID), endpoint, streamerID},
+		ScanTo{&topic})
+	return topic
+}
+
+// ForgetTopic routes back to the General topic whatever went to a deleted one
+func (d *Database) ForgetTopic(userID UserID, topic int) {
+	d.MustExec("update users set forum_topic = 0 where id = $1 and forum_topic = $2", int64(userID), topic)
+	d.MustExec(
+		"update subscriptions set forum_topic = 0 where user_id = $1 and forum_topic = $2",
+		int64(userID), topic)
+}
+
+// MutedStreamers returns the streamers a user has muted past now on an endpoint
+func (d *Database) MutedStreamers(endpoint string, userID UserID, now int) map[int]bool {
+	result := map[int]bool{}
+	var streamerID int
+	d.MustQuery(`
+		select streamer_id
+		from subscriptions
+		where user_id = $1 and endpoint = $2 and muted_until > $3`,
+		QueryParams{int64(userID), endpoint, now},
+		ScanTo{&streamerID},
+		func() { result[streamerID] = true })
+	return result
+}
+
+// ClearExpiredMutes unmutes the subscriptions whose mute ran out at or before now
+func (d *Database) ClearExpiredMutes(now int) {
+	d.MustExec("update subscriptions set muted_until = 0 where muted_until <> 0 and muted_until <= $1", now)
+}
+
+// RecordViewerPeaks raises the peak viewers of the hour holding timestamp
+// for the streamers of a site read of in some digest
+func (d *Database) RecordViewerPeaks(site string, counts []ViewerCount, timestamp int) {
+	if len(counts) == 0 {
+		return
+	}
+	done := d.Measure("db: record viewer peaks")
+	defer done()
+
+	nicknames := make([]string, len(counts))
+	viewers := make([]int, len(counts))
+	for i, c := range counts {
+		nicknames[i] = c.Nickname
+		viewers[i] = c.Viewers
+	}
+	d.MustExec(`
+		insert into viewer_peaks (streamer_id, hour, viewers)
+		select s.id, $4, c.viewers
+		from unnest($2::text[], $3::integer[]) as c(nickname, viewers)
+		join streamers s on s.site = $1 and s.nickname = c.nickname
+		where exists (
+			select 1
+			from subscriptions sub
+			join users u on u.id = sub.user_id
+			where sub.streamer_id = s.id and u.digest_period != 0 and not sub.no_digest
+		)
+		on conflict (streamer_id, hour) do update
+		set viewers = greatest(viewer_peaks.viewers, excluded.viewers)`,
+		site, nicknames, viewers, timestamp-timestamp%3600)
+}
+
+// RecordSessionSamples adds one poll of the streamers online on a site to their sessions.
+// Only the confirmed sessions of streamers someone subscribed to are kept.
+func (d *Database) RecordSessionSamples(site string, samples []SessionSample) {
+	if len(samples) == 0 {
+		return
+	}
+	done := d.Measure("db: record session samples")
+	defer done()
+
+	nicknames := make([]string, len(samples))
+	viewers := make([]*int, len(samples))
+	showKinds := make([]int, len(samples))
+	for i, c := range samples {
+		nicknames[i] = c.Nickname
+		viewers[i] = c.Viewers
+		showKinds[i] = int(c.ShowKind)
+	}
+	d.MustExec(`
+		update streamers s
+		set
+			session_viewers_sum = s.session_viewers_sum + coalesce(c.viewers, 0),
+			session_viewers_samples = s.session_viewers_samples + (c.viewers is not null)::integer,
+			session_viewers_peak = greatest(s.session_viewers_peak, coalesce(c.viewers, 0)),
+			session_show_kinds = s.session_show_kinds | (case when c.show_kind != 0 then 1 << c.show_kind else 0 end)
+		from unnest($2::text[], $3::integer[], $4::integer[]) as c(nickname, viewers, show_kind)
+		where s.site = $1 and s.nickname = c.nickname
+		and s.confirmed_status = 2 and s.session_start != 0
+		and exists (select 1 from subscriptions sub where sub.streamer_id = s.id)`,
+		site, nicknames, viewers, showKinds)
+}
+
+// showKindsOf lists the show kinds of a bitmask of 1 << cmdlib.ShowKind in their order
+func showKindsOf(mask int) []cmdlib.ShowKind {
+	var kinds []cmdlib.ShowKind
+	for k := cmdlib.ShowPublic; k <= cmdlib.ShowAway; k++ {
+		if mask&(1<<k) != 0 {
+			kinds = append(kinds, k)
+		}
+	}
+	return kinds
+}
+
+// PeakViewers returns the peak viewers of streamers in the hours overlapping from to to,
+// leaving out the streamers with none recorded
+func (d *Database) PeakViewers(streamerIDs []int, from int, to int) map[int]int {
+	result := map[int]int{}
+	var streamerID, viewers int
+	d.MustQuery(`
+		select streamer_id, max(viewers)
+		from viewer_peaks
+		where streamer_id = any($1) and hour > $2 - 3600 and hour < $3
+		group by streamer_id`,
+		QueryParams{streamerIDs, from, to},
+		ScanTo{&streamerID, &viewers},
+		func() { result[streamerID] = viewers })
+	return result
+}
+
+// PruneViewerPeaks deletes the peak viewers of the hours before a timestamp
+func (d *Database) PruneViewerPeaks(before int) {
+	d.MustExec("delete from viewer_peaks where hour < $1", before)
+}
+
 // SetSilentMessages updates the silent_messages setting for a user
 func (d *Database) SetSilentMessages(userID UserID, silentMessages bool) {
 	d.MustExec("update users set silent_messages = $1 where id = $2", silentMessages, int64(userID))
@@ -1388,6 +2088,68 @@ func (d *Database) SetTimezone(userID UserID, timezone string) {
 	d.MustExec("update users set timezone = $1 where id = $2", timezone, int64(userID))
 }
 
+// SetQuietHours updates a user's quiet hours, in minutes past local midnight
+func (d *Database) SetQuietHours(userID UserID, start, end int, summary bool) {
+	d.MustExec(
+		"update users set quiet_start = $1, quiet_end = $2, quiet_summary = $3 where id = $4",
+		start, end, summary, int64(userID))
+}
+
+// ResetQuietHours clears a user's quiet hours, releasing what they held to the next summary run
+func (d *Database) ResetQuietHours(userID UserID) {
+	d.MustExec(
+		"update users set quiet_start = null, quiet_end = null, quiet_summary = false where id = $1",
+		int64(userID))
+}
+
+// HoldNotification keeps a status alert back for the chat's quiet-hours summary
+func (d *Database) HoldNotification(n HeldNotification) {
+	d.MustExec(`
+		insert into held_notifications (user_id, endpoint, streamer_id, status, timestamp)
+		values ($1, $2, $3, $4, $5)`,
+		int64(n.UserID), n.Endpoint, n.StreamerID, n.Status, n.Timestamp)
+}
+
+// UsersWithHeldNotifications returns the users holding alerts back, with their quiet hours
+func (d *Database) UsersWithHeldNotifications() []User {
+	var users []User
+	var iter User
+	d.MustQuery(`
+		select
+			u.id, u.chat_id, u.silent_messages, u.affiliate_params,
+			u.timezone, u.quiet_start, u.quiet_end, u.quiet_summary
+		from users u
+		where exists (select 1 from held_notifications h where h.user_id = u.id)
+		order by u.id`,
+		nil,
+		ScanTo{
+			&iter.UserID, &iter.ChatID, &iter.SilentMessages, &iter.AffiliateParams,
+			&iter.Timezone, &iter.QuietStart, &iter.QuietEnd, &iter.QuietSummary,
+		},
+		func() { users = append(users, iter) })
+	return users
+}
+
+// TakeHeldNotifications deletes and returns a user's held alerts, ordered by time
+func (d *Database) TakeHeldNotifications(userID UserID) []HeldNotification {
+	var held []HeldNotification
+	var iter HeldNotification
+	d.MustQuery(`
+		with taken as (
+			delete from held_notifications
+			where user_id = $1
+			returning id, user_id, endpoint, streamer_id, status, timestamp
+		)
+		select t.user_id, t.endpoint, t.streamer_id, s.site, s.nickname, t.status, t.timestamp
+		from taken t
+		join streamers s on s.id = t.streamer_id
+		order by t.timestamp, t.id`,
+		QueryParams{int64(userID)},
+		ScanTo{&iter.UserID, &iter.Endpoint, &iter.StreamerID, &iter.Site, &iter.Nickname, &iter.Status, &iter.Timestamp},
+		func() { held = append(held, iter) })
+	return held
+}
+
 // SetAffiliateParams updates a user's custom affiliate params, empty to clear.
 func (d *Database) SetAffiliateParams(userID UserID, params map[string]string) {
 	if len(params) == 0 {
@@ -1404,7 +2166,7 @@ func (d *Database) UpdateMemberCount(userID UserID, memberCount int) {
 
 // RemoveSubscription deletes a specific subscription
 // and any pending subscription
-func (d *Database) RemoveSubscription(userID UserID, nickname string, endpoint string) {
+func (d *Database) RemoveSubscription(userID UserID, site string, nickname string, endpoint string) {
 	defer d.Measure("db: remove subscription")()
 	tx, err := d.Begin()
 	checkErr(err)
@@ -1414,13 +2176,13 @@ func (d *Database) RemoveSubscription(userID UserID, nickname string, endpoint s
 		delete from subscriptions sub
 		using streamers s
 		where sub.streamer_id = s.id
-		and sub.user_id = $1 and s.nickname = $2 and sub.endpoint = $3`,
-		int64(userID), nickname, endpoint)
+		and sub.user_id = $1 and s.site = $4 and s.nickname = $2 and sub.endpoint = $3`,
+		int64(userID), nickname, endpoint, site)
 	checkErr(err)
 	_, err = tx.Exec(context.Background(), `
 		delete from pending_subscriptions ps
-		where ps.user_id = $1 and ps.nickname = $2 and ps.endpoint = $3`,
-		int64(userID), nickname, endpoint)
+		where ps.user_id = $1 and ps.site = $4 and ps.nickname = $2 and ps.endpoint = $3`,
+		int64(userID), nickname, endpoint, site)
 	checkErr(err)
 	checkErr(tx.Commit(context.Background()))
 }
@@ -1444,6 +2206,62 @@ func (d *Database) RemoveAllSubscriptions(userID UserID, endpoint string) {
 	checkErr(tx.Commit(context.Background()))
 }
 
+// SetSubscriptionFilter sets the alert filter of a confirmed subscription, nil to clear.
+// It reports whether there was such a subscription:
+// a pending one has no row to hold a filter yet.
+func (d *Database) SetSubscriptionFilter(
+	userID UserID,
+	site string,
+	nickname string,
+	endpoint string,
+	filter *SubscriptionFilter,
+) bool {
+	return d.MustExec(`
+		update subscriptions sub set filter = $5
+		from streamers s
+		where sub.streamer_id = s.id
+		and sub.user_id = $1 and s.site = $4 and s.nickname = $2 and sub.endpoint = $3`,
+		int64(userID), nickname, endpoint, site, filter) > 0
+}
+
+// SubscriptionFilter returns the alert filter of a confirmed subscription,
+// nil when it has none, and whether there is such a subscription
+func (d *Database) SubscriptionFilter(
+	userID UserID,
+	site string,
+	nickname string,
+	endpoint string,
+) (filter *SubscriptionFilter, found bool) {
+	found = d.MaybeRecord(`
+		select sub.filter
+		from subscriptions sub
+		join streamers s on s.id = sub.streamer_id
+		where sub.user_id = $1 and s.site = $4 and s.nickname = $2 and sub.endpoint = $3`,
+		QueryParams{int64(userID), nickname, endpoint, site},
+		ScanTo{&filter})
+	return
+}
+
+// FilteredSubscriptions returns a user's subscriptions that have an alert filter.
+// Only Site, Nickname and Filter are filled.
+func (d *Database) FilteredSubscriptions(endpoint string, userID UserID) (subs []FilteredSubscription) {
+	var iter FilteredSubscription
+	d.MustQuery(`
+		select s.site, s.nickname, sub.filter
+		from subscriptions sub
+		join streamers s on s.id = sub.streamer_id
+		where sub.user_id = $1 and sub.endpoint = $2 and sub.filter is not null
+		order by s.site, s.nickname`,
+		QueryParams{int64(userID), endpoint},
+		ScanTo{&iter.Site, &iter.Nickname, &iter.Filter},
+		func() {
+			subs = append(subs, iter)
+			// Scanned into afresh for each row, so the one appended is not overwritten.
+			iter.Filter = nil
+		})
+	return
+}
+
 // AddFeedback stores user feedback
 func (d *Database) AddFeedback(endpoint string, userID UserID, text string, timestamp int) {
 	d.MustExec(`
@@ -1521,6 +2339,29 @@ func (d *Database) LogPerformance(timestamp int, kind PerformanceLogKind, durati
 		jsonData)
 }
 
+// RecentOnlineCounts returns the online counts of a site's latest successful polls since a timestamp,
+// oldest first, leaving out the ones held as truncated
+func (d *Database) RecentOnlineCounts(site string, since int, limit int) []OnlineCount {
+	var counts []OnlineCount
+	var iter OnlineCount
+	d.MustQuery(`
+		select timestamp, count from (
+			select timestamp, (data->>'count')::integer as count
+			from performance_log
+			where kind = $1 and timestamp >= $2
+			and data->>'site' = $3
+			and data->>'failed' = 'false'
+			and coalesce((data->>'held')::boolean, false) = false
+			order by timestamp desc
+			limit $4
+		) latest
+		order by timestamp`,
+		QueryParams{PerformanceLogUpdateQuery, since, site, limit},
+		ScanTo{&iter.Timestamp, &iter.Count},
+		func() { counts = append(counts, iter) })
+	return counts
+}
+
 // MaintainBrinIndexes summarizes new values for BRIN indexes
 func (d *Database) MaintainBrinIndexes() {
 	d.MustExec("select brin_summarize_new_values('ix_sent_message_log_timestamp')")
@@ -1528,16 +2369,30 @@ func (d *Database) MaintainBrinIndexes() {
 	d.MustExec("select brin_summarize_new_values('ix_performance_log_timestamp')")
 }
 
-// MarkUnconfirmedAsChecking marks pending subscriptions as checking
-func (d *Database) MarkUnconfirmedAsChecking() {
-	d.MustExec("update pending_subscriptions set checking = true where not checking")
+// MarkUnconfirmedAsChecking marks pending subscriptions to a site as checking
+func (d *Database) MarkUnconfirmedAsChecking(site string) {
+	d.MustExec("update pending_subscriptions set checking = true where not checking and site = $1", site)
 }
 
-// ResetCheckingToUnconfirmed resets checking pending subscriptions back to not checking
-func (d *Database) ResetCheckingToUnconfirmed() {
+// ResetCheckingToUnconfirmed resets checking pending subscriptions to a site back to not checking
+func (d *Database) ResetCheckingToUnconfirmed(site string) {
+	d.MustExec("update pending_subscriptions set checking = false where checking and site = $1", site)
+}
+
+// ResetAllCheckingToUnconfirmed resets every checking pending subscription back to not checking,
+// as a restart loses the checks in flight for every site
+func (d *Database) ResetAllCheckingToUnconfirmed() {
 	d.MustExec("update pending_subscriptions set checking = false where checking")
 }
 
+// ClaimUnsitedRows assigns the rows written before streamers were keyed by site to the given site.
+// Only the bot knows which site it served, so the migration leaves them empty for it to name.
+func (d *Database) ClaimUnsitedRows(site string) {
+	d.MustExec("update streamers set site = $1 where site = ''", site)
+	d.MustExec("update pending_subscriptions set site = $1 where site = ''", site)
+	d.MustExec("update nicknames set site = $1 where site = ''", site)
+}
+
 // ResetNotificationSending resets all sending notifications to not sending
 func (d *Database) ResetNotificationSending() {
 	d.MustExec("update notification_queue set sending=0")
@@ -1604,12 +2459,16 @@ func (d *Database) IncrementReports(userIDs []UserID) {
 }
 
 // ConfirmStatusChanges finds streamers needing confirmation and updates them.
-// Returns the confirmed status changes with previous status.
+// Returns the confirmed status changes with previous status,
+// and the confirmed show-kind changes of streamers online throughout with previous show kind.
+// A confirmed status takes its show kind along without a show-kind change:
+// the online notification already names the show.
+// A show kind is confirmed after onlineSeconds, as the online status it belongs to is.
 func (d *Database) ConfirmStatusChanges(
 	now int,
 	onlineSeconds int,
 	offlineSeconds int,
-) []ConfirmedStatusChange {
+) ([]ConfirmedStatusChange, []ConfirmedShowKindChange) {
 	// PostgreSQL uses ix_streamers_status_mismatch partial index
 	// for select but not for update — we use a temp table to work around this.
 	done := d.Measure("db: confirm status changes")
@@ -1623,7 +2482,9 @@ func (d *Database) ConfirmStatusChanges(
 		context.Background(),
 		`
 			create temp table to_confirm on commit drop as
-			select id, nickname, unconfirmed_status, confirmed_status
+			select
+				id, site, nickname, unconfirmed_status, unconfirmed_timestamp, confirmed_status,
+				session_start, session_viewers_sum, session_viewers_samples, session_viewers_peak, session_show_kinds
 			from streamers
 			where confirmed_status != unconfirmed_status
 			and (
@@ -1639,15 +2500,79 @@ func (d *Database) ConfirmStatusChanges(
 		context.Background(),
 		`
 			update streamers c
-			set confirmed_status = tc.unconfirmed_status
+			set confirmed_status = tc.unconfirmed_status, confirmed_show_kind = c.unconfirmed_show_kind
 			from to_confirm tc
 			where c.id = tc.id
 		`)
 	checkErr(err)
 
+	// A session starts as it is confirmed online and ends as it is confirmed offline,
+	// so a streamer back before its offline status held stays in the one session.
+	_, err = tx.Exec(
+		context.Background(),
+		`
+			update streamers c
+			set
+				session_start = tc.unconfirmed_timestamp,
+				session_viewers_sum = 0,
+				session_viewers_samples = 0,
+				session_viewers_peak = 0,
+				session_show_kinds = 0
+			from to_confirm tc
+			where c.id = tc.id and tc.unconfirmed_status = 2 and tc.confirmed_status != 2
+		`)
+	checkErr(err)
+
+	_, err = tx.Exec(
+		context.Background(),
+		`
+			insert into sessions (streamer_id, start_timestamp, end_timestamp, peak_viewers, average_viewers, show_kinds)
+			select
+				id, session_start, unconfirmed_timestamp,
+				case when session_viewers_samples > 0 then session_viewers_peak end,
+				round(session_viewers_sum::numeric / nullif(session_viewers_samples, 0)),
+				session_show_kinds
+			from to_confirm
+			where unconfirmed_status = 1 and confirmed_status = 2 and session_start != 0
+			on conflict do nothing
+		`)
+	checkErr(err)
+
+	// After the status update, so a streamer just confirmed online is left out.
+	_, err = tx.Exec(
+		context.Background(),
+		`
+			create temp table show_kinds_to_confirm on commit drop as
+			select id, site, nickname, unconfirmed_show_kind, confirmed_show_kind
+			from streamers
+			where confirmed_show_kind != unconfirmed_show_kind
+			and confirmed_status = 2 and unconfirmed_status = 2
+			and $1 - unconfirmed_show_kind_timestamp >= $2
+		`,
+		now, onlineSeconds)
+	checkErr(err)
+
+	_, err = tx.Exec(
+		context.Background(),
+		`
+			update streamers c
+			set confirmed_show_kind = tc.unconfirmed_show_kind
+			from show_kinds_to_confirm tc
+			where c.id = tc.id
+		`)
+	checkErr(err)
+
 	rows, err := tx.Query(
 		context.Background(),
-		`select id, nickname, unconfirmed_status, confirmed_status from to_confirm`,
+		`
+			select
+				tc.id, tc.site, tc.nickname, tc.unconfirmed_status, tc.confirmed_status,
+				se.start_timestamp, se.end_timestamp, se.peak_viewers, se.average_viewers, se.show_kinds
+			from to_confirm tc
+			left join sessions se
+			on se.streamer_id = tc.id and se.start_timestamp = tc.session_start
+			and tc.unconfirmed_status = 1 and tc.confirmed_status = 2 and tc.session_start != 0
+		`,
 	)
 	checkErr(err)
 	defer rows.Close()
@@ -1655,7 +2580,131 @@ func (d *Database) ConfirmStatusChanges(
 	var result []ConfirmedStatusChange
 	for rows.Next() {
 		var change ConfirmedStatusChange
-		checkErr(rows.Scan(&change.StreamerID, &change.Nickname, &change.Status, &change.PrevStatus))
+		var start, end, showKinds *int
+		var session Session
+		checkErr(rows.Scan(
+			&change.StreamerID, &change.Site, &change.Nickname, &change.Status, &change.PrevStatus,
+			&start, &end, &session.PeakViewers, &session.AverageViewers, &showKinds))
+		if start != nil {
+			session.Start, session.End, session.ShowKinds = *start, *end, showKindsOf(*showKinds)
+			change.Session = &session
+		}
+		change.Timestamp = now
+		result = append(result, change)
+	}
+	checkErr(rows.Err())
+	rows.Close()
+
+	showKindRows, err := tx.Query(
+		context.Background(),
+		`select id, site, nickname, unconfirmed_show_kind, confirmed_show_kind from show_kinds_to_confirm`,
+	)
+	checkErr(err)
+	defer showKindRows.Close()
+
+	var showKindChanges []ConfirmedShowKindChange
+	for showKindRows.Next() {
+		var change ConfirmedShowKindChange
+		checkErr(showKindRows.Scan(
+			&change.StreamerID, &change.Site, &change.Nickname, &change.ShowKind, &change.PrevShowKind))
+		change.Timestamp = now
+		showKindChanges = append(showKindChanges, change)
+	}
+	checkErr(showKindRows.Err())
+
+	checkErr(tx.Commit(context.Background()))
+	return result, showKindChanges
+}
+
+// UpsertUnconfirmedSubjects sets the unconfirmed subjects of online streamers of a site.
+// A started subject is confirmed and recorded at once; any other waits for ConfirmSubjectChanges.
+// The streamers must exist, as UpsertUnconfirmedStatusChanges makes them.
+func (d *Database) UpsertUnconfirmedSubjects(site string, changes []SubjectChange, timestamp int) {
+	if len(changes) == 0 {
+		return
+	}
+	done := d.Measure("db: insert unconfirmed subjects")
+	defer done()
+
+	nicknames := make([]string, len(changes))
+	subjects := make([]string, len(changes))
+	started := make([]bool, len(changes))
+	for i, c := range changes {
+		nicknames[i] = c.Nickname
+		subjects[i] = c.Subject
+		started[i] = c.Started
+	}
+	d.MustExec(`
+		with updated as (
+			update streamers s
+			set
+				unconfirmed_subject = c.subject,
+				unconfirmed_subject_timestamp = $4,
+				confirmed_subject = case when c.started then c.subject else s.confirmed_subject end
+			from unnest($2::text[], $3::text[], $5::boolean[]) as c(nickname, subject, started)
+			where s.site = $1 and s.nickname = c.nickname
+			returning s.id, c.subject, c.started
+		)
+		insert into subject_changes (streamer_id, subject, timestamp)
+		select id, subject, $4 from updated
+		where started and subject != ''`,
+		site, nicknames, subjects, timestamp, started)
+}
+
+// ConfirmSubjectChanges confirms the subjects of online streamers unchanged for stableSeconds,
+// records them, and returns them with the subjects they replace.
+func (d *Database) ConfirmSubjectChanges(now int, stableSeconds int) []ConfirmedSubjectChange {
+	// A temp table for the reason ConfirmStatusChanges has one.
+	done := d.Measure("db: confirm subject changes")
+	defer done()
+
+	tx, err := d.Begin()
+	checkErr(err)
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	_, err = tx.Exec(
+		context.Background(),
+		`
+			create temp table subjects_to_confirm on commit drop as
+			select id, site, nickname, unconfirmed_subject, confirmed_subject
+			from streamers
+			where confirmed_subject != unconfirmed_subject
+			and confirmed_status = 2 and unconfirmed_status = 2
+			and $1 - unconfirmed_subject_timestamp >= $2
+		`,
+		now, stableSeconds)
+	checkErr(err)
+
+	_, err = tx.Exec(
+		context.Background(),
+		`
+			update streamers c
+			set confirmed_subject = tc.unconfirmed_subject
+			from subjects_to_confirm tc
+			where c.id = tc.id
+		`)
+	checkErr(err)
+
+	_, err = tx.Exec(
+		context.Background(),
+		`
+			insert into subject_changes (streamer_id, subject, timestamp)
+			select id, unconfirmed_subject, $1 from subjects_to_confirm
+		`,
+		now)
+	checkErr(err)
+
+	rows, err := tx.Query(
+		context.Background(),
+		`select id, site, nickname, unconfirmed_subject, confirmed_subject from subjects_to_confirm`,
+	)
+	checkErr(err)
+	defer rows.Close()
+
+	var result []ConfirmedSubjectChange
+	for rows.Next() {
+		var change ConfirmedSubjectChange
+		checkErr(rows.Scan(&change.StreamerID, &change.Site, &change.Nickname, &change.Subject, &change.PrevSubject))
 		change.Timestamp = now
 		result = append(result, change)
 	}
@@ -1664,3 +2713,29 @@ func (d *Database) ConfirmStatusChanges(
 	checkErr(tx.Commit(context.Background()))
 	return result
 }
+
+// SubjectsFromTo returns a streamer's confirmed subjects between from and to, ordered by time,
+// led by the last one before from, where there is one
+func (d *Database) SubjectsFromTo(streamerID int, from int, to int) []HistoricSubject {
+	var subjects []HistoricSubject
+	var iter HistoricSubject
+	d.MustQuery(`
+		(
+			select subject, timestamp
+			from subject_changes
+			where streamer_id = $1 and timestamp < $2
+			order by timestamp desc
+			limit 1
+		)
+		union all
+		(
+			select subject, timestamp
+			from subject_changes
+			where streamer_id = $1 and timestamp >= $2 and timestamp <= $3
+		)
+		order by timestamp`,
+		QueryParams{streamerID, from, to},
+		ScanTo{&iter.Subject, &iter.Timestamp},
+		func() { subjects = append(subjects, iter) })
+	return subjects
+}
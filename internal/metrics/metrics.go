@@ -0,0 +1,135 @@
+// Package metrics exports the bot's runtime health for Prometheus to scrape.
+// Every collector is safe to update from any goroutine,
+// so the checker daemons, the deliver goroutines and the main loop record directly.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "siren"
+
+// registry is private rather than the default one,
+// so a library registering on the default cannot leak series into our scrape.
+var registry = prometheus.NewRegistry()
+
+var (
+	// CheckerQueryDuration times a checker daemon request, labelled by site and request type.
+	CheckerQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "checker_query_duration_seconds",
+		Help:      "Duration of a checker daemon request, polls and their pacing included.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"site", "request"})
+
+	// CheckerQueryFailures counts checker daemon requests that returned a failed result.
+	CheckerQueryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checker_query_failures_total",
+		Help:      "Checker daemon requests that returned a failed result.",
+	}, []string{"site", "request"})
+
+	// PollErrors counts per-streamer polls that came back with no status.
+	PollErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "poll_errors_total",
+		Help:      "Per-streamer polls that failed or came back unknown.",
+	}, []string{"site"})
+
+	// SenderQueueLength is an endpoint's outgoing queue, set by the main loop as it changes.
+	SenderQueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sender_queue_length",
+		Help:      "Messages waiting in an endpoint's outgoing queue.",
+	}, []string{"endpoint"})
+
+	// SendResults counts delivery attempts by endpoint and result.
+	SendResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "send_results_total",
+		Help:      "Telegram delivery attempts by result.",
+	}, []string{"endpoint", "result"})
+
+	// HookResults counts notification webhook posts by result.
+	HookResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notification_webhook_results_total",
+		Help:      "Notification webhook post attempts by result.",
+	}, []string{"result"})
+
+	// DBQueryDuration times a database query, labelled by its short name or by the function running it.
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of a database query.",
+		Buckets:   []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 10},
+	}, []string{"query"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		CheckerQueryDuration,
+		CheckerQueryFailures,
+		PollErrors,
+		SenderQueueLength,
+		SendResults,
+		HookResults,
+		DBQueryDuration,
+		queueDepths,
+	)
+}
+
+// ObserveCheckerQuery records one checker daemon request.
+func ObserveCheckerQuery(site, request string, elapsed time.Duration, failed bool) {
+	CheckerQueryDuration.WithLabelValues(site, request).Observe(elapsed.Seconds())
+	if failed {
+		CheckerQueryFailures.WithLabelValues(site, request).Inc()
+	}
+}
+
+// queueDepthCollector reads each site's status request queue at scrape time:
+// len on a channel is safe from any goroutine.
+type queueDepthCollector struct {
+	desc   *prometheus.Desc
+	mu     sync.Mutex
+	depths map[string]func() int
+}
+
+var queueDepths = &queueDepthCollector{
+	desc: prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "checker_queue_depth"),
+		"Status requests waiting in a checker's queue.",
+		[]string{"site"}, nil),
+	depths: map[string]func() int{},
+}
+
+func (c *queueDepthCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.desc }
+
+func (c *queueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for site, depth := range c.depths {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(depth()), site)
+	}
+}
+
+// RegisterQueueDepth exports the depth of a site's status request queue.
+// A later daemon for the same site replaces the earlier one's queue.
+func RegisterQueueDepth[T any](site string, queue <-chan T) {
+	queueDepths.mu.Lock()
+	defer queueDepths.mu.Unlock()
+	queueDepths.depths[site] = func() int { return len(queue) }
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHandlerExportsRecordedSeries scrapes the handler after one record of each kind.
+func TestHandlerExportsRecordedSeries(t *testing.T) {
+	queue := make(chan int, 4)
+	queue <- 1
+	queue <- 2
+	RegisterQueueDepth("metrics_test", queue)
+	ObserveCheckerQuery("metrics_test", "online_list", 2*time.Second, true)
+	PollErrors.WithLabelValues("metrics_test").Add(3)
+	SenderQueueLength.WithLabelValues("metrics_test").Set(5)
+	SendResults.WithLabelValues("metrics_test", "blocked").Inc()
+	DBQueryDuration.WithLabelValues("metrics_test").Observe(0.002)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`siren_checker_queue_depth{site="metrics_test"} 2`,
+		`siren_checker_query_duration_seconds_count{request="online_list",site="metrics_test"} 1`,
+		`siren_checker_query_failures_total{request="online_list",site="metrics_test"} 1`,
+		`siren_poll_errors_total{site="metrics_test"} 3`,
+		`siren_sender_queue_length{endpoint="metrics_test"} 5`,
+		`siren_send_results_total{endpoint="metrics_test",result="blocked"} 1`,
+		`siren_db_query_duration_seconds_count{query="metrics_test"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("scrape lacks %s", want)
+		}
+	}
+}
+
+// TestQueueDepthFollowsTheLatestQueue pins that a restarted daemon's queue replaces the old one
+// rather than panicking on a duplicate series.
+func TestQueueDepthFollowsTheLatestQueue(t *testing.T) {
+	RegisterQueueDepth("metrics_restart", make(chan int, 1))
+	latest := make(chan int, 1)
+	latest <- 1
+	RegisterQueueDepth("metrics_restart", latest)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), `siren_checker_queue_depth{site="metrics_restart"} 1`) {
+		t.Errorf("queue depth does not follow the latest queue:\n%s", rec.Body.String())
+	}
+}
@@ -20,6 +20,31 @@ type CheckerResults interface {
 	ExtraLogFields() map[string]any
 }
 
+// ProxyStats is a proxy's record over a stretch of requests, for the performance log.
+type ProxyStats struct {
+	Proxy     string `json:"proxy"`
+	Successes int    `json:"successes"`
+	Failures  int    `json:"failures"`
+	LatencyMs int    `json:"latency_ms"` // mean latency of the successes
+	Healthy   bool   `json:"healthy"`    // false while the proxy is out of the rotation
+}
+
+// HTTPStats is a checker's record of its upstream requests since its previous report.
+type HTTPStats struct {
+	Attempts int // requests sent, retries included
+	Retries  int
+	Proxies  []ProxyStats // nil for a checker without proxies
+}
+
+// addLogFields adds the stats to performance log fields.
+func (s HTTPStats) addLogFields(fields map[string]any) {
+	fields["http_attempts"] = s.Attempts
+	fields["http_retries"] = s.Retries
+	if len(s.Proxies) > 0 {
+		fields["proxies"] = s.Proxies
+	}
+}
+
 // OnlineListRequest requests statuses for all online streamers.
 // Names in Poll fall back to QueryStatus when not in the bulk result.
 type OnlineListRequest struct {
@@ -34,6 +59,7 @@ type OnlineListResults struct {
 	Streamers  map[string]StreamerInfo
 	PollCount  int
 	PollErrors []string
+	HTTP       HTTPStats
 	duration   time.Duration
 	failed     bool
 }
@@ -63,10 +89,12 @@ func (r *OnlineListResults) Count() int { return len(r.Streamers) }
 
 // ExtraLogFields returns extra fields for performance logging.
 func (r *OnlineListResults) ExtraLogFields() map[string]any {
-	return map[string]any{
+	fields := map[string]any{
 		"poll_count":  r.PollCount,
 		"poll_errors": len(r.PollErrors),
 	}
+	r.HTTP.addLogFields(fields)
+	return fields
 }
 
 // NewOnlineListResults creates a successful OnlineListResults.
@@ -91,6 +119,7 @@ func (r *FixedListOnlineRequest) isStatusRequest() {}
 type FixedListOnlineResults struct {
 	RequestedStreamers map[string]bool
 	Streamers          map[string]StreamerInfo
+	HTTP               HTTPStats
 	duration           time.Duration
 	failed             bool
 }
@@ -107,7 +136,11 @@ func (r *FixedListOnlineResults) Failed() bool { return r.failed }
 func (r *FixedListOnlineResults) Count() int { return len(r.Streamers) }
 
 // ExtraLogFields returns extra fields for performance logging.
-func (r *FixedListOnlineResults) ExtraLogFields() map[string]any { return nil }
+func (r *FixedListOnlineResults) ExtraLogFields() map[string]any {
+	fields := map[string]any{}
+	r.HTTP.addLogFields(fields)
+	return fields
+}
 
 // NewFixedListOnlineResults creates a successful FixedListOnlineResults.
 func NewFixedListOnlineResults(
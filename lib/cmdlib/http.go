@@ -2,6 +2,7 @@ package cmdlib
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net"
@@ -46,8 +47,10 @@ func httpClient(timeout time.Duration, proxy func(*http.Request) (*url.URL, erro
 	}
 }
 
-// OnlineQuery creates and performs online request
+// OnlineQuery creates and performs online request under ctx.
+// Retries are the client's: checkers pass one applying their retry policy.
 func OnlineQuery(
+	ctx context.Context,
 	usersOnlineEndpoint string,
 	client *http.Client,
 	headers [][2]string,
@@ -56,7 +59,7 @@ func OnlineQuery(
 	*bytes.Buffer,
 	error,
 ) {
-	req, err := http.NewRequest("GET", usersOnlineEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", usersOnlineEndpoint, nil)
 	CheckErr(err)
 	for _, h := range headers {
 		req.Header.Set(h[0], h[1])
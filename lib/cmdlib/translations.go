@@ -134,6 +134,17 @@ type Translations struct {
 	TimezoneAppPlaceholder      *Translation `yaml:"timezone_app_placeholder"`
 	TimezoneAppNoResults        *Translation `yaml:"timezone_app_no_results"`
 	TimezoneAppFailed           *Translation `yaml:"timezone_app_failed"`
+	Quiet                       *Translation `yaml:"quiet"`
+	QuietInvalid                *Translation `yaml:"quiet_invalid"`
+	QuietButton                 *Translation `yaml:"quiet_button"`
+	QuietSummary                *Translation `yaml:"quiet_summary"`
+	QuietAppHeader              *Translation `yaml:"quiet_app_header"`
+	QuietAppFrom                *Translation `yaml:"quiet_app_from"`
+	QuietAppTo                  *Translation `yaml:"quiet_app_to"`
+	QuietAppSummary             *Translation `yaml:"quiet_app_summary"`
+	QuietAppSave                *Translation `yaml:"quiet_app_save"`
+	QuietAppOff                 *Translation `yaml:"quiet_app_off"`
+	QuietAppFailed              *Translation `yaml:"quiet_app_failed"`
 	RemoveButton                *Translation `yaml:"remove_button"`
 	RemovalAppHeader            *Translation `yaml:"removal_app_header"`
 	RemovalAppPlaceholder       *Translation `yaml:"removal_app_placeholder"`
@@ -141,6 +152,45 @@ type Translations struct {
 	RemovalAppNoResults         *Translation `yaml:"removal_app_no_results"`
 	RemovalAppFailed            *Translation `yaml:"removal_app_failed"`
 	RemovalAppFailedToRemove    *Translation `yaml:"removal_app_failed_to_remove"`
+	RemovalAppFilter            *Translation `yaml:"removal_app_filter"`
+	RemovalAppFilterPlaceholder *Translation `yaml:"removal_app_filter_placeholder"`
+	RemovalAppFilterSave        *Translation `yaml:"removal_app_filter_save"`
+	RemovalAppFailedToFilter    *Translation `yaml:"removal_app_failed_to_filter"`
+	SyntaxFilter                *Translation `yaml:"syntax_filter"`
+	Filter                      *Translation `yaml:"filter"`
+	FilterInvalid               *Translation `yaml:"filter_invalid"`
+	FilterPending               *Translation `yaml:"filter_pending"`
+	FilterButton                *Translation `yaml:"filter_button"`
+	ShowKindChange              *Translation `yaml:"show_kind_change"`
+	ShowChanges                 *Translation `yaml:"show_changes"`
+	ShowChangesInvalid          *Translation `yaml:"show_changes_invalid"`
+	SubjectChange               *Translation `yaml:"subject_change"`
+	Digest                      *Translation `yaml:"digest"`
+	DigestInvalid               *Translation `yaml:"digest_invalid"`
+	DigestSummary               *Translation `yaml:"digest_summary"`
+	NoDigest                    *Translation `yaml:"nodigest"`
+	SyntaxNoDigest              *Translation `yaml:"syntax_nodigest"`
+	NoDigestPending             *Translation `yaml:"nodigest_pending"`
+	LiveEnded                   *Translation `yaml:"live_ended"`
+	NotificationMuteButton      *Translation `yaml:"notification_mute_button"`
+	NotificationRemoveButton    *Translation `yaml:"notification_remove_button"`
+	NotificationWeekButton      *Translation `yaml:"notification_week_button"`
+	NotificationWatchButton     *Translation `yaml:"notification_watch_button"`
+	Muted                       *Translation `yaml:"muted"`
+	Unmuted                     *Translation `yaml:"unmuted"`
+	SyntaxMute                  *Translation `yaml:"syntax_mute"`
+	Favourite                   *Translation `yaml:"favourite"`
+	SyntaxFavourite             *Translation `yaml:"syntax_favourite"`
+	FavouritePending            *Translation `yaml:"favourite_pending"`
+	InlineStatus                *Translation `yaml:"inline_status"`
+	InlineDescription           *Translation `yaml:"inline_description"`
+	InlineSubscribeButton       *Translation `yaml:"inline_subscribe_button"`
+	Topic                       *Translation `yaml:"topic"`
+	SyntaxTopic                 *Translation `yaml:"syntax_topic"`
+	TopicForumsOnly             *Translation `yaml:"topic_forums_only"`
+	Board                       *Translation `yaml:"board"`
+	BoardSet                    *Translation `yaml:"board_set"`
+	SyntaxBoard                 *Translation `yaml:"syntax_board"`
 }
 
 // LoadEndpointTranslations loads translations for a specific endpoint